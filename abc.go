@@ -0,0 +1,855 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+var (
+	ErrABCString    = errors.New("abc: string length exceeds data")
+	ErrABCMultiname = errors.New("abc: unknown multiname kind")
+	ErrABCTrait     = errors.New("abc: unknown trait kind")
+	ErrABCClasses   = errors.New("abc: instance and class counts differ")
+)
+
+// DoABC holds an ActionScript 3 ABC file. DoABCDefine, the older form of the
+// tag, has neither flags nor name.
+type DoABC struct {
+	LazyInitialize bool
+	ABCName        String
+	ABCData        []byte
+}
+
+func (d *DoABC) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if id == TAG_DO_ABC {
+		var flags uint32
+		if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+			return
+		}
+		d.LazyInitialize = flags&1 == 1
+		if _, err = d.ABCName.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	d.ABCData, err = ioutil.ReadAll(c)
+	return
+}
+
+func (d *DoABC) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if id == TAG_DO_ABC {
+		var flags uint32
+		if d.LazyInitialize {
+			flags = 1
+		}
+		if err = binary.Write(c, binary.LittleEndian, flags); err != nil {
+			return
+		}
+		if _, err = d.ABCName.WriteTo(c); err != nil {
+			return
+		}
+	}
+	_, err = c.Write(d.ABCData)
+	return
+}
+
+func (d *DoABC) Size(ver uint8, id uint16) int32 {
+	size := int32(len(d.ABCData))
+	if id == TAG_DO_ABC {
+		size += 4 + d.ABCName.Size()
+	}
+	return size
+}
+
+func (d *DoABC) MinVersion() uint8 {
+	return 9
+}
+
+func (d *DoABC) TagId() uint16 {
+	return TAG_DO_ABC
+}
+
+func (d *DoABC) Name() string {
+	return "DoABC"
+}
+
+// ABC parses the ABC data of the tag.
+func (d *DoABC) ABC() (*ABCFile, error) {
+	a := new(ABCFile)
+	if _, err := a.ReadFrom(bytes.NewReader(d.ABCData)); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// SetABC encodes the ABC file as the data of the tag.
+func (d *DoABC) SetABC(a *ABCFile) error {
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		return err
+	}
+	d.ABCData = buf.Bytes()
+	return nil
+}
+
+type DoABCDefine struct {
+	DoABC
+}
+
+func (d *DoABCDefine) TagId() uint16 {
+	return TAG_DO_ABC_DEFINE
+}
+
+func (d *DoABCDefine) Name() string {
+	return "DoABCDefine"
+}
+
+const (
+	CONSTANT_UNDEFINED           uint8 = 0x00
+	CONSTANT_UTF8                uint8 = 0x01
+	CONSTANT_INT                 uint8 = 0x03
+	CONSTANT_UINT                uint8 = 0x04
+	CONSTANT_PRIVATE_NS          uint8 = 0x05
+	CONSTANT_DOUBLE              uint8 = 0x06
+	CONSTANT_QNAME               uint8 = 0x07
+	CONSTANT_NAMESPACE           uint8 = 0x08
+	CONSTANT_MULTINAME           uint8 = 0x09
+	CONSTANT_FALSE               uint8 = 0x0a
+	CONSTANT_TRUE                uint8 = 0x0b
+	CONSTANT_NULL                uint8 = 0x0c
+	CONSTANT_QNAME_A             uint8 = 0x0d
+	CONSTANT_MULTINAME_A         uint8 = 0x0e
+	CONSTANT_RTQNAME             uint8 = 0x0f
+	CONSTANT_RTQNAME_A           uint8 = 0x10
+	CONSTANT_RTQNAME_L           uint8 = 0x11
+	CONSTANT_RTQNAME_LA          uint8 = 0x12
+	CONSTANT_PACKAGE_NAMESPACE   uint8 = 0x16
+	CONSTANT_PACKAGE_INTERNAL_NS uint8 = 0x17
+	CONSTANT_PROTECTED_NAMESPACE uint8 = 0x18
+	CONSTANT_EXPLICIT_NAMESPACE  uint8 = 0x19
+	CONSTANT_STATIC_PROTECTED_NS uint8 = 0x1a
+	CONSTANT_MULTINAME_L         uint8 = 0x1b
+	CONSTANT_MULTINAME_LA        uint8 = 0x1c
+	CONSTANT_TYPENAME            uint8 = 0x1d
+)
+
+const (
+	METHOD_NEED_ARGUMENTS  uint8 = 0x01
+	METHOD_NEED_ACTIVATION uint8 = 0x02
+	METHOD_NEED_REST       uint8 = 0x04
+	METHOD_HAS_OPTIONAL    uint8 = 0x08
+	METHOD_SET_DXNS        uint8 = 0x40
+	METHOD_HAS_PARAM_NAMES uint8 = 0x80
+)
+
+const (
+	CLASS_SEALED       uint8 = 0x01
+	CLASS_FINAL        uint8 = 0x02
+	CLASS_INTERFACE    uint8 = 0x04
+	CLASS_PROTECTED_NS uint8 = 0x08
+)
+
+const (
+	TRAIT_SLOT     uint8 = 0
+	TRAIT_METHOD   uint8 = 1
+	TRAIT_GETTER   uint8 = 2
+	TRAIT_SETTER   uint8 = 3
+	TRAIT_CLASS    uint8 = 4
+	TRAIT_FUNCTION uint8 = 5
+	TRAIT_CONST    uint8 = 6
+)
+
+const (
+	ATTR_FINAL    uint8 = 0x1
+	ATTR_OVERRIDE uint8 = 0x2
+	ATTR_METADATA uint8 = 0x4
+)
+
+// abcReader reads the primitive types of an ABC file, keeping the first
+// error encountered.
+type abcReader struct {
+	io.Reader
+	err error
+}
+
+func (r *abcReader) u8() uint8 {
+	var b uint8
+	if r.err == nil {
+		r.err = binary.Read(r, binary.LittleEndian, &b)
+	}
+	return b
+}
+
+func (r *abcReader) u16() uint16 {
+	var u uint16
+	if r.err == nil {
+		r.err = binary.Read(r, binary.LittleEndian, &u)
+	}
+	return u
+}
+
+func (r *abcReader) u30() uint32 {
+	var e EncodedU32
+	if r.err == nil {
+		_, r.err = e.ReadFrom(r)
+	}
+	return uint32(e)
+}
+
+// s32 reads a variable length signed integer, which is sign extended from
+// the last bit read.
+func (r *abcReader) s32() int32 {
+	var e EncodedU32
+	if r.err != nil {
+		return 0
+	}
+	var n int64
+	n, r.err = e.ReadFrom(r)
+	if n > 0 && n < 5 {
+		shift := uint(32 - 7*n)
+		return int32(uint32(e)<<shift) >> shift
+	}
+	return int32(e)
+}
+
+func (r *abcReader) d64() float64 {
+	var d uint64
+	if r.err == nil {
+		r.err = binary.Read(r, binary.LittleEndian, &d)
+	}
+	return math.Float64frombits(d)
+}
+
+func (r *abcReader) string() string {
+	l := r.u30()
+	if r.err != nil {
+		return ""
+	}
+	var data []byte
+	if data, r.err = ioutil.ReadAll(io.LimitReader(r, int64(l))); r.err == nil && len(data) != int(l) {
+		r.err = ErrABCString
+	}
+	return string(data)
+}
+
+// count reads a count of entries, calling read for each.
+func (r *abcReader) count(read func()) {
+	for n := r.u30(); n > 0 && r.err == nil; n-- {
+		read()
+	}
+}
+
+func (r *abcReader) u30s(n uint32) []uint32 {
+	var list []uint32
+	for ; n > 0 && r.err == nil; n-- {
+		list = append(list, r.u30())
+	}
+	return list
+}
+
+type abcWriter struct {
+	io.Writer
+	err error
+}
+
+func (w *abcWriter) u8(b uint8) {
+	if w.err == nil {
+		w.err = binary.Write(w, binary.LittleEndian, b)
+	}
+}
+
+func (w *abcWriter) u16(u uint16) {
+	if w.err == nil {
+		w.err = binary.Write(w, binary.LittleEndian, u)
+	}
+}
+
+func (w *abcWriter) u30(u uint32) {
+	if w.err == nil {
+		e := EncodedU32(u)
+		_, w.err = e.WriteTo(w)
+	}
+}
+
+// s32 writes a signed integer as its 32 bit unsigned representation.
+func (w *abcWriter) s32(i int32) {
+	w.u30(uint32(i))
+}
+
+func (w *abcWriter) d64(d float64) {
+	if w.err == nil {
+		w.err = binary.Write(w, binary.LittleEndian, math.Float64bits(d))
+	}
+}
+
+func (w *abcWriter) string(s string) {
+	w.u30(uint32(len(s)))
+	if w.err == nil {
+		_, w.err = io.WriteString(w, s)
+	}
+}
+
+func (w *abcWriter) u30s(list []uint32) {
+	for _, u := range list {
+		w.u30(u)
+	}
+}
+
+type Namespace struct {
+	Kind uint8
+	Name uint32
+}
+
+// Multiname references its namespace, name and namespace set by index into
+// the constant pool, as used by its kind. For CONSTANT_TYPENAME, Name is the
+// index of the multiname of the generic type, and Params those of its type
+// parameters.
+type Multiname struct {
+	Kind      uint8
+	Namespace uint32
+	Name      uint32
+	NsSet     uint32
+	Params    []uint32
+}
+
+func (m *Multiname) readFrom(r *abcReader) {
+	switch m.Kind = r.u8(); m.Kind {
+	case CONSTANT_QNAME, CONSTANT_QNAME_A:
+		m.Namespace = r.u30()
+		m.Name = r.u30()
+	case CONSTANT_RTQNAME, CONSTANT_RTQNAME_A:
+		m.Name = r.u30()
+	case CONSTANT_MULTINAME, CONSTANT_MULTINAME_A:
+		m.Name = r.u30()
+		m.NsSet = r.u30()
+	case CONSTANT_MULTINAME_L, CONSTANT_MULTINAME_LA:
+		m.NsSet = r.u30()
+	case CONSTANT_TYPENAME:
+		m.Name = r.u30()
+		m.Params = r.u30s(r.u30())
+	case CONSTANT_RTQNAME_L, CONSTANT_RTQNAME_LA:
+	default:
+		if r.err == nil {
+			r.err = ErrABCMultiname
+		}
+	}
+}
+
+func (m *Multiname) writeTo(w *abcWriter) {
+	w.u8(m.Kind)
+	switch m.Kind {
+	case CONSTANT_QNAME, CONSTANT_QNAME_A:
+		w.u30(m.Namespace)
+		w.u30(m.Name)
+	case CONSTANT_RTQNAME, CONSTANT_RTQNAME_A:
+		w.u30(m.Name)
+	case CONSTANT_MULTINAME, CONSTANT_MULTINAME_A:
+		w.u30(m.Name)
+		w.u30(m.NsSet)
+	case CONSTANT_MULTINAME_L, CONSTANT_MULTINAME_LA:
+		w.u30(m.NsSet)
+	case CONSTANT_TYPENAME:
+		w.u30(m.Name)
+		w.u30(uint32(len(m.Params)))
+		w.u30s(m.Params)
+	case CONSTANT_RTQNAME_L, CONSTANT_RTQNAME_LA:
+	default:
+		if w.err == nil {
+			w.err = ErrABCMultiname
+		}
+	}
+}
+
+// ConstantPool holds the constants of an ABC file. Where a pool is not
+// empty, its first entry is the implicit entry at index 0, so that entries
+// may be indexed directly.
+type ConstantPool struct {
+	Ints       []int32
+	Uints      []uint32
+	Doubles    []float64
+	Strings    []string
+	Namespaces []Namespace
+	NsSets     [][]uint32
+	Multinames []Multiname
+}
+
+func (c *ConstantPool) readFrom(r *abcReader) {
+	pool := func(empty func(), read func()) {
+		n := r.u30()
+		if n > 0 {
+			empty()
+		}
+		for i := uint32(1); i < n && r.err == nil; i++ {
+			read()
+		}
+	}
+	pool(func() {
+		c.Ints = []int32{0}
+	}, func() {
+		c.Ints = append(c.Ints, r.s32())
+	})
+	pool(func() {
+		c.Uints = []uint32{0}
+	}, func() {
+		c.Uints = append(c.Uints, r.u30())
+	})
+	pool(func() {
+		c.Doubles = []float64{math.NaN()}
+	}, func() {
+		c.Doubles = append(c.Doubles, r.d64())
+	})
+	pool(func() {
+		c.Strings = []string{""}
+	}, func() {
+		c.Strings = append(c.Strings, r.string())
+	})
+	pool(func() {
+		c.Namespaces = []Namespace{{}}
+	}, func() {
+		kind := r.u8()
+		c.Namespaces = append(c.Namespaces, Namespace{kind, r.u30()})
+	})
+	pool(func() {
+		c.NsSets = [][]uint32{nil}
+	}, func() {
+		c.NsSets = append(c.NsSets, r.u30s(r.u30()))
+	})
+	pool(func() {
+		c.Multinames = []Multiname{{}}
+	}, func() {
+		var m Multiname
+		m.readFrom(r)
+		c.Multinames = append(c.Multinames, m)
+	})
+}
+
+// writeTo writes the pools, skipping the implicit entry at index 0.
+func (c *ConstantPool) writeTo(w *abcWriter) {
+	pool := func(n int, write func(int)) {
+		w.u30(uint32(n))
+		for i := 1; i < n; i++ {
+			write(i)
+		}
+	}
+	pool(len(c.Ints), func(i int) {
+		w.s32(c.Ints[i])
+	})
+	pool(len(c.Uints), func(i int) {
+		w.u30(c.Uints[i])
+	})
+	pool(len(c.Doubles), func(i int) {
+		w.d64(c.Doubles[i])
+	})
+	pool(len(c.Strings), func(i int) {
+		w.string(c.Strings[i])
+	})
+	pool(len(c.Namespaces), func(i int) {
+		w.u8(c.Namespaces[i].Kind)
+		w.u30(c.Namespaces[i].Name)
+	})
+	pool(len(c.NsSets), func(i int) {
+		w.u30(uint32(len(c.NsSets[i])))
+		w.u30s(c.NsSets[i])
+	})
+	pool(len(c.Multinames), func(i int) {
+		c.Multinames[i].writeTo(w)
+	})
+}
+
+type OptionDetail struct {
+	Value uint32
+	Kind  uint8
+}
+
+// MethodInfo describes the signature of a method. Options and ParamNames
+// are only present with the METHOD_HAS_OPTIONAL and METHOD_HAS_PARAM_NAMES
+// flags respectively.
+type MethodInfo struct {
+	ParamTypes []uint32
+	ReturnType uint32
+	Name       uint32
+	Flags      uint8
+	Options    []OptionDetail
+	ParamNames []uint32
+}
+
+func (m *MethodInfo) readFrom(r *abcReader) {
+	paramCount := r.u30()
+	m.ReturnType = r.u30()
+	m.ParamTypes = r.u30s(paramCount)
+	m.Name = r.u30()
+	m.Flags = r.u8()
+	if m.Flags&METHOD_HAS_OPTIONAL != 0 {
+		r.count(func() {
+			value := r.u30()
+			m.Options = append(m.Options, OptionDetail{value, r.u8()})
+		})
+	}
+	if m.Flags&METHOD_HAS_PARAM_NAMES != 0 {
+		m.ParamNames = r.u30s(paramCount)
+	}
+}
+
+// writeTo writes the method, with a parameter name for each parameter type
+// when METHOD_HAS_PARAM_NAMES is set.
+func (m *MethodInfo) writeTo(w *abcWriter) {
+	w.u30(uint32(len(m.ParamTypes)))
+	w.u30(m.ReturnType)
+	w.u30s(m.ParamTypes)
+	w.u30(m.Name)
+	w.u8(m.Flags)
+	if m.Flags&METHOD_HAS_OPTIONAL != 0 {
+		w.u30(uint32(len(m.Options)))
+		for _, o := range m.Options {
+			w.u30(o.Value)
+			w.u8(o.Kind)
+		}
+	}
+	if m.Flags&METHOD_HAS_PARAM_NAMES != 0 {
+		for n := range m.ParamTypes {
+			var name uint32
+			if n < len(m.ParamNames) {
+				name = m.ParamNames[n]
+			}
+			w.u30(name)
+		}
+	}
+}
+
+type MetadataItem struct {
+	Key, Value uint32
+}
+
+type MetadataInfo struct {
+	Name  uint32
+	Items []MetadataItem
+}
+
+// readFrom reads the metadata, which lists all of the keys before the
+// values.
+func (m *MetadataInfo) readFrom(r *abcReader) {
+	m.Name = r.u30()
+	keys := r.u30s(r.u30())
+	values := r.u30s(uint32(len(keys)))
+	for n, k := range keys {
+		if n < len(values) {
+			m.Items = append(m.Items, MetadataItem{k, values[n]})
+		}
+	}
+}
+
+func (m *MetadataInfo) writeTo(w *abcWriter) {
+	w.u30(m.Name)
+	w.u30(uint32(len(m.Items)))
+	for _, item := range m.Items {
+		w.u30(item.Key)
+	}
+	for _, item := range m.Items {
+		w.u30(item.Value)
+	}
+}
+
+// Trait is a property of a class, instance, script or activation. SlotId
+// holds the slot id of slot, const, class and function traits, and the
+// disp id of method, getter and setter traits. Index is the class index of
+// class traits and the method index of method, getter, setter and function
+// traits. TypeName, VIndex and VKind are only used by slot and const
+// traits.
+type Trait struct {
+	Name       uint32
+	Kind       uint8
+	Attributes uint8
+	SlotId     uint32
+	TypeName   uint32
+	VIndex     uint32
+	VKind      uint8
+	Index      uint32
+	Metadata   []uint32
+}
+
+func (t *Trait) readFrom(r *abcReader) {
+	t.Name = r.u30()
+	kind := r.u8()
+	t.Kind, t.Attributes = kind&0xf, kind>>4
+	t.SlotId = r.u30()
+	switch t.Kind {
+	case TRAIT_SLOT, TRAIT_CONST:
+		t.TypeName = r.u30()
+		if t.VIndex = r.u30(); t.VIndex != 0 {
+			t.VKind = r.u8()
+		}
+	case TRAIT_METHOD, TRAIT_GETTER, TRAIT_SETTER, TRAIT_CLASS, TRAIT_FUNCTION:
+		t.Index = r.u30()
+	default:
+		if r.err == nil {
+			r.err = ErrABCTrait
+		}
+	}
+	if t.Attributes&ATTR_METADATA != 0 {
+		t.Metadata = r.u30s(r.u30())
+	}
+}
+
+func (t *Trait) writeTo(w *abcWriter) {
+	w.u30(t.Name)
+	w.u8(t.Kind | t.Attributes<<4)
+	w.u30(t.SlotId)
+	switch t.Kind {
+	case TRAIT_SLOT, TRAIT_CONST:
+		w.u30(t.TypeName)
+		if w.u30(t.VIndex); t.VIndex != 0 {
+			w.u8(t.VKind)
+		}
+	case TRAIT_METHOD, TRAIT_GETTER, TRAIT_SETTER, TRAIT_CLASS, TRAIT_FUNCTION:
+		w.u30(t.Index)
+	default:
+		if w.err == nil {
+			w.err = ErrABCTrait
+		}
+	}
+	if t.Attributes&ATTR_METADATA != 0 {
+		w.u30(uint32(len(t.Metadata)))
+		w.u30s(t.Metadata)
+	}
+}
+
+func readTraits(r *abcReader) []Trait {
+	var traits []Trait
+	r.count(func() {
+		var t Trait
+		t.readFrom(r)
+		traits = append(traits, t)
+	})
+	return traits
+}
+
+func writeTraits(w *abcWriter, traits []Trait) {
+	w.u30(uint32(len(traits)))
+	for n := range traits {
+		traits[n].writeTo(w)
+	}
+}
+
+// InstanceInfo describes the instances of a class. ProtectedNs is only
+// present with the CLASS_PROTECTED_NS flag.
+type InstanceInfo struct {
+	Name        uint32
+	SuperName   uint32
+	Flags       uint8
+	ProtectedNs uint32
+	Interfaces  []uint32
+	IInit       uint32
+	Traits      []Trait
+}
+
+func (i *InstanceInfo) readFrom(r *abcReader) {
+	i.Name = r.u30()
+	i.SuperName = r.u30()
+	if i.Flags = r.u8(); i.Flags&CLASS_PROTECTED_NS != 0 {
+		i.ProtectedNs = r.u30()
+	}
+	i.Interfaces = r.u30s(r.u30())
+	i.IInit = r.u30()
+	i.Traits = readTraits(r)
+}
+
+func (i *InstanceInfo) writeTo(w *abcWriter) {
+	w.u30(i.Name)
+	w.u30(i.SuperName)
+	if w.u8(i.Flags); i.Flags&CLASS_PROTECTED_NS != 0 {
+		w.u30(i.ProtectedNs)
+	}
+	w.u30(uint32(len(i.Interfaces)))
+	w.u30s(i.Interfaces)
+	w.u30(i.IInit)
+	writeTraits(w, i.Traits)
+}
+
+type ClassInfo struct {
+	CInit  uint32
+	Traits []Trait
+}
+
+type ScriptInfo struct {
+	Init   uint32
+	Traits []Trait
+}
+
+type ExceptionInfo struct {
+	From, To, Target uint32
+	ExcType, VarName uint32
+}
+
+type MethodBody struct {
+	Method         uint32
+	MaxStack       uint32
+	LocalCount     uint32
+	InitScopeDepth uint32
+	MaxScopeDepth  uint32
+	Code           []byte
+	Exceptions     []ExceptionInfo
+	Traits         []Trait
+}
+
+func (m *MethodBody) readFrom(r *abcReader) {
+	m.Method = r.u30()
+	m.MaxStack = r.u30()
+	m.LocalCount = r.u30()
+	m.InitScopeDepth = r.u30()
+	m.MaxScopeDepth = r.u30()
+	l := r.u30()
+	if r.err == nil {
+		if m.Code, r.err = ioutil.ReadAll(io.LimitReader(r, int64(l))); r.err == nil && len(m.Code) != int(l) {
+			r.err = io.ErrUnexpectedEOF
+		}
+	}
+	r.count(func() {
+		m.Exceptions = append(m.Exceptions, ExceptionInfo{r.u30(), r.u30(), r.u30(), r.u30(), r.u30()})
+	})
+	m.Traits = readTraits(r)
+}
+
+func (m *MethodBody) writeTo(w *abcWriter) {
+	w.u30(m.Method)
+	w.u30(m.MaxStack)
+	w.u30(m.LocalCount)
+	w.u30(m.InitScopeDepth)
+	w.u30(m.MaxScopeDepth)
+	w.u30(uint32(len(m.Code)))
+	if w.err == nil {
+		_, w.err = w.Write(m.Code)
+	}
+	w.u30(uint32(len(m.Exceptions)))
+	for _, e := range m.Exceptions {
+		w.u30s([]uint32{e.From, e.To, e.Target, e.ExcType, e.VarName})
+	}
+	writeTraits(w, m.Traits)
+}
+
+// ABCFile is a parsed ActionScript 3 ABC file. Indices into the constant
+// pool, methods, metadata and classes are kept as in the file.
+type ABCFile struct {
+	MinorVersion uint16
+	MajorVersion uint16
+	ConstantPool ConstantPool
+	Methods      []MethodInfo
+	Metadata     []MetadataInfo
+	Instances    []InstanceInfo
+	Classes      []ClassInfo
+	Scripts      []ScriptInfo
+	MethodBodies []MethodBody
+}
+
+func (a *ABCFile) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	r := &abcReader{Reader: c}
+	a.MinorVersion = r.u16()
+	a.MajorVersion = r.u16()
+	a.ConstantPool.readFrom(r)
+	r.count(func() {
+		var m MethodInfo
+		m.readFrom(r)
+		a.Methods = append(a.Methods, m)
+	})
+	r.count(func() {
+		var m MetadataInfo
+		m.readFrom(r)
+		a.Metadata = append(a.Metadata, m)
+	})
+	classCount := r.u30()
+	for n := uint32(0); n < classCount && r.err == nil; n++ {
+		var i InstanceInfo
+		i.readFrom(r)
+		a.Instances = append(a.Instances, i)
+	}
+	for n := uint32(0); n < classCount && r.err == nil; n++ {
+		cInit := r.u30()
+		a.Classes = append(a.Classes, ClassInfo{cInit, readTraits(r)})
+	}
+	r.count(func() {
+		init := r.u30()
+		a.Scripts = append(a.Scripts, ScriptInfo{init, readTraits(r)})
+	})
+	r.count(func() {
+		var m MethodBody
+		m.readFrom(r)
+		a.MethodBodies = append(a.MethodBodies, m)
+	})
+	if r.err == io.EOF {
+		r.err = io.ErrUnexpectedEOF
+	}
+	err = r.err
+	return
+}
+
+func (a *ABCFile) WriteTo(f io.Writer) (total int64, err error) {
+	if len(a.Instances) != len(a.Classes) {
+		return 0, ErrABCClasses
+	}
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	w := &abcWriter{Writer: c}
+	w.u16(a.MinorVersion)
+	w.u16(a.MajorVersion)
+	a.ConstantPool.writeTo(w)
+	w.u30(uint32(len(a.Methods)))
+	for n := range a.Methods {
+		a.Methods[n].writeTo(w)
+	}
+	w.u30(uint32(len(a.Metadata)))
+	for n := range a.Metadata {
+		a.Metadata[n].writeTo(w)
+	}
+	w.u30(uint32(len(a.Instances)))
+	for n := range a.Instances {
+		a.Instances[n].writeTo(w)
+	}
+	for _, class := range a.Classes {
+		w.u30(class.CInit)
+		writeTraits(w, class.Traits)
+	}
+	w.u30(uint32(len(a.Scripts)))
+	for _, script := range a.Scripts {
+		w.u30(script.Init)
+		writeTraits(w, script.Traits)
+	}
+	w.u30(uint32(len(a.MethodBodies)))
+	for n := range a.MethodBodies {
+		a.MethodBodies[n].writeTo(w)
+	}
+	err = w.err
+	return
+}
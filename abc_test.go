@@ -0,0 +1,117 @@
+package swf
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func testABC() ([]byte, *ABCFile) {
+	data := []byte{
+		16, 0, 46, 0,
+		// constant pool
+		2, 0xfb, 0xff, 0xff, 0xff, 0x0f,
+		0,
+		2, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f,
+		3, 4, 'M', 'a', 'i', 'n', 1, 'x',
+		2, 0x16, 0,
+		2, 1, 1,
+		4, 0x07, 1, 1, 0x09, 2, 1, 0x1d, 1, 1, 2,
+		// methods
+		1, 1, 0, 2, 2, 0x88, 1, 1, 0x03, 2,
+		// metadata
+		1, 1, 1, 2, 1,
+		// instances and classes
+		1, 1, 0, 0x09, 1, 0, 0, 2,
+		2, 0x40, 1, 0, 1, 0x03, 1, 0,
+		1, 0x21, 2, 0,
+		0, 0,
+		// scripts
+		1, 0, 1, 1, 0x04, 1, 0,
+		// method bodies
+		1, 0, 1, 2, 0, 1, 3, 0xd0, 0x30, 0x47, 1, 0, 2, 2, 1, 2, 0,
+	}
+	return data, &ABCFile{
+		MinorVersion: 16,
+		MajorVersion: 46,
+		ConstantPool: ConstantPool{
+			Ints:       []int32{0, -5},
+			Doubles:    []float64{math.NaN(), 1.5},
+			Strings:    []string{"", "Main", "x"},
+			Namespaces: []Namespace{{}, {CONSTANT_PACKAGE_NAMESPACE, 0}},
+			NsSets:     [][]uint32{nil, {1}},
+			Multinames: []Multiname{
+				{},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 1},
+				{Kind: CONSTANT_MULTINAME, Name: 2, NsSet: 1},
+				{Kind: CONSTANT_TYPENAME, Name: 1, Params: []uint32{2}},
+			},
+		},
+		Methods: []MethodInfo{
+			{
+				ParamTypes: []uint32{2},
+				Name:       2,
+				Flags:      METHOD_HAS_OPTIONAL | METHOD_HAS_PARAM_NAMES,
+				Options:    []OptionDetail{{1, CONSTANT_INT}},
+				ParamNames: []uint32{2},
+			},
+		},
+		Metadata: []MetadataInfo{{1, []MetadataItem{{2, 1}}}},
+		Instances: []InstanceInfo{
+			{
+				Name:        1,
+				Flags:       CLASS_SEALED | CLASS_PROTECTED_NS,
+				ProtectedNs: 1,
+				Traits: []Trait{
+					{Name: 2, Kind: TRAIT_SLOT, Attributes: ATTR_METADATA, SlotId: 1, VIndex: 1, VKind: CONSTANT_INT, Metadata: []uint32{0}},
+					{Name: 1, Kind: TRAIT_METHOD, Attributes: ATTR_OVERRIDE, SlotId: 2},
+				},
+			},
+		},
+		Classes: []ClassInfo{{}},
+		Scripts: []ScriptInfo{{0, []Trait{{Name: 1, Kind: TRAIT_CLASS, SlotId: 1}}}},
+		MethodBodies: []MethodBody{
+			{
+				MaxStack:      1,
+				LocalCount:    2,
+				MaxScopeDepth: 1,
+				Code:          []byte{0xd0, 0x30, 0x47},
+				Exceptions:    []ExceptionInfo{{0, 2, 2, 1, 2}},
+			},
+		},
+	}
+}
+
+func TestDoABC(t *testing.T) {
+	testTag(t, 9, []byte{1, 0, 0, 0, 'm', 0, 16, 0, 46, 0}, &DoABC{true, "m", []byte{16, 0, 46, 0}})
+	testTag(t, 9, []byte{16, 0, 46, 0}, &DoABCDefine{DoABC{ABCData: []byte{16, 0, 46, 0}}})
+}
+
+func TestABCFile(t *testing.T) {
+	data, expected := testABC()
+	a, err := (&DoABC{ABCData: data}).ABC()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d := a.ConstantPool.Doubles[0]; !math.IsNaN(d) {
+		t.Errorf("expecting NaN default double, got %v", d)
+	}
+	a.ConstantPool.Doubles[0], expected.ConstantPool.Doubles[0] = 0, 0
+	if !reflect.DeepEqual(a, expected) {
+		t.Errorf("expecting %v, got %v", expected, a)
+	}
+	for n := range data {
+		if _, err := new(ABCFile).ReadFrom(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("truncated to %d bytes: expecting error", n)
+			break
+		}
+	}
+	if _, err := new(ABCFile).ReadFrom(bytes.NewReader([]byte{16, 0, 46, 0, 0, 0, 0, 0, 0, 0, 2, 0x42})); err != ErrABCMultiname {
+		t.Errorf("expecting error %s, got %v", ErrABCMultiname, err)
+	}
+	if _, err := new(ABCFile).ReadFrom(bytes.NewReader([]byte{16, 0})); err != io.ErrUnexpectedEOF {
+		t.Errorf("expecting error %s, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
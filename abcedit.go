@@ -0,0 +1,751 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrABCOperands = errors.New("avm2: wrong number of operands")
+	ErrABCTarget   = errors.New("avm2: branch target is not an instruction")
+	ErrABCStack    = errors.New("avm2: stack underflow")
+	ErrABCIndex    = errors.New("abc: constant pool index out of range")
+)
+
+func (c *ConstantPool) appendInt(i int32) uint32 {
+	if len(c.Ints) == 0 {
+		c.Ints = []int32{0}
+	}
+	c.Ints = append(c.Ints, i)
+	return uint32(len(c.Ints) - 1)
+}
+
+func (c *ConstantPool) appendUint(u uint32) uint32 {
+	if len(c.Uints) == 0 {
+		c.Uints = []uint32{0}
+	}
+	c.Uints = append(c.Uints, u)
+	return uint32(len(c.Uints) - 1)
+}
+
+func (c *ConstantPool) appendDouble(d float64) uint32 {
+	if len(c.Doubles) == 0 {
+		c.Doubles = []float64{math.NaN()}
+	}
+	c.Doubles = append(c.Doubles, d)
+	return uint32(len(c.Doubles) - 1)
+}
+
+func (c *ConstantPool) appendString(s string) uint32 {
+	if len(c.Strings) == 0 {
+		c.Strings = []string{""}
+	}
+	c.Strings = append(c.Strings, s)
+	return uint32(len(c.Strings) - 1)
+}
+
+func (c *ConstantPool) appendNamespace(ns Namespace) uint32 {
+	if len(c.Namespaces) == 0 {
+		c.Namespaces = []Namespace{{}}
+	}
+	c.Namespaces = append(c.Namespaces, ns)
+	return uint32(len(c.Namespaces) - 1)
+}
+
+func (c *ConstantPool) appendNsSet(set []uint32) uint32 {
+	if len(c.NsSets) == 0 {
+		c.NsSets = [][]uint32{nil}
+	}
+	c.NsSets = append(c.NsSets, set)
+	return uint32(len(c.NsSets) - 1)
+}
+
+func (c *ConstantPool) appendMultiname(m Multiname) uint32 {
+	if len(c.Multinames) == 0 {
+		c.Multinames = []Multiname{{}}
+	}
+	c.Multinames = append(c.Multinames, m)
+	return uint32(len(c.Multinames) - 1)
+}
+
+func equalIndices(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// AddInt returns the index of the integer in the pool, appending it to the
+// pool if it is not already present. The other Add methods behave in the same
+// way for their own pools.
+func (c *ConstantPool) AddInt(i int32) uint32 {
+	for n := 1; n < len(c.Ints); n++ {
+		if c.Ints[n] == i {
+			return uint32(n)
+		}
+	}
+	return c.appendInt(i)
+}
+
+func (c *ConstantPool) AddUint(u uint32) uint32 {
+	for n := 1; n < len(c.Uints); n++ {
+		if c.Uints[n] == u {
+			return uint32(n)
+		}
+	}
+	return c.appendUint(u)
+}
+
+func (c *ConstantPool) AddDouble(d float64) uint32 {
+	for n := 1; n < len(c.Doubles); n++ {
+		if math.Float64bits(c.Doubles[n]) == math.Float64bits(d) {
+			return uint32(n)
+		}
+	}
+	return c.appendDouble(d)
+}
+
+func (c *ConstantPool) AddString(s string) uint32 {
+	for n := 1; n < len(c.Strings); n++ {
+		if c.Strings[n] == s {
+			return uint32(n)
+		}
+	}
+	return c.appendString(s)
+}
+
+// Private namespaces are distinct by index, so AddNamespace always adds them.
+func (c *ConstantPool) AddNamespace(ns Namespace) uint32 {
+	for n := 1; n < len(c.Namespaces) && ns.Kind != CONSTANT_PRIVATE_NS; n++ {
+		if c.Namespaces[n] == ns {
+			return uint32(n)
+		}
+	}
+	return c.appendNamespace(ns)
+}
+
+func (c *ConstantPool) AddNsSet(set []uint32) uint32 {
+	for n := 1; n < len(c.NsSets); n++ {
+		if equalIndices(c.NsSets[n], set) {
+			return uint32(n)
+		}
+	}
+	return c.appendNsSet(set)
+}
+
+func (c *ConstantPool) AddMultiname(m Multiname) uint32 {
+	for n := 1; n < len(c.Multinames); n++ {
+		o := c.Multinames[n]
+		if o.Kind == m.Kind && o.Namespace == m.Namespace && o.Name == m.Name && o.NsSet == m.NsSet && equalIndices(o.Params, m.Params) {
+			return uint32(n)
+		}
+	}
+	return c.appendMultiname(m)
+}
+
+func (w *abcWriter) s24(i int) {
+	if w.err == nil {
+		_, w.err = w.Write([]byte{byte(i), byte(i >> 8), byte(i >> 16)})
+	}
+}
+
+func operandValue(kind uint8, v int) EncodedU32 {
+	if kind == operandShort {
+		return EncodedU32(uint16(v))
+	}
+	return EncodedU32(uint32(v))
+}
+
+func instructionSize(i *Instruction) (int, error) {
+	info := instructionInfo[i.Opcode]
+	if info.name == "" {
+		return 0, InstructionError{i.Offset, i.Opcode}
+	}
+	if i.Opcode == OP_LOOKUPSWITCH {
+		if len(i.Operands) < 2 {
+			return 0, ErrABCOperands
+		}
+		cases := EncodedU32(len(i.Operands) - 2)
+		return 4 + int(cases.Size()) + 3*(len(i.Operands)-1), nil
+	}
+	if len(i.Operands) != len(info.operands) {
+		return 0, ErrABCOperands
+	}
+	size := 1
+	for n, k := range info.operands {
+		switch k {
+		case operandU8, operandS8:
+			size++
+		case operandS24:
+			size += 3
+		default:
+			e := operandValue(k, i.Operands[n])
+			size += int(e.Size())
+		}
+	}
+	return size, nil
+}
+
+// codeLayout maps the offsets of instructions, as given, to the offsets of
+// the encoded instructions.
+type codeLayout struct {
+	offsets   map[int]int
+	last, end int
+}
+
+func (l *codeLayout) target(offset int) (int, error) {
+	if o, ok := l.offsets[offset]; ok {
+		return o, nil
+	} else if offset > l.last {
+		return l.end, nil
+	}
+	return 0, ErrABCTarget
+}
+
+func (l *codeLayout) exceptions(exceptions []ExceptionInfo) ([]ExceptionInfo, error) {
+	var moved []ExceptionInfo
+	for _, e := range exceptions {
+		var offsets [3]int
+		for n, o := range []uint32{e.From, e.To, e.Target} {
+			var err error
+			if offsets[n], err = l.target(int(o)); err != nil {
+				return nil, err
+			}
+		}
+		e.From, e.To, e.Target = uint32(offsets[0]), uint32(offsets[1]), uint32(offsets[2])
+		moved = append(moved, e)
+	}
+	return moved, nil
+}
+
+func encodeInstructions(instructions []Instruction) ([]byte, *codeLayout, error) {
+	l := &codeLayout{offsets: make(map[int]int), last: -1}
+	sizes := make([]int, len(instructions))
+	for n := range instructions {
+		i := &instructions[n]
+		var err error
+		if sizes[n], err = instructionSize(i); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := l.offsets[i.Offset]; !ok {
+			l.offsets[i.Offset] = l.end
+		}
+		if i.Offset > l.last {
+			l.last = i.Offset
+		}
+		l.end += sizes[n]
+	}
+	var buf bytes.Buffer
+	w := &abcWriter{Writer: &buf}
+	for n := range instructions {
+		i := &instructions[n]
+		offset := buf.Len()
+		w.u8(i.Opcode)
+		if i.Opcode == OP_LOOKUPSWITCH {
+			for o, t := range i.Operands {
+				if o == 1 {
+					w.u30(uint32(len(i.Operands) - 2))
+				}
+				target, err := l.target(t)
+				if err != nil {
+					return nil, nil, err
+				}
+				w.s24(target - offset)
+			}
+			continue
+		}
+		for o, k := range instructionInfo[i.Opcode].operands {
+			v := i.Operands[o]
+			switch k {
+			case operandU8, operandS8:
+				w.u8(uint8(v))
+			case operandS24:
+				target, err := l.target(v)
+				if err != nil {
+					return nil, nil, err
+				}
+				w.s24(target - offset - sizes[n])
+			default:
+				w.u30(uint32(operandValue(k, v)))
+			}
+		}
+	}
+	return buf.Bytes(), l, w.err
+}
+
+// EncodeInstructions encodes the instructions, in order, as the code of a
+// method body. Branch targets refer to the Offset of the first instruction
+// with that Offset, and are moved to the offset of that instruction in the
+// encoded code; targets after the last instruction refer to the end of the
+// code. Inserted instructions that are not branched to may use any unused
+// Offset, such as -1.
+func EncodeInstructions(instructions []Instruction) ([]byte, error) {
+	code, _, err := encodeInstructions(instructions)
+	return code, err
+}
+
+// runtimeOperands returns the number of stack values used by the multiname
+// to provide its name or namespace at runtime.
+func (a *ABCFile) runtimeOperands(n int) int {
+	if n < 0 || n >= len(a.ConstantPool.Multinames) {
+		return 0
+	}
+	switch a.ConstantPool.Multinames[n].Kind {
+	case CONSTANT_RTQNAME, CONSTANT_RTQNAME_A, CONSTANT_MULTINAME_L, CONSTANT_MULTINAME_LA:
+		return 1
+	case CONSTANT_RTQNAME_L, CONSTANT_RTQNAME_LA:
+		return 2
+	}
+	return 0
+}
+
+// stackEffect returns the number of values the instruction pops from, and
+// then pushes to, the stack.
+func (a *ABCFile) stackEffect(i *Instruction) (int, int) {
+	var rt, args int
+	if ops := instructionInfo[i.Opcode].operands; len(ops) > 0 && ops[0] == operandMultiname {
+		rt = a.runtimeOperands(i.Operands[0])
+	}
+	if len(i.Operands) > 0 {
+		args = i.Operands[len(i.Operands)-1]
+	}
+	switch i.Opcode {
+	case OP_PUSHNULL, OP_PUSHUNDEFINED, OP_PUSHBYTE, OP_PUSHSHORT, OP_PUSHTRUE, OP_PUSHFALSE, OP_PUSHNAN, OP_PUSHSTRING, OP_PUSHINT, OP_PUSHUINT, OP_PUSHDOUBLE, OP_PUSHNAMESPACE, OP_HASNEXT2, OP_NEWFUNCTION, OP_NEWACTIVATION, OP_NEWCATCH, OP_FINDDEF, OP_GETLEX, OP_GETLOCAL, OP_GETLOCAL0, OP_GETLOCAL1, OP_GETLOCAL2, OP_GETLOCAL3, OP_GETGLOBALSCOPE, OP_GETSCOPEOBJECT, OP_GETOUTERSCOPE, OP_GETGLOBALSLOT:
+		return 0, 1
+	case OP_THROW, OP_DXNSLATE, OP_IFTRUE, OP_IFFALSE, OP_LOOKUPSWITCH, OP_PUSHWITH, OP_PUSHSCOPE, OP_POP, OP_RETURNVALUE, OP_SETLOCAL, OP_SETLOCAL0, OP_SETLOCAL1, OP_SETLOCAL2, OP_SETLOCAL3, OP_SETGLOBALSLOT:
+		return 1, 0
+	case OP_IFNLT, OP_IFNLE, OP_IFNGT, OP_IFNGE, OP_IFEQ, OP_IFNE, OP_IFLT, OP_IFLE, OP_IFGT, OP_IFGE, OP_IFSTRICTEQ, OP_IFSTRICTNE, OP_SI8, OP_SI16, OP_SI32, OP_SF32, OP_SF64, OP_SETSLOT:
+		return 2, 0
+	case OP_LI8, OP_LI16, OP_LI32, OP_LF32, OP_LF64, OP_SXI1, OP_SXI8, OP_SXI16, OP_NEWCLASS, OP_GETSLOT, OP_CONVERT_S, OP_ESC_XELEM, OP_ESC_XATTR, OP_CONVERT_I, OP_CONVERT_U, OP_CONVERT_D, OP_CONVERT_B, OP_CONVERT_O, OP_CHECKFILTER, OP_COERCE, OP_COERCE_B, OP_COERCE_A, OP_COERCE_I, OP_COERCE_D, OP_COERCE_S, OP_ASTYPE, OP_COERCE_U, OP_COERCE_O, OP_NEGATE, OP_INCREMENT, OP_DECREMENT, OP_TYPEOF, OP_NOT, OP_BITNOT, OP_ISTYPE, OP_INCREMENT_I, OP_DECREMENT_I, OP_NEGATE_I:
+		return 1, 1
+	case OP_NEXTNAME, OP_NEXTVALUE, OP_HASNEXT, OP_ASTYPELATE, OP_ADD, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE, OP_MODULO, OP_LSHIFT, OP_RSHIFT, OP_URSHIFT, OP_BITAND, OP_BITOR, OP_BITXOR, OP_EQUALS, OP_STRICTEQUALS, OP_LESSTHAN, OP_LESSEQUALS, OP_GREATERTHAN, OP_GREATEREQUALS, OP_INSTANCEOF, OP_ISTYPELATE, OP_IN, OP_ADD_I, OP_SUBTRACT_I, OP_MULTIPLY_I:
+		return 2, 1
+	case OP_DUP:
+		return 1, 2
+	case OP_SWAP:
+		return 2, 2
+	case OP_GETSUPER, OP_GETPROPERTY, OP_DELETEPROPERTY, OP_GETDESCENDANTS:
+		return 1 + rt, 1
+	case OP_SETSUPER, OP_SETPROPERTY, OP_INITPROPERTY:
+		return 2 + rt, 0
+	case OP_FINDPROPSTRICT, OP_FINDPROPERTY:
+		return rt, 1
+	case OP_CALL:
+		return 2 + args, 1
+	case OP_CONSTRUCT, OP_CALLMETHOD, OP_CALLSTATIC, OP_APPLYTYPE:
+		return 1 + args, 1
+	case OP_CONSTRUCTSUPER:
+		return 1 + args, 0
+	case OP_CALLSUPER, OP_CALLPROPERTY, OP_CALLPROPLEX, OP_CONSTRUCTPROP:
+		return 1 + rt + args, 1
+	case OP_CALLSUPERVOID, OP_CALLPROPVOID:
+		return 1 + rt + args, 0
+	case OP_NEWOBJECT:
+		return 2 * args, 1
+	case OP_NEWARRAY:
+		return args, 1
+	}
+	return 0, 0
+}
+
+// register returns the highest local register used by the instruction, or
+// -1 if it uses none.
+func register(i *Instruction) int {
+	switch i.Opcode {
+	case OP_GETLOCAL, OP_SETLOCAL, OP_KILL, OP_INCLOCAL, OP_DECLOCAL, OP_INCLOCAL_I, OP_DECLOCAL_I:
+		return i.Operands[0]
+	case OP_HASNEXT2:
+		return int(max(int32(i.Operands[0]), int32(i.Operands[1])))
+	case OP_DEBUG:
+		return i.Operands[2]
+	case OP_GETLOCAL0, OP_GETLOCAL1, OP_GETLOCAL2, OP_GETLOCAL3:
+		return int(i.Opcode - OP_GETLOCAL0)
+	case OP_SETLOCAL0, OP_SETLOCAL1, OP_SETLOCAL2, OP_SETLOCAL3:
+		return int(i.Opcode - OP_SETLOCAL0)
+	}
+	return -1
+}
+
+// limits follows the flow of the instructions, from the start of the code
+// and each exception handler, to find the maximum stack size and scope
+// depth, relative to the initial scope depth, that they use.
+func (a *ABCFile) limits(instructions []Instruction, exceptions []ExceptionInfo) (int, int, error) {
+	type state struct {
+		n, stack, scope int
+	}
+	index := make(map[int]int, len(instructions))
+	for n, i := range instructions {
+		index[i.Offset] = n
+	}
+	queue := []state{{}}
+	for _, e := range exceptions {
+		if n, ok := index[int(e.Target)]; ok {
+			queue = append(queue, state{n, 1, 0})
+		}
+	}
+	visited := make([]bool, len(instructions))
+	var maxStack, maxScope int
+	for len(queue) > 0 {
+		s := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for s.n < len(instructions) && !visited[s.n] {
+			visited[s.n] = true
+			i := &instructions[s.n]
+			pop, push := a.stackEffect(i)
+			if s.stack < pop {
+				return 0, 0, ErrABCStack
+			}
+			if s.stack += push - pop; s.stack > maxStack {
+				maxStack = s.stack
+			}
+			switch i.Opcode {
+			case OP_PUSHSCOPE, OP_PUSHWITH:
+				if s.scope++; s.scope > maxScope {
+					maxScope = s.scope
+				}
+			case OP_POPSCOPE:
+				s.scope--
+			}
+			for _, t := range i.Targets() {
+				if n, ok := index[t]; ok {
+					queue = append(queue, state{n, s.stack, s.scope})
+				}
+			}
+			switch i.Opcode {
+			case OP_JUMP, OP_LOOKUPSWITCH, OP_THROW, OP_RETURNVOID, OP_RETURNVALUE:
+				s.n = len(instructions)
+			default:
+				s.n++
+			}
+		}
+	}
+	return maxStack, maxScope, nil
+}
+
+// SetCode encodes the instructions, as with EncodeInstructions, as the code
+// of the method body. The offsets of its exception handlers are moved in the
+// same way as branch targets, and its maximum stack size, local count and
+// maximum scope depth are recomputed from the new code.
+func (a *ABCFile) SetCode(body *MethodBody, instructions []Instruction) error {
+	code, l, err := encodeInstructions(instructions)
+	if err != nil {
+		return err
+	}
+	exceptions, err := l.exceptions(body.Exceptions)
+	if err != nil {
+		return err
+	}
+	if instructions, err = DecodeInstructions(code); err != nil {
+		return err
+	}
+	maxStack, maxScope, err := a.limits(instructions, exceptions)
+	if err != nil {
+		return err
+	}
+	locals := 0
+	if int(body.Method) < len(a.Methods) {
+		m := &a.Methods[body.Method]
+		locals = len(m.ParamTypes) + 1
+		if m.Flags&(METHOD_NEED_ARGUMENTS|METHOD_NEED_REST) != 0 {
+			locals++
+		}
+	}
+	for n := range instructions {
+		if r := register(&instructions[n]); r >= locals {
+			locals = r + 1
+		}
+	}
+	body.Code = code
+	body.Exceptions = exceptions
+	body.MaxStack = uint32(maxStack)
+	body.LocalCount = uint32(locals)
+	body.MaxScopeDepth = body.InitScopeDepth + uint32(maxScope)
+	return nil
+}
+
+// valueOperand returns the kind of pool indexed by a default parameter or
+// slot value of the given kind.
+func valueOperand(kind uint8) (uint8, bool) {
+	switch kind {
+	case CONSTANT_INT:
+		return operandInt, true
+	case CONSTANT_UINT:
+		return operandUint, true
+	case CONSTANT_DOUBLE:
+		return operandDouble, true
+	case CONSTANT_UTF8:
+		return operandString, true
+	case CONSTANT_NAMESPACE, CONSTANT_PACKAGE_NAMESPACE, CONSTANT_PACKAGE_INTERNAL_NS, CONSTANT_PROTECTED_NAMESPACE, CONSTANT_EXPLICIT_NAMESPACE, CONSTANT_STATIC_PROTECTED_NS, CONSTANT_PRIVATE_NS:
+		return operandNamespace, true
+	}
+	return 0, false
+}
+
+// poolReferences calls ref with the kind of pool and the location of each
+// constant pool index held by the file, other than those in method code.
+func (a *ABCFile) poolReferences(ref func(kind uint8, n *uint32)) {
+	value := func(kind uint8, n *uint32) {
+		if k, ok := valueOperand(kind); ok {
+			ref(k, n)
+		}
+	}
+	list := func(kind uint8, list []uint32) {
+		for n := range list {
+			ref(kind, &list[n])
+		}
+	}
+	traits := func(traits []Trait) {
+		for n := range traits {
+			t := &traits[n]
+			ref(operandMultiname, &t.Name)
+			if t.Kind == TRAIT_SLOT || t.Kind == TRAIT_CONST {
+				ref(operandMultiname, &t.TypeName)
+				value(t.VKind, &t.VIndex)
+			}
+		}
+	}
+	for n := range a.Methods {
+		m := &a.Methods[n]
+		list(operandMultiname, m.ParamTypes)
+		ref(operandMultiname, &m.ReturnType)
+		ref(operandString, &m.Name)
+		for o := range m.Options {
+			value(m.Options[o].Kind, &m.Options[o].Value)
+		}
+		list(operandString, m.ParamNames)
+	}
+	for n := range a.Metadata {
+		m := &a.Metadata[n]
+		ref(operandString, &m.Name)
+		for i := range m.Items {
+			ref(operandString, &m.Items[i].Key)
+			ref(operandString, &m.Items[i].Value)
+		}
+	}
+	for n := range a.Instances {
+		i := &a.Instances[n]
+		ref(operandMultiname, &i.Name)
+		ref(operandMultiname, &i.SuperName)
+		ref(operandNamespace, &i.ProtectedNs)
+		list(operandMultiname, i.Interfaces)
+		traits(i.Traits)
+	}
+	for n := range a.Classes {
+		traits(a.Classes[n].Traits)
+	}
+	for n := range a.Scripts {
+		traits(a.Scripts[n].Traits)
+	}
+	for n := range a.MethodBodies {
+		b := &a.MethodBodies[n]
+		for e := range b.Exceptions {
+			ref(operandMultiname, &b.Exceptions[e].ExcType)
+			ref(operandMultiname, &b.Exceptions[e].VarName)
+		}
+		traits(b.Traits)
+	}
+}
+
+type poolKey struct {
+	kind  uint8
+	value interface{}
+}
+
+// poolBuilder builds a new constant pool from the entries of an old pool,
+// in the order that they are referenced.
+type poolBuilder struct {
+	old     *ConstantPool
+	pool    ConstantPool
+	indices map[poolKey]uint32
+	depth   int
+	err     error
+}
+
+func (b *poolBuilder) add(key poolKey, add func() uint32) uint32 {
+	if n, ok := b.indices[key]; ok {
+		return n
+	}
+	n := add()
+	b.indices[key] = n
+	return n
+}
+
+func (b *poolBuilder) nsSet(n uint32) uint32 {
+	if n == 0 || b.err != nil {
+		return 0
+	} else if int(n) >= len(b.old.NsSets) {
+		b.err = ErrABCIndex
+		return 0
+	}
+	set := make([]uint32, len(b.old.NsSets[n]))
+	for i, ns := range b.old.NsSets[n] {
+		set[i] = b.index(operandNamespace, ns)
+	}
+	// namespace sets are not referenced directly, so use an unused kind
+	return b.add(poolKey{0, fmt.Sprint(set)}, func() uint32 {
+		return b.pool.appendNsSet(set)
+	})
+}
+
+func (b *poolBuilder) multiname(m Multiname) uint32 {
+	if b.depth++; b.depth > 8 {
+		b.err = ErrABCIndex
+	}
+	if m.Kind == CONSTANT_TYPENAME {
+		m.Name = b.index(operandMultiname, m.Name)
+		params := make([]uint32, len(m.Params))
+		for n, p := range m.Params {
+			params[n] = b.index(operandMultiname, p)
+		}
+		m.Params = params
+	} else {
+		m.Name = b.index(operandString, m.Name)
+	}
+	m.Namespace = b.index(operandNamespace, m.Namespace)
+	m.NsSet = b.nsSet(m.NsSet)
+	b.depth--
+	return b.add(poolKey{operandMultiname, fmt.Sprint(m)}, func() uint32 {
+		return b.pool.appendMultiname(m)
+	})
+}
+
+// index returns the index in the new pool of the entry at index n of the
+// old pool.
+func (b *poolBuilder) index(kind uint8, n uint32) uint32 {
+	if n == 0 || b.err != nil {
+		return 0
+	}
+	c := b.old
+	switch kind {
+	case operandInt:
+		if int(n) < len(c.Ints) {
+			i := c.Ints[n]
+			return b.add(poolKey{kind, i}, func() uint32 {
+				return b.pool.appendInt(i)
+			})
+		}
+	case operandUint:
+		if int(n) < len(c.Uints) {
+			u := c.Uints[n]
+			return b.add(poolKey{kind, u}, func() uint32 {
+				return b.pool.appendUint(u)
+			})
+		}
+	case operandDouble:
+		if int(n) < len(c.Doubles) {
+			d := c.Doubles[n]
+			return b.add(poolKey{kind, math.Float64bits(d)}, func() uint32 {
+				return b.pool.appendDouble(d)
+			})
+		}
+	case operandString:
+		if int(n) < len(c.Strings) {
+			s := c.Strings[n]
+			return b.add(poolKey{kind, s}, func() uint32 {
+				return b.pool.appendString(s)
+			})
+		}
+	case operandNamespace:
+		if int(n) < len(c.Namespaces) {
+			ns := c.Namespaces[n]
+			ns.Name = b.index(operandString, ns.Name)
+			var key interface{} = ns
+			if ns.Kind == CONSTANT_PRIVATE_NS {
+				// private namespaces are distinct by index, never by value
+				key = n
+			}
+			return b.add(poolKey{kind, key}, func() uint32 {
+				return b.pool.appendNamespace(ns)
+			})
+		}
+	case operandMultiname:
+		if int(n) < len(c.Multinames) {
+			return b.multiname(c.Multinames[n])
+		}
+	default:
+		return n
+	}
+	b.err = ErrABCIndex
+	return 0
+}
+
+// Compact rebuilds the constant pool from the entries referenced by the
+// file, removing unused and duplicate entries, and rewrites every index into
+// the pool, including those in the code of method bodies. The file is left
+// unchanged if an error is returned.
+func (a *ABCFile) Compact() error {
+	b := &poolBuilder{old: &a.ConstantPool, indices: make(map[poolKey]uint32)}
+	var indices []uint32
+	a.poolReferences(func(kind uint8, n *uint32) {
+		indices = append(indices, b.index(kind, *n))
+	})
+	codes := make([][]byte, len(a.MethodBodies))
+	exceptions := make([][]ExceptionInfo, len(a.MethodBodies))
+	for n := range a.MethodBodies {
+		body := &a.MethodBodies[n]
+		instructions, err := DecodeInstructions(body.Code)
+		if err != nil {
+			return err
+		}
+		for _, i := range instructions {
+			for o, k := range instructionInfo[i.Opcode].operands {
+				switch k {
+				case operandString, operandInt, operandUint, operandDouble, operandNamespace, operandMultiname:
+					i.Operands[o] = int(b.index(k, uint32(i.Operands[o])))
+				}
+			}
+		}
+		code, l, err := encodeInstructions(instructions)
+		if err != nil {
+			return err
+		}
+		if exceptions[n], err = l.exceptions(body.Exceptions); err != nil {
+			return err
+		}
+		codes[n] = code
+	}
+	if b.err != nil {
+		return b.err
+	}
+	for n := range a.MethodBodies {
+		a.MethodBodies[n].Code = codes[n]
+		a.MethodBodies[n].Exceptions = exceptions[n]
+	}
+	a.poolReferences(func(kind uint8, n *uint32) {
+		*n = indices[0]
+		indices = indices[1:]
+	})
+	a.ConstantPool = b.pool
+	return nil
+}
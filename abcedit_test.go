@@ -0,0 +1,193 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestABCWriteTo(t *testing.T) {
+	data, expected := testABC()
+	var buf bytes.Buffer
+	if _, err := expected.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("expecting %v, got %v", data, buf.Bytes())
+	}
+	a := new(ABCFile)
+	if _, err := a.ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	buf.Reset()
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("expecting %v, got %v", data, buf.Bytes())
+	}
+	a.Classes = nil
+	if _, err := a.WriteTo(&buf); err != ErrABCClasses {
+		t.Errorf("expecting error %s, got %v", ErrABCClasses, err)
+	}
+}
+
+func TestEncodeInstructions(t *testing.T) {
+	code := []byte{
+		0xd0,
+		0x24, 0x01,
+		0x1b, 0x0b, 0x00, 0x00, 0x01, 0x0c, 0x00, 0x00, 0x10, 0x00, 0x00,
+		0x02,
+		0x10, 0x01, 0x00, 0x00,
+		0x02,
+		0x47,
+	}
+	instructions, err := DecodeInstructions(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if encoded, err := EncodeInstructions(instructions); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !bytes.Equal(encoded, code) {
+		t.Errorf("expecting %v, got %v", code, encoded)
+	}
+	instructions = append(instructions[:4], append([]Instruction{{-1, OP_PUSHSHORT, []int{1000}}}, instructions[4:]...)...)
+	expected := []byte{
+		0xd0,
+		0x24, 0x01,
+		0x1b, 0x0b, 0x00, 0x00, 0x01, 0x0f, 0x00, 0x00, 0x13, 0x00, 0x00,
+		0x02,
+		0x25, 0xe8, 0x07,
+		0x10, 0x01, 0x00, 0x00,
+		0x02,
+		0x47,
+	}
+	if encoded, err := EncodeInstructions(instructions); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !bytes.Equal(encoded, expected) {
+		t.Errorf("expecting %v, got %v", expected, encoded)
+	}
+	for n, test := range []struct {
+		instructions []Instruction
+		err          error
+	}{
+		{[]Instruction{{0, 0xff, nil}}, InstructionError{0, 0xff}},
+		{[]Instruction{{0, OP_PUSHBYTE, nil}}, ErrABCOperands},
+		{[]Instruction{{0, OP_LOOKUPSWITCH, []int{0}}}, ErrABCOperands},
+		{[]Instruction{{0, OP_PUSHBYTE, []int{1}}, {2, OP_JUMP, []int{1}}}, ErrABCTarget},
+	} {
+		if _, err := EncodeInstructions(test.instructions); err != test.err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.err, err)
+		}
+	}
+}
+
+func TestABCEdit(t *testing.T) {
+	a := testClassABC()
+	body := &a.MethodBodies[2]
+	body.Code = body.Code[:10]
+	a.ConstantPool.Multinames[a.Instances[0].Name].Name = a.ConstantPool.AddString("Game")
+	instructions, err := DecodeInstructions(body.Code)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	instructions[3].Operands[0] = int(a.ConstantPool.AddString("goodbye"))
+	instructions[4].Operands[1] = 2
+	instructions = append(instructions[:4], append([]Instruction{{-1, OP_PUSHBYTE, []int{5}}}, instructions[4:]...)...)
+	if err := a.SetCode(body, instructions); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if body.MaxStack != 3 || body.LocalCount != 2 || body.MaxScopeDepth != 1 {
+		t.Errorf("expecting limits 3, 2, 1, got %d, %d, %d", body.MaxStack, body.LocalCount, body.MaxScopeDepth)
+	}
+	if expected := []ExceptionInfo{{2, 11, 11, 8, 0}}; !reflect.DeepEqual(body.Exceptions, expected) {
+		t.Errorf("expecting exceptions %v, got %v", expected, body.Exceptions)
+	}
+	if err := a.SetCode(body, []Instruction{{0, OP_POP, nil}}); err != ErrABCStack {
+		t.Errorf("expecting error %s, got %v", ErrABCStack, err)
+	}
+	if err := a.Compact(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	strings := []string{"", "int", "void", "n", "Game", "Sprite", "flash.display", "count", "hello", "Error", "trace", "goodbye"}
+	if !reflect.DeepEqual(a.ConstantPool.Strings, strings) {
+		t.Errorf("expecting strings %q, got %q", strings, a.ConstantPool.Strings)
+	}
+	d := new(DoABC)
+	if err := d.SetABC(a); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b, err := d.ABC()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := b.Disassemble(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "class Game extends flash.display::Sprite\n" +
+		"\tstatic initializer ():* ; method 1\n" +
+		"\tconstructor ():* ; method 2\n" +
+		"\t\tmaxstack 1 localcount 1 scopedepth 0-1\n" +
+		"\t\t    0  getlocal0\n" +
+		"\t\t    1  pushscope\n" +
+		"\t\t    2  getlocal0\n" +
+		"\t\t    3  constructsuper 0\n" +
+		"\t\t    5  returnvoid\n" +
+		"\tvar private::count:int = 1 ; slot 1\n" +
+		"\tmethod hello(n:int = 1):void ; method 3\n" +
+		"\t\tmaxstack 3 localcount 2 scopedepth 0-1\n" +
+		"\t\t    0  getlocal0\n" +
+		"\t\t    1  pushscope\n" +
+		"\t\t    2  findpropstrict trace\n" +
+		"\t\t    4  pushstring \"goodbye\"\n" +
+		"\t\t    6  pushbyte 5\n" +
+		"\t\t    8  callpropvoid trace 2\n" +
+		"\t\t   11  returnvoid\n" +
+		"\t\texception 2-11 -> 11 type Error\n" +
+		"script 0\n" +
+		"\tinitializer ():* ; method 0\n" +
+		"\t\tmaxstack 1 localcount 1 scopedepth 0-1\n" +
+		"\t\t    0  getlocal0\n" +
+		"\t\t    1  pushscope\n" +
+		"\t\t    2  returnvoid\n" +
+		"\tclass Game ; slot 1\n" +
+		"function ():* ; method 4\n" +
+		"\tmaxstack 0 localcount 1 scopedepth 0-0\n" +
+		"\t    0  returnvoid\n"
+	if listing := buf.String(); listing != expected {
+		t.Errorf("expecting listing:\n%s\ngot:\n%s", expected, listing)
+	}
+}
+
+func TestCompactPrivateNamespaces(t *testing.T) {
+	a := &ABCFile{
+		MinorVersion: 16,
+		MajorVersion: 46,
+		ConstantPool: ConstantPool{
+			Ints:       []int32{0},
+			Uints:      []uint32{0},
+			Doubles:    []float64{0},
+			Strings:    []string{"", "x"},
+			Namespaces: []Namespace{{}, {CONSTANT_PRIVATE_NS, 0}, {CONSTANT_PRIVATE_NS, 0}},
+			NsSets:     [][]uint32{nil},
+			Multinames: []Multiname{{}, {Kind: CONSTANT_QNAME, Namespace: 1, Name: 1}, {Kind: CONSTANT_QNAME, Namespace: 2, Name: 1}},
+		},
+		Scripts: []ScriptInfo{{Traits: []Trait{
+			{Name: 1, Kind: TRAIT_SLOT, SlotId: 1},
+			{Name: 2, Kind: TRAIT_SLOT, SlotId: 2},
+		}}},
+	}
+	if err := a.Compact(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	traits := a.Scripts[0].Traits
+	if traits[0].Name == traits[1].Name {
+		t.Fatalf("expecting distinct multinames, got %d for both", traits[0].Name)
+	}
+	c := a.ConstantPool
+	if ns1, ns2 := c.Multinames[traits[0].Name].Namespace, c.Multinames[traits[1].Name].Namespace; ns1 == ns2 {
+		t.Errorf("expecting distinct namespaces, got %d for both", ns1)
+	}
+	if n := c.AddNamespace(Namespace{CONSTANT_PRIVATE_NS, 0}); int(n) != len(c.Namespaces)-1 || n < 3 {
+		t.Errorf("expecting a new private namespace, got index %d", n)
+	}
+}
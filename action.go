@@ -0,0 +1,480 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"io/ioutil"
+)
+
+var ErrActionLength = errors.New("action: record length does not match contents")
+
+const (
+	ACTION_END               uint8 = 0x00
+	ACTION_NEXT_FRAME        uint8 = 0x04
+	ACTION_PREVIOUS_FRAME    uint8 = 0x05
+	ACTION_PLAY              uint8 = 0x06
+	ACTION_STOP              uint8 = 0x07
+	ACTION_TOGGLE_QUALITY    uint8 = 0x08
+	ACTION_STOP_SOUNDS       uint8 = 0x09
+	ACTION_ADD               uint8 = 0x0a
+	ACTION_SUBTRACT          uint8 = 0x0b
+	ACTION_MULTIPLY          uint8 = 0x0c
+	ACTION_DIVIDE            uint8 = 0x0d
+	ACTION_EQUALS            uint8 = 0x0e
+	ACTION_LESS              uint8 = 0x0f
+	ACTION_AND               uint8 = 0x10
+	ACTION_OR                uint8 = 0x11
+	ACTION_NOT               uint8 = 0x12
+	ACTION_STRING_EQUALS     uint8 = 0x13
+	ACTION_STRING_LENGTH     uint8 = 0x14
+	ACTION_STRING_EXTRACT    uint8 = 0x15
+	ACTION_POP               uint8 = 0x17
+	ACTION_TO_INTEGER        uint8 = 0x18
+	ACTION_GET_VARIABLE      uint8 = 0x1c
+	ACTION_SET_VARIABLE      uint8 = 0x1d
+	ACTION_SET_TARGET2       uint8 = 0x20
+	ACTION_STRING_ADD        uint8 = 0x21
+	ACTION_GET_PROPERTY      uint8 = 0x22
+	ACTION_SET_PROPERTY      uint8 = 0x23
+	ACTION_CLONE_SPRITE      uint8 = 0x24
+	ACTION_REMOVE_SPRITE     uint8 = 0x25
+	ACTION_TRACE             uint8 = 0x26
+	ACTION_START_DRAG        uint8 = 0x27
+	ACTION_END_DRAG          uint8 = 0x28
+	ACTION_STRING_LESS       uint8 = 0x29
+	ACTION_THROW             uint8 = 0x2a
+	ACTION_CAST_OP           uint8 = 0x2b
+	ACTION_IMPLEMENTS_OP     uint8 = 0x2c
+	ACTION_RANDOM_NUMBER     uint8 = 0x30
+	ACTION_MB_STRING_LENGTH  uint8 = 0x31
+	ACTION_CHAR_TO_ASCII     uint8 = 0x32
+	ACTION_ASCII_TO_CHAR     uint8 = 0x33
+	ACTION_GET_TIME          uint8 = 0x34
+	ACTION_MB_STRING_EXTRACT uint8 = 0x35
+	ACTION_MB_CHAR_TO_ASCII  uint8 = 0x36
+	ACTION_MB_ASCII_TO_CHAR  uint8 = 0x37
+	ACTION_DELETE            uint8 = 0x3a
+	ACTION_DELETE2           uint8 = 0x3b
+	ACTION_DEFINE_LOCAL      uint8 = 0x3c
+	ACTION_CALL_FUNCTION     uint8 = 0x3d
+	ACTION_RETURN            uint8 = 0x3e
+	ACTION_MODULO            uint8 = 0x3f
+	ACTION_NEW_OBJECT        uint8 = 0x40
+	ACTION_DEFINE_LOCAL2     uint8 = 0x41
+	ACTION_INIT_ARRAY        uint8 = 0x42
+	ACTION_INIT_OBJECT       uint8 = 0x43
+	ACTION_TYPE_OF           uint8 = 0x44
+	ACTION_TARGET_PATH       uint8 = 0x45
+	ACTION_ENUMERATE         uint8 = 0x46
+	ACTION_ADD2              uint8 = 0x47
+	ACTION_LESS2             uint8 = 0x48
+	ACTION_EQUALS2           uint8 = 0x49
+	ACTION_TO_NUMBER         uint8 = 0x4a
+	ACTION_TO_STRING         uint8 = 0x4b
+	ACTION_PUSH_DUPLICATE    uint8 = 0x4c
+	ACTION_STACK_SWAP        uint8 = 0x4d
+	ACTION_GET_MEMBER        uint8 = 0x4e
+	ACTION_SET_MEMBER        uint8 = 0x4f
+	ACTION_INCREMENT         uint8 = 0x50
+	ACTION_DECREMENT         uint8 = 0x51
+	ACTION_CALL_METHOD       uint8 = 0x52
+	ACTION_NEW_METHOD        uint8 = 0x53
+	ACTION_INSTANCE_OF       uint8 = 0x54
+	ACTION_ENUMERATE2        uint8 = 0x55
+	ACTION_BIT_AND           uint8 = 0x60
+	ACTION_BIT_OR            uint8 = 0x61
+	ACTION_BIT_XOR           uint8 = 0x62
+	ACTION_BIT_LSHIFT        uint8 = 0x63
+	ACTION_BIT_RSHIFT        uint8 = 0x64
+	ACTION_BIT_URSHIFT       uint8 = 0x65
+	ACTION_STRICT_EQUALS     uint8 = 0x66
+	ACTION_GREATER           uint8 = 0x67
+	ACTION_STRING_GREATER    uint8 = 0x68
+	ACTION_EXTENDS           uint8 = 0x69
+	ACTION_GOTO_FRAME        uint8 = 0x81
+	ACTION_GET_URL           uint8 = 0x83
+	ACTION_STORE_REGISTER    uint8 = 0x87
+	ACTION_CONSTANT_POOL     uint8 = 0x88
+	ACTION_WAIT_FOR_FRAME    uint8 = 0x8a
+	ACTION_SET_TARGET        uint8 = 0x8b
+	ACTION_GO_TO_LABEL       uint8 = 0x8c
+	ACTION_WAIT_FOR_FRAME2   uint8 = 0x8d
+	ACTION_DEFINE_FUNCTION2  uint8 = 0x8e
+	ACTION_TRY               uint8 = 0x8f
+	ACTION_WITH              uint8 = 0x94
+	ACTION_PUSH              uint8 = 0x96
+	ACTION_JUMP              uint8 = 0x99
+	ACTION_GET_URL2          uint8 = 0x9a
+	ACTION_DEFINE_FUNCTION   uint8 = 0x9b
+	ACTION_IF                uint8 = 0x9d
+	ACTION_CALL              uint8 = 0x9e
+	ACTION_GOTO_FRAME2       uint8 = 0x9f
+)
+
+// Action is a single ACTIONRECORD. Actions with a code below 0x80 carry no
+// payload, and are represented by BasicAction.
+type Action interface {
+	ActionCode() uint8
+	readFrom(f io.Reader) error
+	writeTo(f io.Writer) error
+	size() int32
+}
+
+type BasicAction uint8
+
+func (b BasicAction) ActionCode() uint8 {
+	return uint8(b)
+}
+
+func (BasicAction) readFrom(f io.Reader) error {
+	return nil
+}
+
+func (BasicAction) writeTo(f io.Writer) error {
+	return nil
+}
+
+func (BasicAction) size() int32 {
+	return 0
+}
+
+// UnknownAction holds the payload of any action with a code of 0x80 or above
+// that is not otherwise recognised.
+type UnknownAction struct {
+	Code uint8
+	Data []byte
+}
+
+func (u *UnknownAction) ActionCode() uint8 {
+	return u.Code
+}
+
+func (u *UnknownAction) readFrom(f io.Reader) (err error) {
+	u.Data, err = ioutil.ReadAll(f)
+	return
+}
+
+func (u *UnknownAction) writeTo(f io.Writer) error {
+	_, err := f.Write(u.Data)
+	return err
+}
+
+func (u *UnknownAction) size() int32 {
+	return int32(len(u.Data))
+}
+
+func newAction(code uint8) Action {
+	switch code {
+	case ACTION_GOTO_FRAME:
+		return new(ActionGotoFrame)
+	case ACTION_GET_URL:
+		return new(ActionGetURL)
+	case ACTION_STORE_REGISTER:
+		return new(ActionStoreRegister)
+	case ACTION_CONSTANT_POOL:
+		return new(ActionConstantPool)
+	case ACTION_WAIT_FOR_FRAME:
+		return new(ActionWaitForFrame)
+	case ACTION_SET_TARGET:
+		return new(ActionSetTarget)
+	case ACTION_GO_TO_LABEL:
+		return new(ActionGoToLabel)
+	case ACTION_WAIT_FOR_FRAME2:
+		return new(ActionWaitForFrame2)
+	case ACTION_DEFINE_FUNCTION2:
+		return new(ActionDefineFunction2)
+	case ACTION_TRY:
+		return new(ActionTry)
+	case ACTION_WITH:
+		return new(ActionWith)
+	case ACTION_PUSH:
+		return new(ActionPush)
+	case ACTION_JUMP:
+		return new(ActionJump)
+	case ACTION_GET_URL2:
+		return new(ActionGetURL2)
+	case ACTION_DEFINE_FUNCTION:
+		return new(ActionDefineFunction)
+	case ACTION_IF:
+		return new(ActionIf)
+	case ACTION_CALL:
+		return new(ActionCall)
+	case ACTION_GOTO_FRAME2:
+		return new(ActionGotoFrame2)
+	}
+	if code < 0x80 {
+		return BasicAction(code)
+	}
+	return &UnknownAction{Code: code}
+}
+
+// DecodeActions decodes a list of action records. An end flag as the final
+// byte is not included in the returned list; an end flag anywhere else is
+// returned as BasicAction(ACTION_END).
+func DecodeActions(data []byte) ([]Action, error) {
+	actions, _, err := decodeActions(data)
+	return actions, err
+}
+
+// decodeActions decodes a list of action records as DecodeActions does,
+// reporting whether the list was terminated by an end flag.
+func decodeActions(data []byte) (actions []Action, end bool, err error) {
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		code, _ := r.ReadByte()
+		if code == ACTION_END && r.Len() == 0 {
+			return actions, true, nil
+		}
+		var action Action
+		if action, err = readAction(r, code); err != nil {
+			return
+		}
+		actions = append(actions, action)
+	}
+	return
+}
+
+// readActions reads action records up to and including the end flag,
+// returning the records without the end flag.
+func readActions(f io.Reader) (actions []Action, err error) {
+	for {
+		var code uint8
+		if err = binary.Read(f, binary.LittleEndian, &code); err != nil || code == ACTION_END {
+			return
+		}
+		var action Action
+		if action, err = readAction(f, code); err != nil {
+			return
+		}
+		actions = append(actions, action)
+	}
+}
+
+// readAction reads the length and payload, if any, of the action with the
+// given code.
+func readAction(f io.Reader, code uint8) (Action, error) {
+	action := newAction(code)
+	if code < 0x80 {
+		return action, nil
+	}
+	var length uint16
+	if err := binary.Read(f, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(f, payload); err != nil {
+		return nil, err
+	}
+	p := bytes.NewReader(payload)
+	if err := action.readFrom(p); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	} else if p.Len() > 0 {
+		return nil, ErrActionLength
+	}
+	return action, nil
+}
+
+func writeActions(f io.Writer, actions []Action) (err error) {
+	for _, action := range actions {
+		code := action.ActionCode()
+		if err = binary.Write(f, binary.LittleEndian, code); err != nil {
+			return
+		}
+		if code < 0x80 {
+			continue
+		}
+		if err = binary.Write(f, binary.LittleEndian, uint16(action.size())); err != nil {
+			return
+		}
+		if err = action.writeTo(f); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// EncodeActions encodes a list of actions, without a trailing end flag.
+func EncodeActions(actions []Action) ([]byte, error) {
+	var buf bytes.Buffer
+	err := writeActions(&buf, actions)
+	return buf.Bytes(), err
+}
+
+// ActionsSize returns the encoded size of the actions, without a trailing
+// end flag.
+func ActionsSize(actions []Action) int32 {
+	total := int32(0)
+	for _, action := range actions {
+		total++
+		if action.ActionCode() >= 0x80 {
+			total += 2 + action.size()
+		}
+	}
+	return total
+}
+
+// DoAction holds the actions of a frame. NoEnd is set when the actions were
+// not terminated by an end flag, so that none is written.
+type DoAction struct {
+	Actions []Action
+	NoEnd   bool
+}
+
+func (d *DoAction) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var data []byte
+	if data, err = ioutil.ReadAll(c); err != nil {
+		return
+	}
+	var end bool
+	d.Actions, end, err = decodeActions(data)
+	d.NoEnd = !end
+	return
+}
+
+func (d *DoAction) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = writeActions(c, d.Actions); err != nil || d.NoEnd {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, ACTION_END)
+	return
+}
+
+func (d *DoAction) Size(ver uint8, id uint16) int32 {
+	return ActionsSize(d.Actions) + endSize(d.NoEnd)
+}
+
+func (d *DoAction) MinVersion() uint8 {
+	return 3
+}
+
+func (d *DoAction) TagId() uint16 {
+	return TAG_DO_ACTION
+}
+
+func (d *DoAction) Name() string {
+	return "DoAction"
+}
+
+// DoInitAction holds the initialisation actions of a sprite. NoEnd is as for
+// DoAction.
+type DoInitAction struct {
+	SpriteId uint16
+	Actions  []Action
+	NoEnd    bool
+}
+
+func (d *DoInitAction) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.SpriteId); err != nil {
+		return
+	}
+	var data []byte
+	if data, err = ioutil.ReadAll(c); err != nil {
+		return
+	}
+	var end bool
+	d.Actions, end, err = decodeActions(data)
+	d.NoEnd = !end
+	return
+}
+
+func (d *DoInitAction) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.SpriteId); err != nil {
+		return
+	}
+	if err = writeActions(c, d.Actions); err != nil || d.NoEnd {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, ACTION_END)
+	return
+}
+
+func (d *DoInitAction) Size(ver uint8, id uint16) int32 {
+	return 2 + ActionsSize(d.Actions) + endSize(d.NoEnd)
+}
+
+func endSize(noEnd bool) int32 {
+	if noEnd {
+		return 0
+	}
+	return 1
+}
+
+func (d *DoInitAction) MinVersion() uint8 {
+	return 6
+}
+
+func (d *DoInitAction) TagId() uint16 {
+	return TAG_DO_INIT_ACTION
+}
+
+func (d *DoInitAction) Name() string {
+	return "DoInitAction"
+}
+
+// actionLists returns the action lists of the tags, including those of
+// buttons, clip events and the tags of sprites.
+func actionLists(tags []Tag) [][]Action {
+	var lists [][]Action
+	clip := func(c ClipActions) {
+		for _, r := range c.Records {
+			lists = append(lists, r.Actions)
+		}
+	}
+	for _, tag := range allTags(tags) {
+		switch t := tag.(type) {
+		case *DoAction:
+			lists = append(lists, t.Actions)
+		case *DoInitAction:
+			lists = append(lists, t.Actions)
+		case *DefineButton:
+			lists = append(lists, t.Actions)
+		case *DefineButton2:
+			for _, b := range t.Actions {
+				lists = append(lists, b.Actions)
+			}
+		case *PlaceObject2:
+			clip(t.ClipActions)
+		case *PlaceObject3:
+			clip(t.ClipActions)
+		}
+	}
+	return lists
+}
@@ -0,0 +1,134 @@
+package swf
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestDoAction(t *testing.T) {
+	testTag(t, 7, []byte{
+		0x88, 7, 0, 2, 0, 'a', 0, 'b', 'c', 0,
+		0x96, 33, 0, 0, 'x', 0, 1, 0, 0, 0xc0, 0x3f, 2, 3, 4, 1, 5, 1, 6, 0, 0, 0xf0, 0x3f, 0, 0, 0, 0, 7, 0xff, 0xff, 0xff, 0xff, 8, 1, 9, 0, 1,
+		0x47,
+		0x9d, 2, 0, 0xfb, 0xff,
+		0x99, 2, 0, 3, 0,
+		0x81, 2, 0, 5, 0,
+		0x83, 4, 0, 'u', 0, 't', 0,
+		0x87, 1, 0, 2,
+		0x8a, 3, 0, 1, 0, 2,
+		0x8b, 2, 0, 't', 0,
+		0x8c, 2, 0, 'l', 0,
+		0x8d, 1, 0, 3,
+		0x8e, 12, 0, 'f', 0, 1, 0, 3, 1, 1, 2, 'p', 0, 1, 0,
+		0x3e,
+		0x8f, 9, 0, 3, 1, 0, 1, 0, 1, 0, 'e', 0,
+		0x8f, 8, 0, 5, 0, 0, 0, 0, 0, 0, 3,
+		0x94, 2, 0, 0, 0,
+		0x9a, 1, 0, 0x82,
+		0x9b, 8, 0, 'g', 0, 1, 0, 'a', 0, 0, 0,
+		0x9e, 0, 0,
+		0x9f, 3, 0, 3, 7, 0,
+		0xff, 2, 0, 1, 2,
+		0x00,
+		0x07,
+		0x00,
+	}, &DoAction{Actions: []Action{
+		&ActionConstantPool{[]String{"a", "bc"}},
+		&ActionPush{[]PushValue{PushString("x"), PushFloat(1.5), PushNull{}, PushUndefined{}, PushRegister(1), PushBoolean(1), PushDouble(1), PushInteger(-1), PushConstant8(1), PushConstant16(256)}},
+		BasicAction(ACTION_ADD2),
+		&ActionIf{-5},
+		&ActionJump{3},
+		&ActionGotoFrame{5},
+		&ActionGetURL{"u", "t"},
+		&ActionStoreRegister{2},
+		&ActionWaitForFrame{1, 2},
+		&ActionSetTarget{"t"},
+		&ActionGoToLabel{"l"},
+		&ActionWaitForFrame2{3},
+		&ActionDefineFunction2{
+			FunctionName:  "f",
+			RegisterCount: 3,
+			PreloadThis:   true,
+			PreloadGlobal: true,
+			Parameters:    []RegisterParam{{2, "p"}},
+			CodeSize:      1,
+		},
+		BasicAction(ACTION_RETURN),
+		&ActionTry{FinallyBlock: true, CatchBlock: true, TrySize: 1, CatchSize: 1, FinallySize: 1, CatchName: "e"},
+		&ActionTry{CatchInRegister: true, CatchBlock: true, CatchRegister: 3},
+		&ActionWith{0},
+		&ActionGetURL2{SendVarsMethod: SEND_VARS_POST, LoadTarget: true},
+		&ActionDefineFunction{"g", []String{"a"}, 0},
+		&ActionCall{},
+		&ActionGotoFrame2{SceneBiasFlag: true, Play: true, SceneBias: 7},
+		&UnknownAction{0xff, []byte{1, 2}},
+		BasicAction(ACTION_END),
+		BasicAction(ACTION_STOP),
+	}})
+	testTag(t, 3, []byte{0}, &DoAction{})
+}
+
+func TestDoInitAction(t *testing.T) {
+	testTag(t, 6, []byte{1, 0, 0x07, 0}, &DoInitAction{SpriteId: 1, Actions: []Action{BasicAction(ACTION_STOP)}})
+}
+
+func TestActionsExact(t *testing.T) {
+	testTag(t, 3, []byte{0x07}, &DoAction{Actions: []Action{BasicAction(ACTION_STOP)}, NoEnd: true})
+	testTag(t, 3, []byte{}, &DoAction{NoEnd: true})
+	testTag(t, 6, []byte{1, 0, 0x07}, &DoInitAction{SpriteId: 1, Actions: []Action{BasicAction(ACTION_STOP)}, NoEnd: true})
+	testTag(t, 7, []byte{
+		0x8f, 8, 0, 0xa5, 1, 0, 1, 0, 0, 0, 3,
+		0x8e, 9, 0, 'f', 0, 0, 0, 0, 0, 0x83, 0, 0,
+		0x00,
+	}, &DoAction{Actions: []Action{
+		&ActionTry{Reserved: 20, CatchInRegister: true, CatchBlock: true, TrySize: 1, CatchSize: 1, CatchRegister: 3},
+		&ActionDefineFunction2{FunctionName: "f", PreloadGlobal: true, Reserved: 0x41, Parameters: []RegisterParam{}},
+	}})
+	testTag(t, 5, []byte{
+		0x96, 2, 0, 5, 2,
+		0x9a, 1, 0, 0x7d,
+		0x9f, 3, 0, 0xaf, 2, 0,
+		0x00,
+	}, &DoAction{Actions: []Action{
+		&ActionPush{[]PushValue{PushBoolean(2)}},
+		&ActionGetURL2{SendVarsMethod: SEND_VARS_GET, Reserved: 15, LoadVariables: true},
+		&ActionGotoFrame2{Reserved: 43, SceneBiasFlag: true, Play: true, SceneBias: 2},
+	}})
+}
+
+func TestDecodeActionsErrors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{0x96, 1, 0, 0xa}, ErrPushType},
+		{[]byte{0x88, 1, 0, 0}, io.ErrUnexpectedEOF},
+		{[]byte{0x87, 2, 0, 1, 2}, ErrActionLength},
+		{[]byte{0x87, 2, 0, 1}, io.ErrUnexpectedEOF},
+	}
+	for n, test := range tests {
+		if _, err := DecodeActions(test.data); err != test.err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.err, err)
+		}
+	}
+}
+
+func TestAllocateRegisters(t *testing.T) {
+	a := &ActionDefineFunction2{
+		PreloadThis:   true,
+		PreloadRoot:   true,
+		PreloadGlobal: true,
+		Parameters:    []RegisterParam{{0, "a"}, {0, "b"}},
+	}
+	preloaded := a.AllocateRegisters()
+	if expected := map[string]uint8{"this": 1, "_root": 2, "_global": 3}; !reflect.DeepEqual(preloaded, expected) {
+		t.Errorf("expecting %v, got %v", expected, preloaded)
+	}
+	if expected := []RegisterParam{{4, "a"}, {5, "b"}}; !reflect.DeepEqual(a.Parameters, expected) {
+		t.Errorf("expecting %v, got %v", expected, a.Parameters)
+	}
+	if a.RegisterCount != 6 {
+		t.Errorf("expecting RegisterCount 6, got %d", a.RegisterCount)
+	}
+}
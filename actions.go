@@ -0,0 +1,822 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+var ErrPushType = errors.New("actionPush: unknown value type")
+
+type ActionGotoFrame struct {
+	Frame uint16
+}
+
+func (a *ActionGotoFrame) ActionCode() uint8 {
+	return ACTION_GOTO_FRAME
+}
+
+func (a *ActionGotoFrame) readFrom(f io.Reader) error {
+	return binary.Read(f, binary.LittleEndian, &a.Frame)
+}
+
+func (a *ActionGotoFrame) writeTo(f io.Writer) error {
+	return binary.Write(f, binary.LittleEndian, a.Frame)
+}
+
+func (a *ActionGotoFrame) size() int32 {
+	return 2
+}
+
+type ActionGetURL struct {
+	URL, Target String
+}
+
+func (a *ActionGetURL) ActionCode() uint8 {
+	return ACTION_GET_URL
+}
+
+func (a *ActionGetURL) readFrom(f io.Reader) error {
+	return ReadAll(f, &a.URL, &a.Target)
+}
+
+func (a *ActionGetURL) writeTo(f io.Writer) error {
+	return WriteAll(f, &a.URL, &a.Target)
+}
+
+func (a *ActionGetURL) size() int32 {
+	return SizeAll(&a.URL, &a.Target)
+}
+
+type ActionStoreRegister struct {
+	Register uint8
+}
+
+func (a *ActionStoreRegister) ActionCode() uint8 {
+	return ACTION_STORE_REGISTER
+}
+
+func (a *ActionStoreRegister) readFrom(f io.Reader) error {
+	return binary.Read(f, binary.LittleEndian, &a.Register)
+}
+
+func (a *ActionStoreRegister) writeTo(f io.Writer) error {
+	return binary.Write(f, binary.LittleEndian, a.Register)
+}
+
+func (a *ActionStoreRegister) size() int32 {
+	return 1
+}
+
+type ActionConstantPool struct {
+	Constants []String
+}
+
+func (a *ActionConstantPool) ActionCode() uint8 {
+	return ACTION_CONSTANT_POOL
+}
+
+func (a *ActionConstantPool) readFrom(f io.Reader) (err error) {
+	var count uint16
+	if err = binary.Read(f, binary.LittleEndian, &count); err != nil {
+		return
+	}
+	a.Constants = make([]String, count)
+	for n := range a.Constants {
+		if _, err = a.Constants[n].ReadFrom(f); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (a *ActionConstantPool) writeTo(f io.Writer) (err error) {
+	if err = binary.Write(f, binary.LittleEndian, uint16(len(a.Constants))); err != nil {
+		return
+	}
+	for n := range a.Constants {
+		if _, err = a.Constants[n].WriteTo(f); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (a *ActionConstantPool) size() int32 {
+	total := int32(2)
+	for n := range a.Constants {
+		total += a.Constants[n].Size()
+	}
+	return total
+}
+
+type ActionWaitForFrame struct {
+	Frame     uint16
+	SkipCount uint8
+}
+
+func (a *ActionWaitForFrame) ActionCode() uint8 {
+	return ACTION_WAIT_FOR_FRAME
+}
+
+func (a *ActionWaitForFrame) readFrom(f io.Reader) error {
+	return binary.Read(f, binary.LittleEndian, a)
+}
+
+func (a *ActionWaitForFrame) writeTo(f io.Writer) error {
+	return binary.Write(f, binary.LittleEndian, a)
+}
+
+func (a *ActionWaitForFrame) size() int32 {
+	return 3
+}
+
+type ActionSetTarget struct {
+	TargetName String
+}
+
+func (a *ActionSetTarget) ActionCode() uint8 {
+	return ACTION_SET_TARGET
+}
+
+func (a *ActionSetTarget) readFrom(f io.Reader) (err error) {
+	_, err = a.TargetName.ReadFrom(f)
+	return
+}
+
+func (a *ActionSetTarget) writeTo(f io.Writer) (err error) {
+	_, err = a.TargetName.WriteTo(f)
+	return
+}
+
+func (a *ActionSetTarget) size() int32 {
+	return a.TargetName.Size()
+}
+
+type ActionGoToLabel struct {
+	Label String
+}
+
+func (a *ActionGoToLabel) ActionCode() uint8 {
+	return ACTION_GO_TO_LABEL
+}
+
+func (a *ActionGoToLabel) readFrom(f io.Reader) (err error) {
+	_, err = a.Label.ReadFrom(f)
+	return
+}
+
+func (a *ActionGoToLabel) writeTo(f io.Writer) (err error) {
+	_, err = a.Label.WriteTo(f)
+	return
+}
+
+func (a *ActionGoToLabel) size() int32 {
+	return a.Label.Size()
+}
+
+type ActionWaitForFrame2 struct {
+	SkipCount uint8
+}
+
+func (a *ActionWaitForFrame2) ActionCode() uint8 {
+	return ACTION_WAIT_FOR_FRAME2
+}
+
+func (a *ActionWaitForFrame2) readFrom(f io.Reader) error {
+	return binary.Read(f, binary.LittleEndian, &a.SkipCount)
+}
+
+func (a *ActionWaitForFrame2) writeTo(f io.Writer) error {
+	return binary.Write(f, binary.LittleEndian, a.SkipCount)
+}
+
+func (a *ActionWaitForFrame2) size() int32 {
+	return 1
+}
+
+type RegisterParam struct {
+	Register  uint8
+	ParamName String
+}
+
+// ActionDefineFunction2 is followed in the action list by the CodeSize bytes
+// of actions that make up the function body. Parameters stored in register 0
+// are not allocated a register. Reserved holds the unused bits of the flags.
+type ActionDefineFunction2 struct {
+	FunctionName      String
+	RegisterCount     uint8
+	PreloadParent     bool
+	PreloadRoot       bool
+	SuppressSuper     bool
+	PreloadSuper      bool
+	SuppressArguments bool
+	PreloadArguments  bool
+	SuppressThis      bool
+	PreloadThis       bool
+	PreloadGlobal     bool
+	Reserved          uint8
+	Parameters        []RegisterParam
+	CodeSize          uint16
+}
+
+func (a *ActionDefineFunction2) ActionCode() uint8 {
+	return ACTION_DEFINE_FUNCTION2
+}
+
+func (a *ActionDefineFunction2) readFrom(f io.Reader) (err error) {
+	if _, err = a.FunctionName.ReadFrom(f); err != nil {
+		return
+	}
+	var numParams uint16
+	if err = binary.Read(f, binary.LittleEndian, &numParams); err != nil {
+		return
+	}
+	if err = binary.Read(f, binary.LittleEndian, &a.RegisterCount); err != nil {
+		return
+	}
+	var flags [2]uint8
+	if err = binary.Read(f, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags[0], &a.PreloadParent, &a.PreloadRoot, &a.SuppressSuper, &a.PreloadSuper, &a.SuppressArguments, &a.PreloadArguments, &a.SuppressThis, &a.PreloadThis)
+	unpackFlags(flags[1], &a.PreloadGlobal)
+	a.Reserved = flags[1] >> 1
+	a.Parameters = make([]RegisterParam, numParams)
+	for n := range a.Parameters {
+		if err = binary.Read(f, binary.LittleEndian, &a.Parameters[n].Register); err != nil {
+			return
+		}
+		if _, err = a.Parameters[n].ParamName.ReadFrom(f); err != nil {
+			return
+		}
+	}
+	return binary.Read(f, binary.LittleEndian, &a.CodeSize)
+}
+
+func (a *ActionDefineFunction2) writeTo(f io.Writer) (err error) {
+	if _, err = a.FunctionName.WriteTo(f); err != nil {
+		return
+	}
+	if err = binary.Write(f, binary.LittleEndian, uint16(len(a.Parameters))); err != nil {
+		return
+	}
+	if err = binary.Write(f, binary.LittleEndian, a.RegisterCount); err != nil {
+		return
+	}
+	flags := [2]uint8{
+		packFlags(a.PreloadParent, a.PreloadRoot, a.SuppressSuper, a.PreloadSuper, a.SuppressArguments, a.PreloadArguments, a.SuppressThis, a.PreloadThis),
+		a.Reserved<<1 | packFlags(a.PreloadGlobal),
+	}
+	if err = binary.Write(f, binary.LittleEndian, flags); err != nil {
+		return
+	}
+	for n := range a.Parameters {
+		if err = binary.Write(f, binary.LittleEndian, a.Parameters[n].Register); err != nil {
+			return
+		}
+		if _, err = a.Parameters[n].ParamName.WriteTo(f); err != nil {
+			return
+		}
+	}
+	return binary.Write(f, binary.LittleEndian, a.CodeSize)
+}
+
+func (a *ActionDefineFunction2) size() int32 {
+	total := a.FunctionName.Size() + 2 + 1 + 2 + 2
+	for n := range a.Parameters {
+		total += 1 + a.Parameters[n].ParamName.Size()
+	}
+	return total
+}
+
+// PreloadedRegisters returns the register of each preloaded variable, which
+// are allocated from register 1 in the order used by the Flash Player.
+func (a *ActionDefineFunction2) PreloadedRegisters() map[string]uint8 {
+	preloaded := make(map[string]uint8)
+	r := uint8(1)
+	for _, p := range [...]struct {
+		name    string
+		preload bool
+	}{
+		{"this", a.PreloadThis},
+		{"arguments", a.PreloadArguments},
+		{"super", a.PreloadSuper},
+		{"_root", a.PreloadRoot},
+		{"_parent", a.PreloadParent},
+		{"_global", a.PreloadGlobal},
+	} {
+		if p.preload {
+			preloaded[p.name] = r
+			r++
+		}
+	}
+	return preloaded
+}
+
+// AllocateRegisters assigns registers to the parameters, following those of
+// the preloaded variables, and sets RegisterCount. It returns the register of
+// each preloaded variable.
+func (a *ActionDefineFunction2) AllocateRegisters() map[string]uint8 {
+	preloaded := a.PreloadedRegisters()
+	r := uint8(len(preloaded) + 1)
+	for n := range a.Parameters {
+		a.Parameters[n].Register = r
+		r++
+	}
+	a.RegisterCount = r
+	return preloaded
+}
+
+// ActionTry is followed in the action list by the TrySize, CatchSize and
+// FinallySize bytes of the try, catch and finally blocks. Reserved holds the
+// unused bits of the flags.
+type ActionTry struct {
+	Reserved        uint8
+	CatchInRegister bool
+	FinallyBlock    bool
+	CatchBlock      bool
+	TrySize         uint16
+	CatchSize       uint16
+	FinallySize     uint16
+	CatchName       String
+	CatchRegister   uint8
+}
+
+func (a *ActionTry) ActionCode() uint8 {
+	return ACTION_TRY
+}
+
+func (a *ActionTry) readFrom(f io.Reader) (err error) {
+	var flags uint8
+	if err = binary.Read(f, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags, &a.CatchInRegister, &a.FinallyBlock, &a.CatchBlock)
+	a.Reserved = flags >> 3
+	var sizes [3]uint16
+	if err = binary.Read(f, binary.LittleEndian, &sizes); err != nil {
+		return
+	}
+	a.TrySize, a.CatchSize, a.FinallySize = sizes[0], sizes[1], sizes[2]
+	if a.CatchInRegister {
+		a.CatchName = ""
+		return binary.Read(f, binary.LittleEndian, &a.CatchRegister)
+	}
+	a.CatchRegister = 0
+	_, err = a.CatchName.ReadFrom(f)
+	return
+}
+
+func (a *ActionTry) writeTo(f io.Writer) (err error) {
+	if err = binary.Write(f, binary.LittleEndian, a.Reserved<<3|packFlags(a.CatchInRegister, a.FinallyBlock, a.CatchBlock)); err != nil {
+		return
+	}
+	if err = binary.Write(f, binary.LittleEndian, [3]uint16{a.TrySize, a.CatchSize, a.FinallySize}); err != nil {
+		return
+	}
+	if a.CatchInRegister {
+		return binary.Write(f, binary.LittleEndian, a.CatchRegister)
+	}
+	_, err = a.CatchName.WriteTo(f)
+	return
+}
+
+func (a *ActionTry) size() int32 {
+	if a.CatchInRegister {
+		return 1 + 6 + 1
+	}
+	return 1 + 6 + a.CatchName.Size()
+}
+
+// ActionWith is followed in the action list by the Size bytes of the with
+// block.
+type ActionWith struct {
+	Size uint16
+}
+
+func (a *ActionWith) ActionCode() uint8 {
+	return ACTION_WITH
+}
+
+func (a *ActionWith) readFrom(f io.Reader) error {
+	return binary.Read(f, binary.LittleEndian, &a.Size)
+}
+
+func (a *ActionWith) writeTo(f io.Writer) error {
+	return binary.Write(f, binary.LittleEndian, a.Size)
+}
+
+func (a *ActionWith) size() int32 {
+	return 2
+}
+
+const (
+	PUSH_STRING uint8 = iota
+	PUSH_FLOAT
+	PUSH_NULL
+	PUSH_UNDEFINED
+	PUSH_REGISTER
+	PUSH_BOOLEAN
+	PUSH_DOUBLE
+	PUSH_INTEGER
+	PUSH_CONSTANT8
+	PUSH_CONSTANT16
+)
+
+// PushValue is one of the typed values of an ActionPush. PushBoolean holds the
+// byte as stored, any non-zero value being true.
+type PushValue interface {
+	PushType() uint8
+}
+
+type (
+	PushString     string
+	PushFloat      float32
+	PushNull       struct{}
+	PushUndefined  struct{}
+	PushRegister   uint8
+	PushBoolean    uint8
+	PushDouble     float64
+	PushInteger    int32
+	PushConstant8  uint8
+	PushConstant16 uint16
+)
+
+func (PushString) PushType() uint8 {
+	return PUSH_STRING
+}
+
+func (PushFloat) PushType() uint8 {
+	return PUSH_FLOAT
+}
+
+func (PushNull) PushType() uint8 {
+	return PUSH_NULL
+}
+
+func (PushUndefined) PushType() uint8 {
+	return PUSH_UNDEFINED
+}
+
+func (PushRegister) PushType() uint8 {
+	return PUSH_REGISTER
+}
+
+func (PushBoolean) PushType() uint8 {
+	return PUSH_BOOLEAN
+}
+
+func (PushDouble) PushType() uint8 {
+	return PUSH_DOUBLE
+}
+
+func (PushInteger) PushType() uint8 {
+	return PUSH_INTEGER
+}
+
+func (PushConstant8) PushType() uint8 {
+	return PUSH_CONSTANT8
+}
+
+func (PushConstant16) PushType() uint8 {
+	return PUSH_CONSTANT16
+}
+
+type ActionPush struct {
+	Values []PushValue
+}
+
+func (a *ActionPush) ActionCode() uint8 {
+	return ACTION_PUSH
+}
+
+func (a *ActionPush) readFrom(f io.Reader) (err error) {
+	a.Values = a.Values[:0]
+	for {
+		var t uint8
+		if err = binary.Read(f, binary.LittleEndian, &t); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+		var v PushValue
+		switch t {
+		case PUSH_STRING:
+			var s String
+			_, err = s.ReadFrom(f)
+			v = PushString(s)
+		case PUSH_FLOAT:
+			var p uint32
+			err = binary.Read(f, binary.LittleEndian, &p)
+			v = PushFloat(math.Float32frombits(p))
+		case PUSH_NULL:
+			v = PushNull{}
+		case PUSH_UNDEFINED:
+			v = PushUndefined{}
+		case PUSH_REGISTER:
+			var p uint8
+			err = binary.Read(f, binary.LittleEndian, &p)
+			v = PushRegister(p)
+		case PUSH_BOOLEAN:
+			var p uint8
+			err = binary.Read(f, binary.LittleEndian, &p)
+			v = PushBoolean(p)
+		case PUSH_DOUBLE:
+			// doubles are stored as two little-endian words, high word
+			// first
+			var p [2]uint32
+			err = binary.Read(f, binary.LittleEndian, &p)
+			v = PushDouble(math.Float64frombits(uint64(p[0])<<32 | uint64(p[1])))
+		case PUSH_INTEGER:
+			var p int32
+			err = binary.Read(f, binary.LittleEndian, &p)
+			v = PushInteger(p)
+		case PUSH_CONSTANT8:
+			var p uint8
+			err = binary.Read(f, binary.LittleEndian, &p)
+			v = PushConstant8(p)
+		case PUSH_CONSTANT16:
+			var p uint16
+			err = binary.Read(f, binary.LittleEndian, &p)
+			v = PushConstant16(p)
+		default:
+			return ErrPushType
+		}
+		if err != nil {
+			return
+		}
+		a.Values = append(a.Values, v)
+	}
+}
+
+func (a *ActionPush) writeTo(f io.Writer) (err error) {
+	for _, v := range a.Values {
+		if err = binary.Write(f, binary.LittleEndian, v.PushType()); err != nil {
+			return
+		}
+		switch v := v.(type) {
+		case PushString:
+			s := String(v)
+			_, err = s.WriteTo(f)
+		case PushFloat:
+			err = binary.Write(f, binary.LittleEndian, math.Float32bits(float32(v)))
+		case PushRegister:
+			err = binary.Write(f, binary.LittleEndian, uint8(v))
+		case PushBoolean:
+			err = binary.Write(f, binary.LittleEndian, uint8(v))
+		case PushDouble:
+			d := math.Float64bits(float64(v))
+			err = binary.Write(f, binary.LittleEndian, [2]uint32{uint32(d >> 32), uint32(d)})
+		case PushInteger:
+			err = binary.Write(f, binary.LittleEndian, int32(v))
+		case PushConstant8:
+			err = binary.Write(f, binary.LittleEndian, uint8(v))
+		case PushConstant16:
+			err = binary.Write(f, binary.LittleEndian, uint16(v))
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (a *ActionPush) size() int32 {
+	total := int32(len(a.Values))
+	for _, v := range a.Values {
+		switch v := v.(type) {
+		case PushString:
+			s := String(v)
+			total += s.Size()
+		case PushFloat, PushInteger:
+			total += 4
+		case PushRegister, PushBoolean, PushConstant8:
+			total++
+		case PushDouble:
+			total += 8
+		case PushConstant16:
+			total += 2
+		}
+	}
+	return total
+}
+
+// ActionJump holds the offset of the branch target from the end of the
+// action.
+type ActionJump struct {
+	BranchOffset int16
+}
+
+func (a *ActionJump) ActionCode() uint8 {
+	return ACTION_JUMP
+}
+
+func (a *ActionJump) readFrom(f io.Reader) error {
+	return binary.Read(f, binary.LittleEndian, &a.BranchOffset)
+}
+
+func (a *ActionJump) writeTo(f io.Writer) error {
+	return binary.Write(f, binary.LittleEndian, a.BranchOffset)
+}
+
+func (a *ActionJump) size() int32 {
+	return 2
+}
+
+const (
+	SEND_VARS_NONE uint8 = iota
+	SEND_VARS_GET
+	SEND_VARS_POST
+)
+
+// ActionGetURL2 holds the unused bits of its flags in Reserved.
+type ActionGetURL2 struct {
+	SendVarsMethod uint8
+	Reserved       uint8
+	LoadTarget     bool
+	LoadVariables  bool
+}
+
+func (a *ActionGetURL2) ActionCode() uint8 {
+	return ACTION_GET_URL2
+}
+
+func (a *ActionGetURL2) readFrom(f io.Reader) (err error) {
+	var flags uint8
+	if err = binary.Read(f, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	a.SendVarsMethod = flags >> 6
+	a.Reserved = flags >> 2 & 0xf
+	unpackFlags(flags, &a.LoadTarget, &a.LoadVariables)
+	return
+}
+
+func (a *ActionGetURL2) writeTo(f io.Writer) error {
+	return binary.Write(f, binary.LittleEndian, a.SendVarsMethod<<6|(a.Reserved&0xf)<<2|packFlags(a.LoadTarget, a.LoadVariables))
+}
+
+func (a *ActionGetURL2) size() int32 {
+	return 1
+}
+
+// ActionDefineFunction is followed in the action list by the CodeSize bytes
+// of actions that make up the function body.
+type ActionDefineFunction struct {
+	FunctionName String
+	Params       []String
+	CodeSize     uint16
+}
+
+func (a *ActionDefineFunction) ActionCode() uint8 {
+	return ACTION_DEFINE_FUNCTION
+}
+
+func (a *ActionDefineFunction) readFrom(f io.Reader) (err error) {
+	if _, err = a.FunctionName.ReadFrom(f); err != nil {
+		return
+	}
+	var numParams uint16
+	if err = binary.Read(f, binary.LittleEndian, &numParams); err != nil {
+		return
+	}
+	a.Params = make([]String, numParams)
+	for n := range a.Params {
+		if _, err = a.Params[n].ReadFrom(f); err != nil {
+			return
+		}
+	}
+	return binary.Read(f, binary.LittleEndian, &a.CodeSize)
+}
+
+func (a *ActionDefineFunction) writeTo(f io.Writer) (err error) {
+	if _, err = a.FunctionName.WriteTo(f); err != nil {
+		return
+	}
+	if err = binary.Write(f, binary.LittleEndian, uint16(len(a.Params))); err != nil {
+		return
+	}
+	for n := range a.Params {
+		if _, err = a.Params[n].WriteTo(f); err != nil {
+			return
+		}
+	}
+	return binary.Write(f, binary.LittleEndian, a.CodeSize)
+}
+
+func (a *ActionDefineFunction) size() int32 {
+	total := a.FunctionName.Size() + 2 + 2
+	for n := range a.Params {
+		total += a.Params[n].Size()
+	}
+	return total
+}
+
+// ActionIf holds the offset of the branch target from the end of the action.
+type ActionIf struct {
+	BranchOffset int16
+}
+
+func (a *ActionIf) ActionCode() uint8 {
+	return ACTION_IF
+}
+
+func (a *ActionIf) readFrom(f io.Reader) error {
+	return binary.Read(f, binary.LittleEndian, &a.BranchOffset)
+}
+
+func (a *ActionIf) writeTo(f io.Writer) error {
+	return binary.Write(f, binary.LittleEndian, a.BranchOffset)
+}
+
+func (a *ActionIf) size() int32 {
+	return 2
+}
+
+// ActionCall has a code above 0x80, and so a length field, but no payload.
+type ActionCall struct{}
+
+func (a *ActionCall) ActionCode() uint8 {
+	return ACTION_CALL
+}
+
+func (a *ActionCall) readFrom(f io.Reader) error {
+	return nil
+}
+
+func (a *ActionCall) writeTo(f io.Writer) error {
+	return nil
+}
+
+func (a *ActionCall) size() int32 {
+	return 0
+}
+
+// ActionGotoFrame2 holds the unused bits of its flags in Reserved.
+type ActionGotoFrame2 struct {
+	Reserved      uint8
+	SceneBiasFlag bool
+	Play          bool
+	SceneBias     uint16
+}
+
+func (a *ActionGotoFrame2) ActionCode() uint8 {
+	return ACTION_GOTO_FRAME2
+}
+
+func (a *ActionGotoFrame2) readFrom(f io.Reader) (err error) {
+	var flags uint8
+	if err = binary.Read(f, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	a.Reserved = flags >> 2
+	unpackFlags(flags, &a.SceneBiasFlag, &a.Play)
+	a.SceneBias = 0
+	if a.SceneBiasFlag {
+		err = binary.Read(f, binary.LittleEndian, &a.SceneBias)
+	}
+	return
+}
+
+func (a *ActionGotoFrame2) writeTo(f io.Writer) (err error) {
+	if err = binary.Write(f, binary.LittleEndian, a.Reserved<<2|packFlags(a.SceneBiasFlag, a.Play)); err != nil {
+		return
+	}
+	if a.SceneBiasFlag {
+		err = binary.Write(f, binary.LittleEndian, a.SceneBias)
+	}
+	return
+}
+
+func (a *ActionGotoFrame2) size() int32 {
+	if a.SceneBiasFlag {
+		return 3
+	}
+	return 1
+}
@@ -0,0 +1,851 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var actionNames = map[uint8]string{
+	ACTION_END:               "End",
+	ACTION_NEXT_FRAME:        "NextFrame",
+	ACTION_PREVIOUS_FRAME:    "PreviousFrame",
+	ACTION_PLAY:              "Play",
+	ACTION_STOP:              "Stop",
+	ACTION_TOGGLE_QUALITY:    "ToggleQuality",
+	ACTION_STOP_SOUNDS:       "StopSounds",
+	ACTION_ADD:               "Add",
+	ACTION_SUBTRACT:          "Subtract",
+	ACTION_MULTIPLY:          "Multiply",
+	ACTION_DIVIDE:            "Divide",
+	ACTION_EQUALS:            "Equals",
+	ACTION_LESS:              "Less",
+	ACTION_AND:               "And",
+	ACTION_OR:                "Or",
+	ACTION_NOT:               "Not",
+	ACTION_STRING_EQUALS:     "StringEquals",
+	ACTION_STRING_LENGTH:     "StringLength",
+	ACTION_STRING_EXTRACT:    "StringExtract",
+	ACTION_POP:               "Pop",
+	ACTION_TO_INTEGER:        "ToInteger",
+	ACTION_GET_VARIABLE:      "GetVariable",
+	ACTION_SET_VARIABLE:      "SetVariable",
+	ACTION_SET_TARGET2:       "SetTarget2",
+	ACTION_STRING_ADD:        "StringAdd",
+	ACTION_GET_PROPERTY:      "GetProperty",
+	ACTION_SET_PROPERTY:      "SetProperty",
+	ACTION_CLONE_SPRITE:      "CloneSprite",
+	ACTION_REMOVE_SPRITE:     "RemoveSprite",
+	ACTION_TRACE:             "Trace",
+	ACTION_START_DRAG:        "StartDrag",
+	ACTION_END_DRAG:          "EndDrag",
+	ACTION_STRING_LESS:       "StringLess",
+	ACTION_THROW:             "Throw",
+	ACTION_CAST_OP:           "CastOp",
+	ACTION_IMPLEMENTS_OP:     "ImplementsOp",
+	ACTION_RANDOM_NUMBER:     "RandomNumber",
+	ACTION_MB_STRING_LENGTH:  "MBStringLength",
+	ACTION_CHAR_TO_ASCII:     "CharToAscii",
+	ACTION_ASCII_TO_CHAR:     "AsciiToChar",
+	ACTION_GET_TIME:          "GetTime",
+	ACTION_MB_STRING_EXTRACT: "MBStringExtract",
+	ACTION_MB_CHAR_TO_ASCII:  "MBCharToAscii",
+	ACTION_MB_ASCII_TO_CHAR:  "MBAsciiToChar",
+	ACTION_DELETE:            "Delete",
+	ACTION_DELETE2:           "Delete2",
+	ACTION_DEFINE_LOCAL:      "DefineLocal",
+	ACTION_CALL_FUNCTION:     "CallFunction",
+	ACTION_RETURN:            "Return",
+	ACTION_MODULO:            "Modulo",
+	ACTION_NEW_OBJECT:        "NewObject",
+	ACTION_DEFINE_LOCAL2:     "DefineLocal2",
+	ACTION_INIT_ARRAY:        "InitArray",
+	ACTION_INIT_OBJECT:       "InitObject",
+	ACTION_TYPE_OF:           "TypeOf",
+	ACTION_TARGET_PATH:       "TargetPath",
+	ACTION_ENUMERATE:         "Enumerate",
+	ACTION_ADD2:              "Add2",
+	ACTION_LESS2:             "Less2",
+	ACTION_EQUALS2:           "Equals2",
+	ACTION_TO_NUMBER:         "ToNumber",
+	ACTION_TO_STRING:         "ToString",
+	ACTION_PUSH_DUPLICATE:    "PushDuplicate",
+	ACTION_STACK_SWAP:        "StackSwap",
+	ACTION_GET_MEMBER:        "GetMember",
+	ACTION_SET_MEMBER:        "SetMember",
+	ACTION_INCREMENT:         "Increment",
+	ACTION_DECREMENT:         "Decrement",
+	ACTION_CALL_METHOD:       "CallMethod",
+	ACTION_NEW_METHOD:        "NewMethod",
+	ACTION_INSTANCE_OF:       "InstanceOf",
+	ACTION_ENUMERATE2:        "Enumerate2",
+	ACTION_BIT_AND:           "BitAnd",
+	ACTION_BIT_OR:            "BitOr",
+	ACTION_BIT_XOR:           "BitXor",
+	ACTION_BIT_LSHIFT:        "BitLShift",
+	ACTION_BIT_RSHIFT:        "BitRShift",
+	ACTION_BIT_URSHIFT:       "BitURShift",
+	ACTION_STRICT_EQUALS:     "StrictEquals",
+	ACTION_GREATER:           "Greater",
+	ACTION_STRING_GREATER:    "StringGreater",
+	ACTION_EXTENDS:           "Extends",
+	ACTION_GOTO_FRAME:        "GotoFrame",
+	ACTION_GET_URL:           "GetURL",
+	ACTION_STORE_REGISTER:    "StoreRegister",
+	ACTION_CONSTANT_POOL:     "ConstantPool",
+	ACTION_WAIT_FOR_FRAME:    "WaitForFrame",
+	ACTION_SET_TARGET:        "SetTarget",
+	ACTION_GO_TO_LABEL:       "GoToLabel",
+	ACTION_WAIT_FOR_FRAME2:   "WaitForFrame2",
+	ACTION_DEFINE_FUNCTION2:  "DefineFunction2",
+	ACTION_TRY:               "Try",
+	ACTION_WITH:              "With",
+	ACTION_PUSH:              "Push",
+	ACTION_JUMP:              "Jump",
+	ACTION_GET_URL2:          "GetURL2",
+	ACTION_DEFINE_FUNCTION:   "DefineFunction",
+	ACTION_IF:                "If",
+	ACTION_CALL:              "Call",
+	ACTION_GOTO_FRAME2:       "GotoFrame2",
+}
+
+var actionCodes = make(map[string]uint8)
+
+func init() {
+	for code, name := range actionNames {
+		actionCodes[name] = code
+	}
+}
+
+// ActionName returns the name of the action code, as used by the
+// disassembler.
+func ActionName(code uint8) string {
+	if name, ok := actionNames[code]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+var sendVarsMethods = [...]string{"none", "get", "post"}
+
+// actionOffsets returns the offset of the start of each action, followed by
+// the offset of the end of the last action.
+func actionOffsets(actions []Action) []int {
+	offsets := make([]int, len(actions)+1)
+	for n, action := range actions {
+		offsets[n+1] = offsets[n] + int(ActionsSize([]Action{action}))
+	}
+	return offsets
+}
+
+// actionTargets returns the absolute offsets referred to by the action, with
+// end being the offset of the end of the action.
+func actionTargets(action Action, end int) []int {
+	switch a := action.(type) {
+	case *ActionJump:
+		return []int{end + int(a.BranchOffset)}
+	case *ActionIf:
+		return []int{end + int(a.BranchOffset)}
+	case *ActionDefineFunction:
+		return []int{end + int(a.CodeSize)}
+	case *ActionDefineFunction2:
+		return []int{end + int(a.CodeSize)}
+	case *ActionWith:
+		return []int{end + int(a.Size)}
+	case *ActionTry:
+		tryEnd := end + int(a.TrySize)
+		catchEnd := tryEnd + int(a.CatchSize)
+		return []int{tryEnd, catchEnd, catchEnd + int(a.FinallySize)}
+	}
+	return nil
+}
+
+func pushValueString(v PushValue) string {
+	switch v := v.(type) {
+	case PushString:
+		return strconv.Quote(string(v))
+	case PushFloat:
+		return "float:" + strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case PushNull:
+		return "null"
+	case PushUndefined:
+		return "undefined"
+	case PushRegister:
+		return "r:" + strconv.Itoa(int(v))
+	case PushBoolean:
+		if v > 1 {
+			return "bool:" + strconv.Itoa(int(v))
+		}
+		return strconv.FormatBool(v == 1)
+	case PushDouble:
+		return "double:" + strconv.FormatFloat(float64(v), 'g', -1, 64)
+	case PushInteger:
+		return strconv.Itoa(int(v))
+	case PushConstant8:
+		return "c8:" + strconv.Itoa(int(v))
+	case PushConstant16:
+		return "c16:" + strconv.Itoa(int(v))
+	}
+	return "?"
+}
+
+// Disassemble writes a textual listing of the actions, as read by Assemble.
+// Branch targets and the ends of functions, with blocks and try blocks are
+// given labels, and constants pushed from the constant pool are shown in a
+// comment.
+func Disassemble(w io.Writer, actions []Action) error {
+	offsets := actionOffsets(actions)
+	starts := make(map[int]bool, len(offsets))
+	for _, o := range offsets {
+		starts[o] = true
+	}
+	var targets []int
+	for n, action := range actions {
+		for _, t := range actionTargets(action, offsets[n+1]) {
+			if starts[t] {
+				targets = append(targets, t)
+			}
+		}
+	}
+	sort.Ints(targets)
+	labels := make(map[int]string)
+	for _, t := range targets {
+		if _, ok := labels[t]; !ok {
+			labels[t] = "L" + strconv.Itoa(len(labels)+1)
+		}
+	}
+	ref := func(target, end int) string {
+		if l, ok := labels[target]; ok {
+			return l
+		}
+		return strconv.Itoa(target - end)
+	}
+	b := bufio.NewWriter(w)
+	var pool []String
+	for n, action := range actions {
+		if l, ok := labels[offsets[n]]; ok {
+			fmt.Fprintf(b, "%s:\n", l)
+		}
+		end := offsets[n+1]
+		args := []string{ActionName(action.ActionCode())}
+		var comment []string
+		switch a := action.(type) {
+		case BasicAction:
+			if _, ok := actionNames[uint8(a)]; !ok {
+				args = append(args, strconv.Itoa(int(a)))
+			}
+		case *UnknownAction:
+			args = append(args, strconv.Itoa(int(a.Code)), hex.EncodeToString(a.Data))
+		case *ActionGotoFrame:
+			args = append(args, strconv.Itoa(int(a.Frame)))
+		case *ActionGetURL:
+			args = append(args, strconv.Quote(string(a.URL)), strconv.Quote(string(a.Target)))
+		case *ActionStoreRegister:
+			args = append(args, strconv.Itoa(int(a.Register)))
+		case *ActionConstantPool:
+			pool = a.Constants
+			for _, c := range a.Constants {
+				args = append(args, strconv.Quote(string(c)))
+			}
+		case *ActionWaitForFrame:
+			args = append(args, strconv.Itoa(int(a.Frame)), strconv.Itoa(int(a.SkipCount)))
+		case *ActionSetTarget:
+			args = append(args, strconv.Quote(string(a.TargetName)))
+		case *ActionGoToLabel:
+			args = append(args, strconv.Quote(string(a.Label)))
+		case *ActionWaitForFrame2:
+			args = append(args, strconv.Itoa(int(a.SkipCount)))
+		case *ActionDefineFunction2:
+			args = append(args, strconv.Quote(string(a.FunctionName)), ref(end+int(a.CodeSize), end), strconv.Itoa(int(a.RegisterCount)))
+			for _, f := range a.flags() {
+				if *f.flag {
+					args = append(args, f.name)
+				}
+			}
+			for _, p := range a.Parameters {
+				args = append(args, strconv.Itoa(int(p.Register))+":"+strconv.Quote(string(p.ParamName)))
+			}
+		case *ActionTry:
+			t := actionTargets(a, end)
+			args = append(args, ref(t[0], end), ref(t[1], t[0]), ref(t[2], t[1]))
+			if a.CatchBlock {
+				args = append(args, "catch")
+			}
+			if a.FinallyBlock {
+				args = append(args, "finally")
+			}
+			if a.CatchInRegister {
+				args = append(args, "r:"+strconv.Itoa(int(a.CatchRegister)))
+			} else {
+				args = append(args, strconv.Quote(string(a.CatchName)))
+			}
+		case *ActionWith:
+			args = append(args, ref(end+int(a.Size), end))
+		case *ActionPush:
+			for _, v := range a.Values {
+				args = append(args, pushValueString(v))
+				c := -1
+				switch v := v.(type) {
+				case PushConstant8:
+					c = int(v)
+				case PushConstant16:
+					c = int(v)
+				default:
+					continue
+				}
+				if c < len(pool) {
+					comment = append(comment, strconv.Quote(string(pool[c])))
+				} else {
+					comment = append(comment, "?")
+				}
+			}
+		case *ActionJump:
+			args = append(args, ref(end+int(a.BranchOffset), end))
+		case *ActionIf:
+			args = append(args, ref(end+int(a.BranchOffset), end))
+		case *ActionGetURL2:
+			args = append(args, sendVarsMethods[a.SendVarsMethod%3])
+			if a.LoadTarget {
+				args = append(args, "loadTarget")
+			}
+			if a.LoadVariables {
+				args = append(args, "loadVariables")
+			}
+		case *ActionDefineFunction:
+			args = append(args, strconv.Quote(string(a.FunctionName)), ref(end+int(a.CodeSize), end))
+			for _, p := range a.Params {
+				args = append(args, strconv.Quote(string(p)))
+			}
+		case *ActionGotoFrame2:
+			if a.Play {
+				args = append(args, "play")
+			}
+			if a.SceneBiasFlag {
+				args = append(args, "sceneBias:"+strconv.Itoa(int(a.SceneBias)))
+			}
+		}
+		fmt.Fprintf(b, "\t%s", strings.Join(args, " "))
+		if len(comment) > 0 {
+			fmt.Fprintf(b, " ; %s", strings.Join(comment, " "))
+		}
+		fmt.Fprintln(b)
+	}
+	if l, ok := labels[offsets[len(actions)]]; ok {
+		fmt.Fprintf(b, "%s:\n", l)
+	}
+	return b.Flush()
+}
+
+type defineFunction2Flag struct {
+	name string
+	flag *bool
+}
+
+func (a *ActionDefineFunction2) flags() []defineFunction2Flag {
+	return []defineFunction2Flag{
+		{"preloadParent", &a.PreloadParent},
+		{"preloadRoot", &a.PreloadRoot},
+		{"suppressSuper", &a.SuppressSuper},
+		{"preloadSuper", &a.PreloadSuper},
+		{"suppressArguments", &a.SuppressArguments},
+		{"preloadArguments", &a.PreloadArguments},
+		{"suppressThis", &a.SuppressThis},
+		{"preloadThis", &a.PreloadThis},
+		{"preloadGlobal", &a.PreloadGlobal},
+	}
+}
+
+type AssemblerError struct {
+	Line int
+	Err  string
+}
+
+func (a AssemblerError) Error() string {
+	return fmt.Sprintf("assemble: line %d: %s", a.Line, a.Err)
+}
+
+// asmTokens splits a line into tokens, keeping quoted strings whole and
+// dropping any comment.
+func asmTokens(line string) ([]string, error) {
+	var (
+		tokens []string
+		token  []byte
+	)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case ' ', '\t', '\r':
+			if len(token) > 0 {
+				tokens = append(tokens, string(token))
+				token = token[:0]
+			}
+		case ';':
+			i = len(line)
+		case '"':
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(line) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			token = append(token, line[i:j+1]...)
+			i = j
+		default:
+			token = append(token, c)
+		}
+	}
+	if len(token) > 0 {
+		tokens = append(tokens, string(token))
+	}
+	return tokens, nil
+}
+
+func asmString(token string) (String, error) {
+	s, err := strconv.Unquote(token)
+	if err != nil || !strings.HasPrefix(token, "\"") {
+		return "", fmt.Errorf("invalid string: %s", token)
+	}
+	return String(s), nil
+}
+
+func asmUint(token string, bits int) (uint64, error) {
+	n, err := strconv.ParseUint(token, 0, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", token)
+	}
+	return n, nil
+}
+
+func asmPushValue(token string) (PushValue, error) {
+	switch token {
+	case "null":
+		return PushNull{}, nil
+	case "undefined":
+		return PushUndefined{}, nil
+	case "true":
+		return PushBoolean(1), nil
+	case "false":
+		return PushBoolean(0), nil
+	}
+	if strings.HasPrefix(token, "\"") {
+		s, err := asmString(token)
+		return PushString(s), err
+	}
+	prefix, value := "", token
+	if p := strings.IndexByte(token, ':'); p >= 0 {
+		prefix, value = token[:p], token[p+1:]
+	}
+	var (
+		v   PushValue
+		err error
+	)
+	switch prefix {
+	case "":
+		var i int64
+		i, err = strconv.ParseInt(value, 0, 32)
+		v = PushInteger(i)
+	case "float":
+		var f float64
+		f, err = strconv.ParseFloat(value, 32)
+		v = PushFloat(f)
+	case "double":
+		var f float64
+		f, err = strconv.ParseFloat(value, 64)
+		v = PushDouble(f)
+	case "r":
+		var u uint64
+		u, err = strconv.ParseUint(value, 0, 8)
+		v = PushRegister(u)
+	case "bool":
+		var u uint64
+		u, err = strconv.ParseUint(value, 0, 8)
+		v = PushBoolean(u)
+	case "c8":
+		var u uint64
+		u, err = strconv.ParseUint(value, 0, 8)
+		v = PushConstant8(u)
+	case "c16":
+		var u uint64
+		u, err = strconv.ParseUint(value, 0, 16)
+		v = PushConstant16(u)
+	default:
+		err = fmt.Errorf("unknown type")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid push value: %s", token)
+	}
+	return v, nil
+}
+
+// asmRef is a reference, by label or relative offset, that is resolved once
+// all of the actions have been assembled. Offsets are relative to the end of
+// the action or, when chained, to the target of the previous reference.
+type asmRef struct {
+	line, action int
+	label        string
+	offset       int
+	chained      bool
+	set          func(int) error
+}
+
+func newAsmRef(token string, chained bool, set func(int) error) (asmRef, error) {
+	r := asmRef{chained: chained, set: set}
+	if c := token[0]; c == '-' || c == '+' || (c >= '0' && c <= '9') {
+		o, err := strconv.ParseInt(token, 0, 32)
+		if err != nil {
+			return r, fmt.Errorf("invalid offset: %s", token)
+		}
+		r.offset = int(o)
+	} else {
+		r.label = token
+	}
+	return r, nil
+}
+
+func setBranch(b *int16) func(int) error {
+	return func(o int) error {
+		if o < -32768 || o > 32767 {
+			return fmt.Errorf("branch out of range: %d", o)
+		}
+		*b = int16(o)
+		return nil
+	}
+}
+
+func setSize(s *uint16) func(int) error {
+	return func(o int) error {
+		if o < 0 || o > 65535 {
+			return fmt.Errorf("block size out of range: %d", o)
+		}
+		*s = uint16(o)
+		return nil
+	}
+}
+
+func asmArgs(tokens []string, min, max int) error {
+	if len(tokens) < min || (max >= 0 && len(tokens) > max) {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	return nil
+}
+
+func asmAction(tokens []string) (Action, []asmRef, error) {
+	name, args := tokens[0], tokens[1:]
+	code, ok := actionCodes[name]
+	if !ok && name != "Unknown" {
+		return nil, nil, fmt.Errorf("unknown action: %s", name)
+	}
+	if name == "Unknown" {
+		if err := asmArgs(args, 1, 2); err != nil {
+			return nil, nil, err
+		}
+		c, err := asmUint(args[0], 8)
+		if err != nil {
+			return nil, nil, err
+		}
+		if c < 0x80 {
+			if len(args) > 1 {
+				return nil, nil, fmt.Errorf("unexpected data for action %d", c)
+			}
+			return BasicAction(c), nil, nil
+		}
+		u := &UnknownAction{Code: uint8(c), Data: []byte{}}
+		if len(args) > 1 {
+			if u.Data, err = hex.DecodeString(args[1]); err != nil {
+				return nil, nil, fmt.Errorf("invalid data: %s", args[1])
+			}
+		}
+		return u, nil, nil
+	}
+	action := newAction(code)
+	var (
+		refs []asmRef
+		err  error
+	)
+	addRef := func(token string, chained bool, set func(int) error) {
+		if err == nil {
+			var r asmRef
+			r, err = newAsmRef(token, chained, set)
+			refs = append(refs, r)
+		}
+	}
+	switch a := action.(type) {
+	case BasicAction, *ActionCall:
+		err = asmArgs(args, 0, 0)
+	case *ActionGotoFrame:
+		if err = asmArgs(args, 1, 1); err == nil {
+			var n uint64
+			n, err = asmUint(args[0], 16)
+			a.Frame = uint16(n)
+		}
+	case *ActionGetURL:
+		if err = asmArgs(args, 2, 2); err == nil {
+			if a.URL, err = asmString(args[0]); err == nil {
+				a.Target, err = asmString(args[1])
+			}
+		}
+	case *ActionStoreRegister:
+		if err = asmArgs(args, 1, 1); err == nil {
+			var n uint64
+			n, err = asmUint(args[0], 8)
+			a.Register = uint8(n)
+		}
+	case *ActionConstantPool:
+		a.Constants = make([]String, len(args))
+		for n := 0; n < len(args) && err == nil; n++ {
+			a.Constants[n], err = asmString(args[n])
+		}
+	case *ActionWaitForFrame:
+		if err = asmArgs(args, 2, 2); err == nil {
+			var f, c uint64
+			if f, err = asmUint(args[0], 16); err == nil {
+				c, err = asmUint(args[1], 8)
+			}
+			a.Frame, a.SkipCount = uint16(f), uint8(c)
+		}
+	case *ActionSetTarget:
+		if err = asmArgs(args, 1, 1); err == nil {
+			a.TargetName, err = asmString(args[0])
+		}
+	case *ActionGoToLabel:
+		if err = asmArgs(args, 1, 1); err == nil {
+			a.Label, err = asmString(args[0])
+		}
+	case *ActionWaitForFrame2:
+		if err = asmArgs(args, 1, 1); err == nil {
+			var n uint64
+			n, err = asmUint(args[0], 8)
+			a.SkipCount = uint8(n)
+		}
+	case *ActionDefineFunction2:
+		if err = asmArgs(args, 3, -1); err != nil {
+			break
+		}
+		if a.FunctionName, err = asmString(args[0]); err != nil {
+			break
+		}
+		addRef(args[1], false, setSize(&a.CodeSize))
+		var n uint64
+		if n, err = asmUint(args[2], 8); err != nil {
+			break
+		}
+		a.RegisterCount = uint8(n)
+		a.Parameters = []RegisterParam{}
+	Args:
+		for _, arg := range args[3:] {
+			for _, f := range a.flags() {
+				if arg == f.name {
+					*f.flag = true
+					continue Args
+				}
+			}
+			p := strings.IndexByte(arg, ':')
+			if p < 0 {
+				err = fmt.Errorf("invalid parameter: %s", arg)
+				break
+			}
+			var param RegisterParam
+			if n, err = asmUint(arg[:p], 8); err != nil {
+				break
+			}
+			param.Register = uint8(n)
+			if param.ParamName, err = asmString(arg[p+1:]); err != nil {
+				break
+			}
+			a.Parameters = append(a.Parameters, param)
+		}
+	case *ActionTry:
+		if err = asmArgs(args, 4, 6); err != nil {
+			break
+		}
+		addRef(args[0], false, setSize(&a.TrySize))
+		addRef(args[1], true, setSize(&a.CatchSize))
+		addRef(args[2], true, setSize(&a.FinallySize))
+		for _, arg := range args[3 : len(args)-1] {
+			switch arg {
+			case "catch":
+				a.CatchBlock = true
+			case "finally":
+				a.FinallyBlock = true
+			default:
+				err = fmt.Errorf("unknown flag: %s", arg)
+			}
+		}
+		if err != nil {
+			break
+		}
+		if last := args[len(args)-1]; strings.HasPrefix(last, "r:") {
+			var n uint64
+			n, err = asmUint(last[2:], 8)
+			a.CatchInRegister, a.CatchRegister = true, uint8(n)
+		} else {
+			a.CatchName, err = asmString(last)
+		}
+	case *ActionWith:
+		if err = asmArgs(args, 1, 1); err == nil {
+			addRef(args[0], false, setSize(&a.Size))
+		}
+	case *ActionPush:
+		for _, arg := range args {
+			var v PushValue
+			if v, err = asmPushValue(arg); err != nil {
+				break
+			}
+			a.Values = append(a.Values, v)
+		}
+	case *ActionJump:
+		if err = asmArgs(args, 1, 1); err == nil {
+			addRef(args[0], false, setBranch(&a.BranchOffset))
+		}
+	case *ActionIf:
+		if err = asmArgs(args, 1, 1); err == nil {
+			addRef(args[0], false, setBranch(&a.BranchOffset))
+		}
+	case *ActionGetURL2:
+		if err = asmArgs(args, 1, 3); err != nil {
+			break
+		}
+		a.SendVarsMethod = 0xff
+		for n, m := range sendVarsMethods {
+			if args[0] == m {
+				a.SendVarsMethod = uint8(n)
+			}
+		}
+		if a.SendVarsMethod == 0xff {
+			err = fmt.Errorf("unknown method: %s", args[0])
+		}
+		for _, arg := range args[1:] {
+			switch arg {
+			case "loadTarget":
+				a.LoadTarget = true
+			case "loadVariables":
+				a.LoadVariables = true
+			default:
+				err = fmt.Errorf("unknown flag: %s", arg)
+			}
+		}
+	case *ActionDefineFunction:
+		if err = asmArgs(args, 2, -1); err != nil {
+			break
+		}
+		if a.FunctionName, err = asmString(args[0]); err != nil {
+			break
+		}
+		addRef(args[1], false, setSize(&a.CodeSize))
+		a.Params = make([]String, len(args)-2)
+		for n := 0; n < len(a.Params) && err == nil; n++ {
+			a.Params[n], err = asmString(args[n+2])
+		}
+	case *ActionGotoFrame2:
+		for _, arg := range args {
+			if err != nil {
+				break
+			}
+			switch {
+			case arg == "play":
+				a.Play = true
+			case strings.HasPrefix(arg, "sceneBias:"):
+				var n uint64
+				n, err = asmUint(arg[10:], 16)
+				a.SceneBiasFlag, a.SceneBias = true, uint16(n)
+			default:
+				err = fmt.Errorf("unknown flag: %s", arg)
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return action, refs, nil
+}
+
+// Assemble parses a listing, as written by Disassemble, back into actions,
+// recalculating branch offsets and block sizes from their labels.
+func Assemble(r io.Reader) ([]Action, error) {
+	var (
+		actions []Action
+		refs    []asmRef
+		labels  = make(map[string]int)
+	)
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		tokens, err := asmTokens(s.Text())
+		if err != nil {
+			return nil, AssemblerError{line, err.Error()}
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		if l := tokens[0]; len(tokens) == 1 && strings.HasSuffix(l, ":") {
+			l = l[:len(l)-1]
+			if _, ok := labels[l]; ok {
+				return nil, AssemblerError{line, "duplicate label: " + l}
+			}
+			labels[l] = len(actions)
+			continue
+		}
+		action, rs, err := asmAction(tokens)
+		if err != nil {
+			return nil, AssemblerError{line, err.Error()}
+		}
+		for _, r := range rs {
+			r.line, r.action = line, len(actions)
+			refs = append(refs, r)
+		}
+		actions = append(actions, action)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	offsets := actionOffsets(actions)
+	var last int
+	for _, r := range refs {
+		from := offsets[r.action+1]
+		if r.chained {
+			from = last
+		}
+		target := from + r.offset
+		if r.label != "" {
+			n, ok := labels[r.label]
+			if !ok {
+				return nil, AssemblerError{r.line, "unknown label: " + r.label}
+			}
+			target = offsets[n]
+		}
+		if err := r.set(target - from); err != nil {
+			return nil, AssemblerError{r.line, err.Error()}
+		}
+		last = target
+	}
+	return actions, nil
+}
@@ -0,0 +1,111 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssembler(t *testing.T) {
+	tests := []struct {
+		listing string
+		data    []byte
+	}{
+		{
+			"\tConstantPool \"a\" \"bc\"\n" +
+				"L1:\n" +
+				"\tPush c8:1 \"x\" c16:5 ; \"bc\" ?\n" +
+				"\tIf L2\n" +
+				"\tJump L1\n" +
+				"L2:\n" +
+				"\tDefineFunction \"f\" L3 \"p\"\n" +
+				"\tPush r:1\n" +
+				"\tReturn\n" +
+				"L3:\n" +
+				"\tTry L4 L5 L5 catch \"e\"\n" +
+				"\tStop\n" +
+				"L4:\n" +
+				"\tPlay\n" +
+				"L5:\n" +
+				"\tWith L6\n" +
+				"\tJump 1\n" +
+				"\tUnknown 200 abcd\n" +
+				"L6:\n",
+			[]byte{
+				0x88, 7, 0, 2, 0, 'a', 0, 'b', 'c', 0,
+				0x96, 8, 0, 8, 1, 0, 'x', 0, 9, 5, 0,
+				0x9d, 2, 0, 5, 0,
+				0x99, 2, 0, 0xeb, 0xff,
+				0x9b, 8, 0, 'f', 0, 1, 0, 'p', 0, 6, 0,
+				0x96, 2, 0, 4, 1,
+				0x3e,
+				0x8f, 9, 0, 1, 1, 0, 1, 0, 0, 0, 'e', 0,
+				0x07,
+				0x06,
+				0x94, 2, 0, 10, 0,
+				0x99, 2, 0, 1, 0,
+				0xc8, 2, 0, 0xab, 0xcd,
+			},
+		},
+		{
+			"\tDefineFunction2 \"g\" L1 4 preloadThis preloadGlobal 3:\"a\"\n" +
+				"L1:\n" +
+				"\tGetURL2 post loadTarget\n" +
+				"\tGotoFrame2 play sceneBias:2\n" +
+				"\tPush float:1.5 double:0.1 -7 true bool:2 null undefined \"a;b \\\"q\\\"\"\n" +
+				"\tWaitForFrame 1 2\n" +
+				"\tTry L2 L2 L2 finally r:2\n" +
+				"L2:\n" +
+				"\tUnknown 26\n",
+			nil,
+		},
+	}
+	for n, test := range tests {
+		actions, err := Assemble(strings.NewReader(test.listing))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+			continue
+		}
+		data, err := EncodeActions(actions)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+			continue
+		}
+		if test.data != nil && !bytes.Equal(data, test.data) {
+			t.Errorf("test %d: expecting data %v, got %v", n+1, test.data, data)
+		}
+		decoded, err := DecodeActions(data)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(decoded, actions) {
+			t.Errorf("test %d: expecting actions %v, got %v", n+1, actions, decoded)
+		}
+		var buf bytes.Buffer
+		if err = Disassemble(&buf, decoded); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if buf.String() != test.listing {
+			t.Errorf("test %d: expecting listing:\n%s\ngot:\n%s", n+1, test.listing, buf.String())
+		}
+	}
+}
+
+func TestAssemblerErrors(t *testing.T) {
+	tests := []struct {
+		listing string
+		err     AssemblerError
+	}{
+		{"\tStop\n\tFoo\n", AssemblerError{2, "unknown action: Foo"}},
+		{"\tJump L1\n", AssemblerError{1, "unknown label: L1"}},
+		{"L1:\nL1:\n", AssemblerError{2, "duplicate label: L1"}},
+		{"\tPush \"a\n", AssemblerError{1, "unterminated string"}},
+		{"\tPush x:1\n", AssemblerError{1, "invalid push value: x:1"}},
+		{"\tStop 1\n", AssemblerError{1, "wrong number of arguments"}},
+		{"\tWith -1\n", AssemblerError{1, "block size out of range: -1"}},
+	}
+	for n, test := range tests {
+		if _, err := Assemble(strings.NewReader(test.listing)); err != test.err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+)
+
+var ErrUnsupportedSoundFormat = errors.New("sound: unsupported sound format")
+
+var adpcmStepTable = [89]int32{
+	7, 8, 9, 10, 11, 12, 13, 14, 16, 17,
+	19, 21, 23, 25, 28, 31, 34, 37, 41, 45,
+	50, 55, 60, 66, 73, 80, 88, 97, 107, 118,
+	130, 143, 157, 173, 190, 209, 230, 253, 279, 307,
+	337, 371, 408, 449, 494, 544, 598, 658, 724, 796,
+	876, 963, 1060, 1166, 1282, 1411, 1552, 1707, 1878, 2066,
+	2272, 2499, 2749, 3024, 3327, 3660, 4026, 4428, 4871, 5358,
+	5894, 6484, 7132, 7845, 8630, 9493, 10442, 11487, 12635, 13899,
+	15289, 16818, 18500, 20350, 22385, 24623, 27086, 29794, 32767,
+}
+
+var adpcmIndexTables = [4][]int32{
+	{-1, 2},
+	{-1, -1, 2, 4},
+	{-1, -1, -1, -1, 2, 4, 6, 8},
+	{-1, -1, -1, -1, -1, -1, -1, -1, 1, 2, 4, 6, 8, 10, 13, 16},
+}
+
+type adpcmChannel struct {
+	predictor int32
+	index     int32
+}
+
+// DecodeADPCM decodes a block of SWF ADPCM data into interleaved 16-bit
+// samples. Each DefineSound and each SoundStreamBlock is a separate block.
+func DecodeADPCM(data []byte, stereo bool) (samples []int16, err error) {
+	channels := 1
+	if stereo {
+		channels = 2
+	}
+	size := len(data) * 8
+	b := &bitReader{Reader: bytes.NewReader(data)}
+	var (
+		u    BitUint
+		s    BitInt
+		read int
+	)
+	if err = u.ReadBitsFrom(b, 2); err != nil {
+		return
+	}
+	read += 2
+	bits := uint8(u) + 2
+	table := adpcmIndexTables[u]
+	k0 := uint32(1) << (bits - 2)
+	signMask := uint32(1) << (bits - 1)
+	state := make([]adpcmChannel, channels)
+	for read+22*channels <= size {
+		for i := range state {
+			if err = s.ReadBitsFrom(b, 16); err != nil {
+				return
+			}
+			if err = u.ReadBitsFrom(b, 6); err != nil {
+				return
+			}
+			read += 22
+			state[i].predictor = int32(s)
+			state[i].index = int32(u)
+			if state[i].index > 88 {
+				state[i].index = 88
+			}
+			samples = append(samples, int16(s))
+		}
+		for count := 0; count < 4095 && read+int(bits)*channels <= size; count++ {
+			for i := range state {
+				if err = u.ReadBitsFrom(b, bits); err != nil {
+					return
+				}
+				read += int(bits)
+				delta := uint32(u)
+				step := adpcmStepTable[state[i].index]
+				var vpdiff int32
+				for k := k0; k > 0; k >>= 1 {
+					if delta&k != 0 {
+						vpdiff += step
+					}
+					step >>= 1
+				}
+				vpdiff += step
+				if delta&signMask != 0 {
+					state[i].predictor -= vpdiff
+				} else {
+					state[i].predictor += vpdiff
+				}
+				if state[i].predictor > 32767 {
+					state[i].predictor = 32767
+				} else if state[i].predictor < -32768 {
+					state[i].predictor = -32768
+				}
+				state[i].index += table[delta&^signMask]
+				if state[i].index < 0 {
+					state[i].index = 0
+				} else if state[i].index > 88 {
+					state[i].index = 88
+				}
+				samples = append(samples, int16(state[i].predictor))
+			}
+		}
+	}
+	return
+}
+
+// DecodePCM converts uncompressed sound data into 16-bit samples. 8-bit
+// samples are unsigned; native-endian data is treated as little-endian, as
+// it is by every Flash Player.
+func DecodePCM(data []byte, is16Bit bool) []int16 {
+	if !is16Bit {
+		samples := make([]int16, len(data))
+		for n, d := range data {
+			samples[n] = (int16(d) - 128) << 8
+		}
+		return samples
+	}
+	samples := make([]int16, len(data)/2)
+	for n := range samples {
+		samples[n] = int16(binary.LittleEndian.Uint16(data[2*n:]))
+	}
+	return samples
+}
+
+func decodeSound(format SoundFormat, data []byte, is16Bit, stereo bool) ([]int16, error) {
+	switch format {
+	case SOUND_UNCOMPRESSED_NATIVE, SOUND_UNCOMPRESSED_LE:
+		return DecodePCM(data, is16Bit), nil
+	case SOUND_ADPCM:
+		return DecodeADPCM(data, stereo)
+	}
+	return nil, ErrUnsupportedSoundFormat
+}
+
+func (d *DefineSound) PCM() ([]int16, error) {
+	samples, err := decodeSound(d.SoundFormat, d.SoundData, d.Sound16Bit, d.SoundStereo)
+	if err != nil {
+		return nil, err
+	}
+	count := int(d.SoundSampleCount)
+	if d.SoundStereo {
+		count *= 2
+	}
+	if count < len(samples) {
+		samples = samples[:count]
+	}
+	return samples, nil
+}
+
+func (d *DefineSound) WriteWAV(w io.Writer) (int64, error) {
+	samples, err := d.PCM()
+	if err != nil {
+		return 0, err
+	}
+	return WriteWAV(w, samples, d.SoundRate.Hz(), d.SoundStereo)
+}
+
+func (s *SoundStream) PCM() (samples []int16, err error) {
+	if s.Head.StreamSoundCompression != SOUND_ADPCM {
+		return decodeSound(s.Head.StreamSoundCompression, s.Data, s.Head.StreamSound16Bit, s.Head.StreamSoundStereo)
+	}
+	for _, f := range s.Frames {
+		var block []int16
+		if block, err = DecodeADPCM(s.Data[f.Offset:f.Offset+f.Length], s.Head.StreamSoundStereo); err != nil {
+			return
+		}
+		samples = append(samples, block...)
+	}
+	return
+}
+
+func (s *SoundStream) WriteWAV(w io.Writer) (int64, error) {
+	samples, err := s.PCM()
+	if err != nil {
+		return 0, err
+	}
+	return WriteWAV(w, samples, s.Head.StreamSoundRate.Hz(), s.Head.StreamSoundStereo)
+}
+
+// WriteWAV writes interleaved 16-bit samples as a PCM WAVE file.
+func WriteWAV(w io.Writer, samples []int16, rate uint32, stereo bool) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: w}
+	defer func() { total = c.BytesWritten() }()
+	channels := uint16(1)
+	if stereo {
+		channels = 2
+	}
+	dataSize := uint32(len(samples)) * 2
+	header := struct {
+		Riff          [4]byte
+		RiffSize      uint32
+		Wave          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		[4]byte{'R', 'I', 'F', 'F'},
+		36 + dataSize,
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		16,
+		1,
+		channels,
+		rate,
+		rate * uint32(channels) * 2,
+		channels * 2,
+		16,
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize,
+	}
+	if err = binary.Write(c, binary.LittleEndian, &header); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, samples)
+	return
+}
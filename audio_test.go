@@ -0,0 +1,48 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDecodePCM(t *testing.T) {
+	if s := DecodePCM([]byte{0, 128, 255}, false); !reflect.DeepEqual(s, []int16{-32768, 0, 32512}) {
+		t.Errorf("8-bit: got %v", s)
+	}
+	if s := DecodePCM([]byte{0x34, 0x12, 0xff, 0xff}, true); !reflect.DeepEqual(s, []int16{0x1234, -1}) {
+		t.Errorf("16-bit: got %v", s)
+	}
+}
+
+func TestDecodeADPCM(t *testing.T) {
+	d := &DefineSound{
+		SoundFormat:      SOUND_ADPCM,
+		Sound16Bit:       true,
+		SoundSampleCount: 3,
+		SoundData:        []byte{0x00, 0x19, 0x00, 0x60},
+	}
+	samples, err := d.PCM()
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+	if expected := []int16{100, 110, 106}; !reflect.DeepEqual(samples, expected) {
+		t.Errorf("expecting %v, got %v", expected, samples)
+	}
+}
+
+func TestWriteWAV(t *testing.T) {
+	buf := new(bytes.Buffer)
+	n, err := WriteWAV(buf, []int16{1, -1}, 22050, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+	expected := []byte{
+		'R', 'I', 'F', 'F', 40, 0, 0, 0, 'W', 'A', 'V', 'E',
+		'f', 'm', 't', ' ', 16, 0, 0, 0, 1, 0, 1, 0, 0x22, 0x56, 0, 0, 0x44, 0xac, 0, 0, 2, 0, 16, 0,
+		'd', 'a', 't', 'a', 4, 0, 0, 0, 1, 0, 0xff, 0xff,
+	}
+	if n != int64(len(expected)) || !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("expecting %v, got %v", expected, buf.Bytes())
+	}
+}
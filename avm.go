@@ -0,0 +1,1391 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrActionLimit  = errors.New("player: action limit exceeded")
+	ErrCallDepth    = errors.New("player: maximum call depth exceeded")
+	ErrArrayLength  = errors.New("player: maximum array length exceeded")
+	ErrStringLength = errors.New("player: maximum string length exceeded")
+)
+
+const (
+	maxCallDepth    = 256
+	maxArrayLength  = 1 << 20
+	maxStringLength = 1 << 24
+)
+
+// avmValue is one of avmUndefined, avmNull, bool, float64, string or
+// *avmObject.
+type avmValue interface{}
+
+type (
+	avmUndefined struct{}
+	avmNull      struct{}
+)
+
+// avmThrow carries a value thrown by script.
+type avmThrow struct {
+	value avmValue
+}
+
+func (t *avmThrow) Error() string {
+	return "player: uncaught exception"
+}
+
+type avmNative func(p *Player, this avmValue, args []avmValue) (avmValue, error)
+
+// avmObject is a script object. Arrays keep their elements in elements, and
+// the object of a MovieClip exposes its properties and children alongside
+// its variables.
+type avmObject struct {
+	class     string
+	props     map[string]avmValue
+	keys      []string
+	proto     *avmObject
+	elements  []avmValue
+	call      avmNative
+	construct avmNative
+	clip      *MovieClip
+}
+
+func newObject(proto *avmObject) *avmObject {
+	return &avmObject{
+		class: "Object",
+		props: make(map[string]avmValue),
+		proto: proto,
+	}
+}
+
+func arrayIndex(name string) (int, bool) {
+	n, err := strconv.Atoi(name)
+	return n, err == nil && n >= 0 && strconv.Itoa(n) == name
+}
+
+func (o *avmObject) own(name string) (avmValue, bool) {
+	if o.clip != nil {
+		if v, ok := o.clip.property(name); ok {
+			return v, true
+		}
+	}
+	if o.class == "Array" {
+		if name == "length" {
+			return float64(len(o.elements)), true
+		}
+		if n, ok := arrayIndex(name); ok {
+			if n < len(o.elements) {
+				return o.elements[n], true
+			}
+			return nil, false
+		}
+	}
+	if name == "__proto__" {
+		if o.proto == nil {
+			return avmUndefined{}, true
+		}
+		return o.proto, true
+	}
+	v, ok := o.props[name]
+	if !ok && o.clip != nil {
+		if c := o.clip.child(name); c != nil {
+			return c.object, true
+		}
+	}
+	return v, ok
+}
+
+func (o *avmObject) get(name string) avmValue {
+	for p, n := o, 0; p != nil && n < maxCallDepth; p, n = p.proto, n+1 {
+		if v, ok := p.own(name); ok {
+			return v
+		}
+	}
+	return avmUndefined{}
+}
+
+// splice replaces the elements of an array from start to end. All growth of
+// arrays goes through splice, which returns ErrArrayLength rather than grow
+// an array beyond maxArrayLength elements.
+func (o *avmObject) splice(start, end int, elements []avmValue) error {
+	if len(o.elements)-(end-start)+len(elements) > maxArrayLength {
+		return ErrArrayLength
+	}
+	if start == len(o.elements) {
+		o.elements = append(o.elements, elements...)
+	} else {
+		e := make([]avmValue, 0, len(o.elements)-(end-start)+len(elements))
+		o.elements = append(append(append(e, o.elements[:start]...), elements...), o.elements[end:]...)
+	}
+	return nil
+}
+
+// pad grows an array with undefined elements to a length of l.
+func (o *avmObject) pad(l int) error {
+	if l > maxArrayLength {
+		return ErrArrayLength
+	} else if l <= len(o.elements) {
+		return nil
+	}
+	elements := make([]avmValue, l-len(o.elements))
+	for n := range elements {
+		elements[n] = avmUndefined{}
+	}
+	return o.splice(len(o.elements), len(o.elements), elements)
+}
+
+func (o *avmObject) set(name string, v avmValue) error {
+	if o.clip != nil && o.clip.setProperty(name, v) {
+		return nil
+	}
+	if o.class == "Array" {
+		if name == "length" {
+			f, _ := v.(float64)
+			if f > maxArrayLength {
+				return ErrArrayLength
+			}
+			l := int(f)
+			if l >= 0 && l < len(o.elements) {
+				o.elements = o.elements[:l]
+			}
+			return o.pad(l)
+		}
+		if n, ok := arrayIndex(name); ok {
+			if err := o.pad(n + 1); err != nil {
+				return err
+			}
+			o.elements[n] = v
+			return nil
+		}
+	}
+	if name == "__proto__" {
+		o.proto, _ = v.(*avmObject)
+		return nil
+	}
+	if _, ok := o.props[name]; !ok {
+		o.keys = append(o.keys, name)
+	}
+	o.props[name] = v
+	return nil
+}
+
+func (o *avmObject) delete(name string) bool {
+	if _, ok := o.props[name]; !ok {
+		return false
+	}
+	delete(o.props, name)
+	for n, k := range o.keys {
+		if k == name {
+			o.keys = append(o.keys[:n], o.keys[n+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// names returns the enumerable names of the object, most recently defined
+// first.
+func (o *avmObject) names() []string {
+	var names []string
+	for n := len(o.elements) - 1; n >= 0; n-- {
+		names = append(names, strconv.Itoa(n))
+	}
+	for n := len(o.keys) - 1; n >= 0; n-- {
+		names = append(names, o.keys[n])
+	}
+	if o.clip != nil {
+		for _, c := range o.clip.Children() {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+func (o *avmObject) instanceOf(constructor *avmObject) bool {
+	proto, ok := constructor.get("prototype").(*avmObject)
+	if !ok {
+		return false
+	}
+	for p, n := o.proto, 0; p != nil && n < maxCallDepth; p, n = p.proto, n+1 {
+		if p == proto {
+			return true
+		}
+	}
+	return false
+}
+
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == math.Trunc(f) && math.Abs(f) < 1e15:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', 15, 64)
+}
+
+func (p *Player) toNumber(v avmValue) float64 {
+	switch v := v.(type) {
+	case avmUndefined, avmNull:
+		if p.version < 7 {
+			return 0
+		}
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			if p.version < 7 {
+				return 0
+			}
+			return math.NaN()
+		}
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			if n, err := strconv.ParseInt(s[2:], 16, 64); err == nil {
+				return float64(n)
+			}
+		} else if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return math.NaN()
+}
+
+func (p *Player) toString(v avmValue) string {
+	switch v := v.(type) {
+	case avmUndefined:
+		if p.version < 7 {
+			return ""
+		}
+		return "undefined"
+	case avmNull:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case *avmObject:
+		switch {
+		case v.clip != nil:
+			return v.clip.Target()
+		case v.class == "Array":
+			s, _ := p.join(v, ",")
+			return s
+		case v.call != nil:
+			return "[type Function]"
+		}
+	}
+	return "[object Object]"
+}
+
+// join joins the elements of an array. It stops with ErrStringLength once the
+// result exceeds maxStringLength bytes, returning a result one byte too long,
+// which anything that concatenates it rejects.
+func (p *Player) join(a *avmObject, sep string) (string, error) {
+	parts := make([]string, 0, len(a.elements))
+	l := 0
+	for n, e := range a.elements {
+		if n > 0 {
+			l += len(sep)
+		}
+		var s string
+		switch e.(type) {
+		case avmUndefined, avmNull:
+		default:
+			s = p.toString(e)
+		}
+		parts = append(parts, s)
+		if l += len(s); l > maxStringLength {
+			return strings.Join(parts, sep)[:maxStringLength+1], ErrStringLength
+		}
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// concat joins strings, returning ErrStringLength rather than create a string
+// longer than maxStringLength bytes.
+func concat(strs ...string) (string, error) {
+	l := 0
+	for _, s := range strs {
+		if l += len(s); l > maxStringLength {
+			return "", ErrStringLength
+		}
+	}
+	return strings.Join(strs, ""), nil
+}
+
+func (p *Player) toBoolean(v avmValue) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		if p.version < 7 {
+			return p.toBoolean(p.toNumber(v))
+		}
+		return v != ""
+	case *avmObject:
+		return true
+	}
+	return false
+}
+
+func (p *Player) typeOf(v avmValue) string {
+	switch v := v.(type) {
+	case avmNull:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *avmObject:
+		switch {
+		case v.clip != nil:
+			return "movieclip"
+		case v.call != nil:
+			return "function"
+		}
+		return "object"
+	}
+	return "undefined"
+}
+
+// isPrimitive returns whether the value is compared by value.
+func isPrimitive(v avmValue) bool {
+	_, ok := v.(*avmObject)
+	return !ok
+}
+
+func (p *Player) equals(a, b avmValue) bool {
+	switch x := a.(type) {
+	case avmUndefined, avmNull:
+		switch b.(type) {
+		case avmUndefined, avmNull:
+			return true
+		}
+		return false
+	case bool:
+		return p.equals(p.toNumber(x), b)
+	case float64:
+		switch y := b.(type) {
+		case float64:
+			return x == y
+		case string, bool:
+			return x == p.toNumber(y)
+		}
+	case string:
+		switch y := b.(type) {
+		case string:
+			return x == y
+		case float64, bool:
+			return p.toNumber(x) == p.toNumber(y)
+		case *avmObject:
+			return x == p.toString(y)
+		}
+	case *avmObject:
+		switch y := b.(type) {
+		case *avmObject:
+			return x == y
+		case string:
+			return p.toString(x) == y
+		case bool:
+			return p.equals(x, p.toNumber(y))
+		}
+	}
+	return false
+}
+
+func strictEquals(a, b avmValue) bool {
+	if isPrimitive(a) != isPrimitive(b) {
+		return false
+	}
+	return a == b
+}
+
+// less compares two values, returning undefined when either is NaN.
+func (p *Player) less(a, b avmValue) avmValue {
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return x < y
+		}
+	}
+	x, y := p.toNumber(a), p.toNumber(b)
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return avmUndefined{}
+	}
+	return x < y
+}
+
+func (p *Player) add(a, b avmValue) (avmValue, error) {
+	_, sa := a.(string)
+	_, sb := b.(string)
+	_, oa := a.(*avmObject)
+	_, ob := b.(*avmObject)
+	if sa || sb || oa || ob {
+		return concat(p.toString(a), p.toString(b))
+	}
+	return p.toNumber(a) + p.toNumber(b), nil
+}
+
+func (p *Player) toInt32(v avmValue) int32 {
+	f := p.toNumber(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return int32(uint32(int64(math.Mod(math.Trunc(f), 1<<32))))
+}
+
+// avmCode is a list of actions along with the offset of each, for resolving
+// branches.
+type avmCode struct {
+	actions []Action
+	offsets []int
+	index   map[int]int
+}
+
+func newCode(actions []Action) *avmCode {
+	c := &avmCode{
+		actions: actions,
+		offsets: actionOffsets(actions),
+		index:   make(map[int]int),
+	}
+	for n, o := range c.offsets {
+		c.index[o] = n
+	}
+	return c
+}
+
+// branch returns the index of the action size bytes after the end of the
+// action at index n. Targets that are not on an action boundary end the
+// code.
+func (c *avmCode) branch(n, size int) int {
+	if t, ok := c.index[c.offsets[n+1]+size]; ok {
+		return t
+	}
+	return len(c.actions)
+}
+
+type avmContext struct {
+	code      *avmCode
+	stack     []avmValue
+	registers []avmValue
+	scope     []*avmObject
+	target    *MovieClip
+	original  *MovieClip
+	this      avmValue
+	pool      []String
+	returned  bool
+	result    avmValue
+}
+
+func (c *avmContext) push(v avmValue) {
+	c.stack = append(c.stack, v)
+}
+
+func (c *avmContext) pop() avmValue {
+	if len(c.stack) == 0 {
+		return avmUndefined{}
+	}
+	v := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	return v
+}
+
+func (c *avmContext) popArgs(p *Player) []avmValue {
+	count := int(p.toNumber(c.pop()))
+	if count < 0 || count > len(c.stack) {
+		count = len(c.stack)
+	}
+	args := make([]avmValue, count)
+	for n := range args {
+		args[n] = c.pop()
+	}
+	return args
+}
+
+func (c *avmContext) register(r uint8) avmValue {
+	if int(r) < len(c.registers) {
+		return c.registers[r]
+	}
+	return avmUndefined{}
+}
+
+func newRegisters(count int) []avmValue {
+	if count < 4 {
+		count = 4
+	}
+	registers := make([]avmValue, count)
+	for n := range registers {
+		registers[n] = avmUndefined{}
+	}
+	return registers
+}
+
+type avmFunction struct {
+	code       *avmCode
+	start, end int
+	name       string
+	params     []String
+	define2    *ActionDefineFunction2
+	scope      []*avmObject
+	target     *MovieClip
+	pool       []String
+}
+
+func (p *Player) newFunction(call, construct avmNative) *avmObject {
+	o := newObject(p.functionProto)
+	o.class = "Function"
+	o.call = call
+	o.construct = construct
+	proto := newObject(p.objectProto)
+	proto.set("constructor", o)
+	o.set("prototype", proto)
+	return o
+}
+
+func (p *Player) defineFunction(f *avmFunction) *avmObject {
+	var o *avmObject
+	o = p.newFunction(func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return p.callFunction(f, o, this, args)
+	}, nil)
+	return o
+}
+
+func (p *Player) callFunction(f *avmFunction, callee *avmObject, this avmValue, args []avmValue) (avmValue, error) {
+	if p.depth >= maxCallDepth {
+		return nil, ErrCallDepth
+	}
+	p.depth++
+	current := p.current
+	p.current = f.target
+	defer func() {
+		p.depth--
+		p.current = current
+	}()
+	locals := newObject(nil)
+	locals.class = "Activation"
+	ctx := &avmContext{
+		code:     f.code,
+		scope:    append(f.scope[:len(f.scope):len(f.scope)], locals),
+		target:   f.target,
+		original: f.target,
+		this:     this,
+		pool:     f.pool,
+	}
+	arguments := p.newArray(args)
+	arguments.set("callee", callee)
+	arg := func(n int) avmValue {
+		if n < len(args) {
+			return args[n]
+		}
+		return avmUndefined{}
+	}
+	if d := f.define2; d != nil {
+		ctx.registers = newRegisters(int(d.RegisterCount))
+		for name, r := range d.PreloadedRegisters() {
+			if int(r) >= len(ctx.registers) {
+				continue
+			}
+			switch name {
+			case "this":
+				ctx.registers[r] = this
+			case "arguments":
+				ctx.registers[r] = arguments
+			case "super":
+				ctx.registers[r] = p.super(this)
+			case "_root":
+				ctx.registers[r] = p.Root.object
+			case "_parent":
+				ctx.registers[r] = f.target.parentObject()
+			case "_global":
+				ctx.registers[r] = p.global
+			}
+		}
+		if !d.SuppressArguments && !d.PreloadArguments {
+			locals.set("arguments", arguments)
+		}
+		for n, param := range d.Parameters {
+			if param.Register != 0 && int(param.Register) < len(ctx.registers) {
+				ctx.registers[param.Register] = arg(n)
+			} else {
+				locals.set(string(param.ParamName), arg(n))
+			}
+		}
+	} else {
+		ctx.registers = newRegisters(4)
+		locals.set("arguments", arguments)
+		for n, param := range f.params {
+			locals.set(string(param), arg(n))
+		}
+	}
+	if _, err := p.run(ctx, f.start, f.end); err != nil {
+		return nil, err
+	}
+	if ctx.returned {
+		return ctx.result, nil
+	}
+	return avmUndefined{}, nil
+}
+
+// super returns the constructor of the superclass of the object.
+func (p *Player) super(this avmValue) avmValue {
+	if o, ok := this.(*avmObject); ok && o.proto != nil {
+		if c, ok := o.proto.own("__constructor__"); ok {
+			return c
+		}
+	}
+	return avmUndefined{}
+}
+
+func (p *Player) call(f avmValue, this avmValue, args []avmValue) (avmValue, error) {
+	if o, ok := f.(*avmObject); ok && o.call != nil {
+		return o.call(p, this, args)
+	}
+	return avmUndefined{}, nil
+}
+
+func (p *Player) construct(f avmValue, args []avmValue) (avmValue, error) {
+	c, ok := f.(*avmObject)
+	if !ok || c.call == nil {
+		return avmUndefined{}, nil
+	}
+	if c.construct != nil {
+		return c.construct(p, nil, args)
+	}
+	proto, _ := c.get("prototype").(*avmObject)
+	o := newObject(proto)
+	r, err := c.call(p, o, args)
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := r.(*avmObject); ok {
+		return r, nil
+	}
+	return o, nil
+}
+
+// getMember returns the named member of the value, with primitives using
+// the prototype of their type.
+func (p *Player) getMember(v avmValue, name string) avmValue {
+	switch v := v.(type) {
+	case *avmObject:
+		return v.get(name)
+	case string:
+		if name == "length" {
+			return float64(len([]rune(v)))
+		}
+		return p.stringProto.get(name)
+	case float64:
+		return p.numberProto.get(name)
+	case bool:
+		return p.booleanProto.get(name)
+	}
+	return avmUndefined{}
+}
+
+func (p *Player) setMember(v avmValue, name string, value avmValue) error {
+	if o, ok := v.(*avmObject); ok {
+		return o.set(name, value)
+	}
+	return nil
+}
+
+// lookup resolves a name through the scope chain, followed by the target
+// clip and the globals.
+func (p *Player) lookup(ctx *avmContext, name string) avmValue {
+	switch name {
+	case "this":
+		return ctx.this
+	case "_root", "_level0":
+		return p.Root.object
+	case "_global":
+		return p.global
+	}
+	for n := len(ctx.scope) - 1; n >= 0; n-- {
+		if v, ok := ctx.scope[n].own(name); ok {
+			return v
+		}
+	}
+	if ctx.target != nil {
+		if name == "_parent" {
+			return ctx.target.parentObject()
+		}
+		if v := ctx.target.object.get(name); v != (avmUndefined{}) {
+			return v
+		}
+	}
+	return p.global.get(name)
+}
+
+// splitPath splits a variable name of the form path:name, or the SWF 4
+// style path/name, returning the clip path and the name.
+func splitPath(name string) (string, string, bool) {
+	if n := strings.LastIndex(name, ":"); n >= 0 {
+		return name[:n], name[n+1:], true
+	}
+	return "", name, false
+}
+
+func (p *Player) getVariable(ctx *avmContext, name string) avmValue {
+	if path, v, ok := splitPath(name); ok {
+		if c := p.resolveTarget(ctx, path); c != nil {
+			return c.object.get(v)
+		}
+		return avmUndefined{}
+	}
+	if strings.Contains(name, "/") {
+		if c := p.resolveTarget(ctx, name); c != nil {
+			return c.object
+		}
+		return avmUndefined{}
+	}
+	parts := strings.Split(name, ".")
+	v := p.lookup(ctx, parts[0])
+	for _, part := range parts[1:] {
+		v = p.getMember(v, part)
+	}
+	return v
+}
+
+func (p *Player) setVariable(ctx *avmContext, name string, value avmValue) error {
+	if path, v, ok := splitPath(name); ok {
+		if c := p.resolveTarget(ctx, path); c != nil {
+			return c.object.set(v, value)
+		}
+		return nil
+	}
+	if n := strings.LastIndex(name, "."); n >= 0 {
+		return p.setMember(p.getVariable(ctx, name[:n]), name[n+1:], value)
+	}
+	for n := len(ctx.scope) - 1; n >= 0; n-- {
+		if _, ok := ctx.scope[n].own(name); ok {
+			return ctx.scope[n].set(name, value)
+		}
+	}
+	if ctx.target != nil {
+		return ctx.target.object.set(name, value)
+	}
+	return nil
+}
+
+// defineLocal creates a variable in the innermost function scope, or on the
+// target clip outside of a function.
+func (p *Player) defineLocal(ctx *avmContext, name string, value avmValue) error {
+	for n := len(ctx.scope) - 1; n >= 0; n-- {
+		if ctx.scope[n].class == "Activation" {
+			return ctx.scope[n].set(name, value)
+		}
+	}
+	if ctx.target != nil {
+		return ctx.target.object.set(name, value)
+	}
+	return nil
+}
+
+func (p *Player) literal(ctx *avmContext, v PushValue) avmValue {
+	switch v := v.(type) {
+	case PushString:
+		return string(v)
+	case PushFloat:
+		return float64(v)
+	case PushDouble:
+		return float64(v)
+	case PushInteger:
+		return float64(v)
+	case PushBoolean:
+		return v != 0
+	case PushNull:
+		return avmNull{}
+	case PushRegister:
+		return ctx.register(uint8(v))
+	case PushConstant8:
+		if int(v) < len(ctx.pool) {
+			return string(ctx.pool[v])
+		}
+	case PushConstant16:
+		if int(v) < len(ctx.pool) {
+			return string(ctx.pool[v])
+		}
+	}
+	return avmUndefined{}
+}
+
+// execute runs a list of actions against the target clip.
+func (p *Player) execute(actions []Action, target *MovieClip) error {
+	p.steps = 0
+	p.current = target
+	ctx := &avmContext{
+		code:      newCode(actions),
+		registers: newRegisters(4),
+		target:    target,
+		original:  target,
+		this:      target.object,
+	}
+	_, err := p.run(ctx, 0, len(actions))
+	if _, ok := err.(*avmThrow); ok {
+		return nil
+	}
+	return err
+}
+
+// run executes the actions in the range. It returns the index of the action
+// to continue from, which is to when the range completes normally and may be
+// outside of the range after a branch. Returning from a function sets
+// returned on the context.
+func (p *Player) run(ctx *avmContext, from, to int) (int, error) {
+	maxSteps := p.MaxSteps
+	if maxSteps == 0 {
+		maxSteps = 1 << 20
+	}
+	for i := from; i < to; i++ {
+		if p.steps++; p.steps > maxSteps {
+			return 0, ErrActionLimit
+		}
+		var next int
+		switch a := ctx.code.actions[i].(type) {
+		case BasicAction:
+			if err := p.basicAction(ctx, uint8(a)); err != nil {
+				return 0, err
+			}
+			if ctx.returned {
+				return to, nil
+			}
+			continue
+		case *ActionPush:
+			for _, v := range a.Values {
+				ctx.push(p.literal(ctx, v))
+			}
+			continue
+		case *ActionConstantPool:
+			ctx.pool = a.Constants
+			continue
+		case *ActionStoreRegister:
+			if int(a.Register) < len(ctx.registers) && len(ctx.stack) > 0 {
+				ctx.registers[a.Register] = ctx.stack[len(ctx.stack)-1]
+			}
+			continue
+		case *ActionJump:
+			next = ctx.code.branch(i, int(a.BranchOffset))
+		case *ActionIf:
+			if !p.toBoolean(ctx.pop()) {
+				continue
+			}
+			next = ctx.code.branch(i, int(a.BranchOffset))
+		case *ActionGotoFrame:
+			ctx.target.gotoFrame(int(a.Frame))
+			ctx.target.Playing = false
+			continue
+		case *ActionGoToLabel:
+			if n, ok := FrameIndex(ctx.target.frames, string(a.Label)); ok {
+				ctx.target.gotoFrame(n)
+			}
+			ctx.target.Playing = false
+			continue
+		case *ActionGotoFrame2:
+			ctx.target.gotoAndPlay(p.frameNumber(ctx.target, ctx.pop())+int(a.SceneBias), a.Play)
+			continue
+		case *ActionGetURL:
+			p.getURL(ctx.target, string(a.URL), string(a.Target), SEND_VARS_NONE, false, false)
+			continue
+		case *ActionGetURL2:
+			target, url := ctx.pop(), ctx.pop()
+			p.getURL(ctx.target, p.toString(url), p.toString(target), a.SendVarsMethod, a.LoadTarget, a.LoadVariables)
+			continue
+		case *ActionSetTarget:
+			p.setTarget(ctx, string(a.TargetName))
+			continue
+		case *ActionWaitForFrame:
+			// all frames are considered loaded
+			continue
+		case *ActionWaitForFrame2:
+			ctx.pop()
+			continue
+		case *ActionCall:
+			ctx.pop()
+			continue
+		case *ActionDefineFunction:
+			end := ctx.code.branch(i, int(a.CodeSize))
+			f := p.defineFunction(&avmFunction{
+				code:   ctx.code,
+				start:  i + 1,
+				end:    end,
+				name:   string(a.FunctionName),
+				params: a.Params,
+				scope:  ctx.scope,
+				target: ctx.target,
+				pool:   ctx.pool,
+			})
+			if a.FunctionName == "" {
+				ctx.push(f)
+			} else if err := p.setVariable(ctx, string(a.FunctionName), f); err != nil {
+				return 0, err
+			}
+			i = end - 1
+			continue
+		case *ActionDefineFunction2:
+			end := ctx.code.branch(i, int(a.CodeSize))
+			f := p.defineFunction(&avmFunction{
+				code:    ctx.code,
+				start:   i + 1,
+				end:     end,
+				name:    string(a.FunctionName),
+				define2: a,
+				scope:   ctx.scope,
+				target:  ctx.target,
+				pool:    ctx.pool,
+			})
+			if a.FunctionName == "" {
+				ctx.push(f)
+			} else if err := p.setVariable(ctx, string(a.FunctionName), f); err != nil {
+				return 0, err
+			}
+			i = end - 1
+			continue
+		case *ActionWith:
+			end := ctx.code.branch(i, int(a.Size))
+			o, ok := ctx.pop().(*avmObject)
+			if !ok {
+				i = end - 1
+				continue
+			}
+			scope := ctx.scope
+			ctx.scope = append(scope[:len(scope):len(scope)], o)
+			n, err := p.run(ctx, i+1, end)
+			ctx.scope = scope
+			if err != nil || ctx.returned {
+				return to, err
+			}
+			next = n
+		case *ActionTry:
+			tryEnd := ctx.code.branch(i, int(a.TrySize))
+			catchEnd := ctx.code.branch(i, int(a.TrySize)+int(a.CatchSize))
+			finallyEnd := ctx.code.branch(i, int(a.TrySize)+int(a.CatchSize)+int(a.FinallySize))
+			n, err := p.run(ctx, i+1, tryEnd)
+			if t, ok := err.(*avmThrow); ok && a.CatchBlock {
+				if a.CatchInRegister {
+					if int(a.CatchRegister) < len(ctx.registers) {
+						ctx.registers[a.CatchRegister] = t.value
+					}
+				} else if err = p.setVariable(ctx, string(a.CatchName), t.value); err != nil {
+					return 0, err
+				}
+				n, err = p.run(ctx, tryEnd, catchEnd)
+			}
+			if a.FinallyBlock {
+				returned, result := ctx.returned, ctx.result
+				ctx.returned = false
+				m, ferr := p.run(ctx, catchEnd, finallyEnd)
+				if ferr != nil || ctx.returned || (m != finallyEnd && m != to) {
+					n, err = m, ferr
+				} else {
+					ctx.returned, ctx.result = returned, result
+				}
+			}
+			if err != nil || ctx.returned {
+				return to, err
+			}
+			if n == tryEnd || n == catchEnd {
+				n = finallyEnd
+			}
+			next = n
+		default:
+			continue
+		}
+		if next < from || next > to {
+			return next, nil
+		}
+		i = next - 1
+	}
+	return to, nil
+}
+
+func (p *Player) basicAction(ctx *avmContext, code uint8) error {
+	number := func(f float64) {
+		ctx.push(f)
+	}
+	switch code {
+	case ACTION_NEXT_FRAME:
+		ctx.target.gotoFrame(ctx.target.frame + 1)
+		ctx.target.Playing = false
+	case ACTION_PREVIOUS_FRAME:
+		ctx.target.gotoFrame(ctx.target.frame - 1)
+		ctx.target.Playing = false
+	case ACTION_PLAY:
+		ctx.target.Playing = true
+	case ACTION_STOP:
+		ctx.target.Playing = false
+	case ACTION_ADD:
+		y, x := ctx.pop(), ctx.pop()
+		number(p.toNumber(x) + p.toNumber(y))
+	case ACTION_SUBTRACT:
+		y, x := ctx.pop(), ctx.pop()
+		number(p.toNumber(x) - p.toNumber(y))
+	case ACTION_MULTIPLY:
+		y, x := ctx.pop(), ctx.pop()
+		number(p.toNumber(x) * p.toNumber(y))
+	case ACTION_DIVIDE:
+		y, x := ctx.pop(), ctx.pop()
+		number(p.toNumber(x) / p.toNumber(y))
+	case ACTION_MODULO:
+		y, x := ctx.pop(), ctx.pop()
+		number(math.Mod(p.toNumber(x), p.toNumber(y)))
+	case ACTION_EQUALS:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.toNumber(x) == p.toNumber(y))
+	case ACTION_LESS:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.toNumber(x) < p.toNumber(y))
+	case ACTION_AND:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.toBoolean(x) && p.toBoolean(y))
+	case ACTION_OR:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.toBoolean(x) || p.toBoolean(y))
+	case ACTION_NOT:
+		ctx.push(!p.toBoolean(ctx.pop()))
+	case ACTION_STRING_EQUALS:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.toString(x) == p.toString(y))
+	case ACTION_STRING_LESS:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.toString(x) < p.toString(y))
+	case ACTION_STRING_GREATER:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.toString(x) > p.toString(y))
+	case ACTION_STRING_LENGTH, ACTION_MB_STRING_LENGTH:
+		number(float64(len([]rune(p.toString(ctx.pop())))))
+	case ACTION_STRING_EXTRACT, ACTION_MB_STRING_EXTRACT:
+		count, index, s := p.toNumber(ctx.pop()), p.toNumber(ctx.pop()), []rune(p.toString(ctx.pop()))
+		start := int(index) - 1
+		if math.IsNaN(index) || start < 0 {
+			start = 0
+		}
+		if start > len(s) {
+			start = len(s)
+		}
+		end := len(s)
+		if !math.IsNaN(count) && count >= 0 && start+int(count) < end {
+			end = start + int(count)
+		}
+		ctx.push(string(s[start:end]))
+	case ACTION_POP:
+		ctx.pop()
+	case ACTION_TO_INTEGER:
+		number(math.Trunc(p.toNumber(ctx.pop())))
+	case ACTION_GET_VARIABLE:
+		ctx.push(p.getVariable(ctx, p.toString(ctx.pop())))
+	case ACTION_SET_VARIABLE:
+		value, name := ctx.pop(), ctx.pop()
+		if err := p.setVariable(ctx, p.toString(name), value); err != nil {
+			return err
+		}
+	case ACTION_SET_TARGET2:
+		v := ctx.pop()
+		if o, ok := v.(*avmObject); ok && o.clip != nil {
+			ctx.target = o.clip
+		} else {
+			p.setTarget(ctx, p.toString(v))
+		}
+	case ACTION_STRING_ADD:
+		y, x := ctx.pop(), ctx.pop()
+		s, err := concat(p.toString(x), p.toString(y))
+		if err != nil {
+			return err
+		}
+		ctx.push(s)
+	case ACTION_GET_PROPERTY:
+		index, target := p.toNumber(ctx.pop()), ctx.pop()
+		v := avmValue(avmUndefined{})
+		if c := p.targetClip(ctx, target); c != nil && index >= 0 && int(index) < len(propertyNames) {
+			v, _ = c.property(propertyNames[int(index)])
+		}
+		ctx.push(v)
+	case ACTION_SET_PROPERTY:
+		value, index, target := ctx.pop(), p.toNumber(ctx.pop()), ctx.pop()
+		if c := p.targetClip(ctx, target); c != nil && index >= 0 && int(index) < len(propertyNames) {
+			c.setProperty(propertyNames[int(index)], value)
+		}
+	case ACTION_CLONE_SPRITE:
+		depth, name, source := p.toNumber(ctx.pop()), p.toString(ctx.pop()), ctx.pop()
+		if c := p.targetClip(ctx, source); c != nil {
+			c.duplicate(name, int(depth))
+		}
+	case ACTION_REMOVE_SPRITE:
+		if c := p.targetClip(ctx, ctx.pop()); c != nil && c.Parent != nil {
+			c.Parent.removeChild(c)
+		}
+	case ACTION_TRACE:
+		v := ctx.pop()
+		if p.Trace != nil {
+			if v == (avmUndefined{}) {
+				p.Trace("undefined")
+			} else {
+				p.Trace(p.toString(v))
+			}
+		}
+	case ACTION_START_DRAG:
+		ctx.pop()
+		ctx.pop()
+		if p.toBoolean(ctx.pop()) {
+			ctx.stack = ctx.stack[:max(0, int32(len(ctx.stack)-4))]
+		}
+	case ACTION_THROW:
+		return &avmThrow{ctx.pop()}
+	case ACTION_CAST_OP:
+		o, constructor := ctx.pop(), ctx.pop()
+		obj, ok1 := o.(*avmObject)
+		c, ok2 := constructor.(*avmObject)
+		if ok1 && ok2 && obj.instanceOf(c) {
+			ctx.push(obj)
+		} else {
+			ctx.push(avmNull{})
+		}
+	case ACTION_IMPLEMENTS_OP:
+		ctx.pop()
+		ctx.popArgs(p)
+	case ACTION_RANDOM_NUMBER:
+		n := int(p.toNumber(ctx.pop()))
+		if n <= 0 {
+			number(0)
+		} else {
+			number(float64(p.random.Intn(n)))
+		}
+	case ACTION_CHAR_TO_ASCII, ACTION_MB_CHAR_TO_ASCII:
+		s := []rune(p.toString(ctx.pop()))
+		if len(s) == 0 {
+			number(0)
+		} else {
+			number(float64(s[0]))
+		}
+	case ACTION_ASCII_TO_CHAR, ACTION_MB_ASCII_TO_CHAR:
+		ctx.push(string(rune(p.toNumber(ctx.pop()))))
+	case ACTION_GET_TIME:
+		number(p.time())
+	case ACTION_DELETE:
+		name, o := p.toString(ctx.pop()), ctx.pop()
+		obj, ok := o.(*avmObject)
+		ctx.push(ok && obj.delete(name))
+	case ACTION_DELETE2:
+		name := p.toString(ctx.pop())
+		deleted := false
+		for n := len(ctx.scope) - 1; n >= 0 && !deleted; n-- {
+			deleted = ctx.scope[n].delete(name)
+		}
+		if !deleted && ctx.target != nil {
+			deleted = ctx.target.object.delete(name)
+		}
+		ctx.push(deleted)
+	case ACTION_DEFINE_LOCAL:
+		value, name := ctx.pop(), ctx.pop()
+		if err := p.defineLocal(ctx, p.toString(name), value); err != nil {
+			return err
+		}
+	case ACTION_DEFINE_LOCAL2:
+		name := p.toString(ctx.pop())
+		if len(ctx.scope) == 0 || ctx.scope[len(ctx.scope)-1].props[name] == nil {
+			p.defineLocal(ctx, name, avmUndefined{})
+		}
+	case ACTION_CALL_FUNCTION:
+		name := p.toString(ctx.pop())
+		args := ctx.popArgs(p)
+		r, err := p.call(p.getVariable(ctx, name), avmUndefined{}, args)
+		if err != nil {
+			return err
+		}
+		ctx.push(r)
+	case ACTION_RETURN:
+		ctx.result = ctx.pop()
+		ctx.returned = true
+	case ACTION_NEW_OBJECT:
+		name := p.toString(ctx.pop())
+		args := ctx.popArgs(p)
+		o, err := p.construct(p.getVariable(ctx, name), args)
+		if err != nil {
+			return err
+		}
+		ctx.push(o)
+	case ACTION_INIT_ARRAY:
+		ctx.push(p.newArray(ctx.popArgs(p)))
+	case ACTION_INIT_OBJECT:
+		count := int(p.toNumber(ctx.pop()))
+		o := newObject(p.objectProto)
+		if count < 0 || 2*count > len(ctx.stack) {
+			count = len(ctx.stack) / 2
+		}
+		for n := 0; n < count; n++ {
+			value, name := ctx.pop(), ctx.pop()
+			o.set(p.toString(name), value)
+		}
+		ctx.push(o)
+	case ACTION_TYPE_OF:
+		ctx.push(p.typeOf(ctx.pop()))
+	case ACTION_TARGET_PATH:
+		if o, ok := ctx.pop().(*avmObject); ok && o.clip != nil {
+			ctx.push(o.clip.Target())
+		} else {
+			ctx.push(avmUndefined{})
+		}
+	case ACTION_ENUMERATE, ACTION_ENUMERATE2:
+		v := ctx.pop()
+		if code == ACTION_ENUMERATE {
+			v = p.getVariable(ctx, p.toString(v))
+		}
+		ctx.push(avmNull{})
+		if o, ok := v.(*avmObject); ok {
+			for _, name := range o.names() {
+				ctx.push(name)
+			}
+		}
+	case ACTION_ADD2:
+		y, x := ctx.pop(), ctx.pop()
+		v, err := p.add(x, y)
+		if err != nil {
+			return err
+		}
+		ctx.push(v)
+	case ACTION_LESS2:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.less(x, y))
+	case ACTION_GREATER:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.less(y, x))
+	case ACTION_EQUALS2:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(p.equals(x, y))
+	case ACTION_STRICT_EQUALS:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(strictEquals(x, y))
+	case ACTION_TO_NUMBER:
+		number(p.toNumber(ctx.pop()))
+	case ACTION_TO_STRING:
+		ctx.push(p.toString(ctx.pop()))
+	case ACTION_PUSH_DUPLICATE:
+		v := ctx.pop()
+		ctx.push(v)
+		ctx.push(v)
+	case ACTION_STACK_SWAP:
+		y, x := ctx.pop(), ctx.pop()
+		ctx.push(y)
+		ctx.push(x)
+	case ACTION_GET_MEMBER:
+		name, o := p.toString(ctx.pop()), ctx.pop()
+		ctx.push(p.getMember(o, name))
+	case ACTION_SET_MEMBER:
+		value, name, o := ctx.pop(), p.toString(ctx.pop()), ctx.pop()
+		if err := p.setMember(o, name, value); err != nil {
+			return err
+		}
+	case ACTION_INCREMENT:
+		number(p.toNumber(ctx.pop()) + 1)
+	case ACTION_DECREMENT:
+		number(p.toNumber(ctx.pop()) - 1)
+	case ACTION_CALL_METHOD, ACTION_NEW_METHOD:
+		name, o := ctx.pop(), ctx.pop()
+		args := ctx.popArgs(p)
+		f, this := o, o
+		if s := p.toString(name); name != (avmUndefined{}) && s != "" {
+			f = p.getMember(o, s)
+			if o == p.super(ctx.this) {
+				// super.method() calls the method of the superclass
+				// on the current object
+				if c, ok := o.(*avmObject); ok {
+					f = p.getMember(c.get("prototype"), s)
+				}
+				this = ctx.this
+			}
+		} else if o == p.super(ctx.this) {
+			this = ctx.this
+		} else {
+			this = avmUndefined{}
+		}
+		var (
+			r   avmValue
+			err error
+		)
+		if code == ACTION_NEW_METHOD {
+			r, err = p.construct(f, args)
+		} else {
+			r, err = p.call(f, this, args)
+		}
+		if err != nil {
+			return err
+		}
+		ctx.push(r)
+	case ACTION_INSTANCE_OF:
+		constructor, o := ctx.pop(), ctx.pop()
+		obj, ok1 := o.(*avmObject)
+		c, ok2 := constructor.(*avmObject)
+		ctx.push(ok1 && ok2 && obj.instanceOf(c))
+	case ACTION_BIT_AND:
+		y, x := p.toInt32(ctx.pop()), p.toInt32(ctx.pop())
+		number(float64(x & y))
+	case ACTION_BIT_OR:
+		y, x := p.toInt32(ctx.pop()), p.toInt32(ctx.pop())
+		number(float64(x | y))
+	case ACTION_BIT_XOR:
+		y, x := p.toInt32(ctx.pop()), p.toInt32(ctx.pop())
+		number(float64(x ^ y))
+	case ACTION_BIT_LSHIFT:
+		y, x := p.toInt32(ctx.pop()), p.toInt32(ctx.pop())
+		number(float64(x << uint(y&31)))
+	case ACTION_BIT_RSHIFT:
+		y, x := p.toInt32(ctx.pop()), p.toInt32(ctx.pop())
+		number(float64(x >> uint(y&31)))
+	case ACTION_BIT_URSHIFT:
+		y, x := p.toInt32(ctx.pop()), p.toInt32(ctx.pop())
+		number(float64(uint32(x) >> uint(y&31)))
+	case ACTION_EXTENDS:
+		super, sub := ctx.pop(), ctx.pop()
+		s, ok1 := super.(*avmObject)
+		c, ok2 := sub.(*avmObject)
+		if ok1 && ok2 {
+			proto, _ := s.get("prototype").(*avmObject)
+			o := newObject(proto)
+			o.props["__constructor__"] = s
+			c.set("prototype", o)
+		}
+	}
+	return nil
+}
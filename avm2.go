@@ -0,0 +1,782 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	OP_BKPT           uint8 = 0x01
+	OP_NOP            uint8 = 0x02
+	OP_THROW          uint8 = 0x03
+	OP_GETSUPER       uint8 = 0x04
+	OP_SETSUPER       uint8 = 0x05
+	OP_DXNS           uint8 = 0x06
+	OP_DXNSLATE       uint8 = 0x07
+	OP_KILL           uint8 = 0x08
+	OP_LABEL          uint8 = 0x09
+	OP_IFNLT          uint8 = 0x0c
+	OP_IFNLE          uint8 = 0x0d
+	OP_IFNGT          uint8 = 0x0e
+	OP_IFNGE          uint8 = 0x0f
+	OP_JUMP           uint8 = 0x10
+	OP_IFTRUE         uint8 = 0x11
+	OP_IFFALSE        uint8 = 0x12
+	OP_IFEQ           uint8 = 0x13
+	OP_IFNE           uint8 = 0x14
+	OP_IFLT           uint8 = 0x15
+	OP_IFLE           uint8 = 0x16
+	OP_IFGT           uint8 = 0x17
+	OP_IFGE           uint8 = 0x18
+	OP_IFSTRICTEQ     uint8 = 0x19
+	OP_IFSTRICTNE     uint8 = 0x1a
+	OP_LOOKUPSWITCH   uint8 = 0x1b
+	OP_PUSHWITH       uint8 = 0x1c
+	OP_POPSCOPE       uint8 = 0x1d
+	OP_NEXTNAME       uint8 = 0x1e
+	OP_HASNEXT        uint8 = 0x1f
+	OP_PUSHNULL       uint8 = 0x20
+	OP_PUSHUNDEFINED  uint8 = 0x21
+	OP_NEXTVALUE      uint8 = 0x23
+	OP_PUSHBYTE       uint8 = 0x24
+	OP_PUSHSHORT      uint8 = 0x25
+	OP_PUSHTRUE       uint8 = 0x26
+	OP_PUSHFALSE      uint8 = 0x27
+	OP_PUSHNAN        uint8 = 0x28
+	OP_POP            uint8 = 0x29
+	OP_DUP            uint8 = 0x2a
+	OP_SWAP           uint8 = 0x2b
+	OP_PUSHSTRING     uint8 = 0x2c
+	OP_PUSHINT        uint8 = 0x2d
+	OP_PUSHUINT       uint8 = 0x2e
+	OP_PUSHDOUBLE     uint8 = 0x2f
+	OP_PUSHSCOPE      uint8 = 0x30
+	OP_PUSHNAMESPACE  uint8 = 0x31
+	OP_HASNEXT2       uint8 = 0x32
+	OP_LI8            uint8 = 0x35
+	OP_LI16           uint8 = 0x36
+	OP_LI32           uint8 = 0x37
+	OP_LF32           uint8 = 0x38
+	OP_LF64           uint8 = 0x39
+	OP_SI8            uint8 = 0x3a
+	OP_SI16           uint8 = 0x3b
+	OP_SI32           uint8 = 0x3c
+	OP_SF32           uint8 = 0x3d
+	OP_SF64           uint8 = 0x3e
+	OP_NEWFUNCTION    uint8 = 0x40
+	OP_CALL           uint8 = 0x41
+	OP_CONSTRUCT      uint8 = 0x42
+	OP_CALLMETHOD     uint8 = 0x43
+	OP_CALLSTATIC     uint8 = 0x44
+	OP_CALLSUPER      uint8 = 0x45
+	OP_CALLPROPERTY   uint8 = 0x46
+	OP_RETURNVOID     uint8 = 0x47
+	OP_RETURNVALUE    uint8 = 0x48
+	OP_CONSTRUCTSUPER uint8 = 0x49
+	OP_CONSTRUCTPROP  uint8 = 0x4a
+	OP_CALLPROPLEX    uint8 = 0x4c
+	OP_CALLSUPERVOID  uint8 = 0x4e
+	OP_CALLPROPVOID   uint8 = 0x4f
+	OP_SXI1           uint8 = 0x50
+	OP_SXI8           uint8 = 0x51
+	OP_SXI16          uint8 = 0x52
+	OP_APPLYTYPE      uint8 = 0x53
+	OP_NEWOBJECT      uint8 = 0x55
+	OP_NEWARRAY       uint8 = 0x56
+	OP_NEWACTIVATION  uint8 = 0x57
+	OP_NEWCLASS       uint8 = 0x58
+	OP_GETDESCENDANTS uint8 = 0x59
+	OP_NEWCATCH       uint8 = 0x5a
+	OP_FINDPROPSTRICT uint8 = 0x5d
+	OP_FINDPROPERTY   uint8 = 0x5e
+	OP_FINDDEF        uint8 = 0x5f
+	OP_GETLEX         uint8 = 0x60
+	OP_SETPROPERTY    uint8 = 0x61
+	OP_GETLOCAL       uint8 = 0x62
+	OP_SETLOCAL       uint8 = 0x63
+	OP_GETGLOBALSCOPE uint8 = 0x64
+	OP_GETSCOPEOBJECT uint8 = 0x65
+	OP_GETPROPERTY    uint8 = 0x66
+	OP_GETOUTERSCOPE  uint8 = 0x67
+	OP_INITPROPERTY   uint8 = 0x68
+	OP_DELETEPROPERTY uint8 = 0x6a
+	OP_GETSLOT        uint8 = 0x6c
+	OP_SETSLOT        uint8 = 0x6d
+	OP_GETGLOBALSLOT  uint8 = 0x6e
+	OP_SETGLOBALSLOT  uint8 = 0x6f
+	OP_CONVERT_S      uint8 = 0x70
+	OP_ESC_XELEM      uint8 = 0x71
+	OP_ESC_XATTR      uint8 = 0x72
+	OP_CONVERT_I      uint8 = 0x73
+	OP_CONVERT_U      uint8 = 0x74
+	OP_CONVERT_D      uint8 = 0x75
+	OP_CONVERT_B      uint8 = 0x76
+	OP_CONVERT_O      uint8 = 0x77
+	OP_CHECKFILTER    uint8 = 0x78
+	OP_COERCE         uint8 = 0x80
+	OP_COERCE_B       uint8 = 0x81
+	OP_COERCE_A       uint8 = 0x82
+	OP_COERCE_I       uint8 = 0x83
+	OP_COERCE_D       uint8 = 0x84
+	OP_COERCE_S       uint8 = 0x85
+	OP_ASTYPE         uint8 = 0x86
+	OP_ASTYPELATE     uint8 = 0x87
+	OP_COERCE_U       uint8 = 0x88
+	OP_COERCE_O       uint8 = 0x89
+	OP_NEGATE         uint8 = 0x90
+	OP_INCREMENT      uint8 = 0x91
+	OP_INCLOCAL       uint8 = 0x92
+	OP_DECREMENT      uint8 = 0x93
+	OP_DECLOCAL       uint8 = 0x94
+	OP_TYPEOF         uint8 = 0x95
+	OP_NOT            uint8 = 0x96
+	OP_BITNOT         uint8 = 0x97
+	OP_ADD            uint8 = 0xa0
+	OP_SUBTRACT       uint8 = 0xa1
+	OP_MULTIPLY       uint8 = 0xa2
+	OP_DIVIDE         uint8 = 0xa3
+	OP_MODULO         uint8 = 0xa4
+	OP_LSHIFT         uint8 = 0xa5
+	OP_RSHIFT         uint8 = 0xa6
+	OP_URSHIFT        uint8 = 0xa7
+	OP_BITAND         uint8 = 0xa8
+	OP_BITOR          uint8 = 0xa9
+	OP_BITXOR         uint8 = 0xaa
+	OP_EQUALS         uint8 = 0xab
+	OP_STRICTEQUALS   uint8 = 0xac
+	OP_LESSTHAN       uint8 = 0xad
+	OP_LESSEQUALS     uint8 = 0xae
+	OP_GREATERTHAN    uint8 = 0xaf
+	OP_GREATEREQUALS  uint8 = 0xb0
+	OP_INSTANCEOF     uint8 = 0xb1
+	OP_ISTYPE         uint8 = 0xb2
+	OP_ISTYPELATE     uint8 = 0xb3
+	OP_IN             uint8 = 0xb4
+	OP_INCREMENT_I    uint8 = 0xc0
+	OP_DECREMENT_I    uint8 = 0xc1
+	OP_INCLOCAL_I     uint8 = 0xc2
+	OP_DECLOCAL_I     uint8 = 0xc3
+	OP_NEGATE_I       uint8 = 0xc4
+	OP_ADD_I          uint8 = 0xc5
+	OP_SUBTRACT_I     uint8 = 0xc6
+	OP_MULTIPLY_I     uint8 = 0xc7
+	OP_GETLOCAL0      uint8 = 0xd0
+	OP_GETLOCAL1      uint8 = 0xd1
+	OP_GETLOCAL2      uint8 = 0xd2
+	OP_GETLOCAL3      uint8 = 0xd3
+	OP_SETLOCAL0      uint8 = 0xd4
+	OP_SETLOCAL1      uint8 = 0xd5
+	OP_SETLOCAL2      uint8 = 0xd6
+	OP_SETLOCAL3      uint8 = 0xd7
+	OP_DEBUG          uint8 = 0xef
+	OP_DEBUGLINE      uint8 = 0xf0
+	OP_DEBUGFILE      uint8 = 0xf1
+	OP_BKPTLINE       uint8 = 0xf2
+	OP_TIMESTAMP      uint8 = 0xf3
+)
+
+const (
+	operandU8 uint8 = iota
+	operandS8
+	operandU30
+	operandS24
+	operandShort
+	operandString
+	operandInt
+	operandUint
+	operandDouble
+	operandNamespace
+	operandMultiname
+	operandMethod
+	operandClass
+	operandException
+)
+
+var instructionInfo = [256]struct {
+	name     string
+	operands []uint8
+}{
+	OP_BKPT:           {"bkpt", nil},
+	OP_NOP:            {"nop", nil},
+	OP_THROW:          {"throw", nil},
+	OP_GETSUPER:       {"getsuper", []uint8{operandMultiname}},
+	OP_SETSUPER:       {"setsuper", []uint8{operandMultiname}},
+	OP_DXNS:           {"dxns", []uint8{operandString}},
+	OP_DXNSLATE:       {"dxnslate", nil},
+	OP_KILL:           {"kill", []uint8{operandU30}},
+	OP_LABEL:          {"label", nil},
+	OP_IFNLT:          {"ifnlt", []uint8{operandS24}},
+	OP_IFNLE:          {"ifnle", []uint8{operandS24}},
+	OP_IFNGT:          {"ifngt", []uint8{operandS24}},
+	OP_IFNGE:          {"ifnge", []uint8{operandS24}},
+	OP_JUMP:           {"jump", []uint8{operandS24}},
+	OP_IFTRUE:         {"iftrue", []uint8{operandS24}},
+	OP_IFFALSE:        {"iffalse", []uint8{operandS24}},
+	OP_IFEQ:           {"ifeq", []uint8{operandS24}},
+	OP_IFNE:           {"ifne", []uint8{operandS24}},
+	OP_IFLT:           {"iflt", []uint8{operandS24}},
+	OP_IFLE:           {"ifle", []uint8{operandS24}},
+	OP_IFGT:           {"ifgt", []uint8{operandS24}},
+	OP_IFGE:           {"ifge", []uint8{operandS24}},
+	OP_IFSTRICTEQ:     {"ifstricteq", []uint8{operandS24}},
+	OP_IFSTRICTNE:     {"ifstrictne", []uint8{operandS24}},
+	OP_LOOKUPSWITCH:   {"lookupswitch", nil},
+	OP_PUSHWITH:       {"pushwith", nil},
+	OP_POPSCOPE:       {"popscope", nil},
+	OP_NEXTNAME:       {"nextname", nil},
+	OP_HASNEXT:        {"hasnext", nil},
+	OP_PUSHNULL:       {"pushnull", nil},
+	OP_PUSHUNDEFINED:  {"pushundefined", nil},
+	OP_NEXTVALUE:      {"nextvalue", nil},
+	OP_PUSHBYTE:       {"pushbyte", []uint8{operandS8}},
+	OP_PUSHSHORT:      {"pushshort", []uint8{operandShort}},
+	OP_PUSHTRUE:       {"pushtrue", nil},
+	OP_PUSHFALSE:      {"pushfalse", nil},
+	OP_PUSHNAN:        {"pushnan", nil},
+	OP_POP:            {"pop", nil},
+	OP_DUP:            {"dup", nil},
+	OP_SWAP:           {"swap", nil},
+	OP_PUSHSTRING:     {"pushstring", []uint8{operandString}},
+	OP_PUSHINT:        {"pushint", []uint8{operandInt}},
+	OP_PUSHUINT:       {"pushuint", []uint8{operandUint}},
+	OP_PUSHDOUBLE:     {"pushdouble", []uint8{operandDouble}},
+	OP_PUSHSCOPE:      {"pushscope", nil},
+	OP_PUSHNAMESPACE:  {"pushnamespace", []uint8{operandNamespace}},
+	OP_HASNEXT2:       {"hasnext2", []uint8{operandU30, operandU30}},
+	OP_LI8:            {"li8", nil},
+	OP_LI16:           {"li16", nil},
+	OP_LI32:           {"li32", nil},
+	OP_LF32:           {"lf32", nil},
+	OP_LF64:           {"lf64", nil},
+	OP_SI8:            {"si8", nil},
+	OP_SI16:           {"si16", nil},
+	OP_SI32:           {"si32", nil},
+	OP_SF32:           {"sf32", nil},
+	OP_SF64:           {"sf64", nil},
+	OP_NEWFUNCTION:    {"newfunction", []uint8{operandMethod}},
+	OP_CALL:           {"call", []uint8{operandU30}},
+	OP_CONSTRUCT:      {"construct", []uint8{operandU30}},
+	OP_CALLMETHOD:     {"callmethod", []uint8{operandU30, operandU30}},
+	OP_CALLSTATIC:     {"callstatic", []uint8{operandMethod, operandU30}},
+	OP_CALLSUPER:      {"callsuper", []uint8{operandMultiname, operandU30}},
+	OP_CALLPROPERTY:   {"callproperty", []uint8{operandMultiname, operandU30}},
+	OP_RETURNVOID:     {"returnvoid", nil},
+	OP_RETURNVALUE:    {"returnvalue", nil},
+	OP_CONSTRUCTSUPER: {"constructsuper", []uint8{operandU30}},
+	OP_CONSTRUCTPROP:  {"constructprop", []uint8{operandMultiname, operandU30}},
+	OP_CALLPROPLEX:    {"callproplex", []uint8{operandMultiname, operandU30}},
+	OP_CALLSUPERVOID:  {"callsupervoid", []uint8{operandMultiname, operandU30}},
+	OP_CALLPROPVOID:   {"callpropvoid", []uint8{operandMultiname, operandU30}},
+	OP_SXI1:           {"sxi1", nil},
+	OP_SXI8:           {"sxi8", nil},
+	OP_SXI16:          {"sxi16", nil},
+	OP_APPLYTYPE:      {"applytype", []uint8{operandU30}},
+	OP_NEWOBJECT:      {"newobject", []uint8{operandU30}},
+	OP_NEWARRAY:       {"newarray", []uint8{operandU30}},
+	OP_NEWACTIVATION:  {"newactivation", nil},
+	OP_NEWCLASS:       {"newclass", []uint8{operandClass}},
+	OP_GETDESCENDANTS: {"getdescendants", []uint8{operandMultiname}},
+	OP_NEWCATCH:       {"newcatch", []uint8{operandException}},
+	OP_FINDPROPSTRICT: {"findpropstrict", []uint8{operandMultiname}},
+	OP_FINDPROPERTY:   {"findproperty", []uint8{operandMultiname}},
+	OP_FINDDEF:        {"finddef", []uint8{operandMultiname}},
+	OP_GETLEX:         {"getlex", []uint8{operandMultiname}},
+	OP_SETPROPERTY:    {"setproperty", []uint8{operandMultiname}},
+	OP_GETLOCAL:       {"getlocal", []uint8{operandU30}},
+	OP_SETLOCAL:       {"setlocal", []uint8{operandU30}},
+	OP_GETGLOBALSCOPE: {"getglobalscope", nil},
+	OP_GETSCOPEOBJECT: {"getscopeobject", []uint8{operandU8}},
+	OP_GETPROPERTY:    {"getproperty", []uint8{operandMultiname}},
+	OP_GETOUTERSCOPE:  {"getouterscope", []uint8{operandU30}},
+	OP_INITPROPERTY:   {"initproperty", []uint8{operandMultiname}},
+	OP_DELETEPROPERTY: {"deleteproperty", []uint8{operandMultiname}},
+	OP_GETSLOT:        {"getslot", []uint8{operandU30}},
+	OP_SETSLOT:        {"setslot", []uint8{operandU30}},
+	OP_GETGLOBALSLOT:  {"getglobalslot", []uint8{operandU30}},
+	OP_SETGLOBALSLOT:  {"setglobalslot", []uint8{operandU30}},
+	OP_CONVERT_S:      {"convert_s", nil},
+	OP_ESC_XELEM:      {"esc_xelem", nil},
+	OP_ESC_XATTR:      {"esc_xattr", nil},
+	OP_CONVERT_I:      {"convert_i", nil},
+	OP_CONVERT_U:      {"convert_u", nil},
+	OP_CONVERT_D:      {"convert_d", nil},
+	OP_CONVERT_B:      {"convert_b", nil},
+	OP_CONVERT_O:      {"convert_o", nil},
+	OP_CHECKFILTER:    {"checkfilter", nil},
+	OP_COERCE:         {"coerce", []uint8{operandMultiname}},
+	OP_COERCE_B:       {"coerce_b", nil},
+	OP_COERCE_A:       {"coerce_a", nil},
+	OP_COERCE_I:       {"coerce_i", nil},
+	OP_COERCE_D:       {"coerce_d", nil},
+	OP_COERCE_S:       {"coerce_s", nil},
+	OP_ASTYPE:         {"astype", []uint8{operandMultiname}},
+	OP_ASTYPELATE:     {"astypelate", nil},
+	OP_COERCE_U:       {"coerce_u", nil},
+	OP_COERCE_O:       {"coerce_o", nil},
+	OP_NEGATE:         {"negate", nil},
+	OP_INCREMENT:      {"increment", nil},
+	OP_INCLOCAL:       {"inclocal", []uint8{operandU30}},
+	OP_DECREMENT:      {"decrement", nil},
+	OP_DECLOCAL:       {"declocal", []uint8{operandU30}},
+	OP_TYPEOF:         {"typeof", nil},
+	OP_NOT:            {"not", nil},
+	OP_BITNOT:         {"bitnot", nil},
+	OP_ADD:            {"add", nil},
+	OP_SUBTRACT:       {"subtract", nil},
+	OP_MULTIPLY:       {"multiply", nil},
+	OP_DIVIDE:         {"divide", nil},
+	OP_MODULO:         {"modulo", nil},
+	OP_LSHIFT:         {"lshift", nil},
+	OP_RSHIFT:         {"rshift", nil},
+	OP_URSHIFT:        {"urshift", nil},
+	OP_BITAND:         {"bitand", nil},
+	OP_BITOR:          {"bitor", nil},
+	OP_BITXOR:         {"bitxor", nil},
+	OP_EQUALS:         {"equals", nil},
+	OP_STRICTEQUALS:   {"strictequals", nil},
+	OP_LESSTHAN:       {"lessthan", nil},
+	OP_LESSEQUALS:     {"lessequals", nil},
+	OP_GREATERTHAN:    {"greaterthan", nil},
+	OP_GREATEREQUALS:  {"greaterequals", nil},
+	OP_INSTANCEOF:     {"instanceof", nil},
+	OP_ISTYPE:         {"istype", []uint8{operandMultiname}},
+	OP_ISTYPELATE:     {"istypelate", nil},
+	OP_IN:             {"in", nil},
+	OP_INCREMENT_I:    {"increment_i", nil},
+	OP_DECREMENT_I:    {"decrement_i", nil},
+	OP_INCLOCAL_I:     {"inclocal_i", []uint8{operandU30}},
+	OP_DECLOCAL_I:     {"declocal_i", []uint8{operandU30}},
+	OP_NEGATE_I:       {"negate_i", nil},
+	OP_ADD_I:          {"add_i", nil},
+	OP_SUBTRACT_I:     {"subtract_i", nil},
+	OP_MULTIPLY_I:     {"multiply_i", nil},
+	OP_GETLOCAL0:      {"getlocal0", nil},
+	OP_GETLOCAL1:      {"getlocal1", nil},
+	OP_GETLOCAL2:      {"getlocal2", nil},
+	OP_GETLOCAL3:      {"getlocal3", nil},
+	OP_SETLOCAL0:      {"setlocal0", nil},
+	OP_SETLOCAL1:      {"setlocal1", nil},
+	OP_SETLOCAL2:      {"setlocal2", nil},
+	OP_SETLOCAL3:      {"setlocal3", nil},
+	OP_DEBUG:          {"debug", []uint8{operandU8, operandString, operandU8, operandU30}},
+	OP_DEBUGLINE:      {"debugline", []uint8{operandU30}},
+	OP_DEBUGFILE:      {"debugfile", []uint8{operandString}},
+	OP_BKPTLINE:       {"bkptline", []uint8{operandU30}},
+	OP_TIMESTAMP:      {"timestamp", nil},
+}
+
+// InstructionName returns the mnemonic of the opcode, or an empty string if
+// the opcode is not recognised.
+func InstructionName(opcode uint8) string {
+	return instructionInfo[opcode].name
+}
+
+type InstructionError struct {
+	Offset int
+	Opcode uint8
+}
+
+func (e InstructionError) Error() string {
+	return fmt.Sprintf("avm2: unknown opcode 0x%02x at offset %d", e.Opcode, e.Offset)
+}
+
+// Instruction is a single AVM2 instruction. Operands holds the value of each
+// operand, with constant pool, method and class operands as indices, and
+// branch operands resolved to the offset of their target. The operands of
+// lookupswitch are the default target followed by the case targets.
+type Instruction struct {
+	Offset   int
+	Opcode   uint8
+	Operands []int
+}
+
+// Targets returns the offsets of the branch targets of the instruction.
+func (i *Instruction) Targets() []int {
+	if i.Opcode == OP_LOOKUPSWITCH {
+		return i.Operands
+	}
+	var targets []int
+	for n, k := range instructionInfo[i.Opcode].operands {
+		if k == operandS24 && n < len(i.Operands) {
+			targets = append(targets, i.Operands[n])
+		}
+	}
+	return targets
+}
+
+func (r *abcReader) s24() int {
+	var b [3]byte
+	if r.err == nil {
+		_, r.err = io.ReadFull(r, b[:])
+	}
+	return int(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8)
+}
+
+// DecodeInstructions decodes the code of a method body.
+func DecodeInstructions(code []byte) ([]Instruction, error) {
+	var instructions []Instruction
+	br := bytes.NewReader(code)
+	r := &abcReader{Reader: br}
+	for br.Len() > 0 {
+		offset := len(code) - br.Len()
+		i := Instruction{
+			Offset: offset,
+			Opcode: r.u8(),
+		}
+		info := instructionInfo[i.Opcode]
+		if info.name == "" {
+			return instructions, InstructionError{offset, i.Opcode}
+		}
+		if i.Opcode == OP_LOOKUPSWITCH {
+			i.Operands = append(i.Operands, offset+r.s24())
+			for n := int(r.u30()); n >= 0 && r.err == nil; n-- {
+				i.Operands = append(i.Operands, offset+r.s24())
+			}
+		} else {
+			for _, k := range info.operands {
+				var v int
+				switch k {
+				case operandU8:
+					v = int(r.u8())
+				case operandS8:
+					v = int(int8(r.u8()))
+				case operandS24:
+					v = r.s24()
+				case operandShort:
+					v = int(int16(r.u30()))
+				default:
+					v = int(r.u30())
+				}
+				i.Operands = append(i.Operands, v)
+			}
+			next := len(code) - br.Len()
+			for n, k := range info.operands {
+				if k == operandS24 {
+					i.Operands[n] += next
+				}
+			}
+		}
+		if r.err != nil {
+			return instructions, io.ErrUnexpectedEOF
+		}
+		instructions = append(instructions, i)
+	}
+	return instructions, nil
+}
+
+func (a *ABCFile) stringConstant(n uint32) (string, bool) {
+	if int(n) < len(a.ConstantPool.Strings) {
+		return a.ConstantPool.Strings[n], true
+	}
+	return "", false
+}
+
+// NamespaceName returns the name of the namespace, using the kind of the
+// namespace for private, protected and internal namespaces.
+func (a *ABCFile) NamespaceName(n uint32) string {
+	if n == 0 || int(n) >= len(a.ConstantPool.Namespaces) {
+		return "*"
+	}
+	ns := a.ConstantPool.Namespaces[n]
+	switch ns.Kind {
+	case CONSTANT_PRIVATE_NS:
+		return "private"
+	case CONSTANT_PROTECTED_NAMESPACE, CONSTANT_STATIC_PROTECTED_NS:
+		return "protected"
+	case CONSTANT_PACKAGE_INTERNAL_NS:
+		return "internal"
+	}
+	name, _ := a.stringConstant(ns.Name)
+	return name
+}
+
+// MultinameName returns a readable name for the multiname, qualified by its
+// namespace where that is known and not the public namespace.
+func (a *ABCFile) MultinameName(n uint32) string {
+	return a.multinameName(n, 0)
+}
+
+func (a *ABCFile) multinameName(n uint32, depth int) string {
+	if n == 0 || int(n) >= len(a.ConstantPool.Multinames) || depth > 8 {
+		return "*"
+	}
+	m := a.ConstantPool.Multinames[n]
+	name, _ := a.stringConstant(m.Name)
+	switch m.Kind {
+	case CONSTANT_QNAME_A, CONSTANT_RTQNAME_A, CONSTANT_MULTINAME_A:
+		name = "@" + name
+	case CONSTANT_RTQNAME_L, CONSTANT_RTQNAME_LA, CONSTANT_MULTINAME_L, CONSTANT_MULTINAME_LA:
+		return "[]"
+	case CONSTANT_TYPENAME:
+		params := make([]string, len(m.Params))
+		for i, p := range m.Params {
+			params[i] = a.multinameName(p, depth+1)
+		}
+		return a.multinameName(m.Name, depth+1) + ".<" + strings.Join(params, ", ") + ">"
+	}
+	if m.Kind == CONSTANT_QNAME || m.Kind == CONSTANT_QNAME_A {
+		if ns := a.NamespaceName(m.Namespace); ns != "" {
+			return ns + "::" + name
+		}
+	}
+	return name
+}
+
+// valueString returns the value of a default parameter or slot value.
+func (a *ABCFile) valueString(kind uint8, n uint32) string {
+	switch kind {
+	case CONSTANT_INT:
+		if int(n) < len(a.ConstantPool.Ints) {
+			return strconv.Itoa(int(a.ConstantPool.Ints[n]))
+		}
+	case CONSTANT_UINT:
+		if int(n) < len(a.ConstantPool.Uints) {
+			return strconv.FormatUint(uint64(a.ConstantPool.Uints[n]), 10)
+		}
+	case CONSTANT_DOUBLE:
+		if int(n) < len(a.ConstantPool.Doubles) {
+			return formatNumber(a.ConstantPool.Doubles[n])
+		}
+	case CONSTANT_UTF8:
+		if s, ok := a.stringConstant(n); ok {
+			return strconv.Quote(s)
+		}
+	case CONSTANT_TRUE:
+		return "true"
+	case CONSTANT_FALSE:
+		return "false"
+	case CONSTANT_NULL:
+		return "null"
+	case CONSTANT_UNDEFINED:
+		return "undefined"
+	case CONSTANT_NAMESPACE, CONSTANT_PACKAGE_NAMESPACE, CONSTANT_PACKAGE_INTERNAL_NS, CONSTANT_PROTECTED_NAMESPACE, CONSTANT_EXPLICIT_NAMESPACE, CONSTANT_STATIC_PROTECTED_NS, CONSTANT_PRIVATE_NS:
+		return "namespace " + a.NamespaceName(n)
+	}
+	return "#" + strconv.Itoa(int(n))
+}
+
+func (a *ABCFile) operandString(kind uint8, v int) string {
+	bad := "#" + strconv.Itoa(v)
+	switch kind {
+	case operandString:
+		if s, ok := a.stringConstant(uint32(v)); ok {
+			return strconv.Quote(s)
+		}
+		return bad
+	case operandInt:
+		return a.valueString(CONSTANT_INT, uint32(v))
+	case operandUint:
+		return a.valueString(CONSTANT_UINT, uint32(v))
+	case operandDouble:
+		return a.valueString(CONSTANT_DOUBLE, uint32(v))
+	case operandNamespace:
+		return a.NamespaceName(uint32(v))
+	case operandMultiname:
+		return a.MultinameName(uint32(v))
+	case operandMethod:
+		return "method " + strconv.Itoa(v)
+	case operandClass:
+		if v < len(a.Instances) {
+			return "class " + a.MultinameName(a.Instances[v].Name)
+		}
+		return bad
+	}
+	return strconv.Itoa(v)
+}
+
+// InstructionString returns the instruction with its operands resolved
+// against the constant pool.
+func (a *ABCFile) InstructionString(i *Instruction) string {
+	s := InstructionName(i.Opcode)
+	if i.Opcode == OP_LOOKUPSWITCH {
+		if len(i.Operands) > 0 {
+			cases := make([]string, len(i.Operands)-1)
+			for n, t := range i.Operands[1:] {
+				cases[n] = strconv.Itoa(t)
+			}
+			s += " " + strconv.Itoa(i.Operands[0]) + " [" + strings.Join(cases, ", ") + "]"
+		}
+		return s
+	}
+	for n, k := range instructionInfo[i.Opcode].operands {
+		if n < len(i.Operands) {
+			s += " " + a.operandString(k, i.Operands[n])
+		}
+	}
+	return s
+}
+
+// MethodSignature returns the name, parameters and return type of a method.
+// An empty name is replaced by the name in the method info.
+func (a *ABCFile) MethodSignature(m uint32, name string) string {
+	if int(m) >= len(a.Methods) {
+		return name + "(?)"
+	}
+	info := a.Methods[m]
+	if name == "" {
+		name, _ = a.stringConstant(info.Name)
+	}
+	params := make([]string, len(info.ParamTypes))
+	for n, t := range info.ParamTypes {
+		params[n] = a.MultinameName(t)
+		if n < len(info.ParamNames) {
+			pname, _ := a.stringConstant(info.ParamNames[n])
+			params[n] = pname + ":" + params[n]
+		}
+		if o := n - (len(info.ParamTypes) - len(info.Options)); o >= 0 {
+			params[n] += " = " + a.valueString(info.Options[o].Kind, info.Options[o].Value)
+		}
+	}
+	if info.Flags&METHOD_NEED_REST != 0 {
+		params = append(params, "...rest")
+	}
+	return name + "(" + strings.Join(params, ", ") + "):" + a.MultinameName(info.ReturnType)
+}
+
+type abcDisassembler struct {
+	*ABCFile
+	w       *bufio.Writer
+	bodies  map[uint32]*MethodBody
+	printed map[uint32]bool
+}
+
+// method writes the signature of the method, followed by its body the first
+// time it is written.
+func (d *abcDisassembler) method(indent, head string, m uint32, name string) {
+	fmt.Fprintf(d.w, "%s%s%s ; method %d\n", indent, head, d.MethodSignature(m, name), m)
+	body, ok := d.bodies[m]
+	if !ok || d.printed[m] {
+		return
+	}
+	d.printed[m] = true
+	indent += "\t"
+	fmt.Fprintf(d.w, "%smaxstack %d localcount %d scopedepth %d-%d\n", indent, body.MaxStack, body.LocalCount, body.InitScopeDepth, body.MaxScopeDepth)
+	d.traits(indent, "", body.Traits)
+	instructions, err := DecodeInstructions(body.Code)
+	for n := range instructions {
+		fmt.Fprintf(d.w, "%s%5d  %s\n", indent, instructions[n].Offset, d.InstructionString(&instructions[n]))
+	}
+	if err != nil {
+		fmt.Fprintf(d.w, "%s; %s\n", indent, err)
+	}
+	for _, e := range body.Exceptions {
+		varName := ""
+		if e.VarName != 0 {
+			varName = " var " + d.MultinameName(e.VarName)
+		}
+		fmt.Fprintf(d.w, "%sexception %d-%d -> %d type %s%s\n", indent, e.From, e.To, e.Target, d.MultinameName(e.ExcType), varName)
+	}
+}
+
+func (d *abcDisassembler) traits(indent, prefix string, traits []Trait) {
+	for _, t := range traits {
+		name := d.MultinameName(t.Name)
+		head := prefix
+		if t.Attributes&ATTR_FINAL != 0 {
+			head += "final "
+		}
+		if t.Attributes&ATTR_OVERRIDE != 0 {
+			head += "override "
+		}
+		switch t.Kind {
+		case TRAIT_SLOT, TRAIT_CONST:
+			if t.Kind == TRAIT_SLOT {
+				head += "var "
+			} else {
+				head += "const "
+			}
+			value := ""
+			if t.VIndex != 0 {
+				value = " = " + d.valueString(t.VKind, t.VIndex)
+			}
+			fmt.Fprintf(d.w, "%s%s%s:%s%s ; slot %d\n", indent, head, name, d.MultinameName(t.TypeName), value, t.SlotId)
+		case TRAIT_CLASS:
+			fmt.Fprintf(d.w, "%s%sclass %s ; slot %d\n", indent, head, name, t.SlotId)
+		case TRAIT_METHOD:
+			d.method(indent, head+"method ", t.Index, name)
+		case TRAIT_GETTER:
+			d.method(indent, head+"get ", t.Index, name)
+		case TRAIT_SETTER:
+			d.method(indent, head+"set ", t.Index, name)
+		case TRAIT_FUNCTION:
+			d.method(indent, head+"function ", t.Index, name)
+		}
+	}
+}
+
+// Disassemble writes a listing of the file, with the methods of each class
+// and script followed by any other methods, such as closures.
+func (a *ABCFile) Disassemble(w io.Writer) error {
+	d := &abcDisassembler{
+		ABCFile: a,
+		w:       bufio.NewWriter(w),
+		bodies:  make(map[uint32]*MethodBody),
+		printed: make(map[uint32]bool),
+	}
+	for n := range a.MethodBodies {
+		d.bodies[a.MethodBodies[n].Method] = &a.MethodBodies[n]
+	}
+	for n, i := range a.Instances {
+		kind := "class"
+		if i.Flags&CLASS_INTERFACE != 0 {
+			kind = "interface"
+		}
+		fmt.Fprintf(d.w, "%s %s", kind, a.MultinameName(i.Name))
+		if i.SuperName != 0 {
+			fmt.Fprintf(d.w, " extends %s", a.MultinameName(i.SuperName))
+		}
+		if len(i.Interfaces) > 0 {
+			interfaces := make([]string, len(i.Interfaces))
+			for m, in := range i.Interfaces {
+				interfaces[m] = a.MultinameName(in)
+			}
+			fmt.Fprintf(d.w, " implements %s", strings.Join(interfaces, ", "))
+		}
+		d.w.WriteString("\n")
+		if n < len(a.Classes) {
+			d.method("\t", "static initializer ", a.Classes[n].CInit, "")
+			d.traits("\t", "static ", a.Classes[n].Traits)
+		}
+		d.method("\t", "constructor ", i.IInit, "")
+		d.traits("\t", "", i.Traits)
+	}
+	for n, s := range a.Scripts {
+		fmt.Fprintf(d.w, "script %d\n", n)
+		d.method("\t", "initializer ", s.Init, "")
+		d.traits("\t", "", s.Traits)
+	}
+	for n := range a.MethodBodies {
+		if m := a.MethodBodies[n].Method; !d.printed[m] {
+			d.method("", "function ", m, "")
+		}
+	}
+	return d.w.Flush()
+}
@@ -0,0 +1,159 @@
+package swf
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeInstructions(t *testing.T) {
+	code := []byte{
+		0xd0,
+		0x30,
+		0x24, 0xff,
+		0x25, 0x80, 0x80, 0x02,
+		0x10, 0x02, 0x00, 0x00,
+		0x02,
+		0x02,
+		0x11, 0xf6, 0xff, 0xff,
+		0x1b, 0xfb, 0xff, 0xff, 0x01, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00,
+		0x4f, 0x02, 0x01,
+		0x47,
+	}
+	expected := []Instruction{
+		{0, OP_GETLOCAL0, nil},
+		{1, OP_PUSHSCOPE, nil},
+		{2, OP_PUSHBYTE, []int{-1}},
+		{4, OP_PUSHSHORT, []int{-32768}},
+		{8, OP_JUMP, []int{14}},
+		{12, OP_NOP, nil},
+		{13, OP_NOP, nil},
+		{14, OP_IFTRUE, []int{8}},
+		{18, OP_LOOKUPSWITCH, []int{13, 18, 23}},
+		{29, OP_CALLPROPVOID, []int{2, 1}},
+		{32, OP_RETURNVOID, nil},
+	}
+	instructions, err := DecodeInstructions(code)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(instructions, expected) {
+		t.Errorf("expecting %v, got %v", expected, instructions)
+	}
+	if targets := instructions[8].Targets(); !reflect.DeepEqual(targets, []int{13, 18, 23}) {
+		t.Errorf("expecting lookupswitch targets [13 18 23], got %v", targets)
+	}
+	if targets := instructions[7].Targets(); !reflect.DeepEqual(targets, []int{8}) {
+		t.Errorf("expecting iftrue target [8], got %v", targets)
+	}
+	if _, err := DecodeInstructions([]byte{0x02, 0xff}); err != (InstructionError{1, 0xff}) {
+		t.Errorf("expecting unknown opcode error, got %v", err)
+	}
+	if _, err := DecodeInstructions([]byte{0x10, 0x01}); err != io.ErrUnexpectedEOF {
+		t.Errorf("expecting error %s, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func testClassABC() *ABCFile {
+	return &ABCFile{
+		MinorVersion: 16,
+		MajorVersion: 46,
+		ConstantPool: ConstantPool{
+			Ints:       []int32{0, 1},
+			Strings:    []string{"", "Main", "flash.display", "Sprite", "hello", "trace", "count", "int", "void", "n", "Error"},
+			Namespaces: []Namespace{{}, {CONSTANT_PACKAGE_NAMESPACE, 0}, {CONSTANT_PACKAGE_NAMESPACE, 2}, {CONSTANT_PRIVATE_NS, 0}},
+			Multinames: []Multiname{
+				{},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 1},
+				{Kind: CONSTANT_QNAME, Namespace: 2, Name: 3},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 5},
+				{Kind: CONSTANT_QNAME, Namespace: 3, Name: 6},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 7},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 8},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 4},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 10},
+			},
+		},
+		Methods: []MethodInfo{
+			{},
+			{},
+			{},
+			{
+				ParamTypes: []uint32{5},
+				ReturnType: 6,
+				Flags:      METHOD_HAS_OPTIONAL | METHOD_HAS_PARAM_NAMES,
+				Options:    []OptionDetail{{1, CONSTANT_INT}},
+				ParamNames: []uint32{9},
+			},
+			{},
+		},
+		Instances: []InstanceInfo{
+			{
+				Name:      1,
+				SuperName: 2,
+				Flags:     CLASS_SEALED,
+				IInit:     2,
+				Traits: []Trait{
+					{Name: 4, Kind: TRAIT_SLOT, SlotId: 1, TypeName: 5, VIndex: 1, VKind: CONSTANT_INT},
+					{Name: 7, Kind: TRAIT_METHOD, SlotId: 1, Index: 3},
+				},
+			},
+		},
+		Classes: []ClassInfo{{CInit: 1}},
+		Scripts: []ScriptInfo{{0, []Trait{{Name: 1, Kind: TRAIT_CLASS, SlotId: 1}}}},
+		MethodBodies: []MethodBody{
+			{Method: 0, MaxStack: 1, LocalCount: 1, MaxScopeDepth: 1, Code: []byte{0xd0, 0x30, 0x47}},
+			{Method: 2, MaxStack: 1, LocalCount: 1, MaxScopeDepth: 1, Code: []byte{0xd0, 0x30, 0xd0, 0x49, 0x00, 0x47}},
+			{
+				Method:        3,
+				MaxStack:      2,
+				LocalCount:    2,
+				MaxScopeDepth: 1,
+				Code:          []byte{0xd0, 0x30, 0x5d, 0x03, 0x2c, 0x04, 0x4f, 0x03, 0x01, 0x47, 0xff},
+				Exceptions:    []ExceptionInfo{{2, 9, 9, 8, 0}},
+			},
+			{Method: 4, MaxStack: 0, LocalCount: 1, Code: []byte{0x47}},
+		},
+	}
+}
+
+func TestDisassembleABC(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testClassABC().Disassemble(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "class Main extends flash.display::Sprite\n" +
+		"\tstatic initializer ():* ; method 1\n" +
+		"\tconstructor ():* ; method 2\n" +
+		"\t\tmaxstack 1 localcount 1 scopedepth 0-1\n" +
+		"\t\t    0  getlocal0\n" +
+		"\t\t    1  pushscope\n" +
+		"\t\t    2  getlocal0\n" +
+		"\t\t    3  constructsuper 0\n" +
+		"\t\t    5  returnvoid\n" +
+		"\tvar private::count:int = 1 ; slot 1\n" +
+		"\tmethod hello(n:int = 1):void ; method 3\n" +
+		"\t\tmaxstack 2 localcount 2 scopedepth 0-1\n" +
+		"\t\t    0  getlocal0\n" +
+		"\t\t    1  pushscope\n" +
+		"\t\t    2  findpropstrict trace\n" +
+		"\t\t    4  pushstring \"hello\"\n" +
+		"\t\t    6  callpropvoid trace 1\n" +
+		"\t\t    9  returnvoid\n" +
+		"\t\t; avm2: unknown opcode 0xff at offset 10\n" +
+		"\t\texception 2-9 -> 9 type Error\n" +
+		"script 0\n" +
+		"\tinitializer ():* ; method 0\n" +
+		"\t\tmaxstack 1 localcount 1 scopedepth 0-1\n" +
+		"\t\t    0  getlocal0\n" +
+		"\t\t    1  pushscope\n" +
+		"\t\t    2  returnvoid\n" +
+		"\tclass Main ; slot 1\n" +
+		"function ():* ; method 4\n" +
+		"\tmaxstack 0 localcount 1 scopedepth 0-0\n" +
+		"\t    0  returnvoid\n"
+	if listing := buf.String(); listing != expected {
+		t.Errorf("expecting listing:\n%s\ngot:\n%s", expected, listing)
+	}
+}
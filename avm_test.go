@@ -0,0 +1,214 @@
+package swf
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func scriptPlayer(t *testing.T, listing string) *Player {
+	actions, err := Assemble(strings.NewReader(listing))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return NewPlayer(&SWF{
+		Version:    8,
+		FrameRate:  10 << 8,
+		FrameCount: 1,
+		Tags: []Tag{
+			&DoAction{Actions: actions},
+			&ShowFrame{},
+		},
+	})
+}
+
+func TestInterpreter(t *testing.T) {
+	tests := []struct {
+		listing string
+		traces  []string
+	}{
+		{
+			"\tPush 1 2 3\n" +
+				"\tMultiply\n" +
+				"\tAdd2\n" +
+				"\tTrace\n" +
+				"\tPush \"a\" 1\n" +
+				"\tAdd2\n" +
+				"\tTrace\n" +
+				"\tPush double:0.1 double:0.2\n" +
+				"\tAdd2\n" +
+				"\tTrace\n" +
+				"\tPush 7 2\n" +
+				"\tDivide\n" +
+				"\tTrace\n" +
+				"\tPush \"b\"\n" +
+				"\tGetVariable\n" +
+				"\tTrace\n" +
+				"\tPush \"10\" 9\n" +
+				"\tLess2\n" +
+				"\tTrace\n",
+			[]string{"7", "a1", "0.3", "3.5", "undefined", "false"},
+		},
+		{
+			"\tDefineFunction2 \"fact\" L1 3 2:\"n\"\n" +
+				"\tPush r:2 1\n" +
+				"\tGreater\n" +
+				"\tNot\n" +
+				"\tIf L2\n" +
+				"\tPush r:2 1\n" +
+				"\tSubtract\n" +
+				"\tPush 1 \"fact\"\n" +
+				"\tCallFunction\n" +
+				"\tPush r:2\n" +
+				"\tMultiply\n" +
+				"\tReturn\n" +
+				"L2:\n" +
+				"\tPush 1\n" +
+				"\tReturn\n" +
+				"L1:\n" +
+				"\tPush 5 1 \"fact\"\n" +
+				"\tCallFunction\n" +
+				"\tTrace\n",
+			[]string{"120"},
+		},
+		{
+			"\tPush \"a\" 2 1 3 3\n" +
+				"\tInitArray\n" +
+				"\tSetVariable\n" +
+				"\tPush 0 \"a\"\n" +
+				"\tGetVariable\n" +
+				"\tPush \"sort\"\n" +
+				"\tCallMethod\n" +
+				"\tPop\n" +
+				"\tPush \"-\" 1 \"a\"\n" +
+				"\tGetVariable\n" +
+				"\tPush \"join\"\n" +
+				"\tCallMethod\n" +
+				"\tTrace\n" +
+				"\tPush 9 4 2 \"Math\"\n" +
+				"\tGetVariable\n" +
+				"\tPush \"max\"\n" +
+				"\tCallMethod\n" +
+				"\tTrace\n" +
+				"\tPush 3 1 2 0 \"Hello\" \"toUpperCase\"\n" +
+				"\tCallMethod\n" +
+				"\tPush \"substr\"\n" +
+				"\tCallMethod\n" +
+				"\tTrace\n" +
+				"\tPush \"o\" \"x\" 5 1\n" +
+				"\tInitObject\n" +
+				"\tSetVariable\n" +
+				"\tPush \"o\"\n" +
+				"\tGetVariable\n" +
+				"\tPush \"x\"\n" +
+				"\tGetMember\n" +
+				"\tPush 1\n" +
+				"\tAdd2\n" +
+				"\tTrace\n",
+			[]string{"1-2-3", "9", "ELL", "6"},
+		},
+		{
+			"\tTry L1 L2 L2 catch \"e\"\n" +
+				"\tPush \"oops\"\n" +
+				"\tThrow\n" +
+				"\tJump L2\n" +
+				"L1:\n" +
+				"\tPush \"caught \" \"e\"\n" +
+				"\tGetVariable\n" +
+				"\tAdd2\n" +
+				"\tTrace\n" +
+				"L2:\n" +
+				"\tPush \"after\"\n" +
+				"\tTrace\n" +
+				"\tPush \"uncaught\"\n" +
+				"\tThrow\n" +
+				"\tPush \"unreachable\"\n" +
+				"\tTrace\n",
+			[]string{"caught oops", "after"},
+		},
+	}
+	for n, test := range tests {
+		var traces []string
+		p := scriptPlayer(t, test.listing)
+		p.Trace = func(s string) {
+			traces = append(traces, s)
+		}
+		if err := p.Run(1); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(traces, test.traces) {
+			t.Errorf("test %d: expecting traces %q, got %q", n+1, test.traces, traces)
+		}
+	}
+}
+
+func TestInterpreterLimit(t *testing.T) {
+	p := scriptPlayer(t, "L1:\n\tJump L1\n")
+	p.MaxSteps = 1000
+	if err := p.Run(1); err != ErrActionLimit {
+		t.Errorf("expecting error %s, got %v", ErrActionLimit, err)
+	}
+}
+
+func TestInterpreterArrayLimit(t *testing.T) {
+	const (
+		newArray = "\tPush \"a\" 0 \"Array\"\n\tNewObject\n\tSetVariable\n"
+		array    = newArray + "\tPush \"a\"\n\tGetVariable\n"
+	)
+	// method calls the named method of a with n arguments, or, when n is 0,
+	// leaves a.name(a, a) ready to be called with apply.
+	method := func(name string, n int) string {
+		if n == 0 {
+			return "\tPush \"a\"\n\tGetVariable\n\tPush \"a\"\n\tGetVariable\n\tPush 2 \"a\"\n\tGetVariable\n\tPush \"" + name + "\"\n\tGetMember\n"
+		}
+		return "\tPush" + strings.Repeat(" 0", n) + " " + strconv.Itoa(n) + " \"a\"\n\tGetVariable\n\tPush \"" + name + "\"\n\tCallMethod\n"
+	}
+	for n, listing := range [...]string{
+		array + "\tPush \"length\" double:4e9\n\tSetMember\n",
+		array + "\tPush \"2000000000\" 1\n\tSetMember\n",
+		newArray + "\tPush \"a.length\" double:1e9\n\tSetVariable\n",
+		"\tPush double:1e9 1 \"Array\"\n\tNewObject\n",
+		array + "\tPush \"length\" 1048576\n\tSetMember\n" + method("push", 1),
+		array + "\tPush \"length\" 1048576\n\tSetMember\n" + method("unshift", 1),
+		array + "\tPush \"length\" 1048576\n\tSetMember\n" + method("splice", 3),
+		"\tPush \"a\" 1 1\n\tInitArray\n\tSetVariable\n" + strings.Repeat(method("push", 0)+"\tPush \"apply\"\n\tCallMethod\n\tPop\n", 21),
+	} {
+		if err := scriptPlayer(t, listing).Run(1); err != ErrArrayLength {
+			t.Errorf("test %d: expecting error %s, got %v", n+1, ErrArrayLength, err)
+		}
+	}
+	p := scriptPlayer(t, array+"\tPush \"length\" 3\n\tSetMember\n\tPush \"a.length\"\n\tGetVariable\n\tTrace\n")
+	var traces []string
+	p.Trace = func(s string) {
+		traces = append(traces, s)
+	}
+	if err := p.Run(1); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(traces, []string{"3"}) {
+		t.Errorf("expecting traces %q, got %q", []string{"3"}, traces)
+	}
+}
+
+func TestInterpreterStringLimit(t *testing.T) {
+	const (
+		double = "\tPush \"s\" \"s\"\n\tGetVariable\n\tPush \"s\"\n\tGetVariable\n\tAdd2\n\tSetVariable\n"
+		array  = "\tPush \"a\" \"s\"\n\tGetVariable\n\tPush \"s\"\n\tGetVariable\n\tPush \"s\"\n\tGetVariable\n\tPush 3\n\tInitArray\n\tSetVariable\n"
+	)
+	s := func(n int) string {
+		return "\tPush \"s\" \"x\"\n\tSetVariable\n" + strings.Repeat(double, n)
+	}
+	for n, listing := range [...]string{
+		s(25),
+		s(24) + "\tPush \"s\"\n\tGetVariable\n\tPush \"x\"\n\tStringAdd\n",
+		s(24) + "\tPush \"x\" 1 \"s\"\n\tGetVariable\n\tPush \"concat\"\n\tCallMethod\n",
+		s(23) + array + "\tPush 0 \"a\"\n\tGetVariable\n\tPush \"join\"\n\tCallMethod\n",
+		s(23) + array + "\tPush \"a\"\n\tGetVariable\n\tPush \"\"\n\tAdd2\n",
+	} {
+		if err := scriptPlayer(t, listing).Run(1); err != ErrStringLength {
+			t.Errorf("test %d: expecting error %s, got %v", n+1, ErrStringLength, err)
+		}
+	}
+	if err := scriptPlayer(t, s(24)).Run(1); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,461 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+)
+
+type ButtonRecord struct {
+	HasBlendMode   bool
+	HasFilterList  bool
+	StateHitTest   bool
+	StateDown      bool
+	StateOver      bool
+	StateUp        bool
+	CharacterId    uint16
+	PlaceDepth     uint16
+	PlaceMatrix    Matrix
+	ColorTransform CXFormWithAlpha
+	FilterList     FilterList
+	BlendMode      BlendMode
+}
+
+// readFrom reads the record following the flags byte. The colour transform,
+// filter list and blend mode are only present in DefineButton2.
+func (b *ButtonRecord) readFrom(f io.Reader, flags uint8, button2 bool) (err error) {
+	unpackFlags(flags, &b.HasBlendMode, &b.HasFilterList, &b.StateHitTest, &b.StateDown, &b.StateOver, &b.StateUp)
+	if !button2 {
+		b.HasBlendMode, b.HasFilterList = false, false
+	}
+	if err = binary.Read(f, binary.LittleEndian, &b.CharacterId); err != nil {
+		return
+	}
+	if err = binary.Read(f, binary.LittleEndian, &b.PlaceDepth); err != nil {
+		return
+	}
+	if _, err = b.PlaceMatrix.ReadFrom(f); err != nil || !button2 {
+		return
+	}
+	if _, err = b.ColorTransform.ReadFrom(f); err != nil {
+		return
+	}
+	if b.HasFilterList {
+		if _, err = b.FilterList.ReadFrom(f); err != nil {
+			return
+		}
+	}
+	if b.HasBlendMode {
+		err = binary.Read(f, binary.LittleEndian, &b.BlendMode)
+	}
+	return
+}
+
+func (b *ButtonRecord) writeTo(f io.Writer, button2 bool) (err error) {
+	hasBlendMode, hasFilterList := b.HasBlendMode && button2, b.HasFilterList && button2
+	if err = binary.Write(f, binary.LittleEndian, packFlags(hasBlendMode, hasFilterList, b.StateHitTest, b.StateDown, b.StateOver, b.StateUp)); err != nil {
+		return
+	}
+	if err = binary.Write(f, binary.LittleEndian, [2]uint16{b.CharacterId, b.PlaceDepth}); err != nil {
+		return
+	}
+	if _, err = b.PlaceMatrix.WriteTo(f); err != nil || !button2 {
+		return
+	}
+	if _, err = b.ColorTransform.WriteTo(f); err != nil {
+		return
+	}
+	if hasFilterList {
+		if _, err = b.FilterList.WriteTo(f); err != nil {
+			return
+		}
+	}
+	if hasBlendMode {
+		err = binary.Write(f, binary.LittleEndian, b.BlendMode)
+	}
+	return
+}
+
+func (b *ButtonRecord) size(button2 bool) int32 {
+	total := 1 + 2 + 2 + b.PlaceMatrix.Size()
+	if button2 {
+		total += b.ColorTransform.Size()
+		if b.HasFilterList {
+			total += b.FilterList.Size()
+		}
+		if b.HasBlendMode {
+			total++
+		}
+	}
+	return total
+}
+
+func readButtonRecords(f io.Reader, button2 bool) (records []ButtonRecord, err error) {
+	for {
+		var flags uint8
+		if err = binary.Read(f, binary.LittleEndian, &flags); err != nil || flags == 0 {
+			return
+		}
+		var b ButtonRecord
+		if err = b.readFrom(f, flags, button2); err != nil {
+			return
+		}
+		records = append(records, b)
+	}
+}
+
+func writeButtonRecords(f io.Writer, records []ButtonRecord, button2 bool) (err error) {
+	for n := range records {
+		if err = records[n].writeTo(f, button2); err != nil {
+			return
+		}
+	}
+	return binary.Write(f, binary.LittleEndian, uint8(0))
+}
+
+func buttonRecordsSize(records []ButtonRecord, button2 bool) int32 {
+	total := int32(1)
+	for n := range records {
+		total += records[n].size(button2)
+	}
+	return total
+}
+
+type DefineButton struct {
+	ButtonId   uint16
+	Characters []ButtonRecord
+	Actions    []Action
+}
+
+func (d *DefineButton) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.ButtonId); err != nil {
+		return
+	}
+	if d.Characters, err = readButtonRecords(c, false); err != nil {
+		return
+	}
+	d.Actions, err = readActions(c)
+	return
+}
+
+func (d *DefineButton) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.ButtonId); err != nil {
+		return
+	}
+	if err = writeButtonRecords(c, d.Characters, false); err != nil {
+		return
+	}
+	if err = writeActions(c, d.Actions); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, ACTION_END)
+	return
+}
+
+func (d *DefineButton) Size(ver uint8, id uint16) int32 {
+	return 2 + buttonRecordsSize(d.Characters, false) + ActionsSize(d.Actions) + 1
+}
+
+func (d *DefineButton) MinVersion() uint8 {
+	return 1
+}
+
+func (d *DefineButton) TagId() uint16 {
+	return TAG_DEFINE_BUTTON
+}
+
+func (d *DefineButton) Name() string {
+	return "DefineButton"
+}
+
+type ButtonCondAction struct {
+	CondIdleToOverDown    bool
+	CondOutDownToIdle     bool
+	CondOutDownToOverDown bool
+	CondOverDownToOutDown bool
+	CondOverDownToOverUp  bool
+	CondOverUpToOverDown  bool
+	CondOverUpToIdle      bool
+	CondIdleToOverUp      bool
+	CondKeyPress          uint8
+	CondOverDownToIdle    bool
+	Actions               []Action
+}
+
+func (b *ButtonCondAction) size() int32 {
+	return 2 + 2 + ActionsSize(b.Actions) + 1
+}
+
+type DefineButton2 struct {
+	ButtonId    uint16
+	TrackAsMenu bool
+	Characters  []ButtonRecord
+	Actions     []ButtonCondAction
+}
+
+func (d *DefineButton2) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var (
+		flags        uint8
+		actionOffset uint16
+	)
+	if err = binary.Read(c, binary.LittleEndian, &d.ButtonId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	d.TrackAsMenu = flags&1 == 1
+	if err = binary.Read(c, binary.LittleEndian, &actionOffset); err != nil {
+		return
+	}
+	start := c.BytesRead()
+	if d.Characters, err = readButtonRecords(c, true); err != nil {
+		return
+	}
+	d.Actions = nil
+	if actionOffset == 0 {
+		return
+	} else if c.BytesRead()-start+2 != int64(actionOffset) {
+		err = &ParserError{d.Name(), "ActionOffset", fmt.Sprintf("%d", actionOffset)}
+		return
+	}
+	for {
+		var (
+			size uint16
+			cond [2]uint8
+			b    ButtonCondAction
+		)
+		if err = binary.Read(c, binary.LittleEndian, &size); err != nil {
+			return
+		}
+		if err = binary.Read(c, binary.LittleEndian, &cond); err != nil {
+			return
+		}
+		unpackFlags(cond[0], &b.CondIdleToOverDown, &b.CondOutDownToIdle, &b.CondOutDownToOverDown, &b.CondOverDownToOutDown, &b.CondOverDownToOverUp, &b.CondOverUpToOverDown, &b.CondOverUpToIdle, &b.CondIdleToOverUp)
+		b.CondKeyPress = cond[1] >> 1
+		b.CondOverDownToIdle = cond[1]&1 == 1
+		if b.Actions, err = readActions(c); err != nil {
+			return
+		}
+		if size != 0 && int32(size) != b.size() {
+			err = &ParserError{d.Name(), "CondActionSize", fmt.Sprintf("%d", size)}
+			return
+		}
+		d.Actions = append(d.Actions, b)
+		if size == 0 {
+			return
+		}
+	}
+}
+
+func (d *DefineButton2) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.ButtonId); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, packFlags(d.TrackAsMenu)); err != nil {
+		return
+	}
+	var actionOffset uint16
+	if len(d.Actions) > 0 {
+		actionOffset = uint16(2 + buttonRecordsSize(d.Characters, true))
+	}
+	if err = binary.Write(c, binary.LittleEndian, actionOffset); err != nil {
+		return
+	}
+	if err = writeButtonRecords(c, d.Characters, true); err != nil {
+		return
+	}
+	for n, b := range d.Actions {
+		var size uint16
+		if n < len(d.Actions)-1 {
+			size = uint16(b.size())
+		}
+		if err = binary.Write(c, binary.LittleEndian, size); err != nil {
+			return
+		}
+		cond := [2]uint8{
+			packFlags(b.CondIdleToOverDown, b.CondOutDownToIdle, b.CondOutDownToOverDown, b.CondOverDownToOutDown, b.CondOverDownToOverUp, b.CondOverUpToOverDown, b.CondOverUpToIdle, b.CondIdleToOverUp),
+			b.CondKeyPress<<1 | packFlags(b.CondOverDownToIdle),
+		}
+		if err = binary.Write(c, binary.LittleEndian, cond); err != nil {
+			return
+		}
+		if err = writeActions(c, b.Actions); err != nil {
+			return
+		}
+		if err = binary.Write(c, binary.LittleEndian, ACTION_END); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (d *DefineButton2) Size(ver uint8, id uint16) int32 {
+	total := 2 + 1 + 2 + buttonRecordsSize(d.Characters, true)
+	for n := range d.Actions {
+		total += d.Actions[n].size()
+	}
+	return total
+}
+
+func (d *DefineButton2) MinVersion() uint8 {
+	for _, c := range d.Characters {
+		if c.HasBlendMode || c.HasFilterList {
+			return 8
+		}
+	}
+	return 3
+}
+
+func (d *DefineButton2) TagId() uint16 {
+	return TAG_DEFINE_BUTTON2
+}
+
+func (d *DefineButton2) Name() string {
+	return "DefineButton2"
+}
+
+type DefineButtonCxform struct {
+	ButtonId             uint16
+	ButtonColorTransform CXForm
+}
+
+func (d *DefineButtonCxform) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.ButtonId); err != nil {
+		return
+	}
+	_, err = d.ButtonColorTransform.ReadFrom(c)
+	return
+}
+
+func (d *DefineButtonCxform) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.ButtonId); err != nil {
+		return
+	}
+	_, err = d.ButtonColorTransform.WriteTo(c)
+	return
+}
+
+func (d *DefineButtonCxform) Size(ver uint8, id uint16) int32 {
+	return 2 + d.ButtonColorTransform.Size()
+}
+
+func (d *DefineButtonCxform) MinVersion() uint8 {
+	return 2
+}
+
+func (d *DefineButtonCxform) TagId() uint16 {
+	return TAG_DEFINE_BUTTON_CXFORM
+}
+
+func (d *DefineButtonCxform) Name() string {
+	return "DefineButtonCxform"
+}
+
+// DefineButtonSound holds the sounds for the OverUpToIdle, IdleToOverUp,
+// OverUpToOverDown and OverDownToOverUp transitions, in that order. A sound
+// id of zero means no sound.
+type DefineButtonSound struct {
+	ButtonId        uint16
+	ButtonSoundChar [4]uint16
+	ButtonSoundInfo [4]SoundInfo
+}
+
+func (d *DefineButtonSound) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.ButtonId); err != nil {
+		return
+	}
+	for n := range d.ButtonSoundChar {
+		if err = binary.Read(c, binary.LittleEndian, &d.ButtonSoundChar[n]); err != nil {
+			return
+		}
+		d.ButtonSoundInfo[n] = SoundInfo{}
+		if d.ButtonSoundChar[n] != 0 {
+			if _, err = d.ButtonSoundInfo[n].ReadFrom(c); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (d *DefineButtonSound) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.ButtonId); err != nil {
+		return
+	}
+	for n, char := range d.ButtonSoundChar {
+		if err = binary.Write(c, binary.LittleEndian, char); err != nil {
+			return
+		}
+		if char != 0 {
+			if _, err = d.ButtonSoundInfo[n].WriteTo(c); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (d *DefineButtonSound) Size(ver uint8, id uint16) int32 {
+	total := int32(2)
+	for n, char := range d.ButtonSoundChar {
+		total += 2
+		if char != 0 {
+			total += d.ButtonSoundInfo[n].Size()
+		}
+	}
+	return total
+}
+
+func (d *DefineButtonSound) MinVersion() uint8 {
+	return 2
+}
+
+func (d *DefineButtonSound) TagId() uint16 {
+	return TAG_DEFINE_BUTTON_SOUND
+}
+
+func (d *DefineButtonSound) Name() string {
+	return "DefineButtonSound"
+}
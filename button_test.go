@@ -0,0 +1,117 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDefineButton(t *testing.T) {
+	testTag(t, 1, []byte{1, 0, 7, 2, 0, 1, 0, 0, 0, 7, 0x81, 2, 0, 5, 0, 0}, &DefineButton{
+		ButtonId: 1,
+		Characters: []ButtonRecord{
+			{StateDown: true, StateOver: true, StateUp: true, CharacterId: 2, PlaceDepth: 1, PlaceMatrix: Matrix{ScaleX: 1, ScaleY: 1}},
+		},
+		Actions: []Action{BasicAction(ACTION_STOP), &ActionGotoFrame{5}},
+	})
+}
+
+func TestDefineButton2(t *testing.T) {
+	testTag(t, 8, []byte{3, 0, 1, 22, 0, 0x38, 2, 0, 1, 0, 0, 0, 1, 1, 0, 0, 5, 0, 0, 0x80, 2, 0, 8, 3, 0, 6, 0, 0x08, 26, 7, 0, 0, 0, 0, 1, 0}, &DefineButton2{
+		ButtonId:    3,
+		TrackAsMenu: true,
+		Characters: []ButtonRecord{
+			{
+				HasBlendMode:   true,
+				HasFilterList:  true,
+				StateHitTest:   true,
+				CharacterId:    2,
+				PlaceDepth:     1,
+				PlaceMatrix:    Matrix{ScaleX: 1, ScaleY: 1},
+				ColorTransform: CXFormWithAlpha{CXForm{256, 256, 256, 0, 0, 0}, 256, 0},
+				FilterList:     FilterList{&BlurFilter{5, 2.5, 1}},
+				BlendMode:      BLEND_MULTIPLY,
+			},
+		},
+		Actions: []ButtonCondAction{
+			{CondOverDownToOverUp: true, CondKeyPress: 13, Actions: []Action{BasicAction(ACTION_STOP)}},
+			{CondOverDownToIdle: true},
+		},
+	})
+	testTag(t, 3, []byte{4, 0, 0, 0, 0, 0}, &DefineButton2{ButtonId: 4})
+	b := &DefineButton2{Characters: []ButtonRecord{{StateUp: true}}}
+	if v := b.MinVersion(); v != 3 {
+		t.Errorf("expecting minimum version 3, got %d", v)
+	}
+	b.Characters = append(b.Characters, ButtonRecord{StateDown: true, HasBlendMode: true})
+	if v := b.MinVersion(); v != 8 {
+		t.Errorf("expecting minimum version 8, got %d", v)
+	}
+}
+
+func TestDefineButtonCxform(t *testing.T) {
+	testTag(t, 2, []byte{4, 0, 0x95, 0x5d, 0}, &DefineButtonCxform{
+		ButtonId:             4,
+		ButtonColorTransform: CXForm{256, 256, 256, 10, -3, 0},
+	})
+}
+
+func TestDefineButtonSound(t *testing.T) {
+	testTag(t, 2, []byte{5, 0, 0, 0, 9, 0, 0, 0, 0, 0, 0}, &DefineButtonSound{
+		ButtonId:        5,
+		ButtonSoundChar: [4]uint16{0, 9, 0, 0},
+	})
+}
+
+func TestFilterList(t *testing.T) {
+	filters := FilterList{
+		&DropShadowFilter{RGBA{RGB{1, 2, 3}, 4}, 4, 4, 0.75, 5, 1.5, true, false, true, 3},
+		&BlurFilter{2, 3, 15},
+		&GlowFilter{RGBA{RGB{255, 0, 0}, 128}, 6, 6, 2, false, true, true, 1},
+		&BevelFilter{RGBA{}, RGBA{RGB{255, 255, 255}, 255}, 1, 1, 0.5, 2, 1, true, false, false, true, 2},
+		&GradientGlowFilter{false, []GradientRecord{{RGBA{}, 0}, {RGBA{RGB{9, 9, 9}, 9}, 255}}, 1, 2, 3, 4, 5, false, false, true, false, 7},
+		&GradientGlowFilter{Bevel: true, Gradient: []GradientRecord{}, Passes: 1},
+		&ConvolutionFilter{2, 1, 1, 0.5, []Float{-1, 1}, RGBA{}, true, false},
+		&ColorMatrixFilter{[20]Float{1, 0, 0, 0, 0, 0, 1}},
+	}
+	buf := new(bytes.Buffer)
+	if n, err := filters.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	} else if n != int64(filters.Size()) {
+		t.Errorf("wrote %d bytes, expecting %d", n, filters.Size())
+	}
+	var read FilterList
+	if n, err := read.ReadFrom(buf); err != nil {
+		t.Fatal(err)
+	} else if n != int64(filters.Size()) {
+		t.Errorf("read %d bytes, expecting %d", n, filters.Size())
+	}
+	if !reflect.DeepEqual(read, filters) {
+		t.Errorf("expecting %v, got %v", filters, read)
+	}
+	if _, err := read.ReadFrom(bytes.NewBuffer([]byte{1, 8})); err == nil {
+		t.Error("expecting error for invalid filter id")
+	}
+}
+
+func TestButtonText(t *testing.T) {
+	s := &SWF{Tags: []Tag{
+		&DefineEditText{CharacterId: 2, HasText: true, InitialText: "OK"},
+		&DefineButton2{ButtonId: 3, Characters: []ButtonRecord{{StateUp: true, CharacterId: 2}, {StateOver: true, CharacterId: 2}, {StateDown: true, CharacterId: 1}}, Actions: []ButtonCondAction{
+			{CondOverDownToOverUp: true, Actions: []Action{&ActionGetURL{URL: "http://example.com/", Target: "_blank"}, &ActionConstantPool{[]String{"Clicked"}}}},
+		}},
+		&PlaceObject2{HasClipActions: true, HasCharacter: true, Depth: 1, CharacterId: 3, ClipActions: ClipActions{
+			AllEventFlags: CLIP_EVENT_LOAD,
+			Records:       []ClipActionRecord{{EventFlags: CLIP_EVENT_LOAD, Actions: []Action{&ActionPush{[]PushValue{PushString("Loaded")}}}}},
+		}},
+	}}
+	expected := []TextItem{
+		{TEXT_EDIT, 2, 0, "OK"},
+		{TEXT_BUTTON, 3, 0, "OK"},
+		{TEXT_ACTION, 3, 0, "Clicked"},
+		{TEXT_ACTION, 0, 0, "Loaded"},
+	}
+	if items := s.Text(); !reflect.DeepEqual(items, expected) {
+		t.Errorf("expecting %v, got %v", expected, items)
+	}
+}
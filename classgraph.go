@@ -0,0 +1,467 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import "sort"
+
+// qualifiedNames returns the fully qualified names, in the dotted form used
+// by SymbolClass, that the multiname may refer to.
+func (a *ABCFile) qualifiedNames(n uint32) []string {
+	if n == 0 || int(n) >= len(a.ConstantPool.Multinames) {
+		return nil
+	}
+	m := a.ConstantPool.Multinames[n]
+	name, _ := a.stringConstant(m.Name)
+	qualify := func(ns uint32) string {
+		if int(ns) < len(a.ConstantPool.Namespaces) {
+			if pkg, _ := a.stringConstant(a.ConstantPool.Namespaces[ns].Name); pkg != "" {
+				return pkg + "." + name
+			}
+		}
+		return name
+	}
+	var names []string
+	switch m.Kind {
+	case CONSTANT_QNAME, CONSTANT_QNAME_A:
+		names = append(names, qualify(m.Namespace))
+	case CONSTANT_MULTINAME, CONSTANT_MULTINAME_A:
+		if int(m.NsSet) < len(a.ConstantPool.NsSets) {
+			for _, ns := range a.ConstantPool.NsSets[m.NsSet] {
+				names = append(names, qualify(ns))
+			}
+		}
+	}
+	return names
+}
+
+// QualifiedName returns the fully qualified name of the multiname, in the
+// dotted form used by SymbolClass. Multinames with a namespace set are
+// qualified by the first namespace of the set.
+func (a *ABCFile) QualifiedName(n uint32) string {
+	if names := a.qualifiedNames(n); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// referencedNames returns the names that the multiname may refer to,
+// including the parameters of generic types.
+func (a *ABCFile) referencedNames(n uint32, depth int) []string {
+	if int(n) < len(a.ConstantPool.Multinames) && depth < 8 {
+		if m := a.ConstantPool.Multinames[n]; m.Kind == CONSTANT_TYPENAME {
+			names := a.referencedNames(m.Name, depth+1)
+			for _, p := range m.Params {
+				names = append(names, a.referencedNames(p, depth+1)...)
+			}
+			return names
+		}
+	}
+	return a.qualifiedNames(n)
+}
+
+// AS3Class is an ActionScript 3 class or interface defined in a DoABC tag.
+// Super and Implements only hold the classes that are defined in the SWF, and
+// Characters the characters linked to the class by SymbolClass. Index is the
+// index of the instance and class info in ABC.
+type AS3Class struct {
+	Name       string
+	SuperName  string
+	Interfaces []string
+	Interface  bool
+	Super      *AS3Class
+	Subclasses []*AS3Class
+	Implements []*AS3Class
+	Characters []uint16
+	ABC        *ABCFile
+	Index      int
+}
+
+// Ancestors returns the names of the superclasses of the class, nearest
+// first, ending with the first that is not defined in the SWF.
+func (c *AS3Class) Ancestors() []string {
+	var names []string
+	for n := 0; c != nil && c.SuperName != "" && n < 64; n++ {
+		names = append(names, c.SuperName)
+		c = c.Super
+	}
+	return names
+}
+
+// ClassGraph links the characters of a SWF to the ActionScript 3 classes
+// defined in its DoABC tags. Symbols maps character ids to class names, from
+// SymbolClass, and Exports maps them to the names given by ExportAssets.
+type ClassGraph struct {
+	Classes       map[string]*AS3Class
+	Symbols       map[uint16]string
+	Exports       map[uint16]string
+	used          map[uint16]bool
+	placedClasses map[string]bool
+	referenced    map[string]bool
+	strings       map[string]bool
+}
+
+// ClassGraph builds the class graph of the SWF.
+func (s *SWF) ClassGraph() (*ClassGraph, error) {
+	g := &ClassGraph{
+		Classes:       make(map[string]*AS3Class),
+		Symbols:       make(map[uint16]string),
+		Exports:       make(map[uint16]string),
+		used:          make(map[uint16]bool),
+		placedClasses: make(map[string]bool),
+		referenced:    make(map[string]bool),
+		strings:       make(map[string]bool),
+	}
+	files, err := g.tags(s.Tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, actions := range actionLists(s.Tags) {
+		for _, a := range actions {
+			switch a := a.(type) {
+			case *ActionConstantPool:
+				for _, c := range a.Constants {
+					g.strings[string(c)] = true
+				}
+			case *ActionPush:
+				for _, v := range a.Values {
+					if str, ok := v.(PushString); ok {
+						g.strings[string(str)] = true
+					}
+				}
+			}
+		}
+	}
+	for _, a := range files {
+		for n := range a.Instances {
+			i := &a.Instances[n]
+			c := &AS3Class{
+				Name:      a.QualifiedName(i.Name),
+				Interface: i.Flags&CLASS_INTERFACE != 0,
+				ABC:       a,
+				Index:     n,
+			}
+			g.Classes[c.Name] = c
+		}
+		if err := g.references(a); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range g.Classes {
+		i := &c.ABC.Instances[c.Index]
+		c.SuperName = g.resolve(c.ABC, i.SuperName)
+		if c.Super = g.Classes[c.SuperName]; c.Super != nil {
+			c.Super.Subclasses = append(c.Super.Subclasses, c)
+		}
+		for _, n := range i.Interfaces {
+			name := g.resolve(c.ABC, n)
+			c.Interfaces = append(c.Interfaces, name)
+			if in := g.Classes[name]; in != nil {
+				c.Implements = append(c.Implements, in)
+			}
+		}
+	}
+	for id, name := range g.Symbols {
+		if c := g.Classes[name]; c != nil {
+			c.Characters = append(c.Characters, id)
+		}
+	}
+	for _, c := range g.Classes {
+		sort.Slice(c.Subclasses, func(i, j int) bool { return c.Subclasses[i].Name < c.Subclasses[j].Name })
+		sort.Slice(c.Characters, func(i, j int) bool { return c.Characters[i] < c.Characters[j] })
+	}
+	return g, nil
+}
+
+// tags records the symbols and exports of the tags, the characters they place
+// or that other characters and sounds use, and the classes they name, and
+// returns the ABC files of their DoABC tags.
+func (g *ClassGraph) tags(tags []Tag) ([]*ABCFile, error) {
+	var files []*ABCFile
+	abc := func(d *DoABC) error {
+		a, err := d.ABC()
+		if err == nil {
+			files = append(files, a)
+		}
+		return err
+	}
+	place := func(p *PlaceObject2) {
+		if p.HasCharacter {
+			g.used[p.CharacterId] = true
+		}
+		if p.HasClassName {
+			g.placedClasses[string(p.ClassName)] = true
+		}
+	}
+	button := func(records []ButtonRecord) {
+		for _, r := range records {
+			g.used[r.CharacterId] = true
+		}
+	}
+	morph := func(d *DefineMorphShape) {
+		for _, f := range d.MorphFillStyles {
+			if isBitmapFill(f.FillStyleType) {
+				g.used[f.BitmapId] = true
+			}
+		}
+		for _, l := range d.MorphLineStyles {
+			if l.HasFill && isBitmapFill(l.FillType.FillStyleType) {
+				g.used[l.FillType.BitmapId] = true
+			}
+		}
+	}
+	text := func(d *DefineText) {
+		for _, r := range d.TextRecords {
+			if r.HasFont {
+				g.used[r.FontId] = true
+			}
+		}
+	}
+	for _, tag := range tags {
+		var err error
+		switch t := tag.(type) {
+		case *DoABC:
+			err = abc(t)
+		case *DoABCDefine:
+			err = abc(&t.DoABC)
+		case *SymbolClass:
+			for _, a := range t.Assets {
+				g.Symbols[a.CharacterId] = string(a.Name)
+			}
+		case *ExportAssets:
+			for _, a := range t.Assets {
+				g.Exports[a.CharacterId] = string(a.Name)
+			}
+		case *PlaceObject:
+			g.used[t.CharacterId] = true
+		case *PlaceObject2:
+			place(t)
+		case *PlaceObject3:
+			place(&t.PlaceObject2)
+		case *DefineButton:
+			button(t.Characters)
+		case *DefineButton2:
+			button(t.Characters)
+		case *DefineButtonSound:
+			for _, id := range t.ButtonSoundChar {
+				g.used[id] = true
+			}
+		case *StartSound:
+			g.used[t.SoundId] = true
+		case *StartSound2:
+			g.referenced[string(t.SoundClassName)] = true
+		case *DefineEditText:
+			if t.HasFont {
+				g.used[t.FontId] = true
+			}
+			if t.HasFontClass {
+				g.referenced[string(t.FontClass)] = true
+			}
+		case *DefineText:
+			text(t)
+		case *DefineText2:
+			text(&t.DefineText)
+		case *DefineMorphShape:
+			morph(t)
+		case *DefineMorphShape2:
+			morph(&t.DefineMorphShape)
+		case *DefineSprite:
+			var sprite []*ABCFile
+			sprite, err = g.tags(t.ControlTags)
+			files = append(files, sprite...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// resolve returns the name of the class that the multiname refers to,
+// preferring classes defined in the SWF.
+func (g *ClassGraph) resolve(a *ABCFile, n uint32) string {
+	names := a.qualifiedNames(n)
+	for _, name := range names {
+		if g.Classes[name] != nil {
+			return name
+		}
+	}
+	if len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// references records the classes referenced by the ABC file. References
+// made by a class to itself, and those made by script initialisers, which
+// only define the classes of the script, are not recorded.
+func (g *ClassGraph) references(a *ABCFile) error {
+	owners := make(map[uint32]string)
+	methods := func(owner string, traits []Trait) {
+		for _, t := range traits {
+			switch t.Kind {
+			case TRAIT_METHOD, TRAIT_GETTER, TRAIT_SETTER, TRAIT_FUNCTION:
+				owners[t.Index] = owner
+			}
+		}
+	}
+	for n, i := range a.Instances {
+		owner := a.QualifiedName(i.Name)
+		owners[i.IInit] = owner
+		methods(owner, i.Traits)
+		if n < len(a.Classes) {
+			owners[a.Classes[n].CInit] = owner
+			methods(owner, a.Classes[n].Traits)
+		}
+	}
+	scripts := make(map[uint32]bool)
+	for _, s := range a.Scripts {
+		scripts[s.Init] = true
+	}
+	ref := func(owner string, n uint32) {
+		for _, name := range a.referencedNames(n, 0) {
+			if name != owner {
+				g.referenced[name] = true
+			}
+		}
+	}
+	traits := func(owner string, traits []Trait) {
+		for _, t := range traits {
+			if t.Kind == TRAIT_SLOT || t.Kind == TRAIT_CONST {
+				ref(owner, t.TypeName)
+			}
+		}
+	}
+	for n, m := range a.Methods {
+		owner := owners[uint32(n)]
+		for _, p := range m.ParamTypes {
+			ref(owner, p)
+		}
+		ref(owner, m.ReturnType)
+	}
+	for n, i := range a.Instances {
+		owner := a.QualifiedName(i.Name)
+		ref(owner, i.SuperName)
+		for _, in := range i.Interfaces {
+			ref(owner, in)
+		}
+		traits(owner, i.Traits)
+		if n < len(a.Classes) {
+			traits(owner, a.Classes[n].Traits)
+		}
+	}
+	for _, s := range a.Scripts {
+		traits("", s.Traits)
+	}
+	for _, b := range a.MethodBodies {
+		if scripts[b.Method] {
+			continue
+		}
+		owner := owners[b.Method]
+		for _, e := range b.Exceptions {
+			ref(owner, e.ExcType)
+		}
+		traits(owner, b.Traits)
+		instructions, err := DecodeInstructions(b.Code)
+		if err != nil {
+			return err
+		}
+		for _, i := range instructions {
+			for o, k := range instructionInfo[i.Opcode].operands {
+				if k == operandMultiname {
+					ref(owner, uint32(i.Operands[o]))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// CharacterClass returns the class linked to the character by SymbolClass,
+// or nil if the character is not linked to a class defined in the SWF.
+func (g *ClassGraph) CharacterClass(id uint16) *AS3Class {
+	if name, ok := g.Symbols[id]; ok {
+		return g.Classes[name]
+	}
+	return nil
+}
+
+// DeadSymbols returns, in order, the ids of the characters named by
+// SymbolClass or ExportAssets that are unused. A character is used when it is
+// placed on a timeline or in a button, used by another character or a sound
+// tag, exported under a name that appears as a string in AVM1 actions, such
+// as that given to attachMovie, or linked to a class that is used. A class is
+// used when it is placed by name, referenced by ActionScript outside of its
+// own definition, or has a subclass that is used.
+func (g *ClassGraph) DeadSymbols() []uint16 {
+	var dead []uint16
+	check := func(id uint16) {
+		if id == 0 || g.used[id] {
+			return
+		}
+		if name, ok := g.Symbols[id]; ok && g.usedClass(name, 0) {
+			return
+		}
+		if name, ok := g.Exports[id]; ok && g.strings[name] {
+			return
+		}
+		for _, d := range dead {
+			if d == id {
+				return
+			}
+		}
+		dead = append(dead, id)
+	}
+	for id := range g.Symbols {
+		check(id)
+	}
+	for id := range g.Exports {
+		check(id)
+	}
+	sort.Slice(dead, func(i, j int) bool { return dead[i] < dead[j] })
+	return dead
+}
+
+// usedClass returns whether the named class is placed by name, referenced,
+// or has a subclass that is used or linked to a used character.
+func (g *ClassGraph) usedClass(name string, depth int) bool {
+	if g.referenced[name] || g.placedClasses[name] {
+		return true
+	}
+	c := g.Classes[name]
+	if c == nil || depth >= 64 {
+		return false
+	}
+	for _, sub := range c.Subclasses {
+		if g.usedClass(sub.Name, depth+1) {
+			return true
+		}
+		for _, id := range sub.Characters {
+			if g.used[id] {
+				return true
+			}
+		}
+	}
+	return false
+}
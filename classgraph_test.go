@@ -0,0 +1,135 @@
+package swf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSymbolClass(t *testing.T) {
+	testTag(t, 5, []byte{1, 0, 2, 0, 's', 0}, &ExportAssets{[]Asset{{2, "s"}}})
+	testTag(t, 9, []byte{2, 0, 1, 0, 'a', 0, 0, 0, 'M', 'a', 'i', 'n', 0}, &SymbolClass{ExportAssets{[]Asset{{1, "a"}, {0, "Main"}}}})
+}
+
+func TestClassGraph(t *testing.T) {
+	a := &ABCFile{
+		MinorVersion: 16,
+		MajorVersion: 46,
+		ConstantPool: ConstantPool{
+			Strings:    []string{"", "game", "flash.display", "Hero", "Sprite", "Enemy", "IMover", "Unused", "MovieClip", "Game"},
+			Namespaces: []Namespace{{}, {CONSTANT_PACKAGE_NAMESPACE, 1}, {CONSTANT_PACKAGE_NAMESPACE, 2}, {CONSTANT_PACKAGE_NAMESPACE, 0}},
+			NsSets:     [][]uint32{nil, {3, 1}},
+			Multinames: []Multiname{
+				{},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 3},
+				{Kind: CONSTANT_QNAME, Namespace: 2, Name: 4},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 5},
+				{Kind: CONSTANT_MULTINAME, Name: 6, NsSet: 1},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 7},
+				{Kind: CONSTANT_QNAME, Namespace: 2, Name: 8},
+				{Kind: CONSTANT_QNAME, Namespace: 3, Name: 9},
+				{Kind: CONSTANT_QNAME, Namespace: 1, Name: 6},
+			},
+		},
+		Methods: make([]MethodInfo, 11),
+		Instances: []InstanceInfo{
+			{Name: 1, SuperName: 2, Interfaces: []uint32{4}, IInit: 0},
+			{Name: 3, SuperName: 1, IInit: 2},
+			{Name: 8, Flags: CLASS_INTERFACE, IInit: 4},
+			{Name: 5, SuperName: 6, IInit: 6},
+			{Name: 7, SuperName: 6, IInit: 8},
+		},
+		Classes: []ClassInfo{{CInit: 1}, {CInit: 3}, {CInit: 5}, {CInit: 7}, {CInit: 9}},
+		Scripts: []ScriptInfo{{Init: 10}},
+		MethodBodies: []MethodBody{
+			{Method: 2, MaxStack: 1, LocalCount: 1, Code: []byte{0x60, 0x03, 0x29, 0x47}},
+			{Method: 8, MaxStack: 1, LocalCount: 1, Code: []byte{0x60, 0x05, 0x29, 0x47}},
+			{Method: 10, MaxStack: 1, LocalCount: 1, Code: []byte{0x60, 0x03, 0x29, 0x47}},
+		},
+	}
+	d := new(DoABC)
+	if err := d.SetABC(a); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s := &SWF{
+		Version: 9,
+		Tags: []Tag{
+			d,
+			&SymbolClass{ExportAssets{[]Asset{{0, "Game"}, {1, "game.Hero"}, {2, "game.Enemy"}, {3, "game.Unused"}}}},
+			&ExportAssets{[]Asset{{4, "sound"}, {5, "clip"}}},
+			&DefineSprite{SpriteId: 6, FrameCount: 1, ControlTags: []Tag{&PlaceObject2{HasCharacter: true, CharacterId: 5, Depth: 1}, &ShowFrame{}}},
+			&PlaceObject2{HasCharacter: true, CharacterId: 1, Depth: 1},
+			&ShowFrame{},
+		},
+	}
+	g, err := s.ClassGraph()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(g.Classes) != 5 {
+		t.Errorf("expecting 5 classes, got %d", len(g.Classes))
+	}
+	if c := g.CharacterClass(0); c == nil || c.Name != "Game" {
+		t.Errorf("expecting character 0 to be class Game, got %v", c)
+	}
+	if c := g.CharacterClass(6); c != nil {
+		t.Errorf("expecting character 6 to have no class, got %s", c.Name)
+	}
+	hero, enemy, mover := g.CharacterClass(1), g.Classes["game.Enemy"], g.Classes["game.IMover"]
+	if hero == nil || enemy == nil || mover == nil {
+		t.Fatalf("missing classes: %v, %v, %v", hero, enemy, mover)
+	}
+	if hero.SuperName != "flash.display.Sprite" || hero.Super != nil {
+		t.Errorf("expecting Hero to extend flash.display.Sprite, got %s", hero.SuperName)
+	}
+	if !reflect.DeepEqual(hero.Interfaces, []string{"game.IMover"}) || len(hero.Implements) != 1 || hero.Implements[0] != mover {
+		t.Errorf("expecting Hero to implement game.IMover, got %v", hero.Interfaces)
+	}
+	if len(hero.Subclasses) != 1 || hero.Subclasses[0] != enemy || enemy.Super != hero {
+		t.Errorf("expecting Enemy to be the subclass of Hero")
+	}
+	if ancestors := enemy.Ancestors(); !reflect.DeepEqual(ancestors, []string{"game.Hero", "flash.display.Sprite"}) {
+		t.Errorf("expecting ancestors [game.Hero flash.display.Sprite], got %v", ancestors)
+	}
+	if !reflect.DeepEqual(enemy.Characters, []uint16{2}) {
+		t.Errorf("expecting Enemy characters [2], got %v", enemy.Characters)
+	}
+	if !mover.Interface || mover.Super != nil {
+		t.Errorf("expecting game.IMover to be an interface")
+	}
+	if dead := g.DeadSymbols(); !reflect.DeepEqual(dead, []uint16{2, 4}) {
+		t.Errorf("expecting dead symbols [2 4], got %v", dead)
+	}
+}
+
+func TestDeadSymbols(t *testing.T) {
+	s := &SWF{
+		Version: 8,
+		Tags: []Tag{
+			&ExportAssets{[]Asset{{10, "linked"}, {11, "orphan"}, {12, "font"}, {14, "bitmap"}, {15, "sound"}, {16, "click"}, {18, "label"}}},
+			&DoAction{Actions: []Action{&ActionPush{[]PushValue{PushString("linked")}}}},
+			&DefineEditText{CharacterId: 13, HasFont: true, FontId: 12},
+			&DefineText{CharacterId: 19, TextRecords: []TextRecord{{HasFont: true, FontId: 18}}},
+			&DefineMorphShape2{DefineMorphShape{CharacterId: 17, MorphFillStyles: []MorphFillStyle{{FillStyleType: FILL_CLIPPED_BITMAP, BitmapId: 14}}}},
+			&DefineButtonSound{ButtonId: 20, ButtonSoundChar: [4]uint16{0, 16, 0, 0}},
+			&DefineSprite{SpriteId: 21, FrameCount: 1, ControlTags: []Tag{&StartSound{SoundId: 15}, &ShowFrame{}}},
+		},
+	}
+	g, err := s.ClassGraph()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if dead := g.DeadSymbols(); !reflect.DeepEqual(dead, []uint16{11}) {
+		t.Errorf("expecting dead symbols [11], got %v", dead)
+	}
+	base := &AS3Class{Name: "Base"}
+	sub := &AS3Class{Name: "Sub", Super: base, Characters: []uint16{2}}
+	base.Subclasses = []*AS3Class{sub}
+	g = &ClassGraph{
+		Classes: map[string]*AS3Class{"Base": base, "Sub": sub, "Other": {Name: "Other"}},
+		Symbols: map[uint16]string{1: "Base", 2: "Sub", 3: "Other"},
+		used:    map[uint16]bool{2: true},
+	}
+	if dead := g.DeadSymbols(); !reflect.DeepEqual(dead, []uint16{3}) {
+		t.Errorf("expecting dead symbols [3], got %v", dead)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+)
+
+type ShowFrame struct{}
+
+func (s *ShowFrame) ReadFrom(f io.Reader, ver uint8, id uint16) (int64, error) {
+	return 0, nil
+}
+
+func (s *ShowFrame) WriteTo(f io.Writer, ver uint8, id uint16) (int64, error) {
+	return 0, nil
+}
+
+func (s *ShowFrame) Size(ver uint8, id uint16) int32 {
+	return 0
+}
+
+func (s *ShowFrame) MinVersion() uint8 {
+	return 1
+}
+
+func (s *ShowFrame) TagId() uint16 {
+	return TAG_SHOW_FRAME
+}
+
+func (s *ShowFrame) Name() string {
+	return "ShowFrame"
+}
+
+type FrameLabel struct {
+	Label       String
+	NamedAnchor bool
+}
+
+func (l *FrameLabel) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if _, err = l.Label.ReadFrom(c); err != nil {
+		return
+	}
+	var anchor uint8
+	if err = binary.Read(c, binary.LittleEndian, &anchor); err == io.EOF {
+		err = nil
+	}
+	l.NamedAnchor = anchor == 1
+	return
+}
+
+func (l *FrameLabel) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if _, err = l.Label.WriteTo(c); err != nil {
+		return
+	}
+	if l.NamedAnchor {
+		err = binary.Write(c, binary.LittleEndian, uint8(1))
+	}
+	return
+}
+
+func (l *FrameLabel) Size(ver uint8, id uint16) int32 {
+	if l.NamedAnchor {
+		return l.Label.Size() + 1
+	}
+	return l.Label.Size()
+}
+
+func (l *FrameLabel) MinVersion() uint8 {
+	return 3
+}
+
+func (l *FrameLabel) TagId() uint16 {
+	return TAG_FRAME_LABEL
+}
+
+func (l *FrameLabel) Name() string {
+	return "FrameLabel"
+}
+
+type Metadata struct {
+	Metadata String
+}
+
+func (m *Metadata) ReadFrom(f io.Reader, ver uint8, id uint16) (int64, error) {
+	return m.Metadata.ReadFrom(f)
+}
+
+func (m *Metadata) WriteTo(f io.Writer, ver uint8, id uint16) (int64, error) {
+	return m.Metadata.WriteTo(f)
+}
+
+func (m *Metadata) Size(ver uint8, id uint16) int32 {
+	return m.Metadata.Size()
+}
+
+func (m *Metadata) MinVersion() uint8 {
+	return 1
+}
+
+func (m *Metadata) TagId() uint16 {
+	return TAG_METADATA
+}
+
+func (m *Metadata) Name() string {
+	return "Metadata"
+}
+
+type FileAttributes struct {
+	UseDirectBlit              bool
+	UseGPU                     bool
+	HasMetadata                bool
+	ActionScript3              bool
+	SuppressCrossDomainCaching bool
+	SWFRelativeURLs            bool
+	UseNetwork                 bool
+}
+
+func (a *FileAttributes) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var (
+		flags    [4]uint8
+		reserved bool
+	)
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags[0], &reserved, &a.UseDirectBlit, &a.UseGPU, &a.HasMetadata, &a.ActionScript3, &a.SuppressCrossDomainCaching, &a.SWFRelativeURLs, &a.UseNetwork)
+	return
+}
+
+func (a *FileAttributes) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	err = binary.Write(c, binary.LittleEndian, [4]uint8{packFlags(false, a.UseDirectBlit, a.UseGPU, a.HasMetadata, a.ActionScript3, a.SuppressCrossDomainCaching, a.SWFRelativeURLs, a.UseNetwork)})
+	return
+}
+
+func (a *FileAttributes) Size(ver uint8, id uint16) int32 {
+	return 4
+}
+
+func (a *FileAttributes) MinVersion() uint8 {
+	return 8
+}
+
+func (a *FileAttributes) TagId() uint16 {
+	return TAG_FILE_ATTRIBUTES
+}
+
+func (a *FileAttributes) Name() string {
+	return "FileAttributes"
+}
+
+type Asset struct {
+	CharacterId uint16
+	Name        String
+}
+
+// ExportAssets names characters so that they may be used by other SWF files.
+type ExportAssets struct {
+	Assets []Asset
+}
+
+func (e *ExportAssets) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var count uint16
+	if err = binary.Read(c, binary.LittleEndian, &count); err != nil {
+		return
+	}
+	e.Assets = make([]Asset, count)
+	for n := range e.Assets {
+		if err = binary.Read(c, binary.LittleEndian, &e.Assets[n].CharacterId); err != nil {
+			return
+		}
+		if _, err = e.Assets[n].Name.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (e *ExportAssets) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, uint16(len(e.Assets))); err != nil {
+		return
+	}
+	for _, a := range e.Assets {
+		if err = binary.Write(c, binary.LittleEndian, a.CharacterId); err != nil {
+			return
+		}
+		if _, err = a.Name.WriteTo(c); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (e *ExportAssets) Size(ver uint8, id uint16) int32 {
+	size := int32(2)
+	for _, a := range e.Assets {
+		size += 2 + a.Name.Size()
+	}
+	return size
+}
+
+func (e *ExportAssets) MinVersion() uint8 {
+	return 5
+}
+
+func (e *ExportAssets) TagId() uint16 {
+	return TAG_EXPORT_ASSETS
+}
+
+func (e *ExportAssets) Name() string {
+	return "ExportAssets"
+}
+
+// SymbolClass links characters to ActionScript 3 classes, with the Name of
+// each asset being the fully qualified class name. Character 0 is the main
+// timeline.
+type SymbolClass struct {
+	ExportAssets
+}
+
+func (s *SymbolClass) MinVersion() uint8 {
+	return 9
+}
+
+func (s *SymbolClass) TagId() uint16 {
+	return TAG_SYMBOL_CLASS
+}
+
+func (s *SymbolClass) Name() string {
+	return "SymbolClass"
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+)
+
+const (
+	CSM_HINT_THIN uint8 = iota
+	CSM_HINT_MEDIUM
+	CSM_HINT_THICK
+)
+
+const (
+	TEXT_RENDERER_NORMAL uint8 = iota
+	TEXT_RENDERER_ADVANCED
+)
+
+const (
+	GRID_FIT_NONE uint8 = iota
+	GRID_FIT_PIXEL
+	GRID_FIT_SUBPIXEL
+)
+
+type ZoneData struct {
+	AlignmentCoordinate, Range Float16
+}
+
+type ZoneRecord struct {
+	ZoneData             []ZoneData
+	ZoneMaskX, ZoneMaskY bool
+}
+
+func (z *ZoneRecord) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var num, mask uint8
+	if err = binary.Read(c, binary.LittleEndian, &num); err != nil {
+		return
+	}
+	z.ZoneData = make([]ZoneData, num)
+	for n := range z.ZoneData {
+		if err = ReadAll(c, &z.ZoneData[n].AlignmentCoordinate, &z.ZoneData[n].Range); err != nil {
+			return
+		}
+	}
+	if err = binary.Read(c, binary.LittleEndian, &mask); err != nil {
+		return
+	}
+	z.ZoneMaskY = mask&2 != 0
+	z.ZoneMaskX = mask&1 != 0
+	return
+}
+
+func (z *ZoneRecord) WriteTo(f io.Writer) (total int64, err error) {
+	if len(z.ZoneData) > 255 {
+		err = errors.New("zoneRecord: too many zones")
+		return
+	}
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, uint8(len(z.ZoneData))); err != nil {
+		return
+	}
+	for n := range z.ZoneData {
+		if err = WriteAll(c, &z.ZoneData[n].AlignmentCoordinate, &z.ZoneData[n].Range); err != nil {
+			return
+		}
+	}
+	err = binary.Write(c, binary.LittleEndian, packFlags(z.ZoneMaskY, z.ZoneMaskX))
+	return
+}
+
+func (z *ZoneRecord) Size() int32 {
+	return 1 + 4*int32(len(z.ZoneData)) + 1
+}
+
+type DefineFontAlignZones struct {
+	FontId       uint16
+	CSMTableHint uint8
+	ZoneTable    []ZoneRecord
+}
+
+func (d *DefineFontAlignZones) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var hint uint8
+	if err = binary.Read(c, binary.LittleEndian, &d.FontId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &hint); err != nil {
+		return
+	}
+	d.CSMTableHint = hint >> 6
+	d.ZoneTable = nil
+	for {
+		var z ZoneRecord
+		if n, e := z.ReadFrom(c); e == io.EOF && n == 0 {
+			break
+		} else if e == io.EOF {
+			err = io.ErrUnexpectedEOF
+			return
+		} else if err = e; err != nil {
+			return
+		}
+		d.ZoneTable = append(d.ZoneTable, z)
+	}
+	return
+}
+
+func (d *DefineFontAlignZones) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.FontId); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, d.CSMTableHint<<6); err != nil {
+		return
+	}
+	for n := range d.ZoneTable {
+		if _, err = d.ZoneTable[n].WriteTo(c); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (d *DefineFontAlignZones) Size(ver uint8, id uint16) int32 {
+	total := int32(3)
+	for n := range d.ZoneTable {
+		total += d.ZoneTable[n].Size()
+	}
+	return total
+}
+
+func (d *DefineFontAlignZones) MinVersion() uint8 {
+	return 8
+}
+
+func (d *DefineFontAlignZones) TagId() uint16 {
+	return TAG_DEFINE_FONT_ALIGN_ZONES
+}
+
+func (d *DefineFontAlignZones) Name() string {
+	return "DefineFontAlignZones"
+}
+
+type CSMTextSettings struct {
+	TextId       uint16
+	UseFlashType uint8
+	GridFit      uint8
+	Thickness    Float
+	Sharpness    Float
+}
+
+func (t *CSMTextSettings) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags, reserved uint8
+	if err = binary.Read(c, binary.LittleEndian, &t.TextId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	t.UseFlashType = flags >> 6
+	t.GridFit = flags >> 3 & 7
+	if err = ReadAll(c, &t.Thickness, &t.Sharpness); err != nil {
+		return
+	}
+	err = binary.Read(c, binary.LittleEndian, &reserved)
+	return
+}
+
+func (t *CSMTextSettings) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, t.TextId); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, (t.UseFlashType&3)<<6|(t.GridFit&7)<<3); err != nil {
+		return
+	}
+	if err = WriteAll(c, &t.Thickness, &t.Sharpness); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, uint8(0))
+	return
+}
+
+func (t *CSMTextSettings) Size(ver uint8, id uint16) int32 {
+	return 12
+}
+
+func (t *CSMTextSettings) MinVersion() uint8 {
+	return 8
+}
+
+func (t *CSMTextSettings) TagId() uint16 {
+	return TAG_CSM_TEXT_SETTINGS
+}
+
+func (t *CSMTextSettings) Name() string {
+	return "CSMTextSettings"
+}
@@ -0,0 +1,37 @@
+package swf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestDefineFontAlignZones(t *testing.T) {
+	testTag(t, 8, []byte{4, 0, 0x40, 2, 0, 60, 0, 0, 0, 128, 1, 60, 3, 2, 0, 0, 0, 0, 0, 0, 0, 0, 1}, &DefineFontAlignZones{
+		FontId:       4,
+		CSMTableHint: CSM_HINT_MEDIUM,
+		ZoneTable: []ZoneRecord{
+			{[]ZoneData{{1, 0}, {0, 1.0009765625}}, true, true},
+			{[]ZoneData{{0, 0}, {0, 0}}, true, false},
+		},
+	})
+	for _, data := range [][]byte{
+		{4, 0, 0x40, 2},
+		{4, 0, 0x40, 1, 0, 60, 0},
+		{4, 0, 0x40, 1, 0, 60, 0, 0},
+	} {
+		if _, err := new(DefineFontAlignZones).ReadFrom(bytes.NewReader(data), 8, TAG_DEFINE_FONT_ALIGN_ZONES); err != io.ErrUnexpectedEOF {
+			t.Errorf("%v: expecting error %s, got %v", data, io.ErrUnexpectedEOF, err)
+		}
+	}
+}
+
+func TestCSMTextSettings(t *testing.T) {
+	testTag(t, 8, []byte{9, 0, 0x50, 0, 0, 0x44, 0x41, 0, 0, 0x80, 0xbf, 0}, &CSMTextSettings{
+		TextId:       9,
+		UseFlashType: TEXT_RENDERER_ADVANCED,
+		GridFit:      GRID_FIT_SUBPIXEL,
+		Thickness:    12.25,
+		Sharpness:    -1,
+	})
+}
@@ -0,0 +1,877 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	precAssign = iota + 1
+	precTernary
+	precOr
+	precAnd
+	precBitOr
+	precBitXor
+	precBitAnd
+	precEquality
+	precRelational
+	precShift
+	precAdd
+	precMultiply
+	precUnary
+	precPostfix
+	precPrimary
+)
+
+var propertyNames = [...]string{"_x", "_y", "_xscale", "_yscale", "_currentframe", "_totalframes", "_alpha", "_visible", "_width", "_height", "_rotation", "_target", "_framesloaded", "_name", "_droptarget", "_url", "_highquality", "_focusrect", "_soundbuftime", "_quality", "_xmouse", "_ymouse"}
+
+// asExpr is a decompiled expression, along with the precedence of its
+// outermost operator.
+type asExpr struct {
+	text  string
+	prec  int
+	value PushValue // for literals
+	not   *asExpr   // for negations
+	inc   *asExpr   // for x + 1 and x - 1
+	op    string
+}
+
+func (e *asExpr) wrap(prec int) string {
+	if e.prec < prec {
+		return "(" + e.text + ")"
+	}
+	return e.text
+}
+
+func asName(name string) *asExpr {
+	return &asExpr{text: name, prec: precPrimary}
+}
+
+func asBinary(a *asExpr, op string, prec int, b *asExpr) *asExpr {
+	return &asExpr{text: a.wrap(prec) + " " + op + " " + b.wrap(prec+1), prec: prec}
+}
+
+func asUnary(op string, a *asExpr) *asExpr {
+	e := &asExpr{text: op + a.wrap(precUnary), prec: precUnary}
+	if op == "!" {
+		e.not = a
+	}
+	return e
+}
+
+func asCall(callee string, args []*asExpr) *asExpr {
+	texts := make([]string, len(args))
+	for n, a := range args {
+		texts[n] = a.wrap(precAssign)
+	}
+	return asName(callee + "(" + strings.Join(texts, ", ") + ")")
+}
+
+func asNegate(e *asExpr) *asExpr {
+	if e.not != nil {
+		return e.not
+	}
+	return asUnary("!", e)
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for n, c := range s {
+		if !(c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (n > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPath returns whether the string is a dotted path of identifiers, as may
+// be used directly as a variable name.
+func isPath(s string) bool {
+	for _, p := range strings.Split(s, ".") {
+		if !isIdentifier(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *asExpr) stringValue() (string, bool) {
+	s, ok := e.value.(PushString)
+	return string(s), ok
+}
+
+func (e *asExpr) intValue() (int, bool) {
+	switch v := e.value.(type) {
+	case PushInteger:
+		return int(v), true
+	case PushDouble:
+		return int(v), true
+	case PushFloat:
+		return int(v), true
+	}
+	return 0, false
+}
+
+func asNumber(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+func asIndent(lines []string) []string {
+	indented := make([]string, len(lines))
+	for n, l := range lines {
+		indented[n] = "\t" + l
+	}
+	return indented
+}
+
+type asScope struct {
+	registers map[uint8]string
+	declared  map[uint8]bool
+}
+
+func (s *asScope) register(r uint8) string {
+	if name, ok := s.registers[r]; ok {
+		return name
+	}
+	return "r" + strconv.Itoa(int(r))
+}
+
+type asLoop struct {
+	header, exit int
+}
+
+type decompiler struct {
+	actions  []Action
+	offsets  []int
+	index    map[int]int
+	whileEnd map[int]int
+	doEnd    map[int]int
+	pool     []String
+}
+
+func newDecompiler(actions []Action) *decompiler {
+	d := &decompiler{
+		actions:  actions,
+		offsets:  actionOffsets(actions),
+		index:    make(map[int]int),
+		whileEnd: make(map[int]int),
+		doEnd:    make(map[int]int),
+	}
+	for n, o := range d.offsets {
+		d.index[o] = n
+	}
+	for n, action := range actions {
+		var loops map[int]int
+		switch action.(type) {
+		case *ActionJump:
+			loops = d.whileEnd
+		case *ActionIf:
+			loops = d.doEnd
+		default:
+			continue
+		}
+		if h, ok := d.target(n); ok && h <= n {
+			if e, ok := loops[h]; !ok || e < n {
+				loops[h] = n
+			}
+		}
+	}
+	return d
+}
+
+// target returns the index of the action targeted by the branch at index n.
+func (d *decompiler) target(n int) (int, bool) {
+	var offset int16
+	switch a := d.actions[n].(type) {
+	case *ActionJump:
+		offset = a.BranchOffset
+	case *ActionIf:
+		offset = a.BranchOffset
+	default:
+		return 0, false
+	}
+	t, ok := d.index[d.offsets[n+1]+int(offset)]
+	return t, ok
+}
+
+// blockEnd returns the index of the action size bytes after the end of the
+// action at index n.
+func (d *decompiler) blockEnd(n int, size uint16) (int, bool) {
+	e, ok := d.index[d.offsets[n+1]+int(size)]
+	return e, ok
+}
+
+// expr decompiles the actions in the range as a single expression.
+func (d *decompiler) expr(from, to int, scope *asScope, loops []asLoop) (*asExpr, bool) {
+	lines, stack := d.block(from, to, -1, scope, loops)
+	if len(lines) > 0 || len(stack) != 1 {
+		return nil, false
+	}
+	return stack[0], true
+}
+
+// block decompiles the actions in the range as a list of statements,
+// returning any values left on the stack. Loop detection is skipped for the
+// action at index skip, so that the body of a loop can be decompiled.
+func (d *decompiler) block(from, to, skip int, scope *asScope, loops []asLoop) (lines []string, stack []*asExpr) {
+	pop := func() *asExpr {
+		if len(stack) == 0 {
+			return &asExpr{text: "undefined", prec: precPrimary, value: PushUndefined{}}
+		}
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return e
+	}
+	popArgs := func() []*asExpr {
+		count, _ := pop().intValue()
+		if count < 0 || count > len(stack) {
+			count = len(stack)
+		}
+		args := make([]*asExpr, count)
+		for n := range args {
+			args[n] = pop()
+		}
+		return args
+	}
+	push := func(e *asExpr) {
+		stack = append(stack, e)
+	}
+	emit := func(s string) {
+		lines = append(lines, strings.Split(s, "\n")...)
+	}
+	member := func(obj, name *asExpr) string {
+		if s, ok := name.stringValue(); ok && isIdentifier(s) {
+			return obj.wrap(precPrimary) + "." + s
+		}
+		return obj.wrap(precPrimary) + "[" + name.text + "]"
+	}
+	assign := func(target string, value *asExpr) string {
+		if value.inc != nil && value.inc.text == target {
+			return target + value.op
+		}
+		return target + " = " + value.wrap(precAssign)
+	}
+	nested := func(head string, from, to, skip int, loops []asLoop) {
+		body, _ := d.block(from, to, skip, scope, loops)
+		emit(head + " {")
+		lines = append(lines, asIndent(body)...)
+		emit("}")
+	}
+	binary := map[uint8]struct {
+		op   string
+		prec int
+	}{
+		ACTION_ADD:            {"+", precAdd},
+		ACTION_ADD2:           {"+", precAdd},
+		ACTION_SUBTRACT:       {"-", precAdd},
+		ACTION_MULTIPLY:       {"*", precMultiply},
+		ACTION_DIVIDE:         {"/", precMultiply},
+		ACTION_MODULO:         {"%", precMultiply},
+		ACTION_EQUALS:         {"==", precEquality},
+		ACTION_EQUALS2:        {"==", precEquality},
+		ACTION_STRICT_EQUALS:  {"===", precEquality},
+		ACTION_LESS:           {"<", precRelational},
+		ACTION_LESS2:          {"<", precRelational},
+		ACTION_GREATER:        {">", precRelational},
+		ACTION_INSTANCE_OF:    {"instanceof", precRelational},
+		ACTION_AND:            {"&&", precAnd},
+		ACTION_OR:             {"||", precOr},
+		ACTION_STRING_EQUALS:  {"eq", precEquality},
+		ACTION_STRING_LESS:    {"lt", precRelational},
+		ACTION_STRING_GREATER: {"gt", precRelational},
+		ACTION_STRING_ADD:     {"add", precAdd},
+		ACTION_BIT_AND:        {"&", precBitAnd},
+		ACTION_BIT_OR:         {"|", precBitOr},
+		ACTION_BIT_XOR:        {"^", precBitXor},
+		ACTION_BIT_LSHIFT:     {"<<", precShift},
+		ACTION_BIT_RSHIFT:     {">>", precShift},
+		ACTION_BIT_URSHIFT:    {">>>", precShift},
+	}
+	functions := map[uint8]string{
+		ACTION_TO_INTEGER:       "int",
+		ACTION_TO_NUMBER:        "Number",
+		ACTION_TO_STRING:        "String",
+		ACTION_STRING_LENGTH:    "length",
+		ACTION_MB_STRING_LENGTH: "mblength",
+		ACTION_CHAR_TO_ASCII:    "ord",
+		ACTION_ASCII_TO_CHAR:    "chr",
+		ACTION_MB_CHAR_TO_ASCII: "mbord",
+		ACTION_MB_ASCII_TO_CHAR: "mbchr",
+		ACTION_RANDOM_NUMBER:    "random",
+		ACTION_TARGET_PATH:      "targetPath",
+	}
+	statements := map[uint8]string{
+		ACTION_NEXT_FRAME:     "nextFrame();",
+		ACTION_PREVIOUS_FRAME: "prevFrame();",
+		ACTION_PLAY:           "play();",
+		ACTION_STOP:           "stop();",
+		ACTION_TOGGLE_QUALITY: "toggleHighQuality();",
+		ACTION_STOP_SOUNDS:    "stopAllSounds();",
+		ACTION_END_DRAG:       "stopDrag();",
+	}
+	for i := from; i < to; i++ {
+		if i != skip {
+			w, isWhile := d.whileEnd[i]
+			e, isDo := d.doEnd[i]
+			if isWhile && w < to && (!isDo || e < w) {
+				exit := w + 1
+				loop := append(loops[:len(loops):len(loops)], asLoop{i, exit})
+				k := -1
+				for n := i; n < w; n++ {
+					if _, ok := d.actions[n].(*ActionIf); ok {
+						if t, ok := d.target(n); ok && t == exit {
+							k = n
+							break
+						}
+					}
+				}
+				if k >= 0 {
+					if cond, ok := d.expr(i, k, scope, loops); ok {
+						nested("while ("+asNegate(cond).text+")", k+1, w, -1, loop)
+						i = w
+						continue
+					}
+				}
+				nested("while (true)", i, w, i, loop)
+				i = w
+				continue
+			}
+			if isDo && e < to {
+				body, rest := d.block(i, e, i, scope, append(loops[:len(loops):len(loops)], asLoop{i, e + 1}))
+				cond := "true"
+				if len(rest) > 0 {
+					cond = rest[len(rest)-1].text
+				}
+				emit("do {")
+				lines = append(lines, asIndent(body)...)
+				emit("} while (" + cond + ");")
+				i = e
+				continue
+			}
+		}
+		switch a := d.actions[i].(type) {
+		case BasicAction:
+			code := uint8(a)
+			if b, ok := binary[code]; ok {
+				y, x := pop(), pop()
+				push(asBinary(x, b.op, b.prec, y))
+				continue
+			}
+			if f, ok := functions[code]; ok {
+				push(asCall(f, []*asExpr{pop()}))
+				continue
+			}
+			if s, ok := statements[code]; ok {
+				emit(s)
+				continue
+			}
+			switch code {
+			case ACTION_POP:
+				if e := pop(); e.value == nil {
+					emit(e.text + ";")
+				}
+			case ACTION_NOT:
+				push(asUnary("!", pop()))
+			case ACTION_TYPE_OF:
+				push(asUnary("typeof ", pop()))
+			case ACTION_INCREMENT, ACTION_DECREMENT:
+				x := pop()
+				e := asBinary(x, "+", precAdd, asName("1"))
+				e.op = "++"
+				if code == ACTION_DECREMENT {
+					e = asBinary(x, "-", precAdd, asName("1"))
+					e.op = "--"
+				}
+				e.inc = x
+				push(e)
+			case ACTION_PUSH_DUPLICATE:
+				e := pop()
+				push(e)
+				push(e)
+			case ACTION_STACK_SWAP:
+				y, x := pop(), pop()
+				push(y)
+				push(x)
+			case ACTION_GET_VARIABLE:
+				name := pop()
+				if s, ok := name.stringValue(); ok && isPath(s) {
+					push(asName(s))
+				} else {
+					push(asCall("eval", []*asExpr{name}))
+				}
+			case ACTION_SET_VARIABLE:
+				value, name := pop(), pop()
+				if s, ok := name.stringValue(); ok && isPath(s) {
+					emit(assign(s, value) + ";")
+				} else {
+					emit(asCall("set", []*asExpr{name, value}).text + ";")
+				}
+			case ACTION_DEFINE_LOCAL:
+				value, name := pop(), pop()
+				if s, ok := name.stringValue(); ok && isIdentifier(s) {
+					emit("var " + s + " = " + value.wrap(precAssign) + ";")
+				} else {
+					emit(asCall("set", []*asExpr{name, value}).text + ";")
+				}
+			case ACTION_DEFINE_LOCAL2:
+				name := pop()
+				if s, ok := name.stringValue(); ok && isIdentifier(s) {
+					emit("var " + s + ";")
+				} else {
+					emit("var " + name.text + ";")
+				}
+			case ACTION_GET_MEMBER:
+				name, obj := pop(), pop()
+				push(asName(member(obj, name)))
+			case ACTION_SET_MEMBER:
+				value, name, obj := pop(), pop(), pop()
+				emit(assign(member(obj, name), value) + ";")
+			case ACTION_DELETE:
+				name, obj := pop(), pop()
+				push(asUnary("delete ", asName(member(obj, name))))
+			case ACTION_DELETE2:
+				name := pop()
+				if s, ok := name.stringValue(); ok && isPath(s) {
+					push(asUnary("delete ", asName(s)))
+				} else {
+					push(asUnary("delete ", name))
+				}
+			case ACTION_CALL_FUNCTION:
+				name := pop()
+				args := popArgs()
+				if s, ok := name.stringValue(); ok && isPath(s) {
+					push(asCall(s, args))
+				} else {
+					push(asCall(asCall("eval", []*asExpr{name}).text, args))
+				}
+			case ACTION_CALL_METHOD:
+				name, obj := pop(), pop()
+				args := popArgs()
+				if s, ok := name.stringValue(); (ok && s == "") || name.value == (PushUndefined{}) {
+					push(asCall(obj.wrap(precPrimary), args))
+				} else {
+					push(asCall(member(obj, name), args))
+				}
+			case ACTION_NEW_OBJECT:
+				name := pop()
+				args := popArgs()
+				callee := name.text
+				if s, ok := name.stringValue(); ok && isPath(s) {
+					callee = s
+				}
+				e := asCall("new "+callee, args)
+				e.prec = precUnary
+				push(e)
+			case ACTION_NEW_METHOD:
+				name, obj := pop(), pop()
+				args := popArgs()
+				e := asCall("new "+member(obj, name), args)
+				e.prec = precUnary
+				push(e)
+			case ACTION_INIT_ARRAY:
+				elements := popArgs()
+				texts := make([]string, len(elements))
+				for n, e := range elements {
+					texts[n] = e.wrap(precAssign)
+				}
+				push(asName("[" + strings.Join(texts, ", ") + "]"))
+			case ACTION_INIT_OBJECT:
+				count, _ := pop().intValue()
+				if count < 0 || 2*count > len(stack) {
+					count = len(stack) / 2
+				}
+				texts := make([]string, count)
+				for n := count - 1; n >= 0; n-- {
+					value, name := pop(), pop()
+					key := name.text
+					if s, ok := name.stringValue(); ok && isIdentifier(s) {
+						key = s
+					}
+					texts[n] = key + ": " + value.wrap(precAssign)
+				}
+				push(asName("{" + strings.Join(texts, ", ") + "}"))
+			case ACTION_RETURN:
+				if e := pop(); e.value == (PushUndefined{}) {
+					emit("return;")
+				} else {
+					emit("return " + e.text + ";")
+				}
+			case ACTION_TRACE:
+				emit(asCall("trace", []*asExpr{pop()}).text + ";")
+			case ACTION_THROW:
+				emit("throw " + pop().text + ";")
+			case ACTION_GET_TIME:
+				push(asName("getTimer()"))
+			case ACTION_STRING_EXTRACT, ACTION_MB_STRING_EXTRACT:
+				count, index, str := pop(), pop(), pop()
+				f := "substring"
+				if code == ACTION_MB_STRING_EXTRACT {
+					f = "mbsubstring"
+				}
+				push(asCall(f, []*asExpr{str, index, count}))
+			case ACTION_GET_PROPERTY:
+				index, target := pop(), pop()
+				push(d.property(target, index))
+			case ACTION_SET_PROPERTY:
+				value, index, target := pop(), pop(), pop()
+				if s, ok := target.stringValue(); ok && s == "" {
+					emit(assign(d.property(target, index).text, value) + ";")
+				} else {
+					emit(asCall("setProperty", []*asExpr{target, asName(d.property(asName(""), index).text), value}).text + ";")
+				}
+			case ACTION_SET_TARGET2:
+				emit(asCall("setTarget", []*asExpr{pop()}).text + ";")
+			case ACTION_CLONE_SPRITE:
+				depth, target, source := pop(), pop(), pop()
+				emit(asCall("duplicateMovieClip", []*asExpr{source, target, depth}).text + ";")
+			case ACTION_REMOVE_SPRITE:
+				emit(asCall("removeMovieClip", []*asExpr{pop()}).text + ";")
+			case ACTION_START_DRAG:
+				target, lock, constrain := pop(), pop(), pop()
+				args := []*asExpr{target, lock}
+				if c, ok := constrain.intValue(); !ok || c != 0 {
+					y2, x2, y1, x1 := pop(), pop(), pop(), pop()
+					args = append(args, x1, y1, x2, y2)
+				}
+				emit(asCall("startDrag", args).text + ";")
+			case ACTION_ENUMERATE, ACTION_ENUMERATE2:
+				push(asCall("enumerate", []*asExpr{pop()}))
+			case ACTION_CAST_OP:
+				obj, constructor := pop(), pop()
+				push(asCall(constructor.wrap(precPrimary), []*asExpr{obj}))
+			case ACTION_IMPLEMENTS_OP:
+				constructor := pop()
+				interfaces := popArgs()
+				texts := make([]string, len(interfaces))
+				for n, e := range interfaces {
+					texts[n] = e.text
+				}
+				emit(constructor.text + " implements " + strings.Join(texts, ", ") + ";")
+			case ACTION_EXTENDS:
+				super, sub := pop(), pop()
+				emit(sub.text + " extends " + super.text + ";")
+			default:
+				emit("// " + ActionName(code))
+			}
+		case *ActionPush:
+			for _, v := range a.Values {
+				push(d.literal(v, scope))
+			}
+		case *ActionConstantPool:
+			d.pool = a.Constants
+		case *ActionStoreRegister:
+			e := pop()
+			name := scope.register(a.Register)
+			if i+1 < to && d.actions[i+1] == Action(BasicAction(ACTION_POP)) {
+				i++
+			} else {
+				push(asName(name))
+			}
+			if !scope.declared[a.Register] {
+				scope.declared[a.Register] = true
+				if _, ok := scope.registers[a.Register]; !ok {
+					name = "var " + name
+				}
+			}
+			emit(assign(name, e) + ";")
+		case *ActionGotoFrame:
+			if i+1 < to && d.actions[i+1] == Action(BasicAction(ACTION_PLAY)) {
+				emit("gotoAndPlay(" + strconv.Itoa(int(a.Frame)+1) + ");")
+				i++
+			} else {
+				emit("gotoAndStop(" + strconv.Itoa(int(a.Frame)+1) + ");")
+			}
+		case *ActionGotoFrame2:
+			f := "gotoAndStop"
+			if a.Play {
+				f = "gotoAndPlay"
+			}
+			emit(asCall(f, []*asExpr{pop()}).text + ";")
+		case *ActionGoToLabel:
+			if i+1 < to && d.actions[i+1] == Action(BasicAction(ACTION_PLAY)) {
+				emit("gotoAndPlay(" + strconv.Quote(string(a.Label)) + ");")
+				i++
+			} else {
+				emit("gotoAndStop(" + strconv.Quote(string(a.Label)) + ");")
+			}
+		case *ActionGetURL:
+			emit("getURL(" + strconv.Quote(string(a.URL)) + ", " + strconv.Quote(string(a.Target)) + ");")
+		case *ActionGetURL2:
+			target, url := pop(), pop()
+			args := []*asExpr{url, target}
+			if a.SendVarsMethod == SEND_VARS_GET {
+				args = append(args, asName(`"GET"`))
+			} else if a.SendVarsMethod == SEND_VARS_POST {
+				args = append(args, asName(`"POST"`))
+			}
+			f := "getURL"
+			if a.LoadVariables {
+				f = "loadVariables"
+			} else if a.LoadTarget {
+				f = "loadMovie"
+			}
+			emit(asCall(f, args).text + ";")
+		case *ActionSetTarget:
+			emit("setTarget(" + strconv.Quote(string(a.TargetName)) + ");")
+		case *ActionWaitForFrame:
+			end := min(int32(i+1+int(a.SkipCount)), int32(to))
+			nested("ifFrameLoaded("+strconv.Itoa(int(a.Frame)+1)+")", i+1, int(end), -1, loops)
+			i = int(end) - 1
+		case *ActionWaitForFrame2:
+			end := min(int32(i+1+int(a.SkipCount)), int32(to))
+			nested("ifFrameLoaded("+pop().text+")", i+1, int(end), -1, loops)
+			i = int(end) - 1
+		case *ActionCall:
+			emit(asCall("call", []*asExpr{pop()}).text + ";")
+		case *ActionDefineFunction, *ActionDefineFunction2:
+			var (
+				name   String
+				params []string
+				size   uint16
+			)
+			fscope := &asScope{registers: make(map[uint8]string), declared: make(map[uint8]bool)}
+			if f, ok := a.(*ActionDefineFunction); ok {
+				name, size = f.FunctionName, f.CodeSize
+				for _, p := range f.Params {
+					params = append(params, string(p))
+				}
+			} else {
+				f := a.(*ActionDefineFunction2)
+				name, size = f.FunctionName, f.CodeSize
+				for n, r := range f.PreloadedRegisters() {
+					fscope.registers[r] = n
+				}
+				for _, p := range f.Parameters {
+					params = append(params, string(p.ParamName))
+					if p.Register != 0 {
+						fscope.registers[p.Register] = string(p.ParamName)
+					}
+				}
+			}
+			end, ok := d.blockEnd(i, size)
+			if !ok || end > to {
+				emit("// unable to decompile function " + string(name))
+				continue
+			}
+			body, _ := d.block(i+1, end, -1, fscope, nil)
+			text := "function " + string(name) + "(" + strings.Join(params, ", ") + ") {\n"
+			for _, l := range asIndent(body) {
+				text += l + "\n"
+			}
+			text += "}"
+			if name == "" {
+				push(asName(text))
+			} else {
+				emit(text)
+			}
+			i = end - 1
+		case *ActionWith:
+			end, ok := d.blockEnd(i, a.Size)
+			if !ok || end > to {
+				emit("// unable to decompile with block")
+				continue
+			}
+			nested("with ("+pop().text+")", i+1, end, -1, loops)
+			i = end - 1
+		case *ActionTry:
+			tryEnd, ok1 := d.blockEnd(i, a.TrySize)
+			catchEnd, ok2 := d.blockEnd(i, a.TrySize+a.CatchSize)
+			finallyEnd, ok3 := d.blockEnd(i, a.TrySize+a.CatchSize+a.FinallySize)
+			if !ok1 || !ok2 || !ok3 || finallyEnd > to {
+				emit("// unable to decompile try block")
+				continue
+			}
+			// the try and catch blocks end with a jump past the
+			// following blocks
+			trim := func(from, to int) int {
+				if to > from {
+					if t, ok := d.target(to - 1); ok && t >= to {
+						if _, ok := d.actions[to-1].(*ActionJump); ok {
+							return to - 1
+						}
+					}
+				}
+				return to
+			}
+			nested("try", i+1, trim(i+1, tryEnd), -1, loops)
+			if a.CatchBlock {
+				name := string(a.CatchName)
+				if a.CatchInRegister {
+					name = scope.register(a.CatchRegister)
+				}
+				lines = lines[:len(lines)-1]
+				nested("} catch ("+name+")", tryEnd, trim(tryEnd, catchEnd), -1, loops)
+			}
+			if a.FinallyBlock {
+				lines = lines[:len(lines)-1]
+				nested("} finally", catchEnd, finallyEnd, -1, loops)
+			}
+			i = finallyEnd - 1
+		case *ActionJump:
+			t, ok := d.target(i)
+			switch {
+			case ok && len(loops) > 0 && t == loops[len(loops)-1].exit:
+				emit("break;")
+			case ok && len(loops) > 0 && t == loops[len(loops)-1].header:
+				emit("continue;")
+			default:
+				emit("// jump " + strconv.Itoa(int(a.BranchOffset)))
+			}
+		case *ActionIf:
+			cond := pop()
+			t, ok := d.target(i)
+			if !ok || t <= i || t > to {
+				switch {
+				case ok && len(loops) > 0 && t == loops[len(loops)-1].exit:
+					emit("if (" + cond.text + ") break;")
+				case ok && len(loops) > 0 && t == loops[len(loops)-1].header:
+					emit("if (" + cond.text + ") continue;")
+				default:
+					emit("if (" + cond.text + ") // jump " + strconv.Itoa(int(a.BranchOffset)))
+				}
+				continue
+			}
+			if i+1 < t && d.actions[i+1] == Action(BasicAction(ACTION_POP)) && i > from && len(stack) > 0 {
+				// a && b and a || b leave a on the stack when they
+				// short-circuit
+				op := ""
+				if d.actions[i-1] == Action(BasicAction(ACTION_PUSH_DUPLICATE)) {
+					op = "||"
+				} else if i-1 > from && d.actions[i-1] == Action(BasicAction(ACTION_NOT)) && d.actions[i-2] == Action(BasicAction(ACTION_PUSH_DUPLICATE)) {
+					op = "&&"
+				}
+				if op != "" {
+					if rhs, ok := d.expr(i+2, t, scope, loops); ok {
+						prec := precOr
+						if op == "&&" {
+							prec = precAnd
+						}
+						push(asBinary(pop(), op, prec, rhs))
+						i = t - 1
+						continue
+					}
+				}
+			}
+			if _, ok := d.actions[t-1].(*ActionJump); ok && t-1 > i {
+				if e, ok := d.target(t - 1); ok && e >= t && e <= to {
+					f, okf := d.expr(i+1, t-1, scope, loops)
+					tr, okt := d.expr(t, e, scope, loops)
+					if okf && okt {
+						push(&asExpr{text: cond.wrap(precOr) + " ? " + tr.wrap(precAssign) + " : " + f.wrap(precAssign), prec: precTernary})
+						i = e - 1
+						continue
+					}
+					then, _ := d.block(i+1, t-1, -1, scope, loops)
+					els, _ := d.block(t, e, -1, scope, loops)
+					emit("if (" + asNegate(cond).text + ") {")
+					lines = append(lines, asIndent(then)...)
+					emit("} else {")
+					lines = append(lines, asIndent(els)...)
+					emit("}")
+					i = e - 1
+					continue
+				}
+			}
+			nested("if ("+asNegate(cond).text+")", i+1, t, -1, loops)
+			i = t - 1
+		case *UnknownAction:
+			emit("// " + ActionName(a.Code))
+		}
+	}
+	return lines, stack
+}
+
+func (d *decompiler) literal(v PushValue, scope *asScope) *asExpr {
+	switch v := v.(type) {
+	case PushString:
+		return &asExpr{text: strconv.Quote(string(v)), prec: precPrimary, value: v}
+	case PushFloat:
+		return &asExpr{text: asNumber(float64(v), 32), prec: precPrimary, value: v}
+	case PushDouble:
+		return &asExpr{text: asNumber(float64(v), 64), prec: precPrimary, value: v}
+	case PushInteger:
+		return &asExpr{text: strconv.Itoa(int(v)), prec: precPrimary, value: v}
+	case PushBoolean:
+		return &asExpr{text: strconv.FormatBool(v != 0), prec: precPrimary, value: v}
+	case PushNull:
+		return &asExpr{text: "null", prec: precPrimary, value: v}
+	case PushUndefined:
+		return &asExpr{text: "undefined", prec: precPrimary, value: v}
+	case PushRegister:
+		return asName(scope.register(uint8(v)))
+	case PushConstant8:
+		return d.literal(d.constant(int(v)), scope)
+	case PushConstant16:
+		return d.literal(d.constant(int(v)), scope)
+	}
+	return asName("undefined")
+}
+
+func (d *decompiler) constant(n int) PushValue {
+	if n < len(d.pool) {
+		return PushString(d.pool[n])
+	}
+	return PushUndefined{}
+}
+
+func (d *decompiler) property(target, index *asExpr) *asExpr {
+	name := index.text
+	if i, ok := index.intValue(); ok && i >= 0 && i < len(propertyNames) {
+		name = propertyNames[i]
+	}
+	if s, ok := target.stringValue(); ok && s == "" {
+		return asName(name)
+	}
+	return asCall("getProperty", []*asExpr{target, asName(name)})
+}
+
+// Decompile writes ActionScript 2 source reconstructed from the actions.
+// Branches that do not match a recognised if, loop or conditional expression
+// are written as comments.
+func Decompile(w io.Writer, actions []Action) error {
+	d := newDecompiler(actions)
+	lines, _ := d.block(0, len(actions), -1, &asScope{registers: make(map[uint8]string), declared: make(map[uint8]bool)}, nil)
+	b := bufio.NewWriter(w)
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.Flush()
+}
@@ -0,0 +1,175 @@
+package swf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecompile(t *testing.T) {
+	tests := []struct {
+		listing, source string
+	}{
+		{
+			"\tConstantPool \"x\" \"a\"\n" +
+				"\tPush c8:0 c8:1\n" +
+				"\tGetVariable\n" +
+				"\tPush \"b\"\n" +
+				"\tGetVariable\n" +
+				"\tPush 2\n" +
+				"\tMultiply\n" +
+				"\tAdd2\n" +
+				"\tSetVariable\n" +
+				"\tPush \"x\"\n" +
+				"\tGetVariable\n" +
+				"\tPush 1 \"c\"\n" +
+				"\tGetVariable\n" +
+				"\tSubtract\n" +
+				"\tMultiply\n" +
+				"\tTrace\n" +
+				"\tPush \"a\" 1 2 \"obj\"\n" +
+				"\tGetVariable\n" +
+				"\tPush \"go\"\n" +
+				"\tCallMethod\n" +
+				"\tPop\n" +
+				"\tGotoFrame 4\n" +
+				"\tPlay\n",
+			"x = a + b * 2;\n" +
+				"trace(x * (1 - c));\n" +
+				"obj.go(1, \"a\");\n" +
+				"gotoAndPlay(5);\n",
+		},
+		{
+			"\tPush \"a\"\n" +
+				"\tGetVariable\n" +
+				"\tPush 1\n" +
+				"\tGreater\n" +
+				"\tNot\n" +
+				"\tIf L1\n" +
+				"\tPush \"big\"\n" +
+				"\tTrace\n" +
+				"\tJump L2\n" +
+				"L1:\n" +
+				"\tPush \"small\"\n" +
+				"\tTrace\n" +
+				"L2:\n" +
+				"\tPush \"y\" \"a\"\n" +
+				"\tGetVariable\n" +
+				"\tPushDuplicate\n" +
+				"\tNot\n" +
+				"\tIf L3\n" +
+				"\tPop\n" +
+				"\tPush \"b\"\n" +
+				"\tGetVariable\n" +
+				"L3:\n" +
+				"\tSetVariable\n" +
+				"\tPush \"z\" \"c\"\n" +
+				"\tGetVariable\n" +
+				"\tIf L4\n" +
+				"\tPush 2\n" +
+				"\tJump L5\n" +
+				"L4:\n" +
+				"\tPush 1\n" +
+				"L5:\n" +
+				"\tSetVariable\n",
+			"if (a > 1) {\n" +
+				"\ttrace(\"big\");\n" +
+				"} else {\n" +
+				"\ttrace(\"small\");\n" +
+				"}\n" +
+				"y = a && b;\n" +
+				"z = c ? 1 : 2;\n",
+		},
+		{
+			"\tPush \"i\" 0\n" +
+				"\tSetVariable\n" +
+				"L1:\n" +
+				"\tPush \"i\"\n" +
+				"\tGetVariable\n" +
+				"\tPush 10\n" +
+				"\tLess2\n" +
+				"\tNot\n" +
+				"\tIf L2\n" +
+				"\tPush \"done\"\n" +
+				"\tGetVariable\n" +
+				"\tIf L2\n" +
+				"\tPush \"i\" \"i\"\n" +
+				"\tGetVariable\n" +
+				"\tIncrement\n" +
+				"\tSetVariable\n" +
+				"\tJump L1\n" +
+				"L2:\n" +
+				"L3:\n" +
+				"\tPush \"i\" \"i\"\n" +
+				"\tGetVariable\n" +
+				"\tDecrement\n" +
+				"\tSetVariable\n" +
+				"\tPush \"i\"\n" +
+				"\tGetVariable\n" +
+				"\tPush 0\n" +
+				"\tGreater\n" +
+				"\tIf L3\n",
+			"i = 0;\n" +
+				"while (i < 10) {\n" +
+				"\tif (done) break;\n" +
+				"\ti++;\n" +
+				"}\n" +
+				"do {\n" +
+				"\ti--;\n" +
+				"} while (i > 0);\n",
+		},
+		{
+			"\tDefineFunction2 \"add\" L1 5 preloadThis 2:\"a\" 3:\"b\"\n" +
+				"\tPush r:2 r:3\n" +
+				"\tAdd2\n" +
+				"\tStoreRegister 4\n" +
+				"\tPop\n" +
+				"\tPush r:1 \"x\"\n" +
+				"\tGetMember\n" +
+				"\tPush r:4\n" +
+				"\tAdd2\n" +
+				"\tReturn\n" +
+				"L1:\n" +
+				"\tPush \"f\"\n" +
+				"\tDefineFunction \"\" L2\n" +
+				"\tStop\n" +
+				"L2:\n" +
+				"\tSetVariable\n" +
+				"\tTry L3 L4 L4 catch \"e\"\n" +
+				"\tPush 0 \"f\"\n" +
+				"\tCallFunction\n" +
+				"\tPop\n" +
+				"\tJump L4\n" +
+				"L3:\n" +
+				"\tPush \"e\"\n" +
+				"\tGetVariable\n" +
+				"\tTrace\n" +
+				"L4:\n",
+			"function add(a, b) {\n" +
+				"\tvar r4 = a + b;\n" +
+				"\treturn this.x + r4;\n" +
+				"}\n" +
+				"f = function () {\n" +
+				"\tstop();\n" +
+				"};\n" +
+				"try {\n" +
+				"\tf();\n" +
+				"} catch (e) {\n" +
+				"\ttrace(e);\n" +
+				"}\n",
+		},
+	}
+	for n, test := range tests {
+		actions, err := Assemble(strings.NewReader(test.listing))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err = Decompile(&buf, actions); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if source := buf.String(); source != test.source {
+			t.Errorf("test %d: expecting source:\n%s\ngot:\n%s", n+1, test.source, source)
+		}
+	}
+}
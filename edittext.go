@@ -0,0 +1,270 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"strings"
+)
+
+const (
+	ALIGN_LEFT uint8 = iota
+	ALIGN_RIGHT
+	ALIGN_CENTER
+	ALIGN_JUSTIFY
+)
+
+type DefineEditText struct {
+	CharacterId  uint16
+	Bounds       Rect
+	HasText      bool
+	WordWrap     bool
+	Multiline    bool
+	Password     bool
+	ReadOnly     bool
+	HasTextColor bool
+	HasMaxLength bool
+	HasFont      bool
+	HasFontClass bool
+	AutoSize     bool
+	HasLayout    bool
+	NoSelect     bool
+	Border       bool
+	WasStatic    bool
+	HTML         bool
+	UseOutlines  bool
+	FontId       uint16
+	FontClass    String
+	FontHeight   uint16
+	TextColor    RGBA
+	MaxLength    uint16
+	Align        uint8
+	LeftMargin   uint16
+	RightMargin  uint16
+	Indent       uint16
+	Leading      int16
+	VariableName String
+	InitialText  String
+}
+
+func (d *DefineEditText) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags [2]uint8
+	if err = binary.Read(c, binary.LittleEndian, &d.CharacterId); err != nil {
+		return
+	}
+	if _, err = d.Bounds.ReadFrom(c); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags[0], &d.HasText, &d.WordWrap, &d.Multiline, &d.Password, &d.ReadOnly, &d.HasTextColor, &d.HasMaxLength, &d.HasFont)
+	unpackFlags(flags[1], &d.HasFontClass, &d.AutoSize, &d.HasLayout, &d.NoSelect, &d.Border, &d.WasStatic, &d.HTML, &d.UseOutlines)
+	if d.HasFont {
+		if err = binary.Read(c, binary.LittleEndian, &d.FontId); err != nil {
+			return
+		}
+	}
+	if d.HasFontClass {
+		if _, err = d.FontClass.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	if d.HasFont || d.HasFontClass {
+		if err = binary.Read(c, binary.LittleEndian, &d.FontHeight); err != nil {
+			return
+		}
+	}
+	if d.HasTextColor {
+		if _, err = d.TextColor.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	if d.HasMaxLength {
+		if err = binary.Read(c, binary.LittleEndian, &d.MaxLength); err != nil {
+			return
+		}
+	}
+	if d.HasLayout {
+		if err = binary.Read(c, binary.LittleEndian, &d.Align); err != nil {
+			return
+		}
+		if d.Align > ALIGN_JUSTIFY {
+			err = &ParserError{d.Name(), "Align", fmt.Sprintf("%d", d.Align)}
+			return
+		}
+		if err = binary.Read(c, binary.LittleEndian, &d.LeftMargin); err != nil {
+			return
+		}
+		if err = binary.Read(c, binary.LittleEndian, &d.RightMargin); err != nil {
+			return
+		}
+		if err = binary.Read(c, binary.LittleEndian, &d.Indent); err != nil {
+			return
+		}
+		if err = binary.Read(c, binary.LittleEndian, &d.Leading); err != nil {
+			return
+		}
+	}
+	if _, err = d.VariableName.ReadFrom(c); err != nil {
+		return
+	}
+	if d.HasText {
+		_, err = d.InitialText.ReadFrom(c)
+	}
+	return
+}
+
+func (d *DefineEditText) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.CharacterId); err != nil {
+		return
+	}
+	if _, err = d.Bounds.WriteTo(c); err != nil {
+		return
+	}
+	flags := [2]uint8{
+		packFlags(d.HasText, d.WordWrap, d.Multiline, d.Password, d.ReadOnly, d.HasTextColor, d.HasMaxLength, d.HasFont),
+		packFlags(d.HasFontClass, d.AutoSize, d.HasLayout, d.NoSelect, d.Border, d.WasStatic, d.HTML, d.UseOutlines),
+	}
+	if err = binary.Write(c, binary.LittleEndian, flags); err != nil {
+		return
+	}
+	if d.HasFont {
+		if err = binary.Write(c, binary.LittleEndian, d.FontId); err != nil {
+			return
+		}
+	}
+	if d.HasFontClass {
+		if _, err = d.FontClass.WriteTo(c); err != nil {
+			return
+		}
+	}
+	if d.HasFont || d.HasFontClass {
+		if err = binary.Write(c, binary.LittleEndian, d.FontHeight); err != nil {
+			return
+		}
+	}
+	if d.HasTextColor {
+		if _, err = d.TextColor.WriteTo(c); err != nil {
+			return
+		}
+	}
+	if d.HasMaxLength {
+		if err = binary.Write(c, binary.LittleEndian, d.MaxLength); err != nil {
+			return
+		}
+	}
+	if d.HasLayout {
+		if err = binary.Write(c, binary.LittleEndian, d.Align); err != nil {
+			return
+		}
+		if err = binary.Write(c, binary.LittleEndian, [3]uint16{d.LeftMargin, d.RightMargin, d.Indent}); err != nil {
+			return
+		}
+		if err = binary.Write(c, binary.LittleEndian, d.Leading); err != nil {
+			return
+		}
+	}
+	if _, err = d.VariableName.WriteTo(c); err != nil {
+		return
+	}
+	if d.HasText {
+		_, err = d.InitialText.WriteTo(c)
+	}
+	return
+}
+
+func (d *DefineEditText) Size(ver uint8, id uint16) int32 {
+	total := 2 + d.Bounds.Size() + 2 + d.VariableName.Size()
+	if d.HasFont {
+		total += 2
+	}
+	if d.HasFontClass {
+		total += d.FontClass.Size()
+	}
+	if d.HasFont || d.HasFontClass {
+		total += 2
+	}
+	if d.HasTextColor {
+		total += 4
+	}
+	if d.HasMaxLength {
+		total += 2
+	}
+	if d.HasLayout {
+		total += 1 + 2 + 2 + 2 + 2
+	}
+	if d.HasText {
+		total += d.InitialText.Size()
+	}
+	return total
+}
+
+func (d *DefineEditText) MinVersion() uint8 {
+	return 4
+}
+
+func (d *DefineEditText) TagId() uint16 {
+	return TAG_DEFINE_EDIT_TEXT
+}
+
+func (d *DefineEditText) Name() string {
+	return "DefineEditText"
+}
+
+// SetText sets the initial text of the field, clearing it when text is empty.
+func (d *DefineEditText) SetText(text string) {
+	d.InitialText = String(text)
+	d.HasText = text != ""
+}
+
+// ReplaceText replaces all occurrences of old in the initial text with new and
+// returns whether the text was changed.
+func (d *DefineEditText) ReplaceText(old, new string) bool {
+	if !d.HasText || old == "" || !strings.Contains(string(d.InitialText), old) {
+		return false
+	}
+	d.SetText(strings.Replace(string(d.InitialText), old, new, -1))
+	return true
+}
+
+// ReplaceEditText calls ReplaceText on every DefineEditText tag and returns
+// the number of tags changed.
+func (s *SWF) ReplaceEditText(old, new string) int {
+	var n int
+	for _, tag := range s.Tags {
+		if d, ok := tag.(*DefineEditText); ok && d.ReplaceText(old, new) {
+			n++
+		}
+	}
+	return n
+}
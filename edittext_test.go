@@ -0,0 +1,58 @@
+package swf
+
+import "testing"
+
+func TestDefineEditText(t *testing.T) {
+	testTag(t, 4, append([]byte{7, 0, 8, 0, 0xcf, 0x6b, 3, 0, 240, 0, 0, 0, 255, 255, 100, 0, 2, 20, 0, 40, 0, 5, 0, 254, 255, 'v', 0}, "<p>Hi</p>\x00"...), &DefineEditText{
+		CharacterId:  7,
+		HasText:      true,
+		WordWrap:     true,
+		ReadOnly:     true,
+		HasTextColor: true,
+		HasMaxLength: true,
+		HasFont:      true,
+		AutoSize:     true,
+		HasLayout:    true,
+		Border:       true,
+		HTML:         true,
+		UseOutlines:  true,
+		FontId:       3,
+		FontHeight:   240,
+		TextColor:    RGBA{RGB{0, 0, 255}, 255},
+		MaxLength:    100,
+		Align:        ALIGN_CENTER,
+		LeftMargin:   20,
+		RightMargin:  40,
+		Indent:       5,
+		Leading:      -2,
+		VariableName: "v",
+		InitialText:  "<p>Hi</p>",
+	})
+	testTag(t, 9, []byte{1, 0, 8, 0, 0, 0x80, 'F', 0, 200, 0, 0}, &DefineEditText{
+		CharacterId:  1,
+		HasFontClass: true,
+		FontClass:    "F",
+		FontHeight:   200,
+	})
+}
+
+func TestReplaceEditText(t *testing.T) {
+	s := &SWF{Tags: []Tag{
+		&DefineEditText{HasText: true, InitialText: "Hello, World"},
+		&DefineEditText{HasText: true, InitialText: "Goodbye"},
+		&DefineEditText{},
+	}}
+	if n := s.ReplaceEditText("Hello", "Bonjour"); n != 1 {
+		t.Errorf("expecting 1 replacement, got %d", n)
+	}
+	if d := s.Tags[0].(*DefineEditText); d.InitialText != "Bonjour, World" || !d.HasText {
+		t.Errorf("expecting %q, got %q", "Bonjour, World", d.InitialText)
+	}
+	if s.Tags[1].(*DefineEditText).ReplaceText("Goodbye", "") {
+		if d := s.Tags[1].(*DefineEditText); d.HasText {
+			t.Errorf("expecting empty text to clear HasText")
+		}
+	} else {
+		t.Errorf("expecting text to be replaced")
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"html"
+	"strings"
+)
+
+type TextSource uint8
+
+const (
+	TEXT_STATIC TextSource = iota
+	TEXT_EDIT
+	TEXT_FRAME_LABEL
+	TEXT_METADATA
+	TEXT_BUTTON
+	TEXT_ACTION
+)
+
+func (t TextSource) String() string {
+	switch t {
+	case TEXT_STATIC:
+		return "Static text"
+	case TEXT_EDIT:
+		return "Edit text"
+	case TEXT_FRAME_LABEL:
+		return "Frame label"
+	case TEXT_METADATA:
+		return "Metadata"
+	case TEXT_BUTTON:
+		return "Button label"
+	case TEXT_ACTION:
+		return "ActionScript string"
+	}
+	return "Unknown text source"
+}
+
+// TextItem is a piece of human readable text found in a SWF. CharacterId is
+// zero for text that does not belong to a character; text found inside a
+// DefineSprite, or in a DoInitAction, belongs to the sprite. Frame is the
+// zero-based frame, of the root or the sprite, of the tag the text was found
+// in.
+type TextItem struct {
+	Source      TextSource
+	CharacterId uint16
+	Frame       int
+	Text        string
+}
+
+// Text returns all of the human readable text in the SWF, in tag order.
+// Static text whose font cannot be found is skipped.
+func (s *SWF) Text() []TextItem {
+	return extractText(s.Tags, s.CodeTables())
+}
+
+func extractText(tags []Tag, codeTables CodeTables) []TextItem {
+	var (
+		items []TextItem
+		frame int
+		texts = make(map[uint16]string)
+		walk  func([]Tag, uint16)
+	)
+	add := func(source TextSource, characterId uint16, text string) {
+		if text != "" {
+			items = append(items, TextItem{source, characterId, frame, text})
+		}
+	}
+	character := func(source TextSource, characterId uint16, text string) {
+		texts[characterId] = text
+		add(source, characterId, text)
+	}
+	button := func(buttonId uint16, records []ButtonRecord) {
+		seen := make(map[uint16]bool)
+		for _, r := range records {
+			if text, ok := texts[r.CharacterId]; ok && !seen[r.CharacterId] {
+				seen[r.CharacterId] = true
+				add(TEXT_BUTTON, buttonId, text)
+			}
+		}
+	}
+	actions := func(characterId uint16, actions []Action) {
+		for _, action := range actions {
+			switch a := action.(type) {
+			case *ActionConstantPool:
+				for _, c := range a.Constants {
+					add(TEXT_ACTION, characterId, string(c))
+				}
+			case *ActionPush:
+				for _, v := range a.Values {
+					if p, ok := v.(PushString); ok {
+						add(TEXT_ACTION, characterId, string(p))
+					}
+				}
+			}
+		}
+	}
+	walk = func(tags []Tag, spriteId uint16) {
+		for _, tag := range tags {
+			switch t := tag.(type) {
+			case *ShowFrame:
+				frame++
+			case *DefineText:
+				if text, err := t.Text(codeTables); err == nil {
+					character(TEXT_STATIC, t.CharacterId, text)
+				}
+			case *DefineText2:
+				if text, err := t.Text(codeTables); err == nil {
+					character(TEXT_STATIC, t.CharacterId, text)
+				}
+			case *DefineEditText:
+				if t.HasText {
+					if t.HTML {
+						character(TEXT_EDIT, t.CharacterId, stripHTML(string(t.InitialText)))
+					} else {
+						character(TEXT_EDIT, t.CharacterId, string(t.InitialText))
+					}
+				}
+			case *DefineButton:
+				button(t.ButtonId, t.Characters)
+				actions(t.ButtonId, t.Actions)
+			case *DefineButton2:
+				button(t.ButtonId, t.Characters)
+				for _, b := range t.Actions {
+					actions(t.ButtonId, b.Actions)
+				}
+			case *PlaceObject2:
+				for _, r := range t.ClipActions.Records {
+					actions(spriteId, r.Actions)
+				}
+			case *PlaceObject3:
+				for _, r := range t.ClipActions.Records {
+					actions(spriteId, r.Actions)
+				}
+			case *DefineSprite:
+				f := frame
+				frame = 0
+				walk(t.ControlTags, t.SpriteId)
+				frame = f
+			case *DoAction:
+				actions(spriteId, t.Actions)
+			case *DoInitAction:
+				actions(t.SpriteId, t.Actions)
+			case *FrameLabel:
+				add(TEXT_FRAME_LABEL, spriteId, string(t.Label))
+			case *Metadata:
+				add(TEXT_METADATA, 0, stripHTML(string(t.Metadata)))
+			}
+		}
+	}
+	walk(tags, 0)
+	return items
+}
+
+// stripHTML removes the markup from the HTML subset used by text fields,
+// turning line breaks and paragraph ends into newlines.
+func stripHTML(s string) string {
+	var (
+		text []byte
+		tag  []byte
+		in   bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '<':
+			in, tag = true, tag[:0]
+		case c == '>' && in:
+			in = false
+			if fields := strings.Fields(string(tag)); len(fields) > 0 {
+				switch strings.ToLower(strings.TrimRight(fields[0], "/")) {
+				case "br", "/p", "/li":
+					text = append(text, '\n')
+				}
+			}
+		case in:
+			tag = append(tag, c)
+		default:
+			text = append(text, c)
+		}
+	}
+	return strings.TrimSpace(html.UnescapeString(string(text)))
+}
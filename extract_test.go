@@ -0,0 +1,71 @@
+package swf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFrameLabel(t *testing.T) {
+	testTag(t, 3, []byte{'i', 'n', 't', 'r', 'o', 0}, &FrameLabel{Label: "intro"})
+	testTag(t, 6, []byte{'a', 0, 1}, &FrameLabel{Label: "a", NamedAnchor: true})
+}
+
+func TestMetadata(t *testing.T) {
+	testTag(t, 1, []byte{'<', 'x', '/', '>', 0}, &Metadata{"<x/>"})
+}
+
+func TestStripHTML(t *testing.T) {
+	for n, test := range [...][2]string{
+		{"plain", "plain"},
+		{"<p align=\"left\"><font face=\"Arial\">Hello &amp; goodbye</font></p>", "Hello & goodbye"},
+		{"<P>one</P><P>two<br/>three</P>", "one\ntwo\nthree"},
+		{"<>x", "x"},
+	} {
+		if s := stripHTML(test[0]); s != test[1] {
+			t.Errorf("test %d: expecting %q, got %q", n+1, test[1], s)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	s := &SWF{Tags: []Tag{
+		&DefineFontInfo{FontId: 2, CodeTable: []uint16{'H', 'i', '!'}},
+		&DefineText{CharacterId: 3, TextRecords: testTextRecords(255)},
+		&FrameLabel{Label: "start"},
+		&ShowFrame{},
+		&DefineEditText{CharacterId: 4, HasText: true, HTML: true, InitialText: "<p>Name:</p>"},
+		&DefineEditText{CharacterId: 5},
+		&DefineText2{DefineText{CharacterId: 6, TextRecords: []TextRecord{{HasFont: true, FontId: 9, GlyphEntries: []GlyphEntry{{}}}}}},
+		&ShowFrame{},
+		&Metadata{"<rdf:RDF><dc:title>Demo</dc:title></rdf:RDF>"},
+		&DefineSprite{SpriteId: 7, FrameCount: 2, ControlTags: []Tag{
+			&ShowFrame{},
+			&FrameLabel{Label: "over"},
+			&DoAction{Actions: []Action{
+				&ActionPush{[]PushValue{PushString("Welcome"), PushInteger(1), PushString("")}},
+				BasicAction(ACTION_END),
+			}},
+			&ShowFrame{},
+		}},
+		&DoInitAction{SpriteId: 7, Actions: []Action{
+			&ActionConstantPool{[]String{"Score", "Lives"}},
+		}},
+		&DoAction{Actions: []Action{
+			&ActionPush{[]PushValue{PushString("Game over")}},
+		}},
+	}}
+	expected := []TextItem{
+		{TEXT_STATIC, 3, 0, "i!\nH"},
+		{TEXT_FRAME_LABEL, 0, 0, "start"},
+		{TEXT_EDIT, 4, 1, "Name:"},
+		{TEXT_METADATA, 0, 2, "Demo"},
+		{TEXT_FRAME_LABEL, 7, 1, "over"},
+		{TEXT_ACTION, 7, 1, "Welcome"},
+		{TEXT_ACTION, 7, 2, "Score"},
+		{TEXT_ACTION, 7, 2, "Lives"},
+		{TEXT_ACTION, 0, 2, "Game over"},
+	}
+	if items := s.Text(); !reflect.DeepEqual(items, expected) {
+		t.Errorf("expecting %v, got %v", expected, items)
+	}
+}
@@ -0,0 +1,525 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+)
+
+type BlendMode uint8
+
+const (
+	BLEND_NORMAL0 BlendMode = iota
+	BLEND_NORMAL
+	BLEND_LAYER
+	BLEND_MULTIPLY
+	BLEND_SCREEN
+	BLEND_LIGHTEN
+	BLEND_DARKEN
+	BLEND_DIFFERENCE
+	BLEND_ADD
+	BLEND_SUBTRACT
+	BLEND_INVERT
+	BLEND_ALPHA
+	BLEND_ERASE
+	BLEND_OVERLAY
+	BLEND_HARDLIGHT
+)
+
+func (b BlendMode) String() string {
+	switch b {
+	case BLEND_NORMAL0, BLEND_NORMAL:
+		return "Normal"
+	case BLEND_LAYER:
+		return "Layer"
+	case BLEND_MULTIPLY:
+		return "Multiply"
+	case BLEND_SCREEN:
+		return "Screen"
+	case BLEND_LIGHTEN:
+		return "Lighten"
+	case BLEND_DARKEN:
+		return "Darken"
+	case BLEND_DIFFERENCE:
+		return "Difference"
+	case BLEND_ADD:
+		return "Add"
+	case BLEND_SUBTRACT:
+		return "Subtract"
+	case BLEND_INVERT:
+		return "Invert"
+	case BLEND_ALPHA:
+		return "Alpha"
+	case BLEND_ERASE:
+		return "Erase"
+	case BLEND_OVERLAY:
+		return "Overlay"
+	case BLEND_HARDLIGHT:
+		return "Hardlight"
+	}
+	return "Unknown blend mode"
+}
+
+const (
+	FILTER_DROP_SHADOW uint8 = iota
+	FILTER_BLUR
+	FILTER_GLOW
+	FILTER_BEVEL
+	FILTER_GRADIENT_GLOW
+	FILTER_CONVOLUTION
+	FILTER_COLOR_MATRIX
+	FILTER_GRADIENT_BEVEL
+)
+
+type Filter interface {
+	io.ReaderFrom
+	io.WriterTo
+	Size() int32
+	FilterId() uint8
+}
+
+func newFilter(id uint8) Filter {
+	switch id {
+	case FILTER_DROP_SHADOW:
+		return new(DropShadowFilter)
+	case FILTER_BLUR:
+		return new(BlurFilter)
+	case FILTER_GLOW:
+		return new(GlowFilter)
+	case FILTER_BEVEL:
+		return new(BevelFilter)
+	case FILTER_GRADIENT_GLOW:
+		return new(GradientGlowFilter)
+	case FILTER_CONVOLUTION:
+		return new(ConvolutionFilter)
+	case FILTER_COLOR_MATRIX:
+		return new(ColorMatrixFilter)
+	case FILTER_GRADIENT_BEVEL:
+		return &GradientGlowFilter{Bevel: true}
+	}
+	return nil
+}
+
+// FilterList is a FILTERLIST, as used by button records and PlaceObject3.
+type FilterList []Filter
+
+func (l *FilterList) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var count, id uint8
+	if err = binary.Read(c, binary.LittleEndian, &count); err != nil {
+		return
+	}
+	*l = make(FilterList, count)
+	for n := range *l {
+		if err = binary.Read(c, binary.LittleEndian, &id); err != nil {
+			return
+		}
+		filter := newFilter(id)
+		if filter == nil {
+			err = &ParserError{"FilterList", "FilterId", fmt.Sprintf("%d", id)}
+			return
+		}
+		if _, err = filter.ReadFrom(c); err != nil {
+			return
+		}
+		(*l)[n] = filter
+	}
+	return
+}
+
+func (l *FilterList) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if len(*l) > 255 {
+		err = &ParserError{"FilterList", "NumberOfFilters", fmt.Sprintf("%d", len(*l))}
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, uint8(len(*l))); err != nil {
+		return
+	}
+	for _, filter := range *l {
+		if err = binary.Write(c, binary.LittleEndian, filter.FilterId()); err != nil {
+			return
+		}
+		if _, err = filter.WriteTo(c); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (l *FilterList) Size() int32 {
+	total := int32(1)
+	for _, filter := range *l {
+		total += 1 + filter.Size()
+	}
+	return total
+}
+
+func filterFlags(passes uint8, flags ...bool) uint8 {
+	bits := 8 - uint(len(flags))
+	return packFlags(flags...)<<bits | passes&(1<<bits-1)
+}
+
+func unpackFilterFlags(b uint8, passes *uint8, flags ...*bool) {
+	bits := 8 - uint(len(flags))
+	unpackFlags(b>>bits, flags...)
+	*passes = b & (1<<bits - 1)
+}
+
+type DropShadowFilter struct {
+	DropShadowColor                        RGBA
+	BlurX, BlurY, Angle, Distance          Fixed
+	Strength                               Fixed8
+	InnerShadow, Knockout, CompositeSource bool
+	Passes                                 uint8
+}
+
+func (d *DropShadowFilter) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags uint8
+	if err = ReadAll(c, &d.DropShadowColor, &d.BlurX, &d.BlurY, &d.Angle, &d.Distance, &d.Strength); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFilterFlags(flags, &d.Passes, &d.InnerShadow, &d.Knockout, &d.CompositeSource)
+	return
+}
+
+func (d *DropShadowFilter) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = WriteAll(c, &d.DropShadowColor, &d.BlurX, &d.BlurY, &d.Angle, &d.Distance, &d.Strength); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, filterFlags(d.Passes, d.InnerShadow, d.Knockout, d.CompositeSource))
+	return
+}
+
+func (d *DropShadowFilter) Size() int32 {
+	return 4 + 4*4 + 2 + 1
+}
+
+func (d *DropShadowFilter) FilterId() uint8 {
+	return FILTER_DROP_SHADOW
+}
+
+type BlurFilter struct {
+	BlurX, BlurY Fixed
+	Passes       uint8
+}
+
+func (b *BlurFilter) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags uint8
+	if err = ReadAll(c, &b.BlurX, &b.BlurY); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	b.Passes = flags >> 3
+	return
+}
+
+func (b *BlurFilter) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = WriteAll(c, &b.BlurX, &b.BlurY); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, b.Passes<<3)
+	return
+}
+
+func (b *BlurFilter) Size() int32 {
+	return 4 + 4 + 1
+}
+
+func (b *BlurFilter) FilterId() uint8 {
+	return FILTER_BLUR
+}
+
+type GlowFilter struct {
+	GlowColor                            RGBA
+	BlurX, BlurY                         Fixed
+	Strength                             Fixed8
+	InnerGlow, Knockout, CompositeSource bool
+	Passes                               uint8
+}
+
+func (g *GlowFilter) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags uint8
+	if err = ReadAll(c, &g.GlowColor, &g.BlurX, &g.BlurY, &g.Strength); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFilterFlags(flags, &g.Passes, &g.InnerGlow, &g.Knockout, &g.CompositeSource)
+	return
+}
+
+func (g *GlowFilter) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = WriteAll(c, &g.GlowColor, &g.BlurX, &g.BlurY, &g.Strength); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, filterFlags(g.Passes, g.InnerGlow, g.Knockout, g.CompositeSource))
+	return
+}
+
+func (g *GlowFilter) Size() int32 {
+	return 4 + 4 + 4 + 2 + 1
+}
+
+func (g *GlowFilter) FilterId() uint8 {
+	return FILTER_GLOW
+}
+
+type BevelFilter struct {
+	ShadowColor, HighlightColor                   RGBA
+	BlurX, BlurY, Angle, Distance                 Fixed
+	Strength                                      Fixed8
+	InnerShadow, Knockout, CompositeSource, OnTop bool
+	Passes                                        uint8
+}
+
+func (b *BevelFilter) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags uint8
+	if err = ReadAll(c, &b.ShadowColor, &b.HighlightColor, &b.BlurX, &b.BlurY, &b.Angle, &b.Distance, &b.Strength); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFilterFlags(flags, &b.Passes, &b.InnerShadow, &b.Knockout, &b.CompositeSource, &b.OnTop)
+	return
+}
+
+func (b *BevelFilter) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = WriteAll(c, &b.ShadowColor, &b.HighlightColor, &b.BlurX, &b.BlurY, &b.Angle, &b.Distance, &b.Strength); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, filterFlags(b.Passes, b.InnerShadow, b.Knockout, b.CompositeSource, b.OnTop))
+	return
+}
+
+func (b *BevelFilter) Size() int32 {
+	return 4 + 4 + 4*4 + 2 + 1
+}
+
+func (b *BevelFilter) FilterId() uint8 {
+	return FILTER_BEVEL
+}
+
+type GradientRecord struct {
+	Color RGBA
+	Ratio uint8
+}
+
+// GradientGlowFilter also holds the gradient bevel filter, which shares its
+// layout.
+type GradientGlowFilter struct {
+	Bevel                                         bool
+	Gradient                                      []GradientRecord
+	BlurX, BlurY, Angle, Distance                 Fixed
+	Strength                                      Fixed8
+	InnerShadow, Knockout, CompositeSource, OnTop bool
+	Passes                                        uint8
+}
+
+func (g *GradientGlowFilter) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var count, flags uint8
+	if err = binary.Read(c, binary.LittleEndian, &count); err != nil {
+		return
+	}
+	g.Gradient = make([]GradientRecord, count)
+	for n := range g.Gradient {
+		if _, err = g.Gradient[n].Color.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	for n := range g.Gradient {
+		if err = binary.Read(c, binary.LittleEndian, &g.Gradient[n].Ratio); err != nil {
+			return
+		}
+	}
+	if err = ReadAll(c, &g.BlurX, &g.BlurY, &g.Angle, &g.Distance, &g.Strength); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFilterFlags(flags, &g.Passes, &g.InnerShadow, &g.Knockout, &g.CompositeSource, &g.OnTop)
+	return
+}
+
+func (g *GradientGlowFilter) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if len(g.Gradient) > 255 {
+		err = &ParserError{"GradientGlowFilter", "NumColors", fmt.Sprintf("%d", len(g.Gradient))}
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, uint8(len(g.Gradient))); err != nil {
+		return
+	}
+	for n := range g.Gradient {
+		if _, err = g.Gradient[n].Color.WriteTo(c); err != nil {
+			return
+		}
+	}
+	for _, r := range g.Gradient {
+		if err = binary.Write(c, binary.LittleEndian, r.Ratio); err != nil {
+			return
+		}
+	}
+	if err = WriteAll(c, &g.BlurX, &g.BlurY, &g.Angle, &g.Distance, &g.Strength); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, filterFlags(g.Passes, g.InnerShadow, g.Knockout, g.CompositeSource, g.OnTop))
+	return
+}
+
+func (g *GradientGlowFilter) Size() int32 {
+	return 1 + 5*int32(len(g.Gradient)) + 4*4 + 2 + 1
+}
+
+func (g *GradientGlowFilter) FilterId() uint8 {
+	if g.Bevel {
+		return FILTER_GRADIENT_BEVEL
+	}
+	return FILTER_GRADIENT_GLOW
+}
+
+type ConvolutionFilter struct {
+	MatrixX, MatrixY     uint8
+	Divisor, Bias        Float
+	Matrix               []Float
+	DefaultColor         RGBA
+	Clamp, PreserveAlpha bool
+}
+
+func (cf *ConvolutionFilter) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags uint8
+	if err = binary.Read(c, binary.LittleEndian, &cf.MatrixX); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &cf.MatrixY); err != nil {
+		return
+	}
+	if err = ReadAll(c, &cf.Divisor, &cf.Bias); err != nil {
+		return
+	}
+	cf.Matrix = make([]Float, int(cf.MatrixX)*int(cf.MatrixY))
+	if err = binary.Read(c, binary.LittleEndian, cf.Matrix); err != nil {
+		return
+	}
+	if _, err = cf.DefaultColor.ReadFrom(c); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags, &cf.Clamp, &cf.PreserveAlpha)
+	return
+}
+
+func (cf *ConvolutionFilter) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if len(cf.Matrix) != int(cf.MatrixX)*int(cf.MatrixY) {
+		err = &ParserError{"ConvolutionFilter", "Matrix", fmt.Sprintf("%d", len(cf.Matrix))}
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, [2]uint8{cf.MatrixX, cf.MatrixY}); err != nil {
+		return
+	}
+	if err = WriteAll(c, &cf.Divisor, &cf.Bias); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, cf.Matrix); err != nil {
+		return
+	}
+	if _, err = cf.DefaultColor.WriteTo(c); err != nil {
+		return
+	}
+	err = binary.Write(c, binary.LittleEndian, packFlags(cf.Clamp, cf.PreserveAlpha))
+	return
+}
+
+func (cf *ConvolutionFilter) Size() int32 {
+	return 2 + 4 + 4 + 4*int32(len(cf.Matrix)) + 4 + 1
+}
+
+func (cf *ConvolutionFilter) FilterId() uint8 {
+	return FILTER_CONVOLUTION
+}
+
+type ColorMatrixFilter struct {
+	Matrix [20]Float
+}
+
+func (cm *ColorMatrixFilter) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	err = binary.Read(c, binary.LittleEndian, &cm.Matrix)
+	return
+}
+
+func (cm *ColorMatrixFilter) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	err = binary.Write(c, binary.LittleEndian, cm.Matrix)
+	return
+}
+
+func (cm *ColorMatrixFilter) Size() int32 {
+	return 20 * 4
+}
+
+func (cm *ColorMatrixFilter) FilterId() uint8 {
+	return FILTER_COLOR_MATRIX
+}
@@ -0,0 +1,592 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"io/ioutil"
+)
+
+var ErrWideOffsets = errors.New("font: glyph offsets too large, WideOffsets required")
+
+func readGlyphShapes(tag string, data []byte, offsets []uint32, base uint32) (shapes []Shape, err error) {
+	shapes = make([]Shape, len(offsets))
+	for n, offset := range offsets {
+		end := base + uint32(len(data))
+		if n+1 < len(offsets) {
+			end = offsets[n+1]
+		}
+		if offset < base || end < offset || end > base+uint32(len(data)) {
+			err = &ParserError{tag, "OffsetTable", fmt.Sprintf("%d", offset)}
+			return
+		}
+		if _, err = shapes[n].ReadFrom(bytes.NewReader(data[offset-base : end-base])); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func glyphShapesSize(shapes []Shape) (total int32) {
+	for n := range shapes {
+		total += shapes[n].Size()
+	}
+	return
+}
+
+type DefineFont struct {
+	FontId          uint16
+	GlyphShapeTable []Shape
+}
+
+func (d *DefineFont) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.FontId); err != nil {
+		return
+	}
+	var data []byte
+	if data, err = ioutil.ReadAll(c); err != nil {
+		return
+	}
+	d.GlyphShapeTable = nil
+	if len(data) < 2 {
+		return
+	}
+	offsets := make([]uint32, binary.LittleEndian.Uint16(data)/2)
+	if len(data) < 2*len(offsets) {
+		err = &ParserError{d.Name(), "OffsetTable", fmt.Sprintf("%d", len(offsets))}
+		return
+	}
+	for n := range offsets {
+		offsets[n] = uint32(binary.LittleEndian.Uint16(data[2*n:]))
+	}
+	d.GlyphShapeTable, err = readGlyphShapes(d.Name(), data[2*len(offsets):], offsets, uint32(2*len(offsets)))
+	return
+}
+
+func (d *DefineFont) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.FontId); err != nil {
+		return
+	}
+	offset := int32(2 * len(d.GlyphShapeTable))
+	for n := range d.GlyphShapeTable {
+		if offset > 0xffff {
+			err = ErrWideOffsets
+			return
+		}
+		if err = binary.Write(c, binary.LittleEndian, uint16(offset)); err != nil {
+			return
+		}
+		offset += d.GlyphShapeTable[n].Size()
+	}
+	for n := range d.GlyphShapeTable {
+		if _, err = d.GlyphShapeTable[n].WriteTo(c); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (d *DefineFont) Size(ver uint8, id uint16) int32 {
+	return 2 + 2*int32(len(d.GlyphShapeTable)) + glyphShapesSize(d.GlyphShapeTable)
+}
+
+func (d *DefineFont) MinVersion() uint8 {
+	return 1
+}
+
+func (d *DefineFont) TagId() uint16 {
+	return TAG_DEFINE_FONT
+}
+
+func (d *DefineFont) Name() string {
+	return "DefineFont"
+}
+
+type DefineFontInfo struct {
+	FontId       uint16
+	FontName     string
+	SmallText    bool
+	ShiftJIS     bool
+	ANSI         bool
+	Italic       bool
+	Bold         bool
+	WideCodes    bool
+	LanguageCode LanguageCode
+	CodeTable    []uint16
+}
+
+func (d *DefineFontInfo) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var (
+		nameLen, flags uint8
+		data           []byte
+	)
+	if err = binary.Read(c, binary.LittleEndian, &d.FontId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &nameLen); err != nil {
+		return
+	}
+	name := make([]byte, nameLen)
+	if _, err = io.ReadFull(c, name); err != nil {
+		return
+	}
+	d.FontName = string(name)
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags, &d.SmallText, &d.ShiftJIS, &d.ANSI, &d.Italic, &d.Bold, &d.WideCodes)
+	d.LanguageCode = LANGUAGE_NONE
+	if id == TAG_DEFINE_FONT_INFO2 {
+		if _, err = d.LanguageCode.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	if data, err = ioutil.ReadAll(c); err != nil {
+		return
+	}
+	d.CodeTable = readCodeTable(data, d.WideCodes)
+	return
+}
+
+func (d *DefineFontInfo) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	if len(d.FontName) > 255 {
+		err = errors.New("defineFontInfo: font name too long")
+		return
+	}
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.FontId); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, uint8(len(d.FontName))); err != nil {
+		return
+	}
+	if _, err = io.WriteString(c, d.FontName); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, packFlags(d.SmallText, d.ShiftJIS, d.ANSI, d.Italic, d.Bold, d.WideCodes)); err != nil {
+		return
+	}
+	if id == TAG_DEFINE_FONT_INFO2 {
+		if _, err = d.LanguageCode.WriteTo(c); err != nil {
+			return
+		}
+	}
+	err = writeCodeTable(c, d.CodeTable, d.WideCodes)
+	return
+}
+
+func (d *DefineFontInfo) Size(ver uint8, id uint16) int32 {
+	total := 2 + 1 + int32(len(d.FontName)) + 1 + codeTableSize(d.CodeTable, d.WideCodes)
+	if id == TAG_DEFINE_FONT_INFO2 {
+		total++
+	}
+	return total
+}
+
+func (d *DefineFontInfo) MinVersion() uint8 {
+	return 1
+}
+
+func (d *DefineFontInfo) TagId() uint16 {
+	return TAG_DEFINE_FONT_INFO
+}
+
+func (d *DefineFontInfo) Name() string {
+	return "DefineFontInfo"
+}
+
+type DefineFontInfo2 struct {
+	DefineFontInfo
+}
+
+func (d *DefineFontInfo2) MinVersion() uint8 {
+	return 6
+}
+
+func (d *DefineFontInfo2) TagId() uint16 {
+	return TAG_DEFINE_FONT_INFO2
+}
+
+func (d *DefineFontInfo2) Name() string {
+	return "DefineFontInfo2"
+}
+
+func readCodeTable(data []byte, wide bool) []uint16 {
+	if !wide {
+		codes := make([]uint16, len(data))
+		for n, c := range data {
+			codes[n] = uint16(c)
+		}
+		return codes
+	}
+	codes := make([]uint16, len(data)/2)
+	for n := range codes {
+		codes[n] = binary.LittleEndian.Uint16(data[2*n:])
+	}
+	return codes
+}
+
+func writeCodeTable(w io.Writer, codes []uint16, wide bool) error {
+	if wide {
+		return binary.Write(w, binary.LittleEndian, codes)
+	}
+	data := make([]byte, len(codes))
+	for n, c := range codes {
+		if c > 0xff {
+			return fmt.Errorf("font: code %d requires WideCodes", c)
+		}
+		data[n] = byte(c)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func codeTableSize(codes []uint16, wide bool) int32 {
+	if wide {
+		return 2 * int32(len(codes))
+	}
+	return int32(len(codes))
+}
+
+type KerningRecord struct {
+	FontKerningCode1, FontKerningCode2 uint16
+	FontKerningAdjustment              int16
+}
+
+type DefineFont2 struct {
+	FontId           uint16
+	HasLayout        bool
+	ShiftJIS         bool
+	SmallText        bool
+	ANSI             bool
+	WideOffsets      bool
+	WideCodes        bool
+	Italic           bool
+	Bold             bool
+	LanguageCode     LanguageCode
+	FontName         string
+	GlyphShapeTable  []Shape
+	CodeTable        []uint16
+	FontAscent       uint16
+	FontDescent      uint16
+	FontLeading      int16
+	FontAdvanceTable []int16
+	FontBoundsTable  []Rect
+	FontKerningTable []KerningRecord
+}
+
+func (d *DefineFont2) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var (
+		flags, nameLen uint8
+		numGlyphs      uint16
+	)
+	if err = binary.Read(c, binary.LittleEndian, &d.FontId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags, &d.HasLayout, &d.ShiftJIS, &d.SmallText, &d.ANSI, &d.WideOffsets, &d.WideCodes, &d.Italic, &d.Bold)
+	if _, err = d.LanguageCode.ReadFrom(c); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &nameLen); err != nil {
+		return
+	}
+	name := make([]byte, nameLen)
+	if _, err = io.ReadFull(c, name); err != nil {
+		return
+	}
+	d.FontName = string(name)
+	if err = binary.Read(c, binary.LittleEndian, &numGlyphs); err != nil {
+		return
+	}
+	offsets := make([]uint32, numGlyphs)
+	var codeTableOffset, offsetSize uint32
+	if d.WideOffsets {
+		offsetSize = 4
+		if numGlyphs > 0 {
+			if err = binary.Read(c, binary.LittleEndian, offsets); err != nil {
+				return
+			}
+			err = binary.Read(c, binary.LittleEndian, &codeTableOffset)
+		}
+	} else {
+		offsetSize = 2
+		if numGlyphs > 0 {
+			o := make([]uint16, numGlyphs+1)
+			if err = binary.Read(c, binary.LittleEndian, o); err != nil {
+				return
+			}
+			for n := range offsets {
+				offsets[n] = uint32(o[n])
+			}
+			codeTableOffset = uint32(o[numGlyphs])
+		}
+	}
+	if err != nil {
+		return
+	}
+	d.GlyphShapeTable, d.CodeTable = nil, nil
+	if numGlyphs > 0 {
+		base := offsetSize * (uint32(numGlyphs) + 1)
+		if codeTableOffset < base {
+			err = &ParserError{d.Name(), "CodeTableOffset", fmt.Sprintf("%d", codeTableOffset)}
+			return
+		}
+		data := make([]byte, codeTableOffset-base)
+		if _, err = io.ReadFull(c, data); err != nil {
+			return
+		}
+		if d.GlyphShapeTable, err = readGlyphShapes(d.Name(), data, offsets, base); err != nil {
+			return
+		}
+		codeSize := uint32(numGlyphs)
+		if d.WideCodes {
+			codeSize *= 2
+		}
+		data = make([]byte, codeSize)
+		if _, err = io.ReadFull(c, data); err != nil {
+			return
+		}
+		d.CodeTable = readCodeTable(data, d.WideCodes)
+	}
+	d.FontAscent, d.FontDescent, d.FontLeading = 0, 0, 0
+	d.FontAdvanceTable, d.FontBoundsTable, d.FontKerningTable = nil, nil, nil
+	if !d.HasLayout {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &d.FontAscent); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &d.FontDescent); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &d.FontLeading); err != nil {
+		return
+	}
+	d.FontAdvanceTable = make([]int16, numGlyphs)
+	if err = binary.Read(c, binary.LittleEndian, d.FontAdvanceTable); err != nil {
+		return
+	}
+	d.FontBoundsTable = make([]Rect, numGlyphs)
+	for n := range d.FontBoundsTable {
+		if _, err = d.FontBoundsTable[n].ReadFrom(c); err != nil {
+			return
+		}
+	}
+	var kerningCount uint16
+	if err = binary.Read(c, binary.LittleEndian, &kerningCount); err != nil {
+		return
+	}
+	d.FontKerningTable = make([]KerningRecord, kerningCount)
+	for n := range d.FontKerningTable {
+		k := &d.FontKerningTable[n]
+		if d.WideCodes {
+			err = binary.Read(c, binary.LittleEndian, k)
+		} else {
+			var codes [2]uint8
+			if err = binary.Read(c, binary.LittleEndian, &codes); err == nil {
+				k.FontKerningCode1, k.FontKerningCode2 = uint16(codes[0]), uint16(codes[1])
+				err = binary.Read(c, binary.LittleEndian, &k.FontKerningAdjustment)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (d *DefineFont2) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	numGlyphs := len(d.GlyphShapeTable)
+	if len(d.FontName) > 255 {
+		err = errors.New("defineFont2: font name too long")
+		return
+	} else if len(d.CodeTable) != numGlyphs {
+		err = errors.New("defineFont2: code table length does not match number of glyphs")
+		return
+	} else if d.HasLayout && (len(d.FontAdvanceTable) != numGlyphs || len(d.FontBoundsTable) != numGlyphs) {
+		err = errors.New("defineFont2: layout tables do not match number of glyphs")
+		return
+	}
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.FontId); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, packFlags(d.HasLayout, d.ShiftJIS, d.SmallText, d.ANSI, d.WideOffsets, d.WideCodes, d.Italic, d.Bold)); err != nil {
+		return
+	}
+	if _, err = d.LanguageCode.WriteTo(c); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, uint8(len(d.FontName))); err != nil {
+		return
+	}
+	if _, err = io.WriteString(c, d.FontName); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, uint16(numGlyphs)); err != nil {
+		return
+	}
+	if numGlyphs > 0 {
+		offsetSize := uint32(2)
+		if d.WideOffsets {
+			offsetSize = 4
+		}
+		offsets := make([]uint32, numGlyphs+1)
+		offsets[0] = offsetSize * uint32(numGlyphs+1)
+		for n := range d.GlyphShapeTable {
+			offsets[n+1] = offsets[n] + uint32(d.GlyphShapeTable[n].Size())
+		}
+		if d.WideOffsets {
+			err = binary.Write(c, binary.LittleEndian, offsets)
+		} else if offsets[numGlyphs] > 0xffff {
+			err = ErrWideOffsets
+		} else {
+			o := make([]uint16, numGlyphs+1)
+			for n, offset := range offsets {
+				o[n] = uint16(offset)
+			}
+			err = binary.Write(c, binary.LittleEndian, o)
+		}
+		if err != nil {
+			return
+		}
+		for n := range d.GlyphShapeTable {
+			if _, err = d.GlyphShapeTable[n].WriteTo(c); err != nil {
+				return
+			}
+		}
+		if err = writeCodeTable(c, d.CodeTable, d.WideCodes); err != nil {
+			return
+		}
+	}
+	if !d.HasLayout {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, d.FontAscent); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, d.FontDescent); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, d.FontLeading); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, d.FontAdvanceTable); err != nil {
+		return
+	}
+	for n := range d.FontBoundsTable {
+		if _, err = d.FontBoundsTable[n].WriteTo(c); err != nil {
+			return
+		}
+	}
+	if err = binary.Write(c, binary.LittleEndian, uint16(len(d.FontKerningTable))); err != nil {
+		return
+	}
+	for _, k := range d.FontKerningTable {
+		if d.WideCodes {
+			err = binary.Write(c, binary.LittleEndian, k)
+		} else if k.FontKerningCode1 > 0xff || k.FontKerningCode2 > 0xff {
+			err = fmt.Errorf("defineFont2: kerning codes %d, %d require WideCodes", k.FontKerningCode1, k.FontKerningCode2)
+		} else if err = binary.Write(c, binary.LittleEndian, [2]uint8{uint8(k.FontKerningCode1), uint8(k.FontKerningCode2)}); err == nil {
+			err = binary.Write(c, binary.LittleEndian, k.FontKerningAdjustment)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (d *DefineFont2) Size(ver uint8, id uint16) int32 {
+	numGlyphs := int32(len(d.GlyphShapeTable))
+	total := 2 + 1 + 1 + 1 + int32(len(d.FontName)) + 2
+	if numGlyphs > 0 {
+		offsetSize := int32(2)
+		if d.WideOffsets {
+			offsetSize = 4
+		}
+		total += offsetSize*(numGlyphs+1) + glyphShapesSize(d.GlyphShapeTable) + codeTableSize(d.CodeTable, d.WideCodes)
+	}
+	if d.HasLayout {
+		total += 2 + 2 + 2 + 2*int32(len(d.FontAdvanceTable)) + 2
+		for n := range d.FontBoundsTable {
+			total += d.FontBoundsTable[n].Size()
+		}
+		kerningSize := int32(4)
+		if d.WideCodes {
+			kerningSize = 6
+		}
+		total += kerningSize * int32(len(d.FontKerningTable))
+	}
+	return total
+}
+
+func (d *DefineFont2) MinVersion() uint8 {
+	return 3
+}
+
+func (d *DefineFont2) TagId() uint16 {
+	return TAG_DEFINE_FONT2
+}
+
+func (d *DefineFont2) Name() string {
+	return "DefineFont2"
+}
+
+// DefineFont3 shares the structure of DefineFont2, but its glyphs are
+// defined on a 20 times finer grid (20480 units to the EM square).
+type DefineFont3 struct {
+	DefineFont2
+}
+
+func (d *DefineFont3) MinVersion() uint8 {
+	return 8
+}
+
+func (d *DefineFont3) TagId() uint16 {
+	return TAG_DEFINE_FONT3
+}
+
+func (d *DefineFont3) Name() string {
+	return "DefineFont3"
+}
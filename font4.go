@@ -0,0 +1,160 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"io/ioutil"
+)
+
+var ErrNoFontData = errors.New("defineFont4: no embedded font data")
+
+type DefineFont4 struct {
+	FontId   uint16
+	Italic   bool
+	Bold     bool
+	FontName String
+	FontData []byte
+}
+
+func (d *DefineFont4) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var (
+		flags       uint8
+		hasFontData bool
+	)
+	if err = binary.Read(c, binary.LittleEndian, &d.FontId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags, &hasFontData, &d.Italic, &d.Bold)
+	if _, err = d.FontName.ReadFrom(c); err != nil {
+		return
+	}
+	d.FontData = nil
+	if hasFontData {
+		d.FontData, err = ioutil.ReadAll(c)
+	}
+	return
+}
+
+func (d *DefineFont4) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.FontId); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, packFlags(len(d.FontData) > 0, d.Italic, d.Bold)); err != nil {
+		return
+	}
+	if _, err = d.FontName.WriteTo(c); err != nil {
+		return
+	}
+	_, err = c.Write(d.FontData)
+	return
+}
+
+func (d *DefineFont4) Size(ver uint8, id uint16) int32 {
+	return 2 + 1 + d.FontName.Size() + int32(len(d.FontData))
+}
+
+func (d *DefineFont4) MinVersion() uint8 {
+	return 10
+}
+
+func (d *DefineFont4) TagId() uint16 {
+	return TAG_DEFINE_FONT4
+}
+
+func (d *DefineFont4) Name() string {
+	return "DefineFont4"
+}
+
+// WriteOTF writes the embedded CFF based OpenType font.
+func (d *DefineFont4) WriteOTF(w io.Writer) (int64, error) {
+	if len(d.FontData) == 0 {
+		return 0, ErrNoFontData
+	}
+	n, err := w.Write(d.FontData)
+	return int64(n), err
+}
+
+type DefineFontName struct {
+	FontId        uint16
+	FontName      String
+	FontCopyright String
+}
+
+func (d *DefineFontName) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.FontId); err != nil {
+		return
+	}
+	err = ReadAll(c, &d.FontName, &d.FontCopyright)
+	return
+}
+
+func (d *DefineFontName) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.FontId); err != nil {
+		return
+	}
+	err = WriteAll(c, &d.FontName, &d.FontCopyright)
+	return
+}
+
+func (d *DefineFontName) Size(ver uint8, id uint16) int32 {
+	return 2 + SizeAll(&d.FontName, &d.FontCopyright)
+}
+
+func (d *DefineFontName) MinVersion() uint8 {
+	return 9
+}
+
+func (d *DefineFontName) TagId() uint16 {
+	return TAG_DEFINE_FONT_NAME
+}
+
+func (d *DefineFontName) Name() string {
+	return "DefineFontName"
+}
+
+// FontName returns the DefineFontName tag for the given font id, if present.
+func (s *SWF) FontName(fontId uint16) *DefineFontName {
+	for _, tag := range s.Tags {
+		if d, ok := tag.(*DefineFontName); ok && d.FontId == fontId {
+			return d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package swf
+
+import "testing"
+
+var testGlyph = []byte{16, 20, 197, 89, 209, 122, 37, 0, 176, 0}
+
+func testGlyphShape() Shape {
+	return Shape{
+		NumFillBits: 1,
+		ShapeRecords: []ShapeRecord{
+			&StyleChangeRecord{MoveTo: true, MoveDeltaX: 10, MoveDeltaY: -20, HasFillStyle1: true, FillStyle1: 1},
+			&StraightEdgeRecord{DeltaY: 30},
+			&CurvedEdgeRecord{ControlDeltaX: 5, AnchorDeltaY: -5},
+		},
+	}
+}
+
+func TestDefineFont(t *testing.T) {
+	testTag(t, 1, append([]byte{2, 0, 2, 0}, testGlyph...), &DefineFont{
+		FontId:          2,
+		GlyphShapeTable: []Shape{testGlyphShape()},
+	})
+}
+
+func TestDefineFontInfo2(t *testing.T) {
+	testTag(t, 6, []byte{1, 0, 3, 'F', 'o', 'o', 3, 2, 65, 0, 0x42, 0x30}, &DefineFontInfo2{DefineFontInfo{
+		FontId:       1,
+		FontName:     "Foo",
+		Bold:         true,
+		WideCodes:    true,
+		LanguageCode: LANGUAGE_JAPENESE,
+		CodeTable:    []uint16{65, 0x3042},
+	}})
+}
+
+func TestDefineFont2(t *testing.T) {
+	data := []byte{1, 0, 0x84, 1, 2, 'A', 'b', 1, 0, 4, 0, 14, 0}
+	data = append(data, testGlyph...)
+	data = append(data, 65, 0, 0x20, 3, 200, 0, 0xf6, 0xff, 0xf4, 1, 8, 0, 1, 0, 65, 0, 66, 0, 0xe2, 0xff)
+	testTag(t, 3, data, &DefineFont2{
+		FontId:           1,
+		HasLayout:        true,
+		WideCodes:        true,
+		LanguageCode:     LANGUAGE_LATIN,
+		FontName:         "Ab",
+		GlyphShapeTable:  []Shape{testGlyphShape()},
+		CodeTable:        []uint16{65},
+		FontAscent:       800,
+		FontDescent:      200,
+		FontLeading:      -10,
+		FontAdvanceTable: []int16{500},
+		FontBoundsTable:  []Rect{{}},
+		FontKerningTable: []KerningRecord{{65, 66, -30}},
+	})
+}
+
+func TestDefineFont4(t *testing.T) {
+	testTag(t, 10, []byte{3, 0, 7, 'S', 'a', 'n', 's', 0, 'O', 'T', 'T', 'O'}, &DefineFont4{
+		FontId:   3,
+		Italic:   true,
+		Bold:     true,
+		FontName: "Sans",
+		FontData: []byte("OTTO"),
+	})
+}
+
+func TestDefineFontName(t *testing.T) {
+	testTag(t, 9, []byte{3, 0, 'S', 'a', 'n', 's', 0, '(', 'c', ')', 0}, &DefineFontName{
+		FontId:        3,
+		FontName:      "Sans",
+		FontCopyright: "(c)",
+	})
+}
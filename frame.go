@@ -0,0 +1,117 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import "fmt"
+
+// Frame is a view of the tags making up a single frame of a timeline. Labels
+// holds the FrameLabel tags of the frame and Tags all other tags preceding
+// its ShowFrame.
+type Frame struct {
+	Labels []*FrameLabel
+	Tags   []Tag
+}
+
+// Anchor returns the first label of the frame marked as a named anchor.
+func (f *Frame) Anchor() (string, bool) {
+	for _, l := range f.Labels {
+		if l.NamedAnchor {
+			return string(l.Label), true
+		}
+	}
+	return "", false
+}
+
+type FrameCountError struct {
+	Tag        string
+	Id         uint16
+	FrameCount uint16
+	ShowFrames int
+}
+
+func (e FrameCountError) Error() string {
+	return fmt.Sprintf("%s %d: FrameCount is %d, but found %d ShowFrame tags", e.Tag, e.Id, e.FrameCount, e.ShowFrames)
+}
+
+// frames groups the tags by ShowFrame. Tags after the last ShowFrame are not
+// part of any frame and are ignored.
+func frames(tags []Tag) []Frame {
+	var (
+		fs []Frame
+		f  Frame
+	)
+	for _, tag := range tags {
+		switch t := tag.(type) {
+		case *ShowFrame:
+			fs = append(fs, f)
+			f = Frame{}
+		case *FrameLabel:
+			f.Labels = append(f.Labels, t)
+		default:
+			f.Tags = append(f.Tags, tag)
+		}
+	}
+	return fs
+}
+
+// Frames returns the frames of the root timeline. If the number of frames
+// does not match FrameCount, for the root or any DefineSprite, the frames are
+// returned along with a FrameCountError.
+func (s *SWF) Frames() ([]Frame, error) {
+	fs := frames(s.Tags)
+	if len(fs) != int(s.FrameCount) {
+		return fs, &FrameCountError{"SWF", 0, s.FrameCount, len(fs)}
+	}
+	for _, tag := range s.Tags {
+		if d, ok := tag.(*DefineSprite); ok {
+			if _, err := d.Frames(); err != nil {
+				return fs, err
+			}
+		}
+	}
+	return fs, nil
+}
+
+// Frames returns the frames of the sprite, with a FrameCountError if their
+// number does not match FrameCount.
+func (d *DefineSprite) Frames() ([]Frame, error) {
+	fs := frames(d.ControlTags)
+	if len(fs) != int(d.FrameCount) {
+		return fs, &FrameCountError{d.Name(), d.SpriteId, d.FrameCount, len(fs)}
+	}
+	return fs, nil
+}
+
+// FrameIndex returns the zero-based index of the frame with the given label.
+func FrameIndex(frames []Frame, label string) (int, bool) {
+	for n, f := range frames {
+		for _, l := range f.Labels {
+			if string(l.Label) == label {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
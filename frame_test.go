@@ -0,0 +1,51 @@
+package swf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFrames(t *testing.T) {
+	label := &FrameLabel{Label: "intro", NamedAnchor: true}
+	place := &PlaceObject2{HasCharacter: true, Depth: 1, CharacterId: 2}
+	sprite := testSprite()
+	s := &SWF{
+		FrameCount: 2,
+		Tags: []Tag{
+			sprite,
+			label,
+			place,
+			&ShowFrame{},
+			&ShowFrame{},
+		},
+	}
+	fs, err := s.Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Frame{
+		{[]*FrameLabel{label}, []Tag{sprite, place}},
+		{},
+	}
+	if !reflect.DeepEqual(fs, expected) {
+		t.Errorf("expecting %v, got %v", expected, fs)
+	}
+	if a, ok := fs[0].Anchor(); !ok || a != "intro" {
+		t.Errorf("expecting anchor %q, got %q", "intro", a)
+	}
+	if _, ok := fs[1].Anchor(); ok {
+		t.Error("expecting no anchor")
+	}
+	if n, ok := FrameIndex(fs, "intro"); !ok || n != 0 {
+		t.Errorf("expecting frame 0, got %d", n)
+	}
+	s.FrameCount = 3
+	if _, err = s.Frames(); !reflect.DeepEqual(err, &FrameCountError{"SWF", 0, 3, 2}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	s.FrameCount = 2
+	sprite.FrameCount = 2
+	if _, err = s.Frames(); !reflect.DeepEqual(err, &FrameCountError{"DefineSprite", 2, 2, 1}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
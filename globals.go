@@ -0,0 +1,711 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func arg(args []avmValue, n int) avmValue {
+	if n < len(args) {
+		return args[n]
+	}
+	return avmUndefined{}
+}
+
+func shift(args []avmValue) []avmValue {
+	if len(args) == 0 {
+		return nil
+	}
+	return args[1:]
+}
+
+func (p *Player) newArray(elements []avmValue) *avmObject {
+	a := newObject(p.arrayProto)
+	a.class = "Array"
+	a.elements = append([]avmValue{}, elements...)
+	return a
+}
+
+func (p *Player) native(o *avmObject, name string, f avmNative) {
+	o.props[name] = p.newFunction(f, nil)
+}
+
+// thisString returns the string a String method was called on.
+func (p *Player) thisString(this avmValue) string {
+	return p.toString(this)
+}
+
+func (p *Player) thisArray(this avmValue) *avmObject {
+	if a, ok := this.(*avmObject); ok && a.class == "Array" {
+		return a
+	}
+	return p.newArray(nil)
+}
+
+func (p *Player) thisClip(this avmValue) *MovieClip {
+	if o, ok := this.(*avmObject); ok && o.clip != nil {
+		return o.clip
+	}
+	return p.Root
+}
+
+// index clamps a possibly negative index, counting negatives from the end.
+func index(v float64, length int) int {
+	if math.IsNaN(v) {
+		return 0
+	}
+	n := int(v)
+	if n < 0 {
+		n += length
+	}
+	if n < 0 {
+		return 0
+	}
+	if n > length {
+		return length
+	}
+	return n
+}
+
+func (p *Player) initGlobals() {
+	p.objectProto = newObject(nil)
+	p.functionProto = newObject(p.objectProto)
+	p.global = newObject(p.objectProto)
+	constructor := func(name string, proto *avmObject, call, construct avmNative) *avmObject {
+		c := p.newFunction(call, construct)
+		c.props["prototype"] = proto
+		proto.props["constructor"] = c
+		p.global.props[name] = c
+		return c
+	}
+	// Object
+	constructor("Object", p.objectProto, func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		if o, ok := arg(args, 0).(*avmObject); ok {
+			return o, nil
+		}
+		return newObject(p.objectProto), nil
+	}, nil)
+	p.native(p.objectProto, "hasOwnProperty", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		if o, ok := this.(*avmObject); ok {
+			_, has := o.own(p.toString(arg(args, 0)))
+			return has, nil
+		}
+		return false, nil
+	})
+	p.native(p.objectProto, "toString", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return p.toString(this), nil
+	})
+	p.native(p.objectProto, "valueOf", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return this, nil
+	})
+	p.native(p.objectProto, "addProperty", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return false, nil
+	})
+	p.native(p.objectProto, "registerClass", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return true, nil
+	})
+	p.native(p.global, "ASSetPropFlags", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return avmUndefined{}, nil
+	})
+
+	// Function
+	constructor("Function", p.functionProto, func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return avmUndefined{}, nil
+	}, nil)
+	p.native(p.functionProto, "call", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		if len(args) == 0 {
+			return p.call(this, avmUndefined{}, nil)
+		}
+		return p.call(this, args[0], args[1:])
+	})
+	p.native(p.functionProto, "apply", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		var list []avmValue
+		if a, ok := arg(args, 1).(*avmObject); ok && a.class == "Array" {
+			list = a.elements
+		}
+		return p.call(this, arg(args, 0), list)
+	})
+
+	// Array
+	p.arrayProto = newObject(p.objectProto)
+	newArray := func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		if len(args) == 1 {
+			if n, ok := args[0].(float64); ok {
+				a := p.newArray(nil)
+				return a, a.set("length", n)
+			}
+		}
+		return p.newArray(args), nil
+	}
+	constructor("Array", p.arrayProto, newArray, newArray)
+	p.native(p.arrayProto, "push", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		a := p.thisArray(this)
+		if err := a.splice(len(a.elements), len(a.elements), args); err != nil {
+			return nil, err
+		}
+		return float64(len(a.elements)), nil
+	})
+	p.native(p.arrayProto, "pop", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		a := p.thisArray(this)
+		if len(a.elements) == 0 {
+			return avmUndefined{}, nil
+		}
+		v := a.elements[len(a.elements)-1]
+		a.elements = a.elements[:len(a.elements)-1]
+		return v, nil
+	})
+	p.native(p.arrayProto, "shift", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		a := p.thisArray(this)
+		if len(a.elements) == 0 {
+			return avmUndefined{}, nil
+		}
+		v := a.elements[0]
+		a.elements = a.elements[1:]
+		return v, nil
+	})
+	p.native(p.arrayProto, "unshift", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		a := p.thisArray(this)
+		if err := a.splice(0, 0, args); err != nil {
+			return nil, err
+		}
+		return float64(len(a.elements)), nil
+	})
+	p.native(p.arrayProto, "join", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		sep := ","
+		if len(args) > 0 && args[0] != (avmUndefined{}) {
+			sep = p.toString(args[0])
+		}
+		return p.join(p.thisArray(this), sep)
+	})
+	p.native(p.arrayProto, "toString", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return p.join(p.thisArray(this), ",")
+	})
+	p.native(p.arrayProto, "reverse", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		a := p.thisArray(this)
+		for i, j := 0, len(a.elements)-1; i < j; i, j = i+1, j-1 {
+			a.elements[i], a.elements[j] = a.elements[j], a.elements[i]
+		}
+		return a, nil
+	})
+	p.native(p.arrayProto, "concat", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		r := p.newArray(p.thisArray(this).elements)
+		for _, v := range args {
+			elements := []avmValue{v}
+			if a, ok := v.(*avmObject); ok && a.class == "Array" {
+				elements = a.elements
+			}
+			if err := r.splice(len(r.elements), len(r.elements), elements); err != nil {
+				return nil, err
+			}
+		}
+		return r, nil
+	})
+	p.native(p.arrayProto, "slice", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		a := p.thisArray(this)
+		start, end := index(p.toNumber(arg(args, 0)), len(a.elements)), len(a.elements)
+		if len(args) > 1 {
+			end = index(p.toNumber(args[1]), len(a.elements))
+		}
+		if end < start {
+			end = start
+		}
+		return p.newArray(a.elements[start:end]), nil
+	})
+	p.native(p.arrayProto, "splice", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		a := p.thisArray(this)
+		start, end := index(p.toNumber(arg(args, 0)), len(a.elements)), len(a.elements)
+		if len(args) > 1 {
+			end = start + int(math.Max(0, p.toNumber(args[1])))
+			if end > len(a.elements) {
+				end = len(a.elements)
+			}
+		}
+		removed := p.newArray(a.elements[start:end])
+		var inserted []avmValue
+		if len(args) > 2 {
+			inserted = args[2:]
+		}
+		return removed, a.splice(start, end, inserted)
+	})
+	p.native(p.arrayProto, "sort", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		a := p.thisArray(this)
+		var err error
+		sort.SliceStable(a.elements, func(i, j int) bool {
+			if f, ok := arg(args, 0).(*avmObject); ok && f.call != nil {
+				if err != nil {
+					return false
+				}
+				var r avmValue
+				r, err = p.call(f, avmUndefined{}, []avmValue{a.elements[i], a.elements[j]})
+				return p.toNumber(r) < 0
+			}
+			return p.toString(a.elements[i]) < p.toString(a.elements[j])
+		})
+		return a, err
+	})
+
+	// String
+	p.stringProto = newObject(p.objectProto)
+	toString := func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		if len(args) == 0 {
+			return "", nil
+		}
+		return p.toString(args[0]), nil
+	}
+	str := constructor("String", p.stringProto, toString, toString)
+	p.native(str, "fromCharCode", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		r := make([]rune, len(args))
+		for n, a := range args {
+			r[n] = rune(p.toNumber(a))
+		}
+		return string(r), nil
+	})
+	p.native(p.stringProto, "toString", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return p.thisString(this), nil
+	})
+	p.native(p.stringProto, "valueOf", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return p.thisString(this), nil
+	})
+	p.native(p.stringProto, "toUpperCase", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return strings.ToUpper(p.thisString(this)), nil
+	})
+	p.native(p.stringProto, "toLowerCase", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return strings.ToLower(p.thisString(this)), nil
+	})
+	p.native(p.stringProto, "charAt", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := []rune(p.thisString(this))
+		if n := int(p.toNumber(arg(args, 0))); n >= 0 && n < len(s) {
+			return string(s[n]), nil
+		}
+		return "", nil
+	})
+	p.native(p.stringProto, "charCodeAt", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := []rune(p.thisString(this))
+		if n := int(p.toNumber(arg(args, 0))); n >= 0 && n < len(s) {
+			return float64(s[n]), nil
+		}
+		return math.NaN(), nil
+	})
+	p.native(p.stringProto, "concat", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		strs := []string{p.thisString(this)}
+		for _, a := range args {
+			strs = append(strs, p.toString(a))
+		}
+		return concat(strs...)
+	})
+	p.native(p.stringProto, "indexOf", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := []rune(p.thisString(this))
+		start := 0
+		if len(args) > 1 {
+			start = index(p.toNumber(args[1]), len(s))
+		}
+		n := strings.Index(string(s[start:]), p.toString(arg(args, 0)))
+		if n < 0 {
+			return -1.0, nil
+		}
+		return float64(start + len([]rune(string(s[start:])[:n]))), nil
+	})
+	p.native(p.stringProto, "lastIndexOf", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := p.thisString(this)
+		n := strings.LastIndex(s, p.toString(arg(args, 0)))
+		if n < 0 {
+			return -1.0, nil
+		}
+		return float64(len([]rune(s[:n]))), nil
+	})
+	p.native(p.stringProto, "slice", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := []rune(p.thisString(this))
+		start, end := index(p.toNumber(arg(args, 0)), len(s)), len(s)
+		if len(args) > 1 {
+			end = index(p.toNumber(args[1]), len(s))
+		}
+		if end < start {
+			end = start
+		}
+		return string(s[start:end]), nil
+	})
+	p.native(p.stringProto, "substring", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := []rune(p.thisString(this))
+		start, end := int(math.Max(0, p.toNumber(arg(args, 0)))), len(s)
+		if len(args) > 1 {
+			end = int(math.Max(0, p.toNumber(args[1])))
+		}
+		if start > end {
+			start, end = end, start
+		}
+		if start > len(s) {
+			start = len(s)
+		}
+		if end > len(s) {
+			end = len(s)
+		}
+		return string(s[start:end]), nil
+	})
+	p.native(p.stringProto, "substr", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := []rune(p.thisString(this))
+		start, end := index(p.toNumber(arg(args, 0)), len(s)), len(s)
+		if len(args) > 1 {
+			if l := p.toNumber(args[1]); l >= 0 && start+int(l) < end {
+				end = start + int(l)
+			} else if l < 0 {
+				end = start
+			}
+		}
+		return string(s[start:end]), nil
+	})
+	p.native(p.stringProto, "split", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := p.thisString(this)
+		if arg(args, 0) == (avmUndefined{}) {
+			return p.newArray([]avmValue{s}), nil
+		}
+		parts := strings.Split(s, p.toString(args[0]))
+		elements := make([]avmValue, len(parts))
+		for n, part := range parts {
+			elements[n] = part
+		}
+		return p.newArray(elements), nil
+	})
+
+	// Number and Boolean
+	p.numberProto = newObject(p.objectProto)
+	toNumber := func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		if len(args) == 0 {
+			return 0.0, nil
+		}
+		return p.toNumber(args[0]), nil
+	}
+	num := constructor("Number", p.numberProto, toNumber, toNumber)
+	num.props["MAX_VALUE"] = math.MaxFloat64
+	num.props["MIN_VALUE"] = 5e-324
+	num.props["NaN"] = math.NaN()
+	num.props["POSITIVE_INFINITY"] = math.Inf(1)
+	num.props["NEGATIVE_INFINITY"] = math.Inf(-1)
+	p.native(p.numberProto, "toString", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		f := p.toNumber(this)
+		if radix := int(p.toNumber(arg(args, 0))); radix >= 2 && radix <= 36 && radix != 10 && f == math.Trunc(f) {
+			return strconv.FormatInt(int64(f), radix), nil
+		}
+		return formatNumber(f), nil
+	})
+	p.booleanProto = newObject(p.objectProto)
+	toBoolean := func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return p.toBoolean(arg(args, 0)), nil
+	}
+	constructor("Boolean", p.booleanProto, toBoolean, toBoolean)
+	p.native(p.booleanProto, "toString", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return strconv.FormatBool(p.toBoolean(this)), nil
+	})
+
+	// Math
+	m := newObject(p.objectProto)
+	p.global.props["Math"] = m
+	for _, f := range [...]struct {
+		name string
+		f    func(float64) float64
+	}{
+		{"abs", math.Abs},
+		{"acos", math.Acos},
+		{"asin", math.Asin},
+		{"atan", math.Atan},
+		{"ceil", math.Ceil},
+		{"cos", math.Cos},
+		{"exp", math.Exp},
+		{"floor", math.Floor},
+		{"log", math.Log},
+		{"round", func(f float64) float64 { return math.Floor(f + 0.5) }},
+		{"sin", math.Sin},
+		{"sqrt", math.Sqrt},
+		{"tan", math.Tan},
+	} {
+		name, f := f.name, f.f
+		p.native(m, name, func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+			return f(p.toNumber(arg(args, 0))), nil
+		})
+	}
+	for _, f := range [...]struct {
+		name string
+		f    func(float64, float64) float64
+	}{
+		{"atan2", math.Atan2},
+		{"max", math.Max},
+		{"min", math.Min},
+		{"pow", math.Pow},
+	} {
+		name, f := f.name, f.f
+		p.native(m, name, func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+			return f(p.toNumber(arg(args, 0)), p.toNumber(arg(args, 1))), nil
+		})
+	}
+	p.native(m, "random", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return p.random.Float64(), nil
+	})
+	m.props["E"] = math.E
+	m.props["LN10"] = math.Ln10
+	m.props["LN2"] = math.Ln2
+	m.props["LOG10E"] = math.Log10E
+	m.props["LOG2E"] = math.Log2E
+	m.props["PI"] = math.Pi
+	m.props["SQRT1_2"] = math.Sqrt2 / 2
+	m.props["SQRT2"] = math.Sqrt2
+
+	// global functions
+	p.global.props["NaN"] = math.NaN()
+	p.global.props["Infinity"] = math.Inf(1)
+	p.native(p.global, "isNaN", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return math.IsNaN(p.toNumber(arg(args, 0))), nil
+	})
+	p.native(p.global, "isFinite", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		f := p.toNumber(arg(args, 0))
+		return !math.IsNaN(f) && !math.IsInf(f, 0), nil
+	})
+	p.native(p.global, "parseInt", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := strings.TrimSpace(p.toString(arg(args, 0)))
+		radix := int(p.toNumber(arg(args, 1)))
+		neg := strings.HasPrefix(s, "-")
+		s = strings.TrimLeft(s, "+-")
+		if (radix == 0 || radix == 16) && (strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")) {
+			s, radix = s[2:], 16
+		} else if radix == 0 {
+			radix = 10
+		}
+		if radix < 2 || radix > 36 {
+			return math.NaN(), nil
+		}
+		l := 0
+		for l < len(s) {
+			if d, err := strconv.ParseInt(s[l:l+1], radix, 64); err != nil || d < 0 {
+				break
+			}
+			l++
+		}
+		n, err := strconv.ParseInt(s[:l], radix, 64)
+		if err != nil {
+			return math.NaN(), nil
+		}
+		if neg {
+			n = -n
+		}
+		return float64(n), nil
+	})
+	p.native(p.global, "parseFloat", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s := strings.TrimSpace(p.toString(arg(args, 0)))
+		for l := len(s); l > 0; l-- {
+			if f, err := strconv.ParseFloat(s[:l], 64); err == nil {
+				return f, nil
+			}
+		}
+		return math.NaN(), nil
+	})
+	p.native(p.global, "escape", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return strings.Replace(url.QueryEscape(p.toString(arg(args, 0))), "+", "%20", -1), nil
+	})
+	p.native(p.global, "unescape", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		s, err := url.QueryUnescape(strings.Replace(p.toString(arg(args, 0)), "+", "%2B", -1))
+		if err != nil {
+			return p.toString(arg(args, 0)), nil
+		}
+		return s, nil
+	})
+	p.native(p.global, "getTimer", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return p.time(), nil
+	})
+	p.native(p.global, "setInterval", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		p.intervalId++
+		i := &playerInterval{id: p.intervalId, this: avmUndefined{}}
+		f, args := arg(args, 0), shift(args)
+		if o, ok := f.(*avmObject); ok && o.call == nil {
+			// setInterval(object, method, interval, ...)
+			i.this, f, args = o, o.get(p.toString(arg(args, 0))), shift(args)
+		}
+		i.function, i.every, i.args = f, p.toNumber(arg(args, 0)), shift(args)
+		if math.IsNaN(i.every) || i.every < 1 {
+			i.every = 1
+		}
+		i.next = p.time() + i.every
+		p.intervals = append(p.intervals, i)
+		return float64(i.id), nil
+	})
+	p.native(p.global, "clearInterval", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		id := int(p.toNumber(arg(args, 0)))
+		for n, i := range p.intervals {
+			if i.id == id {
+				p.intervals = append(p.intervals[:n], p.intervals[n+1:]...)
+				break
+			}
+		}
+		return avmUndefined{}, nil
+	})
+
+	// MovieClip
+	p.clipProto = newObject(p.objectProto)
+	constructor("MovieClip", p.clipProto, func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return avmUndefined{}, nil
+	}, nil)
+	p.native(p.clipProto, "play", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		p.thisClip(this).Playing = true
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "stop", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		p.thisClip(this).Playing = false
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "gotoAndPlay", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		c.gotoAndPlay(p.frameNumber(c, arg(args, 0)), true)
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "gotoAndStop", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		c.gotoAndPlay(p.frameNumber(c, arg(args, 0)), false)
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "nextFrame", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		c.gotoAndPlay(c.frame+1, false)
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "prevFrame", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		c.gotoAndPlay(c.frame-1, false)
+		return avmUndefined{}, nil
+	})
+	method := func(s string) uint8 {
+		switch strings.ToUpper(s) {
+		case "GET":
+			return SEND_VARS_GET
+		case "POST":
+			return SEND_VARS_POST
+		}
+		return SEND_VARS_NONE
+	}
+	p.native(p.clipProto, "getURL", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		p.getURL(c, p.toString(arg(args, 0)), p.toString(arg(args, 1)), method(p.toString(arg(args, 2))), false, false)
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "loadMovie", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		p.getURL(c, p.toString(arg(args, 0)), c.Target(), method(p.toString(arg(args, 1))), true, false)
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "loadVariables", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		p.getURL(c, p.toString(arg(args, 0)), c.Target(), method(p.toString(arg(args, 1))), false, true)
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "unloadMovie", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		for _, child := range c.children {
+			c.removeChild(child)
+		}
+		c.frames, c.frame = nil, -1
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "removeMovieClip", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		if c := p.thisClip(this); c.Parent != nil && c.dynamic {
+			c.Parent.removeChild(c)
+		}
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "duplicateMovieClip", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		if d := p.thisClip(this).duplicate(p.toString(arg(args, 0)), int(p.toNumber(arg(args, 1)))); d != nil {
+			return d.object, nil
+		}
+		return avmUndefined{}, nil
+	})
+	p.native(p.clipProto, "createEmptyMovieClip", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		c := p.thisClip(this)
+		child := c.place(int(p.toNumber(arg(args, 1))), 0, true)
+		child.Name = p.toString(arg(args, 0))
+		child.dynamic = true
+		return child.object, nil
+	})
+	p.native(p.clipProto, "getDepth", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return float64(p.thisClip(this).Depth), nil
+	})
+	p.native(p.clipProto, "getBytesLoaded", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return 1.0, nil
+	})
+	p.native(p.clipProto, "getBytesTotal", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return 1.0, nil
+	})
+	p.native(p.clipProto, "hitTest", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return false, nil
+	})
+
+	// LoadVars
+	p.loadVarsProto = newObject(p.objectProto)
+	constructor("LoadVars", p.loadVarsProto, func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return avmUndefined{}, nil
+	}, func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return newObject(p.loadVarsProto), nil
+	})
+	loadVars := func(t uint8, m uint8) avmNative {
+		return func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+			o, ok := this.(*avmObject)
+			if !ok {
+				return false, nil
+			}
+			r := URLRequest{
+				Type:   t,
+				URL:    p.toString(arg(args, 0)),
+				Source: p.current.Target(),
+			}
+			if t == REQUEST_NAVIGATE {
+				r.Target = p.toString(arg(args, 1))
+			}
+			if n := len(args) - 1; n > 0 && m != SEND_VARS_NONE {
+				m = method(p.toString(args[n]))
+				if m == SEND_VARS_NONE {
+					m = SEND_VARS_POST
+				}
+			}
+			p.request(r, m, o)
+			return true, nil
+		}
+	}
+	p.native(p.loadVarsProto, "load", loadVars(REQUEST_LOAD_VARIABLES, SEND_VARS_NONE))
+	p.native(p.loadVarsProto, "send", loadVars(REQUEST_NAVIGATE, SEND_VARS_POST))
+	p.native(p.loadVarsProto, "sendAndLoad", loadVars(REQUEST_LOAD_VARIABLES, SEND_VARS_POST))
+	p.native(p.loadVarsProto, "addRequestHeader", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		return avmUndefined{}, nil
+	})
+	p.native(p.loadVarsProto, "toString", func(p *Player, this avmValue, args []avmValue) (avmValue, error) {
+		o, ok := this.(*avmObject)
+		if !ok {
+			return "", nil
+		}
+		v := url.Values{}
+		for k, s := range p.variables(o) {
+			v.Set(k, s)
+		}
+		return v.Encode(), nil
+	})
+}
@@ -0,0 +1,466 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownString = errors.New("translate: unknown string id")
+	ErrPOSyntax      = errors.New("po: syntax error")
+)
+
+// Translation is a localisable string. Id identifies the character holding
+// the string, for example "text:12" for a DefineText or "edit:7" for a
+// DefineEditText. Strings from ActionScript constant pools are identified by
+// the index of the pool, counting every ActionConstantPool in tag order, and
+// of the constant within it, so "pool:0:3" is the fourth constant of the
+// first pool.
+type Translation struct {
+	Id, Source, Target string
+}
+
+// MissingGlyph records a character used in a translation that the font of
+// the text does not contain.
+type MissingGlyph struct {
+	Id     string
+	FontId uint16
+	Char   rune
+}
+
+func (m MissingGlyph) String() string {
+	return fmt.Sprintf("%s: font %d has no glyph for %q", m.Id, m.FontId, m.Char)
+}
+
+// Strings returns the editable strings in the SWF, with empty targets.
+func (s *SWF) Strings() []Translation {
+	var (
+		ts         []Translation
+		codeTables = s.CodeTables()
+	)
+	for _, tag := range s.Tags {
+		var d *DefineText
+		switch t := tag.(type) {
+		case *DefineText:
+			d = t
+		case *DefineText2:
+			d = &t.DefineText
+		case *DefineEditText:
+			if t.HasText && t.InitialText != "" {
+				ts = append(ts, Translation{Id: fmt.Sprintf("edit:%d", t.CharacterId), Source: string(t.InitialText)})
+			}
+		}
+		if d != nil {
+			if text, err := d.Text(codeTables); err == nil && text != "" {
+				ts = append(ts, Translation{Id: fmt.Sprintf("text:%d", d.CharacterId), Source: text})
+			}
+		}
+	}
+	for n, p := range constantPools(s.Tags) {
+		for m, c := range p.Constants {
+			if c != "" {
+				ts = append(ts, Translation{Id: fmt.Sprintf("pool:%d:%d", n, m), Source: string(c)})
+			}
+		}
+	}
+	return ts
+}
+
+// constantPools returns the ActionConstantPool actions of the frame, button
+// and clip actions, including those in sprites, in tag order.
+func constantPools(tags []Tag) []*ActionConstantPool {
+	var pools []*ActionConstantPool
+	for _, actions := range actionLists(tags) {
+		for _, a := range actions {
+			if p, ok := a.(*ActionConstantPool); ok {
+				pools = append(pools, p)
+			}
+		}
+	}
+	return pools
+}
+
+// Translate replaces the strings in the SWF with the targets of the given
+// translations; translations with an empty target are ignored. Static text
+// has its glyph entries regenerated from the font. Strings that need glyphs
+// the font lacks, or whose font cannot be found, are left unchanged and the
+// missing glyphs are returned.
+func (s *SWF) Translate(ts []Translation) ([]MissingGlyph, error) {
+	texts := make(map[string]*DefineText)
+	edits := make(map[string]*DefineEditText)
+	consts := make(map[string]*String)
+	for _, tag := range s.Tags {
+		switch t := tag.(type) {
+		case *DefineText:
+			texts[fmt.Sprintf("text:%d", t.CharacterId)] = t
+		case *DefineText2:
+			texts[fmt.Sprintf("text:%d", t.CharacterId)] = &t.DefineText
+		case *DefineEditText:
+			edits[fmt.Sprintf("edit:%d", t.CharacterId)] = t
+		}
+	}
+	for n, p := range constantPools(s.Tags) {
+		for m := range p.Constants {
+			consts[fmt.Sprintf("pool:%d:%d", n, m)] = &p.Constants[m]
+		}
+	}
+	for _, t := range ts {
+		if _, ok := texts[t.Id]; ok {
+			continue
+		} else if _, ok = edits[t.Id]; ok {
+			continue
+		} else if _, ok = consts[t.Id]; !ok {
+			return nil, ErrUnknownString
+		}
+	}
+	var (
+		missing []MissingGlyph
+		fonts   = newTextFonts(s.Tags)
+	)
+	for _, t := range ts {
+		if t.Target == "" {
+			continue
+		}
+		if d, ok := texts[t.Id]; ok {
+			missing = append(missing, d.setText(t.Id, t.Target, fonts)...)
+		} else if e, ok := edits[t.Id]; ok {
+			missing = append(missing, e.setText(t.Id, t.Target, fonts)...)
+		} else {
+			*consts[t.Id] = String(t.Target)
+		}
+	}
+	return missing, nil
+}
+
+type textFont struct {
+	glyphs   map[rune]uint32
+	advances []int16
+	emSize   int32
+}
+
+func newTextFonts(tags []Tag) map[uint16]*textFont {
+	fonts := make(map[uint16]*textFont)
+	add := func(fontId uint16, codes []uint16, advances []int16, emSize int32) {
+		f := &textFont{make(map[rune]uint32, len(codes)), advances, emSize}
+		for n, code := range codes {
+			f.glyphs[rune(code)] = uint32(n)
+		}
+		fonts[fontId] = f
+	}
+	for _, tag := range tags {
+		switch t := tag.(type) {
+		case *DefineFontInfo:
+			add(t.FontId, t.CodeTable, nil, 1024)
+		case *DefineFontInfo2:
+			add(t.FontId, t.CodeTable, nil, 1024)
+		case *DefineFont2:
+			add(t.FontId, t.CodeTable, t.FontAdvanceTable, 1024)
+		case *DefineFont3:
+			add(t.FontId, t.CodeTable, t.FontAdvanceTable, 20*1024)
+		}
+	}
+	return fonts
+}
+
+func (f *textFont) missing(id string, fontId uint16, text string) []MissingGlyph {
+	var missing []MissingGlyph
+	for _, r := range text {
+		if _, ok := f.glyphs[r]; !ok && r != '\n' && r != '\r' {
+			missing = append(missing, MissingGlyph{id, fontId, r})
+		}
+	}
+	return missing
+}
+
+func (d *DefineEditText) setText(id, text string, fonts map[uint16]*textFont) []MissingGlyph {
+	if d.HasFont && d.UseOutlines {
+		if f, ok := fonts[d.FontId]; ok {
+			plain := text
+			if d.HTML {
+				plain = stripHTML(text)
+			}
+			if missing := f.missing(id, d.FontId, plain); len(missing) > 0 {
+				return missing
+			}
+		}
+	}
+	d.SetText(text)
+	return nil
+}
+
+// setText replaces the text records with ones drawing text, one line per
+// record. Each line takes its style and position from the corresponding
+// line of the original text; extra lines continue the original line spacing.
+func (d *DefineText) setText(id, text string, fonts map[uint16]*textFont) []MissingGlyph {
+	var (
+		lines    []TextRecord
+		style    TextRecord
+		hasFont  bool
+		advances = make(map[uint16]map[uint32]int32)
+	)
+	for n, t := range d.TextRecords {
+		if t.HasFont {
+			style.FontId, style.TextHeight, hasFont = t.FontId, t.TextHeight, true
+		}
+		if t.HasColor {
+			style.HasColor, style.TextColor = true, t.TextColor
+		}
+		newLine := n == 0 || (t.HasYOffset && (!style.HasYOffset || t.YOffset != style.YOffset))
+		if t.HasYOffset {
+			style.HasYOffset, style.YOffset = true, t.YOffset
+		}
+		if t.HasXOffset {
+			style.HasXOffset, style.XOffset = true, t.XOffset
+		}
+		if newLine && hasFont {
+			lines = append(lines, style)
+		}
+		if hasFont {
+			if advances[style.FontId] == nil {
+				advances[style.FontId] = make(map[uint32]int32)
+			}
+			for _, g := range t.GlyphEntries {
+				advances[style.FontId][g.GlyphIndex] = g.GlyphAdvance
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return new(textFont).missing(id, 0, text)
+	}
+	var (
+		missing []MissingGlyph
+		records []TextRecord
+		record  TextRecord
+	)
+	for n, line := range strings.Split(text, "\n") {
+		if n < len(lines) {
+			record = lines[n]
+		} else {
+			spacing := int16(record.TextHeight)
+			if l := len(lines); l > 1 {
+				spacing = lines[l-1].YOffset - lines[l-2].YOffset
+			}
+			record.HasYOffset, record.YOffset = true, record.YOffset+spacing
+		}
+		record.HasFont, record.GlyphEntries = true, nil
+		f, ok := fonts[record.FontId]
+		if !ok {
+			// a font that cannot be found has no glyphs
+			f = new(textFont)
+		}
+		if m := f.missing(id, record.FontId, line); len(m) > 0 {
+			missing = append(missing, m...)
+			continue
+		}
+		for _, r := range line {
+			if r == '\r' {
+				continue
+			}
+			index := f.glyphs[r]
+			record.GlyphEntries = append(record.GlyphEntries, GlyphEntry{index, f.advance(index, record.TextHeight, advances[record.FontId])})
+		}
+		records = append(records, record)
+	}
+	if len(missing) > 0 {
+		return missing
+	}
+	d.TextRecords = records
+	d.FitBits()
+	return nil
+}
+
+// advance returns the advance of the glyph at the given text height, taken
+// from the font layout when available, or else from the advances already used
+// for the glyph in the text.
+func (f *textFont) advance(index uint32, height uint16, used map[uint32]int32) int32 {
+	if index < uint32(len(f.advances)) {
+		return int32(f.advances[index]) * int32(height) / f.emSize
+	}
+	if a, ok := used[index]; ok {
+		return a
+	}
+	if len(used) > 0 {
+		var total int32
+		for _, a := range used {
+			total += a
+		}
+		return total / int32(len(used))
+	}
+	return int32(height) / 2
+}
+
+// WritePO writes the translations in gettext PO format, using the id as the
+// message context.
+func WritePO(w io.Writer, ts []Translation) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("msgid \"\"\nmsgstr \"Content-Type: text/plain; charset=UTF-8\\n\"\n")
+	for _, t := range ts {
+		fmt.Fprintf(b, "\nmsgctxt %s\nmsgid %s\nmsgstr %s\n", poQuote(t.Id), poQuote(t.Source), poQuote(t.Target))
+	}
+	return b.Flush()
+}
+
+func poQuote(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\t", "\\t", "\r", "\\r")
+	if !strings.Contains(strings.TrimSuffix(s, "\n"), "\n") {
+		return "\"" + r.Replace(s) + "\""
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for n, l := range lines {
+		lines[n] = "\"" + r.Replace(l) + "\""
+	}
+	return "\"\"\n" + strings.Join(lines, "\n")
+}
+
+// ReadPO reads translations written in gettext PO format. The header entry
+// and entries without a message context are skipped.
+func ReadPO(r io.Reader) ([]Translation, error) {
+	var (
+		ts      []Translation
+		t       Translation
+		hasCtxt bool
+		field   *string
+		line    int
+	)
+	flush := func() {
+		if hasCtxt && t.Source != "" {
+			ts = append(ts, t)
+		}
+		t, hasCtxt, field = Translation{}, false, nil
+	}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line++
+		l := strings.TrimSpace(s.Text())
+		if l == "" {
+			flush()
+			continue
+		} else if l[0] == '#' {
+			continue
+		}
+		keyword := ""
+		if l[0] != '"' {
+			p := strings.IndexByte(l, ' ')
+			if p < 0 {
+				return nil, fmt.Errorf("%s (line %d)", ErrPOSyntax, line)
+			}
+			keyword, l = l[:p], strings.TrimSpace(l[p+1:])
+		}
+		str, err := strconv.Unquote(l)
+		if err != nil {
+			return nil, fmt.Errorf("%s (line %d)", ErrPOSyntax, line)
+		}
+		switch keyword {
+		case "":
+			if field == nil {
+				return nil, fmt.Errorf("%s (line %d)", ErrPOSyntax, line)
+			}
+		case "msgctxt":
+			if field != nil {
+				flush()
+			}
+			field, hasCtxt = &t.Id, true
+		case "msgid":
+			if field == &t.Target {
+				flush()
+			}
+			field = &t.Source
+		case "msgstr":
+			field = &t.Target
+		default:
+			return nil, fmt.Errorf("%s (line %d)", ErrPOSyntax, line)
+		}
+		*field += str
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return ts, nil
+}
+
+type xliffUnit struct {
+	Id     string `xml:"id,attr"`
+	Source string `xml:"source"`
+	Target string `xml:"target,omitempty"`
+}
+
+type xliffFile struct {
+	XMLName xml.Name `xml:"xliff"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Version string   `xml:"version,attr"`
+	File    struct {
+		Original       string      `xml:"original,attr"`
+		SourceLanguage string      `xml:"source-language,attr"`
+		TargetLanguage string      `xml:"target-language,attr,omitempty"`
+		Datatype       string      `xml:"datatype,attr"`
+		Units          []xliffUnit `xml:"body>trans-unit"`
+	} `xml:"file"`
+}
+
+// WriteXLIFF writes the translations as an XLIFF 1.2 document.
+func WriteXLIFF(w io.Writer, ts []Translation, sourceLanguage, targetLanguage string) error {
+	x := xliffFile{
+		Xmlns:   "urn:oasis:names:tc:xliff:document:1.2",
+		Version: "1.2",
+	}
+	x.File.Original = "swf"
+	x.File.SourceLanguage = sourceLanguage
+	x.File.TargetLanguage = targetLanguage
+	x.File.Datatype = "x-swf"
+	x.File.Units = make([]xliffUnit, len(ts))
+	for n, t := range ts {
+		x.File.Units[n] = xliffUnit{t.Id, t.Source, t.Target}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "\t")
+	return e.Encode(x)
+}
+
+// ReadXLIFF reads the translation units of an XLIFF 1.2 document.
+func ReadXLIFF(r io.Reader) ([]Translation, error) {
+	var x xliffFile
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, err
+	}
+	ts := make([]Translation, len(x.File.Units))
+	for n, u := range x.File.Units {
+		ts[n] = Translation{u.Id, u.Source, u.Target}
+	}
+	return ts, nil
+}
@@ -0,0 +1,146 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testLocaliseSWF() *SWF {
+	return &SWF{Tags: []Tag{
+		&DefineFont2{FontId: 2, HasLayout: true, CodeTable: []uint16{'H', 'i', '!'}, FontAdvanceTable: []int16{512, 256, 300}},
+		&DefineText{CharacterId: 3, GlyphBits: 3, AdvanceBits: 5, TextRecords: testTextRecords(255)},
+		&DefineEditText{CharacterId: 4, HasText: true, HasFont: true, UseOutlines: true, FontId: 2, HTML: true, InitialText: "<p>Hi</p>"},
+		&DefineEditText{CharacterId: 5},
+	}}
+}
+
+var testTranslations = []Translation{
+	{"text:3", "i!\nH", ""},
+	{"edit:4", "<p>Hi</p>", ""},
+}
+
+func TestStrings(t *testing.T) {
+	if ts := testLocaliseSWF().Strings(); !reflect.DeepEqual(ts, testTranslations) {
+		t.Errorf("expecting %v, got %v", testTranslations, ts)
+	}
+}
+
+func TestPO(t *testing.T) {
+	ts := []Translation{
+		{"text:3", "i!\nH", "\"H\"\ni\n"},
+		{"edit:4", "a\\b\tc", ""},
+	}
+	buf := new(bytes.Buffer)
+	if err := WritePO(buf, ts); err != nil {
+		t.Fatal(err)
+	}
+	if rts, err := ReadPO(buf); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(rts, ts) {
+		t.Errorf("expecting %v, got %v", ts, rts)
+	}
+	if _, err := ReadPO(bytes.NewBufferString("msgctxt \"a\"\nmsgid unquoted\n")); err == nil {
+		t.Error("expecting syntax error")
+	}
+}
+
+func TestXLIFF(t *testing.T) {
+	ts := []Translation{
+		{"text:3", "i!\nH", "<H>"},
+		{"edit:4", "<p>Hi</p>", ""},
+	}
+	buf := new(bytes.Buffer)
+	if err := WriteXLIFF(buf, ts, "en", "fr"); err != nil {
+		t.Fatal(err)
+	}
+	if rts, err := ReadXLIFF(buf); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(rts, ts) {
+		t.Errorf("expecting %v, got %v", ts, rts)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	s := testLocaliseSWF()
+	missing, err := s.Translate([]Translation{
+		{"text:3", "i!\nH", "Hi\nHi!\n!"},
+		{"edit:4", "<p>Hi</p>", "<p>Hix</p>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []MissingGlyph{{"edit:4", 2, 'x'}}; !reflect.DeepEqual(missing, expected) {
+		t.Errorf("expecting missing glyphs %v, got %v", expected, missing)
+	}
+	if d := s.Tags[2].(*DefineEditText); d.InitialText != "<p>Hi</p>" {
+		t.Errorf("expecting edit text to be unchanged, got %q", d.InitialText)
+	}
+	d := s.Tags[1].(*DefineText)
+	if text, _ := d.Text(s.CodeTables()); text != "Hi\nHi!\n!" {
+		t.Errorf("expecting text %q, got %q", "Hi\nHi!\n!", text)
+	}
+	if l := len(d.TextRecords); l != 3 {
+		t.Fatalf("expecting 3 text records, got %d", l)
+	}
+	if r := d.TextRecords[2]; r.YOffset != 120 || !reflect.DeepEqual(r.GlyphEntries, []GlyphEntry{{2, 70}}) {
+		t.Errorf("unexpected third line: %v", r)
+	}
+	if g := d.TextRecords[0].GlyphEntries; !reflect.DeepEqual(g, []GlyphEntry{{0, 120}, {1, 60}}) {
+		t.Errorf("unexpected glyph entries: %v", g)
+	}
+	if d.GlyphBits != 2 || d.AdvanceBits != 8 {
+		t.Errorf("expecting bits 2 and 8, got %d and %d", d.GlyphBits, d.AdvanceBits)
+	}
+	if missing, _ = s.Translate([]Translation{{"text:3", "", "xyz"}}); len(missing) != 3 {
+		t.Errorf("expecting 3 missing glyphs, got %v", missing)
+	}
+	if _, err = s.Translate([]Translation{{"text:9", "", "a"}}); err != ErrUnknownString {
+		t.Errorf("expecting error %q, got %q", ErrUnknownString, err)
+	}
+}
+
+func TestTranslateConstantPool(t *testing.T) {
+	pool := &ActionConstantPool{[]String{"Play", "", "_root"}}
+	s := &SWF{Tags: []Tag{
+		&DefineSprite{SpriteId: 1, ControlTags: []Tag{
+			&DoAction{Actions: []Action{&ActionConstantPool{[]String{"Score"}}}},
+		}},
+		&DoAction{Actions: []Action{pool, BasicAction(ACTION_END)}},
+	}}
+	expected := []Translation{
+		{"pool:0:0", "Score", ""},
+		{"pool:1:0", "Play", ""},
+		{"pool:1:2", "_root", ""},
+	}
+	if ts := s.Strings(); !reflect.DeepEqual(ts, expected) {
+		t.Errorf("expecting %v, got %v", expected, ts)
+	}
+	if missing, err := s.Translate([]Translation{{"pool:1:0", "Play", "Jouer"}, {"pool:1:2", "_root", ""}}); err != nil {
+		t.Fatal(err)
+	} else if len(missing) != 0 {
+		t.Errorf("expecting no missing glyphs, got %v", missing)
+	}
+	if expected := []String{"Jouer", "", "_root"}; !reflect.DeepEqual(pool.Constants, expected) {
+		t.Errorf("expecting %v, got %v", expected, pool.Constants)
+	}
+	if _, err := s.Translate([]Translation{{"pool:1:3", "", "a"}}); err != ErrUnknownString {
+		t.Errorf("expecting error %q, got %q", ErrUnknownString, err)
+	}
+}
+
+func TestTranslateMissingFont(t *testing.T) {
+	s := testLocaliseSWF()
+	s.Tags = s.Tags[1:]
+	records := s.Tags[0].(*DefineText).TextRecords
+	missing, err := s.Translate([]Translation{{"text:3", "", "Hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []MissingGlyph{{"text:3", 2, 'H'}, {"text:3", 2, 'i'}}; !reflect.DeepEqual(missing, expected) {
+		t.Errorf("expecting missing glyphs %v, got %v", expected, missing)
+	}
+	if d := s.Tags[0].(*DefineText); !reflect.DeepEqual(d.TextRecords, records) {
+		t.Errorf("expecting text records to be unchanged, got %v", d.TextRecords)
+	}
+}
@@ -0,0 +1,669 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"math"
+)
+
+var (
+	ErrMorphEdges       = errors.New("defineMorphShape: start and end edges do not match")
+	ErrMorphFillStyle   = errors.New("morphFillStyle: unknown fill style type")
+	ErrTooManyGradients = errors.New("morphFillStyle: too many gradient records")
+)
+
+type MorphGradientRecord struct {
+	StartRatio uint8
+	StartColor RGBA
+	EndRatio   uint8
+	EndColor   RGBA
+}
+
+type MorphFillStyle struct {
+	FillStyleType       uint8
+	StartColor          RGBA
+	EndColor            RGBA
+	StartGradientMatrix Matrix
+	EndGradientMatrix   Matrix
+	SpreadMode          uint8
+	InterpolationMode   uint8
+	Gradient            []MorphGradientRecord
+	StartFocalPoint     Fixed8
+	EndFocalPoint       Fixed8
+	BitmapId            uint16
+	StartBitmapMatrix   Matrix
+	EndBitmapMatrix     Matrix
+}
+
+func (m *MorphFillStyle) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &m.FillStyleType); err != nil {
+		return
+	}
+	switch {
+	case m.FillStyleType == FILL_SOLID:
+		err = ReadAll(c, &m.StartColor, &m.EndColor)
+	case isGradientFill(m.FillStyleType):
+		if err = ReadAll(c, &m.StartGradientMatrix, &m.EndGradientMatrix); err != nil {
+			return
+		}
+		var count uint8
+		if err = binary.Read(c, binary.LittleEndian, &count); err != nil {
+			return
+		}
+		m.SpreadMode, m.InterpolationMode = count>>6, (count>>4)&3
+		m.Gradient = make([]MorphGradientRecord, count&15)
+		for n := range m.Gradient {
+			g := &m.Gradient[n]
+			if err = binary.Read(c, binary.LittleEndian, &g.StartRatio); err != nil {
+				return
+			}
+			if _, err = g.StartColor.ReadFrom(c); err != nil {
+				return
+			}
+			if err = binary.Read(c, binary.LittleEndian, &g.EndRatio); err != nil {
+				return
+			}
+			if _, err = g.EndColor.ReadFrom(c); err != nil {
+				return
+			}
+		}
+		if m.FillStyleType == FILL_FOCAL_RADIAL_GRADIENT {
+			err = ReadAll(c, &m.StartFocalPoint, &m.EndFocalPoint)
+		}
+	case isBitmapFill(m.FillStyleType):
+		if err = binary.Read(c, binary.LittleEndian, &m.BitmapId); err != nil {
+			return
+		}
+		err = ReadAll(c, &m.StartBitmapMatrix, &m.EndBitmapMatrix)
+	default:
+		err = ErrMorphFillStyle
+	}
+	return
+}
+
+func (m *MorphFillStyle) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, m.FillStyleType); err != nil {
+		return
+	}
+	switch {
+	case m.FillStyleType == FILL_SOLID:
+		err = WriteAll(c, &m.StartColor, &m.EndColor)
+	case isGradientFill(m.FillStyleType):
+		if err = WriteAll(c, &m.StartGradientMatrix, &m.EndGradientMatrix); err != nil {
+			return
+		}
+		if len(m.Gradient) > 15 {
+			err = ErrTooManyGradients
+			return
+		}
+		count := m.SpreadMode<<6 | (m.InterpolationMode&3)<<4 | uint8(len(m.Gradient))
+		if err = binary.Write(c, binary.LittleEndian, count); err != nil {
+			return
+		}
+		for n := range m.Gradient {
+			g := &m.Gradient[n]
+			if err = binary.Write(c, binary.LittleEndian, g.StartRatio); err != nil {
+				return
+			}
+			if _, err = g.StartColor.WriteTo(c); err != nil {
+				return
+			}
+			if err = binary.Write(c, binary.LittleEndian, g.EndRatio); err != nil {
+				return
+			}
+			if _, err = g.EndColor.WriteTo(c); err != nil {
+				return
+			}
+		}
+		if m.FillStyleType == FILL_FOCAL_RADIAL_GRADIENT {
+			err = WriteAll(c, &m.StartFocalPoint, &m.EndFocalPoint)
+		}
+	case isBitmapFill(m.FillStyleType):
+		if err = binary.Write(c, binary.LittleEndian, m.BitmapId); err != nil {
+			return
+		}
+		err = WriteAll(c, &m.StartBitmapMatrix, &m.EndBitmapMatrix)
+	default:
+		err = ErrMorphFillStyle
+	}
+	return
+}
+
+func (m *MorphFillStyle) Size() int32 {
+	switch {
+	case m.FillStyleType == FILL_SOLID:
+		return 1 + 4 + 4
+	case isGradientFill(m.FillStyleType):
+		total := 1 + SizeAll(&m.StartGradientMatrix, &m.EndGradientMatrix) + 1 + 10*int32(len(m.Gradient))
+		if m.FillStyleType == FILL_FOCAL_RADIAL_GRADIENT {
+			total += SizeAll(&m.StartFocalPoint, &m.EndFocalPoint)
+		}
+		return total
+	case isBitmapFill(m.FillStyleType):
+		return 1 + 2 + SizeAll(&m.StartBitmapMatrix, &m.EndBitmapMatrix)
+	}
+	return 1
+}
+
+// Interpolate returns the fill style at the given point, from 0 (start) to 1
+// (end).
+func (m *MorphFillStyle) Interpolate(r float64) FillStyle {
+	fs := FillStyle{
+		FillStyleType: m.FillStyleType,
+		Color:         lerpRGBA(m.StartColor, m.EndColor, r),
+		BitmapId:      m.BitmapId,
+	}
+	if isGradientFill(m.FillStyleType) {
+		fs.GradientMatrix = lerpMatrix(m.StartGradientMatrix, m.EndGradientMatrix, r)
+		fs.Gradient.SpreadMode = m.SpreadMode
+		fs.Gradient.InterpolationMode = m.InterpolationMode
+		fs.Gradient.FocalPoint = m.StartFocalPoint + (m.EndFocalPoint-m.StartFocalPoint)*Fixed8(r)
+		fs.Gradient.GradientRecords = make([]GradientRecord, len(m.Gradient))
+		for n, g := range m.Gradient {
+			fs.Gradient.GradientRecords[n] = GradientRecord{
+				Color: lerpRGBA(g.StartColor, g.EndColor, r),
+				Ratio: uint8(lerp(float64(g.StartRatio), float64(g.EndRatio), r)),
+			}
+		}
+	} else if isBitmapFill(m.FillStyleType) {
+		fs.BitmapMatrix = lerpMatrix(m.StartBitmapMatrix, m.EndBitmapMatrix, r)
+	}
+	return fs
+}
+
+// MorphLineStyle holds both MORPHLINESTYLE and MORPHLINESTYLE2; the cap, join
+// and fill fields are only used by DefineMorphShape2.
+type MorphLineStyle struct {
+	StartWidth       uint16
+	EndWidth         uint16
+	StartCapStyle    uint8
+	JoinStyle        uint8
+	EndCapStyle      uint8
+	HasFill          bool
+	NoHScale         bool
+	NoVScale         bool
+	PixelHinting     bool
+	NoClose          bool
+	MiterLimitFactor Fixed8
+	StartColor       RGBA
+	EndColor         RGBA
+	FillType         MorphFillStyle
+}
+
+func (m *MorphLineStyle) readFrom(f io.Reader, morph2 bool) (err error) {
+	if err = binary.Read(f, binary.LittleEndian, &m.StartWidth); err != nil {
+		return
+	}
+	if err = binary.Read(f, binary.LittleEndian, &m.EndWidth); err != nil {
+		return
+	}
+	m.HasFill = false
+	if morph2 {
+		var flags [2]uint8
+		if err = binary.Read(f, binary.LittleEndian, &flags); err != nil {
+			return
+		}
+		m.StartCapStyle, m.JoinStyle = flags[0]>>6, (flags[0]>>4)&3
+		unpackFlags(flags[0], &m.HasFill, &m.NoHScale, &m.NoVScale, &m.PixelHinting)
+		unpackFlags(flags[1]>>2, &m.NoClose)
+		m.EndCapStyle = flags[1] & 3
+		if m.JoinStyle == JOIN_MITER {
+			if _, err = m.MiterLimitFactor.ReadFrom(f); err != nil {
+				return
+			}
+		}
+		if m.HasFill {
+			_, err = m.FillType.ReadFrom(f)
+			return
+		}
+	}
+	err = ReadAll(f, &m.StartColor, &m.EndColor)
+	return
+}
+
+func (m *MorphLineStyle) writeTo(f io.Writer, morph2 bool) (err error) {
+	if err = binary.Write(f, binary.LittleEndian, m.StartWidth); err != nil {
+		return
+	}
+	if err = binary.Write(f, binary.LittleEndian, m.EndWidth); err != nil {
+		return
+	}
+	if morph2 {
+		flags := [2]uint8{
+			m.StartCapStyle<<6 | (m.JoinStyle&3)<<4 | packFlags(m.HasFill, m.NoHScale, m.NoVScale, m.PixelHinting),
+			packFlags(m.NoClose)<<2 | m.EndCapStyle&3,
+		}
+		if err = binary.Write(f, binary.LittleEndian, flags); err != nil {
+			return
+		}
+		if m.JoinStyle == JOIN_MITER {
+			if _, err = m.MiterLimitFactor.WriteTo(f); err != nil {
+				return
+			}
+		}
+		if m.HasFill {
+			_, err = m.FillType.WriteTo(f)
+			return
+		}
+	}
+	err = WriteAll(f, &m.StartColor, &m.EndColor)
+	return
+}
+
+func (m *MorphLineStyle) size(morph2 bool) int32 {
+	if !morph2 {
+		return 2 + 2 + 4 + 4
+	}
+	total := int32(2 + 2 + 2)
+	if m.JoinStyle == JOIN_MITER {
+		total += 2
+	}
+	if m.HasFill {
+		return total + m.FillType.Size()
+	}
+	return total + 4 + 4
+}
+
+// Interpolate returns the line style at the given point, from 0 (start) to 1
+// (end).
+func (m *MorphLineStyle) Interpolate(r float64) LineStyle {
+	ls := LineStyle{
+		Width:            uint16(lerp(float64(m.StartWidth), float64(m.EndWidth), r)),
+		Color:            lerpRGBA(m.StartColor, m.EndColor, r),
+		StartCapStyle:    m.StartCapStyle,
+		JoinStyle:        m.JoinStyle,
+		EndCapStyle:      m.EndCapStyle,
+		HasFill:          m.HasFill,
+		NoHScale:         m.NoHScale,
+		NoVScale:         m.NoVScale,
+		PixelHinting:     m.PixelHinting,
+		NoClose:          m.NoClose,
+		MiterLimitFactor: m.MiterLimitFactor,
+	}
+	if m.HasFill {
+		ls.FillType = m.FillType.Interpolate(r)
+	}
+	return ls
+}
+
+func readStyleCount(f io.Reader) (int, error) {
+	var count uint8
+	if err := binary.Read(f, binary.LittleEndian, &count); err != nil || count != 0xff {
+		return int(count), err
+	}
+	var extended uint16
+	err := binary.Read(f, binary.LittleEndian, &extended)
+	return int(extended), err
+}
+
+func writeStyleCount(f io.Writer, count int) error {
+	if count < 0xff {
+		return binary.Write(f, binary.LittleEndian, uint8(count))
+	}
+	if err := binary.Write(f, binary.LittleEndian, uint8(0xff)); err != nil {
+		return err
+	}
+	return binary.Write(f, binary.LittleEndian, uint16(count))
+}
+
+func styleCountSize(count int) int32 {
+	if count < 0xff {
+		return 1
+	}
+	return 3
+}
+
+// DefineMorphShape also holds DefineMorphShape2; the edge bounds and stroke
+// flags are only used by the latter.
+type DefineMorphShape struct {
+	CharacterId           uint16
+	StartBounds           Rect
+	EndBounds             Rect
+	StartEdgeBounds       Rect
+	EndEdgeBounds         Rect
+	UsesNonScalingStrokes bool
+	UsesScalingStrokes    bool
+	MorphFillStyles       []MorphFillStyle
+	MorphLineStyles       []MorphLineStyle
+	StartEdges            Shape
+	EndEdges              Shape
+}
+
+func (d *DefineMorphShape) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	morph2 := id == TAG_DEFINE_MORPH_SHAPE2
+	if err = binary.Read(c, binary.LittleEndian, &d.CharacterId); err != nil {
+		return
+	}
+	if err = ReadAll(c, &d.StartBounds, &d.EndBounds); err != nil {
+		return
+	}
+	d.UsesNonScalingStrokes, d.UsesScalingStrokes = false, false
+	if morph2 {
+		if err = ReadAll(c, &d.StartEdgeBounds, &d.EndEdgeBounds); err != nil {
+			return
+		}
+		var flags uint8
+		if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+			return
+		}
+		unpackFlags(flags, &d.UsesNonScalingStrokes, &d.UsesScalingStrokes)
+	}
+	var offset uint32
+	if err = binary.Read(c, binary.LittleEndian, &offset); err != nil {
+		return
+	}
+	var count int
+	if count, err = readStyleCount(c); err != nil {
+		return
+	}
+	d.MorphFillStyles = make([]MorphFillStyle, count)
+	for n := range d.MorphFillStyles {
+		if _, err = d.MorphFillStyles[n].ReadFrom(c); err != nil {
+			return
+		}
+	}
+	if count, err = readStyleCount(c); err != nil {
+		return
+	}
+	d.MorphLineStyles = make([]MorphLineStyle, count)
+	for n := range d.MorphLineStyles {
+		if err = d.MorphLineStyles[n].readFrom(c, morph2); err != nil {
+			return
+		}
+	}
+	err = ReadAll(c, &d.StartEdges, &d.EndEdges)
+	return
+}
+
+func (d *DefineMorphShape) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	morph2 := id == TAG_DEFINE_MORPH_SHAPE2
+	if err = binary.Write(c, binary.LittleEndian, d.CharacterId); err != nil {
+		return
+	}
+	if err = WriteAll(c, &d.StartBounds, &d.EndBounds); err != nil {
+		return
+	}
+	if morph2 {
+		if err = WriteAll(c, &d.StartEdgeBounds, &d.EndEdgeBounds); err != nil {
+			return
+		}
+		if err = binary.Write(c, binary.LittleEndian, packFlags(d.UsesNonScalingStrokes, d.UsesScalingStrokes)); err != nil {
+			return
+		}
+	}
+	if err = binary.Write(c, binary.LittleEndian, uint32(d.stylesSize(morph2)+d.StartEdges.Size())); err != nil {
+		return
+	}
+	if err = writeStyleCount(c, len(d.MorphFillStyles)); err != nil {
+		return
+	}
+	for n := range d.MorphFillStyles {
+		if _, err = d.MorphFillStyles[n].WriteTo(c); err != nil {
+			return
+		}
+	}
+	if err = writeStyleCount(c, len(d.MorphLineStyles)); err != nil {
+		return
+	}
+	for n := range d.MorphLineStyles {
+		if err = d.MorphLineStyles[n].writeTo(c, morph2); err != nil {
+			return
+		}
+	}
+	err = WriteAll(c, &d.StartEdges, &d.EndEdges)
+	return
+}
+
+func (d *DefineMorphShape) stylesSize(morph2 bool) int32 {
+	total := styleCountSize(len(d.MorphFillStyles)) + styleCountSize(len(d.MorphLineStyles))
+	for n := range d.MorphFillStyles {
+		total += d.MorphFillStyles[n].Size()
+	}
+	for n := range d.MorphLineStyles {
+		total += d.MorphLineStyles[n].size(morph2)
+	}
+	return total
+}
+
+func (d *DefineMorphShape) Size(ver uint8, id uint16) int32 {
+	morph2 := id == TAG_DEFINE_MORPH_SHAPE2
+	total := 2 + SizeAll(&d.StartBounds, &d.EndBounds) + 4 + d.stylesSize(morph2) + SizeAll(&d.StartEdges, &d.EndEdges)
+	if morph2 {
+		total += SizeAll(&d.StartEdgeBounds, &d.EndEdgeBounds) + 1
+	}
+	return total
+}
+
+func (d *DefineMorphShape) MinVersion() uint8 {
+	return 3
+}
+
+func (d *DefineMorphShape) TagId() uint16 {
+	return TAG_DEFINE_MORPH_SHAPE
+}
+
+func (d *DefineMorphShape) Name() string {
+	return "DefineMorphShape"
+}
+
+// Bounds returns the bounds of the shape at the given ratio, as used by
+// PlaceObject2, from 0 (start) to 65535 (end).
+func (d *DefineMorphShape) Bounds(ratio uint16) Rect {
+	return lerpRect(d.StartBounds, d.EndBounds, morphRatio(ratio))
+}
+
+// Interpolate returns the shape at the given ratio, as used by PlaceObject2,
+// from 0 (start) to 65535 (end).
+func (d *DefineMorphShape) Interpolate(ratio uint16) (*ShapeWithStyle, error) {
+	r := morphRatio(ratio)
+	records, err := morphEdges(d.StartEdges.ShapeRecords, d.EndEdges.ShapeRecords, r)
+	if err != nil {
+		return nil, err
+	}
+	s := &ShapeWithStyle{
+		FillStyles:   make([]FillStyle, len(d.MorphFillStyles)),
+		LineStyles:   make([]LineStyle, len(d.MorphLineStyles)),
+		ShapeRecords: records,
+	}
+	for n := range d.MorphFillStyles {
+		s.FillStyles[n] = d.MorphFillStyles[n].Interpolate(r)
+	}
+	for n := range d.MorphLineStyles {
+		s.LineStyles[n] = d.MorphLineStyles[n].Interpolate(r)
+	}
+	return s, nil
+}
+
+type DefineMorphShape2 struct {
+	DefineMorphShape
+}
+
+func (d *DefineMorphShape2) MinVersion() uint8 {
+	return 8
+}
+
+func (d *DefineMorphShape2) TagId() uint16 {
+	return TAG_DEFINE_MORPH_SHAPE2
+}
+
+func (d *DefineMorphShape2) Name() string {
+	return "DefineMorphShape2"
+}
+
+func morphRatio(ratio uint16) float64 {
+	return float64(ratio) / 65535
+}
+
+func lerp(a, b, r float64) float64 {
+	return math.Floor(a + (b-a)*r + 0.5)
+}
+
+func lerpTwips(a, b Twips, r float64) Twips {
+	return Twips(lerp(float64(a), float64(b), r))
+}
+
+func lerpRGBA(a, b RGBA, r float64) RGBA {
+	return RGBA{
+		RGB: RGB{
+			Red:   uint8(lerp(float64(a.Red), float64(b.Red), r)),
+			Green: uint8(lerp(float64(a.Green), float64(b.Green), r)),
+			Blue:  uint8(lerp(float64(a.Blue), float64(b.Blue), r)),
+		},
+		Alpha: uint8(lerp(float64(a.Alpha), float64(b.Alpha), r)),
+	}
+}
+
+func lerpMatrix(a, b Matrix, r float64) Matrix {
+	f := func(a, b float32) float32 {
+		return a + (b-a)*float32(r)
+	}
+	return Matrix{
+		ScaleX:      f(a.ScaleX, b.ScaleX),
+		ScaleY:      f(a.ScaleY, b.ScaleY),
+		RotateSkew0: f(a.RotateSkew0, b.RotateSkew0),
+		RotateSkew1: f(a.RotateSkew1, b.RotateSkew1),
+		TranslateX:  lerpTwips(a.TranslateX, b.TranslateX, r),
+		TranslateY:  lerpTwips(a.TranslateY, b.TranslateY, r),
+	}
+}
+
+func lerpRect(a, b Rect, r float64) Rect {
+	return Rect{
+		Xmin: lerpTwips(a.Xmin, b.Xmin, r),
+		Xmax: lerpTwips(a.Xmax, b.Xmax, r),
+		Ymin: lerpTwips(a.Ymin, b.Ymin, r),
+		Ymax: lerpTwips(a.Ymax, b.Ymax, r),
+	}
+}
+
+// edgeCurve returns the edge as a curve, splitting straight edges at their
+// midpoint.
+func edgeCurve(r ShapeRecord) CurvedEdgeRecord {
+	switch e := r.(type) {
+	case *CurvedEdgeRecord:
+		return *e
+	case *StraightEdgeRecord:
+		return CurvedEdgeRecord{
+			ControlDeltaX: e.DeltaX / 2,
+			ControlDeltaY: e.DeltaY / 2,
+			AnchorDeltaX:  e.DeltaX - e.DeltaX/2,
+			AnchorDeltaY:  e.DeltaY - e.DeltaY/2,
+		}
+	}
+	return CurvedEdgeRecord{}
+}
+
+func edgeDelta(r ShapeRecord) (Twips, Twips) {
+	switch e := r.(type) {
+	case *CurvedEdgeRecord:
+		return e.ControlDeltaX + e.AnchorDeltaX, e.ControlDeltaY + e.AnchorDeltaY
+	case *StraightEdgeRecord:
+		return e.DeltaX, e.DeltaY
+	}
+	return 0, 0
+}
+
+// morphEdges pairs each edge in the start records with one in the end
+// records. Style changes are taken from the start records, with end records
+// only contributing their move positions.
+func morphEdges(start, end []ShapeRecord, r float64) ([]ShapeRecord, error) {
+	var (
+		records        []ShapeRecord
+		sx, sy, ex, ey Twips
+		j              int
+	)
+	endMove := func() bool {
+		moved := false
+		for ; j < len(end); j++ {
+			e, ok := end[j].(*StyleChangeRecord)
+			if !ok {
+				break
+			}
+			if e.MoveTo {
+				ex, ey, moved = e.MoveDeltaX, e.MoveDeltaY, true
+			}
+		}
+		return moved
+	}
+	for _, record := range start {
+		if s, ok := record.(*StyleChangeRecord); ok {
+			sc := *s
+			if s.MoveTo {
+				sx, sy = s.MoveDeltaX, s.MoveDeltaY
+			}
+			if endMove() || s.MoveTo {
+				sc.MoveTo = true
+				sc.MoveDeltaX, sc.MoveDeltaY = lerpTwips(sx, ex, r), lerpTwips(sy, ey, r)
+			}
+			records = append(records, &sc)
+			continue
+		}
+		if endMove() {
+			records = append(records, &StyleChangeRecord{
+				MoveTo:     true,
+				MoveDeltaX: lerpTwips(sx, ex, r),
+				MoveDeltaY: lerpTwips(sy, ey, r),
+			})
+		}
+		if j == len(end) {
+			return nil, ErrMorphEdges
+		}
+		e := end[j]
+		j++
+		se, sok := record.(*StraightEdgeRecord)
+		ee, eok := e.(*StraightEdgeRecord)
+		if sok && eok {
+			records = append(records, &StraightEdgeRecord{
+				DeltaX: lerpTwips(se.DeltaX, ee.DeltaX, r),
+				DeltaY: lerpTwips(se.DeltaY, ee.DeltaY, r),
+			})
+		} else {
+			sc, ec := edgeCurve(record), edgeCurve(e)
+			records = append(records, &CurvedEdgeRecord{
+				ControlDeltaX: lerpTwips(sc.ControlDeltaX, ec.ControlDeltaX, r),
+				ControlDeltaY: lerpTwips(sc.ControlDeltaY, ec.ControlDeltaY, r),
+				AnchorDeltaX:  lerpTwips(sc.AnchorDeltaX, ec.AnchorDeltaX, r),
+				AnchorDeltaY:  lerpTwips(sc.AnchorDeltaY, ec.AnchorDeltaY, r),
+			})
+		}
+		dx, dy := edgeDelta(record)
+		sx, sy = sx+dx, sy+dy
+		dx, dy = edgeDelta(e)
+		ex, ey = ex+dx, ey+dy
+	}
+	return records, nil
+}
@@ -0,0 +1,185 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testMorphShape() *DefineMorphShape {
+	return &DefineMorphShape{
+		CharacterId: 1,
+		StartBounds: Rect{0, 100, 0, 100},
+		EndBounds:   Rect{20, 220, 0, 220},
+		MorphFillStyles: []MorphFillStyle{
+			{FillStyleType: FILL_SOLID, StartColor: RGBA{RGB{255, 0, 0}, 255}, EndColor: RGBA{RGB{0, 0, 255}, 128}},
+		},
+		MorphLineStyles: []MorphLineStyle{
+			{StartWidth: 20, EndWidth: 40, StartColor: RGBA{RGB{0, 0, 0}, 255}, EndColor: RGBA{RGB{0, 255, 0}, 255}},
+		},
+		StartEdges: Shape{1, 1, []ShapeRecord{
+			&StyleChangeRecord{HasFillStyle1: true, FillStyle1: 1, HasLineStyle: true, LineStyle: 1},
+			&StraightEdgeRecord{100, 0},
+			&StraightEdgeRecord{0, 100},
+			&StraightEdgeRecord{-100, 0},
+			&StraightEdgeRecord{0, -100},
+		}},
+		EndEdges: Shape{0, 0, []ShapeRecord{
+			&StyleChangeRecord{MoveTo: true, MoveDeltaX: 20, MoveDeltaY: 20},
+			&CurvedEdgeRecord{100, -20, 100, 20},
+			&StraightEdgeRecord{0, 200},
+			&StraightEdgeRecord{-200, 0},
+			&StraightEdgeRecord{0, -200},
+		}},
+	}
+}
+
+func TestDefineMorphShape(t *testing.T) {
+	testTag(t, 3, []byte{1, 0, 64, 3, 32, 3, 32, 72, 81, 184, 0, 110, 0, 34, 0, 0, 0, 1, 0, 255, 0, 0, 255, 0, 0, 255, 128, 1, 20, 0, 40, 0, 0, 0, 0, 255, 0, 255, 0, 255, 17, 51, 216, 100, 217, 100, 216, 156, 217, 156, 0, 0, 4, 202, 41, 51, 39, 99, 32, 166, 235, 35, 114, 113, 187, 56, 0}, testMorphShape())
+}
+
+func TestDefineMorphShape2(t *testing.T) {
+	testTag(t, 8, []byte{2, 0, 64, 3, 32, 3, 32, 64, 3, 32, 3, 32, 64, 82, 208, 82, 208, 64, 82, 208, 82, 208, 1, 53, 0, 0, 0, 1, 16, 0, 14, 160, 0, 66, 0, 255, 0, 0, 255, 0, 0, 0, 255, 255, 255, 255, 255, 255, 255, 128, 0, 0, 0, 255, 1, 20, 0, 20, 0, 168, 6, 0, 3, 65, 5, 0, 0, 205, 0, 0, 32, 0, 0, 0, 17, 12, 39, 58, 148, 0, 0, 4, 38, 151, 138, 0}, &DefineMorphShape2{DefineMorphShape{
+		CharacterId:        2,
+		StartBounds:        Rect{0, 100, 0, 100},
+		EndBounds:          Rect{0, 100, 0, 100},
+		StartEdgeBounds:    Rect{10, 90, 10, 90},
+		EndEdgeBounds:      Rect{10, 90, 10, 90},
+		UsesScalingStrokes: true,
+		MorphFillStyles: []MorphFillStyle{{
+			FillStyleType:       FILL_LINEAR_GRADIENT,
+			StartGradientMatrix: Matrix{ScaleX: 1, ScaleY: 1},
+			EndGradientMatrix:   Matrix{ScaleX: 1, ScaleY: 1, TranslateX: 40},
+			SpreadMode:          SPREAD_REFLECT,
+			Gradient: []MorphGradientRecord{
+				{0, RGBA{RGB{255, 0, 0}, 255}, 0, RGBA{RGB{0, 0, 255}, 255}},
+				{255, RGBA{RGB{255, 255, 255}, 255}, 128, RGBA{RGB{0, 0, 0}, 255}},
+			},
+		}},
+		MorphLineStyles: []MorphLineStyle{{
+			StartWidth:       20,
+			EndWidth:         20,
+			StartCapStyle:    CAP_SQUARE,
+			JoinStyle:        JOIN_MITER,
+			EndCapStyle:      CAP_SQUARE,
+			HasFill:          true,
+			NoClose:          true,
+			MiterLimitFactor: 3,
+			FillType: MorphFillStyle{
+				FillStyleType:     FILL_CLIPPED_BITMAP,
+				BitmapId:          5,
+				StartBitmapMatrix: Matrix{ScaleX: 1, ScaleY: 1},
+				EndBitmapMatrix:   Matrix{ScaleX: 2, ScaleY: 2},
+			},
+		}},
+		StartEdges: Shape{1, 1, []ShapeRecord{
+			&StyleChangeRecord{MoveTo: true, HasFillStyle0: true, FillStyle0: 1},
+			&StraightEdgeRecord{10, 10},
+		}},
+		EndEdges: Shape{0, 0, []ShapeRecord{
+			&StyleChangeRecord{MoveTo: true},
+			&StraightEdgeRecord{30, 10},
+		}},
+	}})
+}
+
+func TestMorphInterpolate(t *testing.T) {
+	d := testMorphShape()
+	tests := []struct {
+		ratio  uint16
+		bounds Rect
+		shape  *ShapeWithStyle
+	}{
+		{
+			0,
+			Rect{0, 100, 0, 100},
+			&ShapeWithStyle{
+				FillStyles: []FillStyle{{FillStyleType: FILL_SOLID, Color: RGBA{RGB{255, 0, 0}, 255}}},
+				LineStyles: []LineStyle{{Width: 20, Color: RGBA{RGB{0, 0, 0}, 255}}},
+				ShapeRecords: []ShapeRecord{
+					&StyleChangeRecord{MoveTo: true, HasFillStyle1: true, FillStyle1: 1, HasLineStyle: true, LineStyle: 1},
+					&CurvedEdgeRecord{50, 0, 50, 0},
+					&StraightEdgeRecord{0, 100},
+					&StraightEdgeRecord{-100, 0},
+					&StraightEdgeRecord{0, -100},
+				},
+			},
+		},
+		{
+			32768,
+			Rect{10, 160, 0, 160},
+			&ShapeWithStyle{
+				FillStyles: []FillStyle{{FillStyleType: FILL_SOLID, Color: RGBA{RGB{127, 0, 128}, 191}}},
+				LineStyles: []LineStyle{{Width: 30, Color: RGBA{RGB{0, 128, 0}, 255}}},
+				ShapeRecords: []ShapeRecord{
+					&StyleChangeRecord{MoveTo: true, MoveDeltaX: 10, MoveDeltaY: 10, HasFillStyle1: true, FillStyle1: 1, HasLineStyle: true, LineStyle: 1},
+					&CurvedEdgeRecord{75, -10, 75, 10},
+					&StraightEdgeRecord{0, 150},
+					&StraightEdgeRecord{-150, 0},
+					&StraightEdgeRecord{0, -150},
+				},
+			},
+		},
+		{
+			65535,
+			Rect{20, 220, 0, 220},
+			&ShapeWithStyle{
+				FillStyles: []FillStyle{{FillStyleType: FILL_SOLID, Color: RGBA{RGB{0, 0, 255}, 128}}},
+				LineStyles: []LineStyle{{Width: 40, Color: RGBA{RGB{0, 255, 0}, 255}}},
+				ShapeRecords: []ShapeRecord{
+					&StyleChangeRecord{MoveTo: true, MoveDeltaX: 20, MoveDeltaY: 20, HasFillStyle1: true, FillStyle1: 1, HasLineStyle: true, LineStyle: 1},
+					&CurvedEdgeRecord{100, -20, 100, 20},
+					&StraightEdgeRecord{0, 200},
+					&StraightEdgeRecord{-200, 0},
+					&StraightEdgeRecord{0, -200},
+				},
+			},
+		},
+	}
+	for n, test := range tests {
+		if b := d.Bounds(test.ratio); b != test.bounds {
+			t.Errorf("test %d: expecting bounds %v, got %v", n+1, test.bounds, b)
+		}
+		s, err := d.Interpolate(test.ratio)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(s, test.shape) {
+			t.Errorf("test %d: expecting %v, got %v", n+1, test.shape, s)
+		}
+	}
+	d.EndEdges.ShapeRecords = d.EndEdges.ShapeRecords[:3]
+	if _, err := d.Interpolate(0); err != ErrMorphEdges {
+		t.Errorf("expecting ErrMorphEdges, got %v", err)
+	}
+}
+
+func TestMorphFocalGradient(t *testing.T) {
+	data := []byte{0x13, 0, 0, 0x41, 0, 255, 0, 0, 255, 255, 0, 0, 255, 255, 0x80, 0, 0, 1}
+	expected := MorphFillStyle{
+		FillStyleType:       FILL_FOCAL_RADIAL_GRADIENT,
+		StartGradientMatrix: Matrix{ScaleX: 1, ScaleY: 1},
+		EndGradientMatrix:   Matrix{ScaleX: 1, ScaleY: 1},
+		SpreadMode:          SPREAD_REFLECT,
+		Gradient:            []MorphGradientRecord{{0, RGBA{RGB{255, 0, 0}, 255}, 255, RGBA{RGB{0, 0, 255}, 255}}},
+		StartFocalPoint:     0.5,
+		EndFocalPoint:       1,
+	}
+	var m MorphFillStyle
+	if n, err := m.ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if n != int64(len(data)) || m.Size() != int32(len(data)) {
+		t.Errorf("expecting %d bytes, read %d, size %d", len(data), n, m.Size())
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expecting %v, got %v", expected, m)
+	}
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("expecting %v, got %v", data, buf.Bytes())
+	}
+	if fs := m.Interpolate(0.5); fs.Gradient.FocalPoint != 0.75 {
+		t.Errorf("expecting focal point 0.75, got %v", fs.Gradient.FocalPoint)
+	}
+}
@@ -0,0 +1,603 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"io/ioutil"
+)
+
+type PlaceObject struct {
+	CharacterId       uint16
+	Depth             uint16
+	Matrix            Matrix
+	HasColorTransform bool
+	ColorTransform    CXForm
+}
+
+func (p *PlaceObject) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &p.CharacterId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &p.Depth); err != nil {
+		return
+	}
+	if _, err = p.Matrix.ReadFrom(c); err != nil {
+		return
+	}
+	var data []byte
+	if data, err = ioutil.ReadAll(c); err != nil {
+		return
+	}
+	p.HasColorTransform = len(data) > 0
+	if p.HasColorTransform {
+		_, err = p.ColorTransform.ReadFrom(bytes.NewReader(data))
+	}
+	return
+}
+
+func (p *PlaceObject) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, [2]uint16{p.CharacterId, p.Depth}); err != nil {
+		return
+	}
+	if _, err = p.Matrix.WriteTo(c); err != nil {
+		return
+	}
+	if p.HasColorTransform {
+		_, err = p.ColorTransform.WriteTo(c)
+	}
+	return
+}
+
+func (p *PlaceObject) Size(ver uint8, id uint16) int32 {
+	total := 2 + 2 + p.Matrix.Size()
+	if p.HasColorTransform {
+		total += p.ColorTransform.Size()
+	}
+	return total
+}
+
+func (p *PlaceObject) MinVersion() uint8 {
+	return 1
+}
+
+func (p *PlaceObject) TagId() uint16 {
+	return TAG_PLACE_OBJECT
+}
+
+func (p *PlaceObject) Name() string {
+	return "PlaceObject"
+}
+
+// PlaceObject2 also holds the fields of PlaceObject3, which are only read and
+// written for that tag.
+type PlaceObject2 struct {
+	HasClipActions    bool
+	HasClipDepth      bool
+	HasName           bool
+	HasRatio          bool
+	HasColorTransform bool
+	HasMatrix         bool
+	HasCharacter      bool
+	Move              bool
+	OpaqueBackground  bool
+	HasVisible        bool
+	HasImage          bool
+	HasClassName      bool
+	HasCacheAsBitmap  bool
+	HasBlendMode      bool
+	HasFilterList     bool
+	Depth             uint16
+	ClassName         String
+	CharacterId       uint16
+	Matrix            Matrix
+	ColorTransform    CXFormWithAlpha
+	Ratio             uint16
+	ObjectName        String
+	ClipDepth         uint16
+	SurfaceFilterList FilterList
+	BlendMode         BlendMode
+	BitmapCache       uint8
+	Visible           uint8
+	BackgroundColor   RGBA
+	ClipActions       ClipActions
+}
+
+func (p *PlaceObject2) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags uint8
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	unpackFlags(flags, &p.HasClipActions, &p.HasClipDepth, &p.HasName, &p.HasRatio, &p.HasColorTransform, &p.HasMatrix, &p.HasCharacter, &p.Move)
+	if id == TAG_PLACE_OBJECT3 {
+		if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+			return
+		}
+		unpackFlags(flags, &p.OpaqueBackground, &p.HasVisible, &p.HasImage, &p.HasClassName, &p.HasCacheAsBitmap, &p.HasBlendMode, &p.HasFilterList)
+	}
+	if err = binary.Read(c, binary.LittleEndian, &p.Depth); err != nil {
+		return
+	}
+	if p.hasClassName(id) {
+		if _, err = p.ClassName.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	if p.HasCharacter {
+		if err = binary.Read(c, binary.LittleEndian, &p.CharacterId); err != nil {
+			return
+		}
+	}
+	if p.HasMatrix {
+		if _, err = p.Matrix.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	if p.HasColorTransform {
+		if _, err = p.ColorTransform.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	if p.HasRatio {
+		if err = binary.Read(c, binary.LittleEndian, &p.Ratio); err != nil {
+			return
+		}
+	}
+	if p.HasName {
+		if _, err = p.ObjectName.ReadFrom(c); err != nil {
+			return
+		}
+	}
+	if p.HasClipDepth {
+		if err = binary.Read(c, binary.LittleEndian, &p.ClipDepth); err != nil {
+			return
+		}
+	}
+	if id == TAG_PLACE_OBJECT3 {
+		if p.HasFilterList {
+			if _, err = p.SurfaceFilterList.ReadFrom(c); err != nil {
+				return
+			}
+		}
+		if p.HasBlendMode {
+			if err = binary.Read(c, binary.LittleEndian, &p.BlendMode); err != nil {
+				return
+			}
+		}
+		if p.HasCacheAsBitmap {
+			if err = binary.Read(c, binary.LittleEndian, &p.BitmapCache); err != nil {
+				return
+			}
+		}
+		if p.HasVisible {
+			if err = binary.Read(c, binary.LittleEndian, &p.Visible); err != nil {
+				return
+			}
+		}
+		if p.OpaqueBackground {
+			if _, err = p.BackgroundColor.ReadFrom(c); err != nil {
+				return
+			}
+		}
+	}
+	p.ClipActions = ClipActions{}
+	if p.HasClipActions {
+		err = p.ClipActions.readFrom(c, ver)
+	}
+	return
+}
+
+func (p *PlaceObject2) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, packFlags(p.HasClipActions, p.HasClipDepth, p.HasName, p.HasRatio, p.HasColorTransform, p.HasMatrix, p.HasCharacter, p.Move)); err != nil {
+		return
+	}
+	if id == TAG_PLACE_OBJECT3 {
+		if err = binary.Write(c, binary.LittleEndian, packFlags(p.OpaqueBackground, p.HasVisible, p.HasImage, p.HasClassName, p.HasCacheAsBitmap, p.HasBlendMode, p.HasFilterList)); err != nil {
+			return
+		}
+	}
+	if err = binary.Write(c, binary.LittleEndian, p.Depth); err != nil {
+		return
+	}
+	if p.hasClassName(id) {
+		if _, err = p.ClassName.WriteTo(c); err != nil {
+			return
+		}
+	}
+	if p.HasCharacter {
+		if err = binary.Write(c, binary.LittleEndian, p.CharacterId); err != nil {
+			return
+		}
+	}
+	if p.HasMatrix {
+		if _, err = p.Matrix.WriteTo(c); err != nil {
+			return
+		}
+	}
+	if p.HasColorTransform {
+		if _, err = p.ColorTransform.WriteTo(c); err != nil {
+			return
+		}
+	}
+	if p.HasRatio {
+		if err = binary.Write(c, binary.LittleEndian, p.Ratio); err != nil {
+			return
+		}
+	}
+	if p.HasName {
+		if _, err = p.ObjectName.WriteTo(c); err != nil {
+			return
+		}
+	}
+	if p.HasClipDepth {
+		if err = binary.Write(c, binary.LittleEndian, p.ClipDepth); err != nil {
+			return
+		}
+	}
+	if id == TAG_PLACE_OBJECT3 {
+		if p.HasFilterList {
+			if _, err = p.SurfaceFilterList.WriteTo(c); err != nil {
+				return
+			}
+		}
+		if p.HasBlendMode {
+			if err = binary.Write(c, binary.LittleEndian, p.BlendMode); err != nil {
+				return
+			}
+		}
+		if p.HasCacheAsBitmap {
+			if err = binary.Write(c, binary.LittleEndian, p.BitmapCache); err != nil {
+				return
+			}
+		}
+		if p.HasVisible {
+			if err = binary.Write(c, binary.LittleEndian, p.Visible); err != nil {
+				return
+			}
+		}
+		if p.OpaqueBackground {
+			if _, err = p.BackgroundColor.WriteTo(c); err != nil {
+				return
+			}
+		}
+	}
+	if p.HasClipActions {
+		err = p.ClipActions.writeTo(c, ver)
+	}
+	return
+}
+
+func (p *PlaceObject2) Size(ver uint8, id uint16) int32 {
+	total := int32(1 + 2)
+	if id == TAG_PLACE_OBJECT3 {
+		total++
+		if p.HasFilterList {
+			total += p.SurfaceFilterList.Size()
+		}
+		if p.HasBlendMode {
+			total++
+		}
+		if p.HasCacheAsBitmap {
+			total++
+		}
+		if p.HasVisible {
+			total++
+		}
+		if p.OpaqueBackground {
+			total += 4
+		}
+	}
+	if p.hasClassName(id) {
+		total += p.ClassName.Size()
+	}
+	if p.HasCharacter {
+		total += 2
+	}
+	if p.HasMatrix {
+		total += p.Matrix.Size()
+	}
+	if p.HasColorTransform {
+		total += p.ColorTransform.Size()
+	}
+	if p.HasRatio {
+		total += 2
+	}
+	if p.HasName {
+		total += p.ObjectName.Size()
+	}
+	if p.HasClipDepth {
+		total += 2
+	}
+	if p.HasClipActions {
+		total += p.ClipActions.size(ver)
+	}
+	return total
+}
+
+func (p *PlaceObject2) hasClassName(id uint16) bool {
+	return id == TAG_PLACE_OBJECT3 && (p.HasClassName || (p.HasImage && p.HasCharacter))
+}
+
+func (p *PlaceObject2) MinVersion() uint8 {
+	return 3
+}
+
+func (p *PlaceObject2) TagId() uint16 {
+	return TAG_PLACE_OBJECT2
+}
+
+func (p *PlaceObject2) Name() string {
+	return "PlaceObject2"
+}
+
+// ClipEventFlags is a set of CLIP_EVENT flags. Only the flags below
+// CLIP_EVENT_DRAG_OUT are stored in files of version 5 and earlier.
+type ClipEventFlags uint32
+
+const (
+	CLIP_EVENT_LOAD ClipEventFlags = 1 << iota
+	CLIP_EVENT_ENTER_FRAME
+	CLIP_EVENT_UNLOAD
+	CLIP_EVENT_MOUSE_MOVE
+	CLIP_EVENT_MOUSE_DOWN
+	CLIP_EVENT_MOUSE_UP
+	CLIP_EVENT_KEY_DOWN
+	CLIP_EVENT_KEY_UP
+	CLIP_EVENT_DATA
+	CLIP_EVENT_INITIALIZE
+	CLIP_EVENT_PRESS
+	CLIP_EVENT_RELEASE
+	CLIP_EVENT_RELEASE_OUTSIDE
+	CLIP_EVENT_ROLL_OVER
+	CLIP_EVENT_ROLL_OUT
+	CLIP_EVENT_DRAG_OVER
+	CLIP_EVENT_DRAG_OUT
+	CLIP_EVENT_KEY_PRESS
+	CLIP_EVENT_CONSTRUCT
+)
+
+func (c *ClipEventFlags) readFrom(f io.Reader, ver uint8) error {
+	if ver >= 6 {
+		return binary.Read(f, binary.LittleEndian, c)
+	}
+	var flags uint16
+	err := binary.Read(f, binary.LittleEndian, &flags)
+	*c = ClipEventFlags(flags)
+	return err
+}
+
+func (c ClipEventFlags) writeTo(f io.Writer, ver uint8) error {
+	if ver >= 6 {
+		return binary.Write(f, binary.LittleEndian, c)
+	}
+	return binary.Write(f, binary.LittleEndian, uint16(c))
+}
+
+func clipEventFlagsSize(ver uint8) int32 {
+	if ver >= 6 {
+		return 4
+	}
+	return 2
+}
+
+// ClipActionRecord holds the actions run for the events in EventFlags.
+// KeyCode is only present when EventFlags contains CLIP_EVENT_KEY_PRESS, and
+// NoEnd is as for DoAction.
+type ClipActionRecord struct {
+	EventFlags ClipEventFlags
+	KeyCode    uint8
+	Actions    []Action
+	NoEnd      bool
+}
+
+func (c *ClipActionRecord) size() int32 {
+	total := ActionsSize(c.Actions) + endSize(c.NoEnd)
+	if c.EventFlags&CLIP_EVENT_KEY_PRESS != 0 {
+		total++
+	}
+	return total
+}
+
+// ClipActions holds the event handlers of a placed sprite. AllEventFlags is
+// the union of the flags of the records.
+type ClipActions struct {
+	AllEventFlags ClipEventFlags
+	Records       []ClipActionRecord
+}
+
+func (c *ClipActions) readFrom(f io.Reader, ver uint8) (err error) {
+	var reserved uint16
+	if err = binary.Read(f, binary.LittleEndian, &reserved); err != nil {
+		return
+	}
+	if err = c.AllEventFlags.readFrom(f, ver); err != nil {
+		return
+	}
+	for {
+		var (
+			r    ClipActionRecord
+			size uint32
+			data []byte
+		)
+		if err = r.EventFlags.readFrom(f, ver); err != nil || r.EventFlags == 0 {
+			return
+		}
+		if err = binary.Read(f, binary.LittleEndian, &size); err != nil {
+			return
+		}
+		if data, err = ioutil.ReadAll(io.LimitReader(f, int64(size))); err != nil {
+			return
+		} else if len(data) < int(size) {
+			return io.ErrUnexpectedEOF
+		}
+		if r.EventFlags&CLIP_EVENT_KEY_PRESS != 0 {
+			if len(data) == 0 {
+				return io.ErrUnexpectedEOF
+			}
+			r.KeyCode, data = data[0], data[1:]
+		}
+		var end bool
+		if r.Actions, end, err = decodeActions(data); err != nil {
+			return
+		}
+		r.NoEnd = !end
+		c.Records = append(c.Records, r)
+	}
+}
+
+func (c *ClipActions) writeTo(f io.Writer, ver uint8) (err error) {
+	if err = binary.Write(f, binary.LittleEndian, uint16(0)); err != nil {
+		return
+	}
+	if err = c.AllEventFlags.writeTo(f, ver); err != nil {
+		return
+	}
+	for _, r := range c.Records {
+		if err = r.EventFlags.writeTo(f, ver); err != nil {
+			return
+		}
+		if err = binary.Write(f, binary.LittleEndian, uint32(r.size())); err != nil {
+			return
+		}
+		if r.EventFlags&CLIP_EVENT_KEY_PRESS != 0 {
+			if err = binary.Write(f, binary.LittleEndian, r.KeyCode); err != nil {
+				return
+			}
+		}
+		if err = writeActions(f, r.Actions); err != nil {
+			return
+		}
+		if !r.NoEnd {
+			if err = binary.Write(f, binary.LittleEndian, ACTION_END); err != nil {
+				return
+			}
+		}
+	}
+	return ClipEventFlags(0).writeTo(f, ver)
+}
+
+func (c *ClipActions) size(ver uint8) int32 {
+	total := 2 + 2*clipEventFlagsSize(ver)
+	for n := range c.Records {
+		total += clipEventFlagsSize(ver) + 4 + c.Records[n].size()
+	}
+	return total
+}
+
+type PlaceObject3 struct {
+	PlaceObject2
+}
+
+func (p *PlaceObject3) MinVersion() uint8 {
+	return 8
+}
+
+func (p *PlaceObject3) TagId() uint16 {
+	return TAG_PLACE_OBJECT3
+}
+
+func (p *PlaceObject3) Name() string {
+	return "PlaceObject3"
+}
+
+type RemoveObject struct {
+	CharacterId uint16
+	Depth       uint16
+}
+
+func (r *RemoveObject) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	err = binary.Read(c, binary.LittleEndian, r)
+	return
+}
+
+func (r *RemoveObject) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	err = binary.Write(c, binary.LittleEndian, r)
+	return
+}
+
+func (r *RemoveObject) Size(ver uint8, id uint16) int32 {
+	return 2 + 2
+}
+
+func (r *RemoveObject) MinVersion() uint8 {
+	return 1
+}
+
+func (r *RemoveObject) TagId() uint16 {
+	return TAG_REMOVE_OBJECT
+}
+
+func (r *RemoveObject) Name() string {
+	return "RemoveObject"
+}
+
+type RemoveObject2 struct {
+	Depth uint16
+}
+
+func (r *RemoveObject2) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	err = binary.Read(c, binary.LittleEndian, &r.Depth)
+	return
+}
+
+func (r *RemoveObject2) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	err = binary.Write(c, binary.LittleEndian, r.Depth)
+	return
+}
+
+func (r *RemoveObject2) Size(ver uint8, id uint16) int32 {
+	return 2
+}
+
+func (r *RemoveObject2) MinVersion() uint8 {
+	return 3
+}
+
+func (r *RemoveObject2) TagId() uint16 {
+	return TAG_REMOVE_OBJECT2
+}
+
+func (r *RemoveObject2) Name() string {
+	return "RemoveObject2"
+}
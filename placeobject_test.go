@@ -0,0 +1,84 @@
+package swf
+
+import "testing"
+
+func TestPlaceObject(t *testing.T) {
+	testTag(t, 1, []byte{1, 0, 2, 0, 0}, &PlaceObject{CharacterId: 1, Depth: 2, Matrix: Matrix{ScaleX: 1, ScaleY: 1}})
+	testTag(t, 1, []byte{1, 0, 2, 0, 0, 0x95, 0x5d, 0}, &PlaceObject{
+		CharacterId:       1,
+		Depth:             2,
+		Matrix:            Matrix{ScaleX: 1, ScaleY: 1},
+		HasColorTransform: true,
+		ColorTransform:    CXForm{256, 256, 256, 10, -3, 0},
+	})
+}
+
+func TestPlaceObject2(t *testing.T) {
+	testTag(t, 3, []byte{0x71, 5, 0, 7, 0, 'a', 0, 9, 0}, &PlaceObject2{
+		HasClipDepth: true,
+		HasName:      true,
+		HasRatio:     true,
+		Move:         true,
+		Depth:        5,
+		Ratio:        7,
+		ObjectName:   "a",
+		ClipDepth:    9,
+	})
+	testTag(t, 5, []byte{0x81, 1, 0, 0, 0, 1, 0, 0, 0}, &PlaceObject2{
+		HasClipActions: true,
+		Move:           true,
+		Depth:          1,
+		ClipActions:    ClipActions{AllEventFlags: CLIP_EVENT_LOAD},
+	})
+	testTag(t, 6, []byte{0x80, 1, 0, 0, 0, 3, 0, 2, 0, 2, 0, 0, 0, 2, 0, 0, 0, 7, 0, 0, 0, 2, 0, 3, 0, 0, 0, 13, 6, 0, 0, 0, 0, 0}, &PlaceObject2{
+		HasClipActions: true,
+		Depth:          1,
+		ClipActions: ClipActions{
+			AllEventFlags: CLIP_EVENT_LOAD | CLIP_EVENT_ENTER_FRAME | CLIP_EVENT_KEY_PRESS,
+			Records: []ClipActionRecord{
+				{EventFlags: CLIP_EVENT_ENTER_FRAME, Actions: []Action{BasicAction(ACTION_STOP)}},
+				{EventFlags: CLIP_EVENT_KEY_PRESS, KeyCode: 13, Actions: []Action{BasicAction(ACTION_PLAY)}},
+			},
+		},
+	})
+	testTag(t, 6, []byte{0x80, 1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 7, 0, 0, 0, 0}, &PlaceObject2{
+		HasClipActions: true,
+		Depth:          1,
+		ClipActions: ClipActions{
+			AllEventFlags: CLIP_EVENT_LOAD,
+			Records:       []ClipActionRecord{{EventFlags: CLIP_EVENT_LOAD, Actions: []Action{BasicAction(ACTION_STOP)}, NoEnd: true}},
+		},
+	})
+}
+
+func TestPlaceObject3(t *testing.T) {
+	testTag(t, 8, []byte{0x02, 0x76, 1, 0, 'B', 0, 4, 0, 3, 1, 1, 1, 2, 3, 4}, &PlaceObject3{PlaceObject2{
+		HasCharacter:     true,
+		OpaqueBackground: true,
+		HasVisible:       true,
+		HasImage:         true,
+		HasCacheAsBitmap: true,
+		HasBlendMode:     true,
+		Depth:            1,
+		ClassName:        "B",
+		CharacterId:      4,
+		BlendMode:        BLEND_MULTIPLY,
+		BitmapCache:      1,
+		Visible:          1,
+		BackgroundColor:  RGBA{RGB{1, 2, 3}, 4},
+	}})
+	testTag(t, 8, []byte{0x00, 0x20, 1, 0, 0}, &PlaceObject3{PlaceObject2{
+		HasVisible: true,
+		Depth:      1,
+	}})
+	testTag(t, 8, []byte{0x00, 0x40, 1, 0, 1, 2, 3, 4}, &PlaceObject3{PlaceObject2{
+		OpaqueBackground: true,
+		Depth:            1,
+		BackgroundColor:  RGBA{RGB{1, 2, 3}, 4},
+	}})
+}
+
+func TestRemoveObject(t *testing.T) {
+	testTag(t, 1, []byte{1, 0, 2, 0}, &RemoveObject{1, 2})
+	testTag(t, 3, []byte{3, 0}, &RemoveObject2{3})
+}
@@ -0,0 +1,680 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	REQUEST_NAVIGATE uint8 = iota
+	REQUEST_LOAD_MOVIE
+	REQUEST_LOAD_VARIABLES
+	REQUEST_FSCOMMAND
+)
+
+// URLRequest describes a getURL, loadMovie, loadVariables or fscommand call
+// made by script. Variables holds the variables sent with a GET or POST
+// request, and Source the target path of the clip that made the request.
+type URLRequest struct {
+	Type      uint8
+	URL       string
+	Target    string
+	Method    string
+	Variables map[string]string
+	Source    string
+}
+
+// MovieClip is an instance on the simulated display list.
+type MovieClip struct {
+	Name                                  string
+	Depth                                 int
+	CharacterId                           uint16
+	Parent                                *MovieClip
+	Playing                               bool
+	Visible                               bool
+	X, Y, XScale, YScale, Rotation, Alpha float64
+	player                                *Player
+	frames                                []Frame
+	frame                                 int
+	clipActions                           []ClipActionRecord
+	children                              map[int]*MovieClip
+	object                                *avmObject
+	dynamic                               bool
+	removed                               bool
+}
+
+// CurrentFrame returns the one-based number of the current frame, or 0
+// before the clip has been shown.
+func (c *MovieClip) CurrentFrame() int {
+	return c.frame + 1
+}
+
+func (c *MovieClip) TotalFrames() int {
+	return len(c.frames)
+}
+
+// Children returns the child clips ordered by depth.
+func (c *MovieClip) Children() []*MovieClip {
+	children := make([]*MovieClip, 0, len(c.children))
+	for _, child := range c.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Depth < children[j].Depth
+	})
+	return children
+}
+
+func (c *MovieClip) child(name string) *MovieClip {
+	for _, child := range c.Children() {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Target returns the dot syntax path of the clip.
+func (c *MovieClip) Target() string {
+	if c.Parent == nil {
+		return "_level0"
+	}
+	return c.Parent.Target() + "." + c.Name
+}
+
+func (c *MovieClip) slashTarget() string {
+	if c.Parent == nil {
+		return "/"
+	}
+	if c.Parent.Parent == nil {
+		return "/" + c.Name
+	}
+	return c.Parent.slashTarget() + "/" + c.Name
+}
+
+// Variable returns the string value of a variable set on the clip.
+func (c *MovieClip) Variable(name string) (string, bool) {
+	v, ok := c.object.props[name]
+	if !ok {
+		return "", false
+	}
+	if v == (avmUndefined{}) {
+		return "undefined", true
+	}
+	return c.player.toString(v), true
+}
+
+func (c *MovieClip) parentObject() avmValue {
+	if c == nil || c.Parent == nil {
+		return avmUndefined{}
+	}
+	return c.Parent.object
+}
+
+func (c *MovieClip) property(name string) (avmValue, bool) {
+	switch name {
+	case "_x":
+		return c.X, true
+	case "_y":
+		return c.Y, true
+	case "_xscale":
+		return c.XScale, true
+	case "_yscale":
+		return c.YScale, true
+	case "_currentframe":
+		return float64(c.CurrentFrame()), true
+	case "_totalframes", "_framesloaded":
+		return float64(len(c.frames)), true
+	case "_alpha":
+		return c.Alpha, true
+	case "_visible":
+		return c.Visible, true
+	case "_width", "_height", "_xmouse", "_ymouse":
+		return 0.0, true
+	case "_rotation":
+		return c.Rotation, true
+	case "_target":
+		return c.slashTarget(), true
+	case "_name":
+		return c.Name, true
+	case "_droptarget":
+		return "", true
+	case "_url":
+		return c.player.URL, true
+	case "_highquality", "_focusrect":
+		return 1.0, true
+	case "_quality":
+		return "HIGH", true
+	case "_soundbuftime":
+		return 5.0, true
+	case "_parent":
+		if c.Parent != nil {
+			return c.Parent.object, true
+		}
+	}
+	return nil, false
+}
+
+// setProperty sets the named property, returning false if the name is not
+// a property. Read-only properties are ignored.
+func (c *MovieClip) setProperty(name string, v avmValue) bool {
+	f := func() float64 {
+		return c.player.toNumber(v)
+	}
+	switch name {
+	case "_x":
+		c.X = f()
+	case "_y":
+		c.Y = f()
+	case "_xscale":
+		c.XScale = f()
+	case "_yscale":
+		c.YScale = f()
+	case "_alpha":
+		c.Alpha = f()
+	case "_visible":
+		c.Visible = c.player.toBoolean(v)
+	case "_rotation":
+		c.Rotation = f()
+	case "_name":
+		c.Name = c.player.toString(v)
+	case "_currentframe", "_totalframes", "_framesloaded", "_width", "_height", "_target", "_droptarget", "_url", "_highquality", "_focusrect", "_quality", "_soundbuftime", "_xmouse", "_ymouse", "_parent":
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *MovieClip) setMatrix(m Matrix) {
+	c.X = float64(m.TranslateX) / 20
+	c.Y = float64(m.TranslateY) / 20
+	c.XScale = math.Hypot(float64(m.ScaleX), float64(m.RotateSkew0)) * 100
+	c.YScale = math.Hypot(float64(m.ScaleY), float64(m.RotateSkew1)) * 100
+	c.Rotation = math.Atan2(float64(m.RotateSkew0), float64(m.ScaleX)) * 180 / math.Pi
+}
+
+// gotoFrame moves the clip to the zero-based frame, updating the display
+// list and queuing the actions of that frame. Going backwards rebuilds the
+// display list from the first frame.
+func (c *MovieClip) gotoFrame(n int) {
+	if n >= len(c.frames) {
+		n = len(c.frames) - 1
+	}
+	if n < 0 || n == c.frame {
+		return
+	}
+	if n < c.frame {
+		for _, child := range c.children {
+			if !child.dynamic {
+				c.removeChild(child)
+			}
+		}
+		c.frame = -1
+	}
+	for f := c.frame + 1; f <= n; f++ {
+		c.applyFrame(f, f == n)
+	}
+	c.frame = n
+}
+
+func (c *MovieClip) gotoAndPlay(n int, play bool) {
+	c.gotoFrame(n)
+	c.Playing = play
+}
+
+func (c *MovieClip) applyFrame(f int, actions bool) {
+	p := c.player
+	for _, tag := range c.frames[f].Tags {
+		switch t := tag.(type) {
+		case *PlaceObject:
+			child := c.place(int(t.Depth), t.CharacterId, true)
+			child.setMatrix(t.Matrix)
+		case *PlaceObject2:
+			c.placeObject(t)
+		case *PlaceObject3:
+			c.placeObject(&t.PlaceObject2)
+			if t.HasVisible {
+				if child := c.children[int(t.Depth)]; child != nil {
+					child.Visible = t.Visible != 0
+				}
+			}
+		case *RemoveObject:
+			if child := c.children[int(t.Depth)]; child != nil {
+				c.removeChild(child)
+			}
+		case *RemoveObject2:
+			if child := c.children[int(t.Depth)]; child != nil {
+				c.removeChild(child)
+			}
+		case *DoInitAction:
+			if !p.initialised[t.SpriteId] {
+				p.initialised[t.SpriteId] = true
+				p.queue = append(p.queue, playerAction{c, t.Actions})
+			}
+		case *DoAction:
+			if actions {
+				p.queue = append(p.queue, playerAction{c, t.Actions})
+			}
+		}
+	}
+}
+
+func (c *MovieClip) placeObject(t *PlaceObject2) {
+	child := c.children[int(t.Depth)]
+	if t.HasCharacter {
+		child = c.place(int(t.Depth), t.CharacterId, !t.Move)
+	}
+	if child == nil {
+		return
+	}
+	if t.HasName {
+		child.Name = string(t.ObjectName)
+	}
+	if t.HasMatrix {
+		child.setMatrix(t.Matrix)
+	}
+	if t.HasCharacter && t.HasClipActions {
+		child.clipActions = t.ClipActions.Records
+		child.clipEvent(CLIP_EVENT_LOAD)
+	}
+}
+
+// clipEvent queues the clip actions of the clip that handle the event.
+func (c *MovieClip) clipEvent(event ClipEventFlags) {
+	for _, r := range c.clipActions {
+		if r.EventFlags&event != 0 {
+			c.player.queue = append(c.player.queue, playerAction{c, r.Actions})
+		}
+	}
+}
+
+// place puts an instance of the character at the depth, replacing any
+// instance of a different character.
+func (c *MovieClip) place(depth int, id uint16, replace bool) *MovieClip {
+	if child := c.children[depth]; child != nil {
+		if !replace && child.CharacterId == id {
+			return child
+		}
+		c.removeChild(child)
+	}
+	child := c.player.newClip(id, c, depth)
+	c.children[depth] = child
+	return child
+}
+
+func (c *MovieClip) removeChild(child *MovieClip) {
+	if c.children[child.Depth] == child {
+		delete(c.children, child.Depth)
+	}
+	child.remove()
+}
+
+func (c *MovieClip) remove() {
+	c.removed = true
+	for _, child := range c.children {
+		child.remove()
+	}
+}
+
+// duplicate creates a copy of the clip alongside it, as with
+// duplicateMovieClip.
+func (c *MovieClip) duplicate(name string, depth int) *MovieClip {
+	if c.Parent == nil {
+		return nil
+	}
+	d := c.Parent.place(depth, c.CharacterId, true)
+	d.Name = name
+	d.dynamic = true
+	d.X, d.Y, d.XScale, d.YScale, d.Rotation, d.Alpha, d.Visible = c.X, c.Y, c.XScale, c.YScale, c.Rotation, c.Alpha, c.Visible
+	return d
+}
+
+type playerAction struct {
+	clip    *MovieClip
+	actions []Action
+}
+
+// Player executes the frame scripts of a movie headlessly, against a
+// simulated display list. Nothing is rendered; the Request and Trace hooks
+// observe what the scripts do. Sandboxed scripts cannot access anything
+// outside of the player, and MaxSteps bounds the number of actions a single
+// script may execute.
+type Player struct {
+	Root     *MovieClip
+	Request  func(URLRequest)
+	Trace    func(string)
+	MaxSteps int
+	URL      string
+
+	version      uint8
+	frameRate    float64
+	ticks        int
+	sprites      map[uint16]*DefineSprite
+	buttons      map[uint16][]ButtonCondAction
+	initialised  map[uint16]bool
+	queue        []playerAction
+	intervals    []*playerInterval
+	intervalId   int
+	instances    int
+	current      *MovieClip
+	random       *rand.Rand
+	steps, depth int
+
+	global, objectProto, functionProto, arrayProto, stringProto, numberProto, booleanProto, clipProto, loadVarsProto *avmObject
+}
+
+type playerInterval struct {
+	id             int
+	function, this avmValue
+	args           []avmValue
+	every, next    float64
+}
+
+// NewPlayer creates a player for the movie. Random numbers are generated
+// from a fixed seed, so that runs are repeatable.
+func NewPlayer(s *SWF) *Player {
+	p := &Player{
+		version:     s.Version,
+		frameRate:   float64(s.FrameRate) / 256,
+		sprites:     make(map[uint16]*DefineSprite),
+		buttons:     make(map[uint16][]ButtonCondAction),
+		initialised: make(map[uint16]bool),
+		random:      rand.New(rand.NewSource(1)),
+	}
+	if p.frameRate <= 0 {
+		p.frameRate = 12
+	}
+	p.initGlobals()
+	for _, tag := range s.Tags {
+		switch t := tag.(type) {
+		case *DefineSprite:
+			p.sprites[t.SpriteId] = t
+		case *DefineButton:
+			p.buttons[t.ButtonId] = []ButtonCondAction{{CondOverDownToOverUp: true, Actions: t.Actions}}
+		case *DefineButton2:
+			p.buttons[t.ButtonId] = t.Actions
+		}
+	}
+	p.Root = p.newClip(0, nil, 0)
+	p.Root.Name = ""
+	p.Root.frames = frames(s.Tags)
+	p.current = p.Root
+	return p
+}
+
+func (p *Player) newClip(id uint16, parent *MovieClip, depth int) *MovieClip {
+	p.instances++
+	c := &MovieClip{
+		Name:        "instance" + strconv.Itoa(p.instances),
+		Depth:       depth,
+		CharacterId: id,
+		Parent:      parent,
+		Playing:     true,
+		Visible:     true,
+		XScale:      100,
+		YScale:      100,
+		Alpha:       100,
+		player:      p,
+		frame:       -1,
+		children:    make(map[int]*MovieClip),
+	}
+	if d, ok := p.sprites[id]; ok && parent != nil {
+		c.frames = frames(d.ControlTags)
+	}
+	c.object = newObject(p.clipProto)
+	c.object.class = "MovieClip"
+	c.object.clip = c
+	return c
+}
+
+// Advance plays a single frame: each playing clip moves to its next frame,
+// the queued frame scripts are run and any due intervals are called.
+func (p *Player) Advance() error {
+	p.ticks++
+	p.advance(p.Root)
+	if err := p.runQueue(); err != nil {
+		return err
+	}
+	now := p.time()
+	for _, i := range p.intervals {
+		if i.next > now {
+			continue
+		}
+		i.next = now + i.every
+		p.steps = 0
+		if _, err := p.call(i.function, i.this, i.args); err != nil {
+			if _, ok := err.(*avmThrow); !ok {
+				return err
+			}
+		}
+		if err := p.runQueue(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run advances the given number of frames.
+func (p *Player) Run(frames int) error {
+	for n := 0; n < frames; n++ {
+		if err := p.Advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Click simulates the mouse being pressed and released over the clip. The
+// actions of a button run on the timeline containing it, and the press and
+// release clip actions of a sprite on the sprite.
+func (p *Player) Click(c *MovieClip) error {
+	if c.removed {
+		return nil
+	}
+	if c.Parent != nil {
+		for _, b := range p.buttons[c.CharacterId] {
+			if b.CondOverUpToOverDown {
+				p.queue = append(p.queue, playerAction{c.Parent, b.Actions})
+			}
+		}
+	}
+	c.clipEvent(CLIP_EVENT_PRESS)
+	if c.Parent != nil {
+		for _, b := range p.buttons[c.CharacterId] {
+			if b.CondOverDownToOverUp {
+				p.queue = append(p.queue, playerAction{c.Parent, b.Actions})
+			}
+		}
+	}
+	c.clipEvent(CLIP_EVENT_RELEASE)
+	return p.runQueue()
+}
+
+func (p *Player) advance(c *MovieClip) {
+	if c.frame >= 0 {
+		c.clipEvent(CLIP_EVENT_ENTER_FRAME)
+	}
+	switch {
+	case c.frame < 0:
+		c.gotoFrame(0)
+	case c.Playing && len(c.frames) > 1:
+		n := c.frame + 1
+		if n == len(c.frames) {
+			n = 0
+		}
+		c.gotoFrame(n)
+	}
+	for _, child := range c.Children() {
+		if !child.removed {
+			p.advance(child)
+		}
+	}
+}
+
+func (p *Player) runQueue() error {
+	for len(p.queue) > 0 {
+		a := p.queue[0]
+		p.queue = p.queue[1:]
+		if a.clip.removed {
+			continue
+		}
+		if err := p.execute(a.actions, a.clip); err != nil {
+			p.queue = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// time returns the milliseconds elapsed, as measured in frames.
+func (p *Player) time() float64 {
+	return math.Floor(float64(p.ticks) * 1000 / p.frameRate)
+}
+
+func (p *Player) getURL(c *MovieClip, url, target string, method uint8, loadTarget, loadVariables bool) {
+	r := URLRequest{
+		URL:    url,
+		Target: target,
+		Source: c.Target(),
+	}
+	switch {
+	case strings.HasPrefix(strings.ToLower(url), "fscommand:"):
+		r.Type = REQUEST_FSCOMMAND
+	case loadVariables:
+		r.Type = REQUEST_LOAD_VARIABLES
+	case loadTarget || strings.HasPrefix(target, "_level"):
+		r.Type = REQUEST_LOAD_MOVIE
+	}
+	p.request(r, method, c.object)
+}
+
+func (p *Player) request(r URLRequest, method uint8, o *avmObject) {
+	switch method {
+	case SEND_VARS_GET:
+		r.Method = "GET"
+	case SEND_VARS_POST:
+		r.Method = "POST"
+	}
+	if r.Method != "" {
+		r.Variables = p.variables(o)
+	}
+	if p.Request != nil {
+		p.Request(r)
+	}
+}
+
+// variables returns the primitive valued variables of the object, as sent
+// with a request.
+func (p *Player) variables(o *avmObject) map[string]string {
+	vars := make(map[string]string)
+	for _, k := range o.keys {
+		if v := o.props[k]; isPrimitive(v) {
+			vars[k] = p.toString(v)
+		}
+	}
+	return vars
+}
+
+func (p *Player) setTarget(ctx *avmContext, path string) {
+	if path == "" {
+		ctx.target = ctx.original
+	} else if c := p.resolveTarget(ctx, path); c != nil {
+		ctx.target = c
+	}
+}
+
+// targetClip returns the clip referred to by the value, which may be a clip
+// or a target path. An empty path refers to the current target.
+func (p *Player) targetClip(ctx *avmContext, v avmValue) *MovieClip {
+	if o, ok := v.(*avmObject); ok {
+		return o.clip
+	}
+	if s := p.toString(v); s != "" {
+		return p.resolveTarget(ctx, s)
+	}
+	return ctx.target
+}
+
+// resolveTarget resolves a target path, in either slash or dot syntax,
+// relative to the current target.
+func (p *Player) resolveTarget(ctx *avmContext, path string) *MovieClip {
+	c := ctx.target
+	if strings.HasPrefix(path, "/") {
+		c = p.Root
+	}
+	for n, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '.' }) {
+		switch part {
+		case "..", "_parent":
+			c = c.Parent
+		case "_root", "_level0":
+			c = p.Root
+		case "this":
+			if o, ok := ctx.this.(*avmObject); ok && n == 0 && o.clip != nil {
+				c = o.clip
+			}
+		default:
+			next := c.child(part)
+			if next == nil {
+				var v avmValue
+				if n == 0 {
+					v = p.lookup(ctx, part)
+				} else {
+					v = c.object.get(part)
+				}
+				if o, ok := v.(*avmObject); ok {
+					next = o.clip
+				}
+			}
+			c = next
+		}
+		if c == nil {
+			return nil
+		}
+	}
+	return c
+}
+
+// frameNumber returns the zero-based frame of the clip referred to by a
+// frame number or label.
+func (p *Player) frameNumber(c *MovieClip, v avmValue) int {
+	if s, ok := v.(string); ok {
+		if n, ok := FrameIndex(c.frames, s); ok {
+			return n
+		}
+		if n := strings.LastIndex(s, ":"); n >= 0 {
+			s = s[n+1:]
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return int(f) - 1
+		}
+		return -1
+	}
+	return int(p.toNumber(v)) - 1
+}
@@ -0,0 +1,161 @@
+package swf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlayer(t *testing.T) {
+	assemble := func(listing string) []Action {
+		actions, err := Assemble(strings.NewReader(listing))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return actions
+	}
+	s := &SWF{
+		Version:    8,
+		FrameRate:  10 << 8,
+		FrameCount: 3,
+		Tags: []Tag{
+			&DefineSprite{
+				SpriteId:   1,
+				FrameCount: 2,
+				ControlTags: []Tag{
+					&DoAction{Actions: assemble("\tPush \"_name\"\n\tGetVariable\n\tTrace\n")},
+					&ShowFrame{},
+					&DoAction{Actions: assemble("\tStop\n")},
+					&ShowFrame{},
+				},
+			},
+			&PlaceObject2{
+				HasName:      true,
+				HasCharacter: true,
+				Depth:        1,
+				CharacterId:  1,
+				ObjectName:   "banner",
+			},
+			&DoAction{Actions: assemble("\tPush \"start\"\n\tTrace\n")},
+			&ShowFrame{},
+			&DoAction{Actions: assemble("" +
+				"\tGetURL \"http://example.com/click\" \"_blank\"\n" +
+				"\tPush \"http://ads.example.com/a.swf\" \"_level1\"\n" +
+				"\tGetURL2 none\n" +
+				"\tPush \"lv\" 0 \"LoadVars\"\n" +
+				"\tNewObject\n" +
+				"\tSetVariable\n" +
+				"\tPush \"lv\"\n" +
+				"\tGetVariable\n" +
+				"\tPush \"id\" \"42\"\n" +
+				"\tSetMember\n" +
+				"\tPush \"POST\" \"_self\" \"http://t.example.com/track\" 3 \"lv\"\n" +
+				"\tGetVariable\n" +
+				"\tPush \"send\"\n" +
+				"\tCallMethod\n" +
+				"\tPop\n" +
+				"\tPush \"n\" 0\n" +
+				"\tSetVariable\n" +
+				"\tDefineFunction \"tick\" L1\n" +
+				"\tPush \"n\" \"n\"\n" +
+				"\tGetVariable\n" +
+				"\tIncrement\n" +
+				"\tSetVariable\n" +
+				"L1:\n" +
+				"\tPush 100 \"tick\"\n" +
+				"\tGetVariable\n" +
+				"\tPush 2 \"setInterval\"\n" +
+				"\tCallFunction\n" +
+				"\tPop\n" +
+				"\tStop\n")},
+			&ShowFrame{},
+			&DoAction{Actions: assemble("\tPush \"never\"\n\tTrace\n")},
+			&ShowFrame{},
+		},
+	}
+	var (
+		traces   []string
+		requests []URLRequest
+	)
+	p := NewPlayer(s)
+	p.Trace = func(s string) {
+		traces = append(traces, s)
+	}
+	p.Request = func(r URLRequest) {
+		requests = append(requests, r)
+	}
+	if err := p.Run(5); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := []string{"start", "banner"}; !reflect.DeepEqual(traces, expected) {
+		t.Errorf("expecting traces %q, got %q", expected, traces)
+	}
+	expected := []URLRequest{
+		{REQUEST_NAVIGATE, "http://example.com/click", "_blank", "", nil, "_level0"},
+		{REQUEST_LOAD_MOVIE, "http://ads.example.com/a.swf", "_level1", "", nil, "_level0"},
+		{REQUEST_NAVIGATE, "http://t.example.com/track", "_self", "POST", map[string]string{"id": "42"}, "_level0"},
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("expecting requests %v, got %v", expected, requests)
+	}
+	if f := p.Root.CurrentFrame(); f != 2 || p.Root.Playing {
+		t.Errorf("expecting root stopped at frame 2, got frame %d, playing %v", f, p.Root.Playing)
+	}
+	children := p.Root.Children()
+	if len(children) != 1 || children[0].Name != "banner" || children[0].CurrentFrame() != 2 {
+		t.Errorf("expecting banner stopped at frame 2, got %v", children)
+	}
+	if n, _ := p.Root.Variable("n"); n != "3" {
+		t.Errorf("expecting interval to have run 3 times, got %s", n)
+	}
+}
+
+func TestPlayerEvents(t *testing.T) {
+	trace := func(s string) []Action {
+		return []Action{&ActionPush{[]PushValue{PushString(s)}}, BasicAction(ACTION_TRACE)}
+	}
+	s := &SWF{
+		Version:    6,
+		FrameRate:  10 << 8,
+		FrameCount: 1,
+		Tags: []Tag{
+			&DefineSprite{SpriteId: 1, FrameCount: 1, ControlTags: []Tag{&ShowFrame{}}},
+			&DefineButton2{ButtonId: 2, Actions: []ButtonCondAction{
+				{CondOverDownToOverUp: true, Actions: trace("release")},
+				{CondOverUpToOverDown: true, Actions: trace("press")},
+			}},
+			&DefineButton{ButtonId: 3, Actions: trace("button")},
+			&PlaceObject2{HasClipActions: true, HasCharacter: true, Depth: 1, CharacterId: 1, ClipActions: ClipActions{
+				AllEventFlags: CLIP_EVENT_LOAD | CLIP_EVENT_ENTER_FRAME | CLIP_EVENT_RELEASE,
+				Records: []ClipActionRecord{
+					{EventFlags: CLIP_EVENT_LOAD, Actions: trace("load")},
+					{EventFlags: CLIP_EVENT_ENTER_FRAME, Actions: trace("enter")},
+					{EventFlags: CLIP_EVENT_RELEASE, Actions: trace("clip")},
+				},
+			}},
+			&PlaceObject2{HasCharacter: true, Depth: 2, CharacterId: 2},
+			&PlaceObject2{HasCharacter: true, Depth: 3, CharacterId: 3},
+			&ShowFrame{},
+		},
+	}
+	var traces []string
+	p := NewPlayer(s)
+	p.Trace = func(s string) {
+		traces = append(traces, s)
+	}
+	if err := p.Run(2); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	children := p.Root.Children()
+	if len(children) != 3 {
+		t.Fatalf("expecting 3 children, got %d", len(children))
+	}
+	for _, c := range children {
+		if err := p.Click(c); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if expected := []string{"load", "enter", "clip", "press", "release", "button"}; !reflect.DeepEqual(traces, expected) {
+		t.Errorf("expecting traces %q, got %q", expected, traces)
+	}
+}
@@ -0,0 +1,390 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"math"
+	"sort"
+)
+
+var (
+	ErrUnknownCharacter = errors.New("scalingGrid: no sprite, button or shape with that character id")
+	ErrCharacterCycle   = errors.New("scalingGrid: character contains itself")
+)
+
+type DefineScalingGrid struct {
+	CharacterId uint16
+	Splitter    Rect
+}
+
+func (d *DefineScalingGrid) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.CharacterId); err != nil {
+		return
+	}
+	_, err = d.Splitter.ReadFrom(c)
+	return
+}
+
+func (d *DefineScalingGrid) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.CharacterId); err != nil {
+		return
+	}
+	_, err = d.Splitter.WriteTo(c)
+	return
+}
+
+func (d *DefineScalingGrid) Size(ver uint8, id uint16) int32 {
+	return 2 + d.Splitter.Size()
+}
+
+func (d *DefineScalingGrid) MinVersion() uint8 {
+	return 8
+}
+
+func (d *DefineScalingGrid) TagId() uint16 {
+	return TAG_DEFINE_SCALING_GRID
+}
+
+func (d *DefineScalingGrid) Name() string {
+	return "DefineScalingGrid"
+}
+
+// ScalingGrid returns the DefineScalingGrid tag for the given sprite or
+// button, if present.
+func (s *SWF) ScalingGrid(characterId uint16) *DefineScalingGrid {
+	for _, tag := range allTags(s.Tags) {
+		if d, ok := tag.(*DefineScalingGrid); ok && d.CharacterId == characterId {
+			return d
+		}
+	}
+	return nil
+}
+
+// sliceAxis maps a coordinate along one axis of a 9-slice grid. The margins
+// outside of the grid keep their size, unless there is not enough room for
+// them, in which case they are shrunk to fit and the centre disappears.
+func sliceAxis(v, min, max, gmin, gmax Twips, scale float64) float64 {
+	if scale <= 0 || gmin < min || gmax > max || gmax <= gmin {
+		return float64(v) * scale
+	}
+	size := float64(max-min) * scale
+	origin := float64(min) * scale
+	left, right := float64(gmin-min), float64(max-gmax)
+	if left+right >= size {
+		k := size / (left + right)
+		switch {
+		case v <= gmin:
+			return origin + float64(v-min)*k
+		case v >= gmax:
+			return origin + size - float64(max-v)*k
+		}
+		return origin + left*k
+	}
+	switch {
+	case v < gmin:
+		return origin + float64(v-min)
+	case v > gmax:
+		return origin + size - float64(max-v)
+	}
+	return origin + left + float64(v-gmin)*(size-left-right)/float64(gmax-gmin)
+}
+
+func roundTwips(f float64) Twips {
+	return Twips(math.Floor(f + 0.5))
+}
+
+// pointMapper returns a function that transforms points by the matrix,
+// applying the 9-slice grid when given one. As with the Flash Player, the grid
+// is ignored for matrices that rotate or skew.
+func pointMapper(m Matrix, bounds Rect, grid *DefineScalingGrid) func(x, y Twips) (Twips, Twips) {
+	if grid == nil || m.RotateSkew0 != 0 || m.RotateSkew1 != 0 {
+		return func(x, y Twips) (Twips, Twips) {
+			return roundTwips(float64(x)*float64(m.ScaleX)+float64(y)*float64(m.RotateSkew1)) + m.TranslateX, roundTwips(float64(x)*float64(m.RotateSkew0)+float64(y)*float64(m.ScaleY)) + m.TranslateY
+		}
+	}
+	s := grid.Splitter
+	return func(x, y Twips) (Twips, Twips) {
+		return roundTwips(sliceAxis(x, bounds.Xmin, bounds.Xmax, s.Xmin, s.Xmax, float64(m.ScaleX))) + m.TranslateX, roundTwips(sliceAxis(y, bounds.Ymin, bounds.Ymax, s.Ymin, s.Ymax, float64(m.ScaleY))) + m.TranslateY
+	}
+}
+
+// multiplyMatrix returns the matrix that applies n and then m.
+func multiplyMatrix(m, n Matrix) Matrix {
+	return Matrix{
+		ScaleX:      m.ScaleX*n.ScaleX + m.RotateSkew1*n.RotateSkew0,
+		RotateSkew0: m.RotateSkew0*n.ScaleX + m.ScaleY*n.RotateSkew0,
+		RotateSkew1: m.ScaleX*n.RotateSkew1 + m.RotateSkew1*n.ScaleY,
+		ScaleY:      m.RotateSkew0*n.RotateSkew1 + m.ScaleY*n.ScaleY,
+		TranslateX:  roundTwips(float64(m.ScaleX)*float64(n.TranslateX)+float64(m.RotateSkew1)*float64(n.TranslateY)) + m.TranslateX,
+		TranslateY:  roundTwips(float64(m.RotateSkew0)*float64(n.TranslateX)+float64(m.ScaleY)*float64(n.TranslateY)) + m.TranslateY,
+	}
+}
+
+func transformFillStyle(fs FillStyle, m Matrix) FillStyle {
+	if isGradientFill(fs.FillStyleType) {
+		fs.GradientMatrix = multiplyMatrix(m, fs.GradientMatrix)
+	} else if isBitmapFill(fs.FillStyleType) {
+		fs.BitmapMatrix = multiplyMatrix(m, fs.BitmapMatrix)
+	}
+	return fs
+}
+
+// Transform returns a copy of the shape, and its bounds, transformed by the
+// matrix, such as that of the PlaceObject2 tag that places it. When grid is
+// not nil, the shape is 9-slice scaled, so that only the part of the shape
+// within the splitter rectangle is stretched. Fill matrices are transformed
+// without the grid, and line widths are unchanged.
+func (s *ShapeWithStyle) Transform(m Matrix, bounds Rect, grid *DefineScalingGrid) (*ShapeWithStyle, Rect) {
+	mapPoint := pointMapper(m, bounds, grid)
+	t := &ShapeWithStyle{
+		FillStyles:   make([]FillStyle, len(s.FillStyles)),
+		LineStyles:   make([]LineStyle, len(s.LineStyles)),
+		ShapeRecords: make([]ShapeRecord, len(s.ShapeRecords)),
+	}
+	for n, fs := range s.FillStyles {
+		t.FillStyles[n] = transformFillStyle(fs, m)
+	}
+	for n, ls := range s.LineStyles {
+		if ls.HasFill {
+			ls.FillType = transformFillStyle(ls.FillType, m)
+		}
+		t.LineStyles[n] = ls
+	}
+	var x, y Twips
+	px, py := mapPoint(0, 0)
+	for n, record := range s.ShapeRecords {
+		switch r := record.(type) {
+		case *StyleChangeRecord:
+			sc := *r
+			if r.MoveTo {
+				x, y = r.MoveDeltaX, r.MoveDeltaY
+				px, py = mapPoint(x, y)
+				sc.MoveDeltaX, sc.MoveDeltaY = px, py
+			}
+			t.ShapeRecords[n] = &sc
+		case *StraightEdgeRecord:
+			x, y = x+r.DeltaX, y+r.DeltaY
+			nx, ny := mapPoint(x, y)
+			t.ShapeRecords[n] = &StraightEdgeRecord{nx - px, ny - py}
+			px, py = nx, ny
+		case *CurvedEdgeRecord:
+			cx, cy := mapPoint(x+r.ControlDeltaX, y+r.ControlDeltaY)
+			x, y = x+r.ControlDeltaX+r.AnchorDeltaX, y+r.ControlDeltaY+r.AnchorDeltaY
+			nx, ny := mapPoint(x, y)
+			t.ShapeRecords[n] = &CurvedEdgeRecord{cx - px, cy - py, nx - cx, ny - cy}
+			px, py = nx, ny
+		default:
+			t.ShapeRecords[n] = record
+		}
+	}
+	var b Rect
+	for n, corner := range [...][2]Twips{{bounds.Xmin, bounds.Ymin}, {bounds.Xmax, bounds.Ymin}, {bounds.Xmin, bounds.Ymax}, {bounds.Xmax, bounds.Ymax}} {
+		cx, cy := mapPoint(corner[0], corner[1])
+		if n == 0 {
+			b = Rect{cx, cx, cy, cy}
+			continue
+		}
+		b.Xmin, b.Xmax = Twips(min(int32(b.Xmin), int32(cx))), Twips(max(int32(b.Xmax), int32(cx)))
+		b.Ymin, b.Ymax = Twips(min(int32(b.Ymin), int32(cy))), Twips(max(int32(b.Ymax), int32(cy)))
+	}
+	return t, b
+}
+
+// CharacterShapes returns the shapes drawn by the first frame of the sprite,
+// the up state of the button, or the morph shape, with the given id, in depth
+// order, along with their combined bounds. The shapes are transformed by the
+// matrix, such as that of the PlaceObject2 tag placing the character, with
+// the scaling grid of the character applied. Children that are not shapes,
+// sprites or buttons are skipped.
+func (s *SWF) CharacterShapes(characterId uint16, m Matrix) ([]*ShapeWithStyle, Rect, error) {
+	c := &shapeCollector{
+		morphs:   make(map[uint16]*DefineMorphShape),
+		sprites:  make(map[uint16]*DefineSprite),
+		buttons:  make(map[uint16][]ButtonRecord),
+		grids:    make(map[uint16]*DefineScalingGrid),
+		visiting: make(map[uint16]bool),
+	}
+	for _, tag := range allTags(s.Tags) {
+		switch t := tag.(type) {
+		case *DefineMorphShape:
+			c.morphs[t.CharacterId] = t
+		case *DefineMorphShape2:
+			c.morphs[t.CharacterId] = &t.DefineMorphShape
+		case *DefineSprite:
+			c.sprites[t.SpriteId] = t
+		case *DefineButton:
+			c.buttons[t.ButtonId] = t.Characters
+		case *DefineButton2:
+			c.buttons[t.ButtonId] = t.Characters
+		case *DefineScalingGrid:
+			if c.grids[t.CharacterId] == nil {
+				c.grids[t.CharacterId] = t
+			}
+		}
+	}
+	if c.morphs[characterId] == nil && c.sprites[characterId] == nil && c.buttons[characterId] == nil {
+		return nil, Rect{}, ErrUnknownCharacter
+	}
+	shapes, bounds, err := c.shapes(characterId, 0)
+	if err != nil || len(shapes) == 0 {
+		return nil, Rect{}, err
+	}
+	var b Rect
+	for n, shape := range shapes {
+		shapes[n], b = shape.Transform(m, bounds, c.grids[characterId])
+	}
+	return shapes, b, nil
+}
+
+// WriteCharacterSVG writes the shapes of the sprite, button or morph shape,
+// as returned by CharacterShapes, as an SVG image.
+func (s *SWF) WriteCharacterSVG(w io.Writer, characterId uint16, m Matrix) error {
+	shapes, bounds, err := s.CharacterShapes(characterId, m)
+	if err != nil {
+		return err
+	}
+	return writeSVG(w, shapes, bounds)
+}
+
+type shapeCollector struct {
+	morphs   map[uint16]*DefineMorphShape
+	sprites  map[uint16]*DefineSprite
+	buttons  map[uint16][]ButtonRecord
+	grids    map[uint16]*DefineScalingGrid
+	visiting map[uint16]bool
+}
+
+type shapePlacement struct {
+	characterId uint16
+	matrix      Matrix
+	ratio       uint16
+}
+
+// shapes returns the shapes of the character in its own coordinate space,
+// along with their combined bounds. Children are transformed by their
+// matrices with their own scaling grids applied.
+func (c *shapeCollector) shapes(characterId, ratio uint16) ([]*ShapeWithStyle, Rect, error) {
+	if d, ok := c.morphs[characterId]; ok {
+		shape, err := d.Interpolate(ratio)
+		if err != nil {
+			return nil, Rect{}, err
+		}
+		return []*ShapeWithStyle{shape}, d.Bounds(ratio), nil
+	}
+	if c.visiting[characterId] {
+		return nil, Rect{}, ErrCharacterCycle
+	}
+	c.visiting[characterId] = true
+	defer delete(c.visiting, characterId)
+	placed := make(map[int]shapePlacement)
+	if d, ok := c.sprites[characterId]; ok {
+		for _, tag := range d.ControlTags {
+			if _, ok := tag.(*ShowFrame); ok {
+				break
+			}
+			switch t := tag.(type) {
+			case *PlaceObject:
+				placed[int(t.Depth)] = shapePlacement{t.CharacterId, t.Matrix, 0}
+			case *PlaceObject2:
+				c.place(placed, t)
+			case *PlaceObject3:
+				c.place(placed, &t.PlaceObject2)
+			case *RemoveObject:
+				delete(placed, int(t.Depth))
+			case *RemoveObject2:
+				delete(placed, int(t.Depth))
+			}
+		}
+	} else {
+		for _, r := range c.buttons[characterId] {
+			if r.StateUp {
+				placed[int(r.PlaceDepth)] = shapePlacement{r.CharacterId, r.PlaceMatrix, 0}
+			}
+		}
+	}
+	depths := make([]int, 0, len(placed))
+	for depth := range placed {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	var (
+		shapes []*ShapeWithStyle
+		bounds Rect
+	)
+	for _, depth := range depths {
+		p := placed[depth]
+		children, b, err := c.shapes(p.characterId, p.ratio)
+		if err != nil {
+			return nil, Rect{}, err
+		}
+		for _, child := range children {
+			var cb Rect
+			child, cb = child.Transform(p.matrix, b, c.grids[p.characterId])
+			if len(shapes) == 0 {
+				bounds = cb
+			} else {
+				bounds = unionRect(bounds, cb)
+			}
+			shapes = append(shapes, child)
+		}
+	}
+	return shapes, bounds, nil
+}
+
+func (c *shapeCollector) place(placed map[int]shapePlacement, t *PlaceObject2) {
+	p, ok := placed[int(t.Depth)]
+	if t.HasCharacter {
+		if !ok || !t.Move || p.characterId != t.CharacterId {
+			p = shapePlacement{matrix: Matrix{ScaleX: 1, ScaleY: 1}}
+		}
+		p.characterId = t.CharacterId
+	} else if !ok {
+		return
+	}
+	if t.HasMatrix {
+		p.matrix = t.Matrix
+	}
+	if t.HasRatio {
+		p.ratio = t.Ratio
+	}
+	placed[int(t.Depth)] = p
+}
+
+func unionRect(a, b Rect) Rect {
+	return Rect{
+		Twips(min(int32(a.Xmin), int32(b.Xmin))),
+		Twips(max(int32(a.Xmax), int32(b.Xmax))),
+		Twips(min(int32(a.Ymin), int32(b.Ymin))),
+		Twips(max(int32(a.Ymax), int32(b.Ymax))),
+	}
+}
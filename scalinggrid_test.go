@@ -0,0 +1,169 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDefineScalingGrid(t *testing.T) {
+	testTag(t, 8, []byte{1, 0, 72, 81, 104, 20, 90, 0}, &DefineScalingGrid{1, Rect{20, 180, 20, 180}})
+}
+
+func TestScalingGridTransform(t *testing.T) {
+	s := &ShapeWithStyle{
+		FillStyles: []FillStyle{{FillStyleType: FILL_LINEAR_GRADIENT, GradientMatrix: Matrix{ScaleX: 1, ScaleY: 1}}},
+		ShapeRecords: []ShapeRecord{
+			&StyleChangeRecord{MoveTo: true, HasFillStyle1: true, FillStyle1: 1},
+			&StraightEdgeRecord{10, 0},
+			&StraightEdgeRecord{190, 0},
+			&StraightEdgeRecord{0, 200},
+			&CurvedEdgeRecord{-100, 0, -100, 0},
+			&StraightEdgeRecord{0, -200},
+		},
+	}
+	bounds := Rect{0, 200, 0, 200}
+	grid := &DefineScalingGrid{1, Rect{20, 180, 20, 180}}
+	tests := []struct {
+		matrix  Matrix
+		grid    *DefineScalingGrid
+		records []ShapeRecord
+		bounds  Rect
+	}{
+		{
+			Matrix{ScaleX: 3, ScaleY: 1, TranslateX: 100},
+			grid,
+			[]ShapeRecord{
+				&StyleChangeRecord{MoveTo: true, MoveDeltaX: 100, HasFillStyle1: true, FillStyle1: 1},
+				&StraightEdgeRecord{10, 0},
+				&StraightEdgeRecord{590, 0},
+				&StraightEdgeRecord{0, 200},
+				&CurvedEdgeRecord{-300, 0, -300, 0},
+				&StraightEdgeRecord{0, -200},
+			},
+			Rect{100, 700, 0, 200},
+		},
+		{
+			Matrix{ScaleX: 3, ScaleY: 1, TranslateX: 100},
+			nil,
+			[]ShapeRecord{
+				&StyleChangeRecord{MoveTo: true, MoveDeltaX: 100, HasFillStyle1: true, FillStyle1: 1},
+				&StraightEdgeRecord{30, 0},
+				&StraightEdgeRecord{570, 0},
+				&StraightEdgeRecord{0, 200},
+				&CurvedEdgeRecord{-300, 0, -300, 0},
+				&StraightEdgeRecord{0, -200},
+			},
+			Rect{100, 700, 0, 200},
+		},
+		{
+			Matrix{ScaleX: 0.1, ScaleY: 1, TranslateX: 100},
+			grid,
+			[]ShapeRecord{
+				&StyleChangeRecord{MoveTo: true, MoveDeltaX: 100, HasFillStyle1: true, FillStyle1: 1},
+				&StraightEdgeRecord{5, 0},
+				&StraightEdgeRecord{15, 0},
+				&StraightEdgeRecord{0, 200},
+				&CurvedEdgeRecord{-10, 0, -10, 0},
+				&StraightEdgeRecord{0, -200},
+			},
+			Rect{100, 120, 0, 200},
+		},
+	}
+	for n, test := range tests {
+		r, b := s.Transform(test.matrix, bounds, test.grid)
+		if !reflect.DeepEqual(r.ShapeRecords, test.records) {
+			t.Errorf("test %d: expecting records %v, got %v", n+1, test.records, r.ShapeRecords)
+		}
+		if b != test.bounds {
+			t.Errorf("test %d: expecting bounds %v, got %v", n+1, test.bounds, b)
+		}
+		if m := r.FillStyles[0].GradientMatrix; m != test.matrix {
+			t.Errorf("test %d: expecting gradient matrix %v, got %v", n+1, test.matrix, m)
+		}
+	}
+}
+
+func TestCharacterShapes(t *testing.T) {
+	s := &SWF{Tags: []Tag{
+		testMorphShape(),
+		&DefineSprite{SpriteId: 5, FrameCount: 1, ControlTags: []Tag{
+			&PlaceObject2{HasCharacter: true, Depth: 2, CharacterId: 1, HasMatrix: true, Matrix: Matrix{ScaleX: 1, ScaleY: 1, TranslateX: 100}},
+			&PlaceObject2{HasCharacter: true, Depth: 1, CharacterId: 1},
+			&ShowFrame{},
+		}},
+		&DefineScalingGrid{5, Rect{20, 180, 20, 80}},
+		&DefineButton2{ButtonId: 6, Characters: []ButtonRecord{
+			{StateUp: true, CharacterId: 5, PlaceDepth: 1, PlaceMatrix: Matrix{ScaleX: 1, ScaleY: 1, TranslateX: 10}},
+			{StateDown: true, CharacterId: 1, PlaceDepth: 2, PlaceMatrix: Matrix{ScaleX: 1, ScaleY: 1}},
+		}},
+		&DefineSprite{SpriteId: 7, FrameCount: 1, ControlTags: []Tag{
+			&PlaceObject2{HasCharacter: true, Depth: 1, CharacterId: 7},
+			&ShowFrame{},
+		}},
+	}}
+	square := func(x, control, anchor Twips) []ShapeRecord {
+		return []ShapeRecord{
+			&StyleChangeRecord{MoveTo: true, MoveDeltaX: x, HasFillStyle1: true, FillStyle1: 1, HasLineStyle: true, LineStyle: 1},
+			&CurvedEdgeRecord{control, 0, anchor, 0},
+			&StraightEdgeRecord{0, 100},
+			&StraightEdgeRecord{-control - anchor, 0},
+			&StraightEdgeRecord{0, -100},
+		}
+	}
+	shapes, bounds, err := s.CharacterShapes(5, Matrix{ScaleX: 2, ScaleY: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := (Rect{0, 400, 0, 100}); bounds != expected {
+		t.Errorf("expecting bounds %v, got %v", expected, bounds)
+	}
+	if len(shapes) != 2 {
+		t.Fatalf("expecting 2 shapes, got %d", len(shapes))
+	}
+	for n, records := range [...][]ShapeRecord{square(0, 88, 112), square(200, 113, 87)} {
+		if !reflect.DeepEqual(shapes[n].ShapeRecords, records) {
+			t.Errorf("shape %d: expecting records %v, got %v", n+1, records, shapes[n].ShapeRecords)
+		}
+	}
+	if shapes, bounds, err = s.CharacterShapes(6, Matrix{ScaleX: 1, ScaleY: 1}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expected := (Rect{10, 210, 0, 100}); bounds != expected || len(shapes) != 2 {
+		t.Errorf("expecting 2 shapes with bounds %v, got %d with %v", expected, len(shapes), bounds)
+	}
+	var buf bytes.Buffer
+	if err = s.WriteCharacterSVG(&buf, 5, Matrix{ScaleX: 2, ScaleY: 1}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if c := bytes.Count(buf.Bytes(), []byte("<path d=\"M")); !bytes.Contains(buf.Bytes(), []byte(`width="20" height="5"`)) || c != 4 {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+	s.Tags = append(s.Tags, &DefineSprite{SpriteId: 9, FrameCount: 1, ControlTags: []Tag{
+		&PlaceObject2{HasCharacter: true, Depth: 1, CharacterId: 5, HasMatrix: true, Matrix: Matrix{ScaleX: 2, ScaleY: 1}},
+		&ShowFrame{},
+	}})
+	if shapes, bounds, err = s.CharacterShapes(9, Matrix{ScaleX: 1, ScaleY: 1}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expected := (Rect{0, 400, 0, 100}); bounds != expected || len(shapes) != 2 {
+		t.Errorf("expecting 2 shapes with bounds %v, got %d with %v", expected, len(shapes), bounds)
+	} else {
+		for n, records := range [...][]ShapeRecord{square(0, 88, 112), square(200, 113, 87)} {
+			if !reflect.DeepEqual(shapes[n].ShapeRecords, records) {
+				t.Errorf("nested shape %d: expecting records %v, got %v", n+1, records, shapes[n].ShapeRecords)
+			}
+		}
+	}
+	if _, _, err = s.CharacterShapes(7, Matrix{ScaleX: 1, ScaleY: 1}); err != ErrCharacterCycle {
+		t.Errorf("expecting error %q, got %q", ErrCharacterCycle, err)
+	}
+	if _, _, err = s.CharacterShapes(8, Matrix{ScaleX: 1, ScaleY: 1}); err != ErrUnknownCharacter {
+		t.Errorf("expecting error %q, got %q", ErrUnknownCharacter, err)
+	}
+}
+
+func TestScalingGridInSprite(t *testing.T) {
+	grid := &DefineScalingGrid{3, Rect{20, 180, 20, 180}}
+	s := &SWF{Tags: []Tag{&DefineSprite{SpriteId: 2, ControlTags: []Tag{grid}}}}
+	if g := s.ScalingGrid(3); g != grid {
+		t.Errorf("expecting %v, got %v", grid, g)
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+)
+
+var ErrEdgeTooLong = errors.New("shapeRecord: edge delta too large to encode")
+
+type ShapeRecord interface {
+	shapeBits(fillBits, lineBits uint8) int32
+	writeShapeBits(b BitWriter, fillBits, lineBits uint8) error
+}
+
+type StyleChangeRecord struct {
+	MoveTo                                     bool
+	MoveDeltaX, MoveDeltaY                     Twips
+	HasFillStyle0, HasFillStyle1, HasLineStyle bool
+	FillStyle0, FillStyle1, LineStyle          uint32
+}
+
+func (s *StyleChangeRecord) moveBits() uint8 {
+	x, y := BitInt(s.MoveDeltaX), BitInt(s.MoveDeltaY)
+	return uint8(max(x.Size(), y.Size()))
+}
+
+func (s *StyleChangeRecord) shapeBits(fillBits, lineBits uint8) int32 {
+	total := int32(6)
+	if s.MoveTo {
+		total += 5 + 2*int32(s.moveBits())
+	}
+	if s.HasFillStyle0 {
+		total += int32(fillBits)
+	}
+	if s.HasFillStyle1 {
+		total += int32(fillBits)
+	}
+	if s.HasLineStyle {
+		total += int32(lineBits)
+	}
+	return total
+}
+
+func (s *StyleChangeRecord) writeShapeBits(b BitWriter, fillBits, lineBits uint8) (err error) {
+	if err = b.WriteBits([]bool{false, false, s.HasLineStyle, s.HasFillStyle1, s.HasFillStyle0, s.MoveTo}); err != nil {
+		return
+	}
+	if s.MoveTo {
+		n := s.moveBits()
+		if n > 31 {
+			return ErrEdgeTooLong
+		}
+		bits := BitUint(n)
+		x, y := BitInt(s.MoveDeltaX), BitInt(s.MoveDeltaY)
+		if err = bits.WriteBitsTo(b, 5); err != nil {
+			return
+		}
+		if err = x.WriteBitsTo(b, n); err != nil {
+			return
+		}
+		if err = y.WriteBitsTo(b, n); err != nil {
+			return
+		}
+	}
+	if s.HasFillStyle0 {
+		style := BitUint(s.FillStyle0)
+		if err = style.WriteBitsTo(b, fillBits); err != nil {
+			return
+		}
+	}
+	if s.HasFillStyle1 {
+		style := BitUint(s.FillStyle1)
+		if err = style.WriteBitsTo(b, fillBits); err != nil {
+			return
+		}
+	}
+	if s.HasLineStyle {
+		style := BitUint(s.LineStyle)
+		err = style.WriteBitsTo(b, lineBits)
+	}
+	return
+}
+
+type StraightEdgeRecord struct {
+	DeltaX, DeltaY Twips
+}
+
+func (s *StraightEdgeRecord) numBits() uint8 {
+	x, y := BitInt(s.DeltaX), BitInt(s.DeltaY)
+	return uint8(max(2, x.Size(), y.Size()))
+}
+
+func (s *StraightEdgeRecord) shapeBits(fillBits, lineBits uint8) int32 {
+	total := int32(2 + 4 + 1)
+	if s.DeltaX != 0 && s.DeltaY != 0 {
+		return total + 2*int32(s.numBits())
+	}
+	return total + 1 + int32(s.numBits())
+}
+
+func (s *StraightEdgeRecord) writeShapeBits(b BitWriter, fillBits, lineBits uint8) (err error) {
+	n := s.numBits()
+	if n > 17 {
+		return ErrEdgeTooLong
+	}
+	general := s.DeltaX != 0 && s.DeltaY != 0
+	bits := BitUint(n - 2)
+	if err = b.WriteBits([]bool{true, true}); err != nil {
+		return
+	}
+	if err = bits.WriteBitsTo(b, 4); err != nil {
+		return
+	}
+	x, y := BitInt(s.DeltaX), BitInt(s.DeltaY)
+	if general {
+		if err = b.WriteBits([]bool{true}); err != nil {
+			return
+		}
+		if err = x.WriteBitsTo(b, n); err != nil {
+			return
+		}
+		return y.WriteBitsTo(b, n)
+	} else if s.DeltaX == 0 {
+		if err = b.WriteBits([]bool{false, true}); err != nil {
+			return
+		}
+		return y.WriteBitsTo(b, n)
+	}
+	if err = b.WriteBits([]bool{false, false}); err != nil {
+		return
+	}
+	return x.WriteBitsTo(b, n)
+}
+
+type CurvedEdgeRecord struct {
+	ControlDeltaX, ControlDeltaY, AnchorDeltaX, AnchorDeltaY Twips
+}
+
+func (c *CurvedEdgeRecord) numBits() uint8 {
+	cx, cy, ax, ay := BitInt(c.ControlDeltaX), BitInt(c.ControlDeltaY), BitInt(c.AnchorDeltaX), BitInt(c.AnchorDeltaY)
+	return uint8(max(2, cx.Size(), cy.Size(), ax.Size(), ay.Size()))
+}
+
+func (c *CurvedEdgeRecord) shapeBits(fillBits, lineBits uint8) int32 {
+	return 2 + 4 + 4*int32(c.numBits())
+}
+
+func (c *CurvedEdgeRecord) writeShapeBits(b BitWriter, fillBits, lineBits uint8) (err error) {
+	n := c.numBits()
+	if n > 17 {
+		return ErrEdgeTooLong
+	}
+	bits := BitUint(n - 2)
+	if err = b.WriteBits([]bool{true, false}); err != nil {
+		return
+	}
+	if err = bits.WriteBitsTo(b, 4); err != nil {
+		return
+	}
+	for _, d := range [...]Twips{c.ControlDeltaX, c.ControlDeltaY, c.AnchorDeltaX, c.AnchorDeltaY} {
+		v := BitInt(d)
+		if err = v.WriteBitsTo(b, n); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func readBit(b BitReader) (bool, error) {
+	var u BitUint
+	err := u.ReadBitsFrom(b, 1)
+	return u == 1, err
+}
+
+func readShapeRecords(b BitReader, fillBits, lineBits uint8) (records []ShapeRecord, err error) {
+	var (
+		u     BitUint
+		i     BitInt
+		flags [6]bool
+	)
+	for {
+		if err = b.ReadBits(flags[:1]); err != nil {
+			return
+		}
+		if !flags[0] {
+			if err = b.ReadBits(flags[1:]); err != nil {
+				return
+			}
+			if flags == [6]bool{} {
+				return
+			}
+			if flags[1] {
+				err = &ParserError{"SHAPE", "StateNewStyles", "1"}
+				return
+			}
+			s := &StyleChangeRecord{
+				HasLineStyle:  flags[2],
+				HasFillStyle1: flags[3],
+				HasFillStyle0: flags[4],
+				MoveTo:        flags[5],
+			}
+			if s.MoveTo {
+				if err = u.ReadBitsFrom(b, 5); err != nil {
+					return
+				}
+				n := uint8(u)
+				if err = i.ReadBitsFrom(b, n); err != nil {
+					return
+				}
+				s.MoveDeltaX = Twips(i)
+				if err = i.ReadBitsFrom(b, n); err != nil {
+					return
+				}
+				s.MoveDeltaY = Twips(i)
+			}
+			if s.HasFillStyle0 {
+				if err = u.ReadBitsFrom(b, fillBits); err != nil {
+					return
+				}
+				s.FillStyle0 = uint32(u)
+			}
+			if s.HasFillStyle1 {
+				if err = u.ReadBitsFrom(b, fillBits); err != nil {
+					return
+				}
+				s.FillStyle1 = uint32(u)
+			}
+			if s.HasLineStyle {
+				if err = u.ReadBitsFrom(b, lineBits); err != nil {
+					return
+				}
+				s.LineStyle = uint32(u)
+			}
+			records = append(records, s)
+			continue
+		}
+		var straight bool
+		if straight, err = readBit(b); err != nil {
+			return
+		}
+		if err = u.ReadBitsFrom(b, 4); err != nil {
+			return
+		}
+		n := uint8(u) + 2
+		if straight {
+			var general, vertical bool
+			if general, err = readBit(b); err != nil {
+				return
+			}
+			if !general {
+				if vertical, err = readBit(b); err != nil {
+					return
+				}
+			}
+			s := new(StraightEdgeRecord)
+			if general || !vertical {
+				if err = i.ReadBitsFrom(b, n); err != nil {
+					return
+				}
+				s.DeltaX = Twips(i)
+			}
+			if general || vertical {
+				if err = i.ReadBitsFrom(b, n); err != nil {
+					return
+				}
+				s.DeltaY = Twips(i)
+			}
+			records = append(records, s)
+		} else {
+			var d [4]Twips
+			for j := range d {
+				if err = i.ReadBitsFrom(b, n); err != nil {
+					return
+				}
+				d[j] = Twips(i)
+			}
+			records = append(records, &CurvedEdgeRecord{d[0], d[1], d[2], d[3]})
+		}
+	}
+}
+
+func writeShapeRecords(b BitWriter, records []ShapeRecord, fillBits, lineBits uint8) (err error) {
+	for _, r := range records {
+		if err = r.writeShapeBits(b, fillBits, lineBits); err != nil {
+			return
+		}
+	}
+	return b.WriteBits(make([]bool, 6))
+}
+
+func shapeRecordsBits(records []ShapeRecord, fillBits, lineBits uint8) int32 {
+	total := int32(6)
+	for _, r := range records {
+		total += r.shapeBits(fillBits, lineBits)
+	}
+	return total
+}
+
+// Shape is the SHAPE structure, as used for font glyphs.
+type Shape struct {
+	NumFillBits, NumLineBits uint8
+	ShapeRecords             []ShapeRecord
+}
+
+func (s *Shape) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	b := &bitReader{Reader: c}
+	var n BitUint
+	if err = n.ReadBitsFrom(b, 4); err != nil {
+		return
+	}
+	s.NumFillBits = uint8(n)
+	if err = n.ReadBitsFrom(b, 4); err != nil {
+		return
+	}
+	s.NumLineBits = uint8(n)
+	s.ShapeRecords, err = readShapeRecords(b, s.NumFillBits, s.NumLineBits)
+	return
+}
+
+func (s *Shape) WriteTo(f io.Writer) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	b := &bitWriter{Writer: c}
+	defer b.Align()
+	if s.NumFillBits > 15 || s.NumLineBits > 15 {
+		err = fmt.Errorf("shape: style bits out of range: %d, %d", s.NumFillBits, s.NumLineBits)
+		return
+	}
+	fill, line := BitUint(s.NumFillBits), BitUint(s.NumLineBits)
+	if err = fill.WriteBitsTo(b, 4); err != nil {
+		return
+	}
+	if err = line.WriteBitsTo(b, 4); err != nil {
+		return
+	}
+	err = writeShapeRecords(b, s.ShapeRecords, s.NumFillBits, s.NumLineBits)
+	return
+}
+
+func (s *Shape) Size() int32 {
+	total := 8 + shapeRecordsBits(s.ShapeRecords, s.NumFillBits, s.NumLineBits)
+	if total%8 == 0 {
+		return total / 8
+	}
+	return 1 + total/8
+}
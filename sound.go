@@ -0,0 +1,370 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"io/ioutil"
+)
+
+type SoundFormat uint8
+
+const (
+	SOUND_UNCOMPRESSED_NATIVE SoundFormat = 0
+	SOUND_ADPCM               SoundFormat = 1
+	SOUND_MP3                 SoundFormat = 2
+	SOUND_UNCOMPRESSED_LE     SoundFormat = 3
+	SOUND_NELLYMOSER_16       SoundFormat = 4
+	SOUND_NELLYMOSER_8        SoundFormat = 5
+	SOUND_NELLYMOSER          SoundFormat = 6
+	SOUND_SPEEX               SoundFormat = 11
+)
+
+func (s SoundFormat) valid() bool {
+	return s <= SOUND_NELLYMOSER || s == SOUND_SPEEX
+}
+
+func (s SoundFormat) String() string {
+	switch s {
+	case SOUND_UNCOMPRESSED_NATIVE:
+		return "Uncompressed, native-endian"
+	case SOUND_ADPCM:
+		return "ADPCM"
+	case SOUND_MP3:
+		return "MP3"
+	case SOUND_UNCOMPRESSED_LE:
+		return "Uncompressed, little-endian"
+	case SOUND_NELLYMOSER_16:
+		return "Nellymoser 16 kHz"
+	case SOUND_NELLYMOSER_8:
+		return "Nellymoser 8 kHz"
+	case SOUND_NELLYMOSER:
+		return "Nellymoser"
+	case SOUND_SPEEX:
+		return "Speex"
+	}
+	return "Unknown sound format"
+}
+
+type SoundRate uint8
+
+const (
+	SOUND_RATE_5_5 SoundRate = iota
+	SOUND_RATE_11
+	SOUND_RATE_22
+	SOUND_RATE_44
+)
+
+func (s SoundRate) Hz() uint32 {
+	switch s {
+	case SOUND_RATE_5_5:
+		return 5512
+	case SOUND_RATE_11:
+		return 11025
+	case SOUND_RATE_22:
+		return 22050
+	}
+	return 44100
+}
+
+func (s SoundRate) String() string {
+	switch s {
+	case SOUND_RATE_5_5:
+		return "5.5 kHz"
+	case SOUND_RATE_11:
+		return "11 kHz"
+	case SOUND_RATE_22:
+		return "22 kHz"
+	}
+	return "44 kHz"
+}
+
+func soundFlags(format SoundFormat, rate SoundRate, is16Bit, stereo bool) uint8 {
+	flags := uint8(format)<<4 | uint8(rate&3)<<2
+	if is16Bit {
+		flags |= 2
+	}
+	if stereo {
+		flags |= 1
+	}
+	return flags
+}
+
+type DefineSound struct {
+	SoundId          uint16
+	SoundFormat      SoundFormat
+	SoundRate        SoundRate
+	Sound16Bit       bool
+	SoundStereo      bool
+	SoundSampleCount uint32
+	SoundData        []byte
+}
+
+func (d *DefineSound) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags uint8
+	if err = binary.Read(c, binary.LittleEndian, &d.SoundId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	d.SoundFormat = SoundFormat(flags >> 4)
+	d.SoundRate = SoundRate(flags >> 2 & 3)
+	d.Sound16Bit = flags&2 != 0
+	d.SoundStereo = flags&1 != 0
+	if !d.SoundFormat.valid() {
+		err = &ParserError{d.Name(), "SoundFormat", fmt.Sprintf("%d", d.SoundFormat)}
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &d.SoundSampleCount); err != nil {
+		return
+	}
+	d.SoundData, err = ioutil.ReadAll(c)
+	return
+}
+
+func (d *DefineSound) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.SoundId); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, soundFlags(d.SoundFormat, d.SoundRate, d.Sound16Bit, d.SoundStereo)); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, d.SoundSampleCount); err != nil {
+		return
+	}
+	_, err = c.Write(d.SoundData)
+	return
+}
+
+func (d *DefineSound) Size(ver uint8, id uint16) int32 {
+	return 2 + 1 + 4 + int32(len(d.SoundData))
+}
+
+func (d *DefineSound) MinVersion() uint8 {
+	return 1
+}
+
+func (d *DefineSound) TagId() uint16 {
+	return TAG_DEFINE_SOUND
+}
+
+func (d *DefineSound) Name() string {
+	return "DefineSound"
+}
+
+type SoundEnvelope struct {
+	Pos44                 uint32
+	LeftLevel, RightLevel uint16
+}
+
+type SoundInfo struct {
+	SyncStop, SyncNoMultiple                       bool
+	HasInPoint, HasOutPoint, HasLoops, HasEnvelope bool
+	InPoint, OutPoint                              uint32
+	LoopCount                                      uint16
+	Envelope                                       []SoundEnvelope
+}
+
+func (s *SoundInfo) ReadFrom(f io.Reader) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags uint8
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	s.SyncStop = flags&32 != 0
+	s.SyncNoMultiple = flags&16 != 0
+	s.HasEnvelope = flags&8 != 0
+	s.HasLoops = flags&4 != 0
+	s.HasOutPoint = flags&2 != 0
+	s.HasInPoint = flags&1 != 0
+	if s.HasInPoint {
+		if err = binary.Read(c, binary.LittleEndian, &s.InPoint); err != nil {
+			return
+		}
+	}
+	if s.HasOutPoint {
+		if err = binary.Read(c, binary.LittleEndian, &s.OutPoint); err != nil {
+			return
+		}
+	}
+	if s.HasLoops {
+		if err = binary.Read(c, binary.LittleEndian, &s.LoopCount); err != nil {
+			return
+		}
+	}
+	s.Envelope = nil
+	if s.HasEnvelope {
+		var points uint8
+		if err = binary.Read(c, binary.LittleEndian, &points); err != nil {
+			return
+		}
+		s.Envelope = make([]SoundEnvelope, points)
+		err = binary.Read(c, binary.LittleEndian, s.Envelope)
+	}
+	return
+}
+
+func (s *SoundInfo) WriteTo(f io.Writer) (total int64, err error) {
+	if s.HasEnvelope && len(s.Envelope) > 255 {
+		err = errors.New("soundInfo: too many envelope points")
+		return
+	}
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	var flags uint8
+	for n, b := range [...]bool{s.HasInPoint, s.HasOutPoint, s.HasLoops, s.HasEnvelope, s.SyncNoMultiple, s.SyncStop} {
+		if b {
+			flags |= 1 << uint(n)
+		}
+	}
+	if err = binary.Write(c, binary.LittleEndian, flags); err != nil {
+		return
+	}
+	if s.HasInPoint {
+		if err = binary.Write(c, binary.LittleEndian, s.InPoint); err != nil {
+			return
+		}
+	}
+	if s.HasOutPoint {
+		if err = binary.Write(c, binary.LittleEndian, s.OutPoint); err != nil {
+			return
+		}
+	}
+	if s.HasLoops {
+		if err = binary.Write(c, binary.LittleEndian, s.LoopCount); err != nil {
+			return
+		}
+	}
+	if s.HasEnvelope {
+		if err = binary.Write(c, binary.LittleEndian, uint8(len(s.Envelope))); err != nil {
+			return
+		}
+		err = binary.Write(c, binary.LittleEndian, s.Envelope)
+	}
+	return
+}
+
+func (s *SoundInfo) Size() int32 {
+	total := int32(1)
+	if s.HasInPoint {
+		total += 4
+	}
+	if s.HasOutPoint {
+		total += 4
+	}
+	if s.HasLoops {
+		total += 2
+	}
+	if s.HasEnvelope {
+		total += 1 + 8*int32(len(s.Envelope))
+	}
+	return total
+}
+
+type StartSound struct {
+	SoundId   uint16
+	SoundInfo SoundInfo
+}
+
+func (s *StartSound) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &s.SoundId); err != nil {
+		return
+	}
+	_, err = s.SoundInfo.ReadFrom(c)
+	return
+}
+
+func (s *StartSound) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, s.SoundId); err != nil {
+		return
+	}
+	_, err = s.SoundInfo.WriteTo(c)
+	return
+}
+
+func (s *StartSound) Size(ver uint8, id uint16) int32 {
+	return 2 + s.SoundInfo.Size()
+}
+
+func (s *StartSound) MinVersion() uint8 {
+	return 1
+}
+
+func (s *StartSound) TagId() uint16 {
+	return TAG_START_SOUND
+}
+
+func (s *StartSound) Name() string {
+	return "StartSound"
+}
+
+type StartSound2 struct {
+	SoundClassName String
+	SoundInfo      SoundInfo
+}
+
+func (s *StartSound2) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	err = ReadAll(c, &s.SoundClassName, &s.SoundInfo)
+	return
+}
+
+func (s *StartSound2) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	err = WriteAll(c, &s.SoundClassName, &s.SoundInfo)
+	return
+}
+
+func (s *StartSound2) Size(ver uint8, id uint16) int32 {
+	return SizeAll(&s.SoundClassName, &s.SoundInfo)
+}
+
+func (s *StartSound2) MinVersion() uint8 {
+	return 9
+}
+
+func (s *StartSound2) TagId() uint16 {
+	return TAG_START_SOUND2
+}
+
+func (s *StartSound2) Name() string {
+	return "StartSound2"
+}
@@ -0,0 +1,42 @@
+package swf
+
+import "testing"
+
+func TestDefineSound(t *testing.T) {
+	testTag(t, 1, []byte{7, 0, 0x2e, 0, 1, 0, 0, 1, 2, 3}, &DefineSound{
+		SoundId:          7,
+		SoundFormat:      SOUND_MP3,
+		SoundRate:        SOUND_RATE_44,
+		Sound16Bit:       true,
+		SoundSampleCount: 256,
+		SoundData:        []byte{1, 2, 3},
+	})
+}
+
+func TestStartSound(t *testing.T) {
+	testTag(t, 1, []byte{3, 0, 0x3f, 10, 0, 0, 0, 20, 0, 0, 0, 5, 0, 2, 0, 0, 0, 0, 0, 128, 0, 128, 68, 172, 0, 0, 255, 127, 0, 0}, &StartSound{
+		SoundId: 3,
+		SoundInfo: SoundInfo{
+			SyncStop:       true,
+			SyncNoMultiple: true,
+			HasInPoint:     true,
+			HasOutPoint:    true,
+			HasLoops:       true,
+			HasEnvelope:    true,
+			InPoint:        10,
+			OutPoint:       20,
+			LoopCount:      5,
+			Envelope: []SoundEnvelope{
+				{0, 32768, 32768},
+				{44100, 32767, 0},
+			},
+		},
+	})
+}
+
+func TestStartSound2(t *testing.T) {
+	testTag(t, 9, []byte{83, 110, 100, 0, 0x20}, &StartSound2{
+		SoundClassName: "Snd",
+		SoundInfo:      SoundInfo{SyncStop: true},
+	})
+}
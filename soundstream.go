@@ -0,0 +1,278 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"io/ioutil"
+)
+
+type SoundStreamHead struct {
+	PlaybackSoundRate      SoundRate
+	PlaybackSound16Bit     bool
+	PlaybackSoundStereo    bool
+	StreamSoundCompression SoundFormat
+	StreamSoundRate        SoundRate
+	StreamSound16Bit       bool
+	StreamSoundStereo      bool
+	StreamSoundSampleCount uint16
+	LatencySeek            int16
+}
+
+func (s *SoundStreamHead) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var flags [2]uint8
+	if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+		return
+	}
+	s.PlaybackSoundRate = SoundRate(flags[0] >> 2 & 3)
+	s.PlaybackSound16Bit = flags[0]&2 != 0
+	s.PlaybackSoundStereo = flags[0]&1 != 0
+	s.StreamSoundCompression = SoundFormat(flags[1] >> 4)
+	s.StreamSoundRate = SoundRate(flags[1] >> 2 & 3)
+	s.StreamSound16Bit = flags[1]&2 != 0
+	s.StreamSoundStereo = flags[1]&1 != 0
+	if !s.StreamSoundCompression.valid() {
+		err = &ParserError{s.Name(), "StreamSoundCompression", fmt.Sprintf("%d", s.StreamSoundCompression)}
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &s.StreamSoundSampleCount); err != nil {
+		return
+	}
+	s.LatencySeek = 0
+	if s.StreamSoundCompression == SOUND_MP3 {
+		err = binary.Read(c, binary.LittleEndian, &s.LatencySeek)
+	}
+	return
+}
+
+func (s *SoundStreamHead) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	flags := [2]uint8{
+		soundFlags(0, s.PlaybackSoundRate, s.PlaybackSound16Bit, s.PlaybackSoundStereo),
+		soundFlags(s.StreamSoundCompression, s.StreamSoundRate, s.StreamSound16Bit, s.StreamSoundStereo),
+	}
+	if err = binary.Write(c, binary.LittleEndian, flags); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, s.StreamSoundSampleCount); err != nil {
+		return
+	}
+	if s.StreamSoundCompression == SOUND_MP3 {
+		err = binary.Write(c, binary.LittleEndian, s.LatencySeek)
+	}
+	return
+}
+
+func (s *SoundStreamHead) Size(ver uint8, id uint16) int32 {
+	if s.StreamSoundCompression == SOUND_MP3 {
+		return 6
+	}
+	return 4
+}
+
+func (s *SoundStreamHead) MinVersion() uint8 {
+	return 1
+}
+
+func (s *SoundStreamHead) TagId() uint16 {
+	return TAG_SOUND_STREAM_HEAD
+}
+
+func (s *SoundStreamHead) Name() string {
+	return "SoundStreamHead"
+}
+
+type SoundStreamHead2 struct {
+	SoundStreamHead
+}
+
+func (s *SoundStreamHead2) MinVersion() uint8 {
+	return 3
+}
+
+func (s *SoundStreamHead2) TagId() uint16 {
+	return TAG_SOUND_STREAM_HEAD2
+}
+
+func (s *SoundStreamHead2) Name() string {
+	return "SoundStreamHead2"
+}
+
+type SoundStreamBlock struct {
+	StreamSoundData []byte
+}
+
+func (s *SoundStreamBlock) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	s.StreamSoundData, err = ioutil.ReadAll(c)
+	return
+}
+
+func (s *SoundStreamBlock) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	_, err = c.Write(s.StreamSoundData)
+	return
+}
+
+func (s *SoundStreamBlock) Size(ver uint8, id uint16) int32 {
+	return int32(len(s.StreamSoundData))
+}
+
+func (s *SoundStreamBlock) MinVersion() uint8 {
+	return 1
+}
+
+func (s *SoundStreamBlock) TagId() uint16 {
+	return TAG_SOUND_STREAM_BLOCK
+}
+
+func (s *SoundStreamBlock) Name() string {
+	return "SoundStreamBlock"
+}
+
+// SoundStreamFrame records where the block for a given timeline frame sits
+// within the reassembled stream.
+type SoundStreamFrame struct {
+	Frame       int
+	Offset      int
+	Length      int
+	Sample      uint32
+	SampleCount uint32
+	SeekSamples int16
+}
+
+type SoundStream struct {
+	Head   SoundStreamHead
+	Data   []byte
+	Frames []SoundStreamFrame
+}
+
+var ErrNoSoundStream = errors.New("soundStream: no SoundStreamHead found")
+
+// NewSoundStream reassembles the SoundStreamBlock tags of a timeline (the
+// root Tags of a SWF, or the Tags of a sprite) into a single stream, with
+// MP3 block headers removed.
+func NewSoundStream(tags []Tag) (*SoundStream, error) {
+	var (
+		s     *SoundStream
+		frame int
+	)
+	for _, tag := range tags {
+		switch t := tag.(type) {
+		case *ShowFrame:
+			frame++
+		case *SoundStreamHead:
+			if s == nil {
+				s = &SoundStream{Head: *t}
+			}
+		case *SoundStreamHead2:
+			if s == nil {
+				s = &SoundStream{Head: t.SoundStreamHead}
+			}
+		case *SoundStreamBlock:
+			if s == nil {
+				return nil, ErrNoSoundStream
+			}
+			if err := s.addBlock(frame, t.StreamSoundData); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if s == nil {
+		return nil, ErrNoSoundStream
+	}
+	return s, nil
+}
+
+func (s *SoundStream) addBlock(frame int, data []byte) error {
+	sf := SoundStreamFrame{
+		Frame:  frame,
+		Offset: len(s.Data),
+	}
+	if l := len(s.Frames); l > 0 {
+		sf.Sample = s.Frames[l-1].Sample + s.Frames[l-1].SampleCount
+	}
+	channels := uint32(1)
+	if s.Head.StreamSoundStereo {
+		channels = 2
+	}
+	switch s.Head.StreamSoundCompression {
+	case SOUND_MP3:
+		if len(data) < 4 {
+			return &ParserError{"SoundStreamBlock", "MP3SoundData", fmt.Sprintf("%d bytes", len(data))}
+		}
+		sf.SampleCount = uint32(binary.LittleEndian.Uint16(data))
+		sf.SeekSamples = int16(binary.LittleEndian.Uint16(data[2:]))
+		data = data[4:]
+	case SOUND_UNCOMPRESSED_NATIVE, SOUND_UNCOMPRESSED_LE:
+		bytesPerSample := uint32(1)
+		if s.Head.StreamSound16Bit {
+			bytesPerSample = 2
+		}
+		sf.SampleCount = uint32(len(data)) / (bytesPerSample * channels)
+	default:
+		sf.SampleCount = uint32(s.Head.StreamSoundSampleCount)
+	}
+	sf.Length = len(data)
+	s.Data = append(s.Data, data...)
+	s.Frames = append(s.Frames, sf)
+	return nil
+}
+
+// FrameSample returns the sample at which playback should be positioned when
+// the given frame is displayed, taking into account MP3 seek samples.
+func (s *SoundStream) FrameSample(frame int) (uint32, bool) {
+	for _, f := range s.Frames {
+		if f.Frame == frame {
+			return uint32(int64(f.Sample) + int64(f.SeekSamples)), true
+		} else if f.Frame > frame {
+			break
+		}
+	}
+	return 0, false
+}
+
+// ExpectedSample returns the sample that should be playing at the start of
+// the given frame for the given frame rate (in the 8.8 fixed point format of
+// SWF.FrameRate).
+func (s *SoundStream) ExpectedSample(frame int, frameRate uint16) uint32 {
+	if frameRate == 0 {
+		return 0
+	}
+	return uint32(uint64(frame) * uint64(s.Head.StreamSoundRate.Hz()) * 256 / uint64(frameRate))
+}
+
+func (s *SWF) SoundStream() (*SoundStream, error) {
+	return NewSoundStream(s.Tags)
+}
@@ -0,0 +1,57 @@
+package swf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSoundStreamHead2(t *testing.T) {
+	testTag(t, 3, []byte{0x0e, 0x2e, 0x40, 0x04, 0xfe, 0xff}, &SoundStreamHead2{SoundStreamHead{
+		PlaybackSoundRate:      SOUND_RATE_44,
+		PlaybackSound16Bit:     true,
+		StreamSoundCompression: SOUND_MP3,
+		StreamSoundRate:        SOUND_RATE_44,
+		StreamSound16Bit:       true,
+		StreamSoundSampleCount: 1088,
+		LatencySeek:            -2,
+	}})
+}
+
+func TestSoundStream(t *testing.T) {
+	head := &SoundStreamHead{
+		StreamSoundCompression: SOUND_MP3,
+		StreamSoundRate:        SOUND_RATE_22,
+		StreamSound16Bit:       true,
+		StreamSoundSampleCount: 576,
+	}
+	tags := []Tag{
+		head,
+		&SoundStreamBlock{[]byte{0x40, 0x02, 0x00, 0x00, 1, 2, 3}},
+		new(ShowFrame),
+		new(ShowFrame),
+		&SoundStreamBlock{[]byte{0x40, 0x02, 0x10, 0x00, 4, 5}},
+		new(ShowFrame),
+	}
+	s, err := NewSoundStream(tags)
+	if err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+	if !bytes.Equal(s.Data, []byte{1, 2, 3, 4, 5}) {
+		t.Errorf("expecting stream data %v, got %v", []byte{1, 2, 3, 4, 5}, s.Data)
+	}
+	if len(s.Frames) != 2 {
+		t.Fatalf("expecting 2 frames, got %d", len(s.Frames))
+	}
+	if f := s.Frames[1]; f.Frame != 2 || f.Offset != 3 || f.Length != 2 || f.Sample != 576 || f.SeekSamples != 16 {
+		t.Errorf("unexpected frame data: %+v", f)
+	}
+	if n, ok := s.FrameSample(2); !ok || n != 592 {
+		t.Errorf("expecting frame 2 to start at sample 592, got %d", n)
+	}
+	if _, ok := s.FrameSample(1); ok {
+		t.Errorf("not expecting a block for frame 1")
+	}
+	if _, err = NewSoundStream(tags[1:]); err != ErrNoSoundStream {
+		t.Errorf("expecting ErrNoSoundStream, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+)
+
+type DefineSprite struct {
+	SpriteId    uint16
+	FrameCount  uint16
+	ControlTags []Tag
+}
+
+func (d *DefineSprite) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.SpriteId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &d.FrameCount); err != nil {
+		return
+	}
+	d.ControlTags, err = readTags(c, ver, IsControlTag)
+	return
+}
+
+func (d *DefineSprite) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, [2]uint16{d.SpriteId, d.FrameCount}); err != nil {
+		return
+	}
+	err = writeTags(c, d.ControlTags, ver, IsControlTag)
+	return
+}
+
+func (d *DefineSprite) Size(ver uint8, id uint16) int32 {
+	return 2 + 2 + tagsSize(d.ControlTags, ver)
+}
+
+// MinVersion returns the highest minimum version of the sprite and the tags
+// it contains.
+func (d *DefineSprite) MinVersion() uint8 {
+	v := uint8(3)
+	for _, tag := range d.ControlTags {
+		if tv := tag.MinVersion(); tv > v {
+			v = tv
+		}
+	}
+	return v
+}
+
+func (d *DefineSprite) TagId() uint16 {
+	return TAG_DEFINE_SPRITE
+}
+
+func (d *DefineSprite) Name() string {
+	return "DefineSprite"
+}
+
+// allTags returns the given tags with the control tags of each DefineSprite
+// following the sprite itself.
+func allTags(tags []Tag) []Tag {
+	all := make([]Tag, 0, len(tags))
+	for _, tag := range tags {
+		all = append(all, tag)
+		if d, ok := tag.(*DefineSprite); ok {
+			all = append(all, allTags(d.ControlTags)...)
+		}
+	}
+	return all
+}
+
+// IsControlTag returns whether the tag with the given id may appear in a
+// DefineSprite.
+func IsControlTag(id uint16) bool {
+	switch id {
+	case TAG_SHOW_FRAME, TAG_PLACE_OBJECT, TAG_REMOVE_OBJECT, TAG_DO_ACTION, TAG_START_SOUND, TAG_FRAME_LABEL, TAG_SOUND_STREAM_HEAD, TAG_SOUND_STREAM_BLOCK, TAG_PLACE_OBJECT2, TAG_REMOVE_OBJECT2, TAG_SOUND_STREAM_HEAD2, TAG_VIDEO_FRAME, TAG_PLACE_OBJECT3, TAG_START_SOUND2:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testSprite() *DefineSprite {
+	return &DefineSprite{
+		SpriteId:   2,
+		FrameCount: 1,
+		ControlTags: []Tag{
+			&PlaceObject2{HasMatrix: true, HasCharacter: true, Depth: 1, CharacterId: 3, Matrix: Matrix{ScaleX: 1, ScaleY: 1}},
+			&ShowFrame{},
+		},
+	}
+}
+
+func TestDefineSprite(t *testing.T) {
+	testTag(t, 3, []byte{2, 0, 1, 0, 0x86, 6, 6, 1, 0, 3, 0, 0, 0x40, 0, 0, 0}, testSprite())
+}
+
+func TestDefineSpriteControlTags(t *testing.T) {
+	var d DefineSprite
+	_, err := d.ReadFrom(bytes.NewReader([]byte{2, 0, 1, 0, 0x04, 0x16, 1, 0, 0, 0, 0, 0}), 9, TAG_DEFINE_SPRITE)
+	if e, ok := err.(*ErrNotAllowed); !ok || e.Tag != "DefineFontName" {
+		t.Errorf("expecting ErrNotAllowed for DefineFontName, got %v", err)
+	}
+	d.ControlTags = []Tag{&DefineFontName{}}
+	if _, err = d.WriteTo(new(bytes.Buffer), 9, TAG_DEFINE_SPRITE); err == nil {
+		t.Error("expecting error writing non-control tag")
+	}
+	_, err = d.ReadFrom(bytes.NewReader([]byte{2, 0, 1, 0, 0x84, 0x11, 0, 0, 1, 0, 0, 0}), 7, TAG_DEFINE_SPRITE)
+	if _, ok := err.(*ErrMinVersion); !ok {
+		t.Errorf("expecting ErrMinVersion for PlaceObject3 in version 7, got %v", err)
+	}
+	if _, err = d.ReadFrom(bytes.NewReader([]byte{2, 0, 1, 0, 0x84, 0x11, 0, 0, 1, 0, 0, 0}), 8, TAG_DEFINE_SPRITE); err != nil {
+		t.Errorf("unexpected error: %q", err)
+	}
+}
+
+func TestDefineSpriteSWF(t *testing.T) {
+	s := &SWF{
+		FrameSize:  Rect{0, 11000, 0, 8000},
+		FrameRate:  12 << 8,
+		FrameCount: 1,
+		Tags: []Tag{
+			testSprite(),
+			&PlaceObject2{HasCharacter: true, Depth: 1, CharacterId: 2},
+			&ShowFrame{},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if _, err := s.WriteTo(buf); err != nil {
+		t.Fatalf("unexpected error writing: %q", err)
+	}
+	if s.Version != 3 {
+		t.Errorf("expecting version 3, got %d", s.Version)
+	}
+	var r SWF
+	if _, err := r.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("unexpected error reading: %q", err)
+	}
+	if !reflect.DeepEqual(s.Tags, r.Tags) {
+		t.Errorf("expecting %v, got %v", s.Tags, r.Tags)
+	}
+}
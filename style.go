@@ -0,0 +1,108 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+const (
+	FILL_SOLID                         uint8 = 0x00
+	FILL_LINEAR_GRADIENT               uint8 = 0x10
+	FILL_RADIAL_GRADIENT               uint8 = 0x12
+	FILL_FOCAL_RADIAL_GRADIENT         uint8 = 0x13
+	FILL_REPEATING_BITMAP              uint8 = 0x40
+	FILL_CLIPPED_BITMAP                uint8 = 0x41
+	FILL_NON_SMOOTHED_REPEATING_BITMAP uint8 = 0x42
+	FILL_NON_SMOOTHED_CLIPPED_BITMAP   uint8 = 0x43
+)
+
+const (
+	SPREAD_PAD uint8 = iota
+	SPREAD_REFLECT
+	SPREAD_REPEAT
+)
+
+const (
+	INTERPOLATION_NORMAL_RGB uint8 = iota
+	INTERPOLATION_LINEAR_RGB
+)
+
+const (
+	CAP_ROUND uint8 = iota
+	CAP_NONE
+	CAP_SQUARE
+)
+
+const (
+	JOIN_ROUND uint8 = iota
+	JOIN_BEVEL
+	JOIN_MITER
+)
+
+func isGradientFill(fillStyleType uint8) bool {
+	return fillStyleType == FILL_LINEAR_GRADIENT || fillStyleType == FILL_RADIAL_GRADIENT || fillStyleType == FILL_FOCAL_RADIAL_GRADIENT
+}
+
+func isBitmapFill(fillStyleType uint8) bool {
+	return fillStyleType >= FILL_REPEATING_BITMAP && fillStyleType <= FILL_NON_SMOOTHED_CLIPPED_BITMAP
+}
+
+type Gradient struct {
+	SpreadMode        uint8
+	InterpolationMode uint8
+	GradientRecords   []GradientRecord
+	FocalPoint        Fixed8
+}
+
+type FillStyle struct {
+	FillStyleType  uint8
+	Color          RGBA
+	GradientMatrix Matrix
+	Gradient       Gradient
+	BitmapId       uint16
+	BitmapMatrix   Matrix
+}
+
+// LineStyle holds both LINESTYLE and LINESTYLE2; the cap, join and fill
+// fields are only used by the latter.
+type LineStyle struct {
+	Width            uint16
+	Color            RGBA
+	StartCapStyle    uint8
+	JoinStyle        uint8
+	EndCapStyle      uint8
+	HasFill          bool
+	NoHScale         bool
+	NoVScale         bool
+	PixelHinting     bool
+	NoClose          bool
+	MiterLimitFactor Fixed8
+	FillType         FillStyle
+}
+
+// ShapeWithStyle is a shape along with the fill and line styles that its
+// records refer to, as produced by DefineMorphShape.Interpolate.
+type ShapeWithStyle struct {
+	FillStyles   []FillStyle
+	LineStyles   []LineStyle
+	ShapeRecords []ShapeRecord
+}
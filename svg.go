@@ -0,0 +1,260 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type svgPoint struct {
+	X, Y Twips
+}
+
+type svgEdge struct {
+	From, Control, To svgPoint
+	Curved            bool
+}
+
+func (e svgEdge) reverse() svgEdge {
+	return svgEdge{From: e.To, Control: e.Control, To: e.From, Curved: e.Curved}
+}
+
+func svgNum(t Twips) string {
+	return strconv.FormatFloat(float64(t)/20, 'f', -1, 64)
+}
+
+func (e svgEdge) segment() string {
+	if e.Curved {
+		return "Q" + svgNum(e.Control.X) + " " + svgNum(e.Control.Y) + " " + svgNum(e.To.X) + " " + svgNum(e.To.Y)
+	}
+	return "L" + svgNum(e.To.X) + " " + svgNum(e.To.Y)
+}
+
+// svgEdges splits the shape records into edges by the fill and line styles
+// they use. Fill 0 edges are reversed so that each fill is bounded by edges
+// running in the same direction.
+func svgEdges(s *ShapeWithStyle) (fills, lines [][]svgEdge) {
+	fills = make([][]svgEdge, len(s.FillStyles)+1)
+	lines = make([][]svgEdge, len(s.LineStyles)+1)
+	var (
+		pen                     svgPoint
+		fill0, fill1, lineStyle uint32
+	)
+	for _, record := range s.ShapeRecords {
+		var e svgEdge
+		switch r := record.(type) {
+		case *StyleChangeRecord:
+			if r.MoveTo {
+				pen = svgPoint{r.MoveDeltaX, r.MoveDeltaY}
+			}
+			if r.HasFillStyle0 {
+				fill0 = r.FillStyle0
+			}
+			if r.HasFillStyle1 {
+				fill1 = r.FillStyle1
+			}
+			if r.HasLineStyle {
+				lineStyle = r.LineStyle
+			}
+			continue
+		case *StraightEdgeRecord:
+			e = svgEdge{From: pen, To: svgPoint{pen.X + r.DeltaX, pen.Y + r.DeltaY}}
+		case *CurvedEdgeRecord:
+			control := svgPoint{pen.X + r.ControlDeltaX, pen.Y + r.ControlDeltaY}
+			e = svgEdge{From: pen, Control: control, To: svgPoint{control.X + r.AnchorDeltaX, control.Y + r.AnchorDeltaY}, Curved: true}
+		default:
+			continue
+		}
+		pen = e.To
+		if fill0 > 0 && int(fill0) < len(fills) {
+			fills[fill0] = append(fills[fill0], e.reverse())
+		}
+		if fill1 > 0 && int(fill1) < len(fills) {
+			fills[fill1] = append(fills[fill1], e)
+		}
+		if lineStyle > 0 && int(lineStyle) < len(lines) {
+			lines[lineStyle] = append(lines[lineStyle], e)
+		}
+	}
+	return
+}
+
+// svgFillPath joins the edges of a fill into closed paths.
+func svgFillPath(edges []svgEdge) string {
+	starts := make(map[svgPoint][]int)
+	for n, e := range edges {
+		starts[e.From] = append(starts[e.From], n)
+	}
+	used := make([]bool, len(edges))
+	next := func(p svgPoint) int {
+		for _, n := range starts[p] {
+			if !used[n] {
+				return n
+			}
+		}
+		return -1
+	}
+	var path []string
+	for n := range edges {
+		if used[n] {
+			continue
+		}
+		first := edges[n].From
+		path = append(path, "M"+svgNum(first.X)+" "+svgNum(first.Y))
+		for m := n; m >= 0; m = next(edges[m].To) {
+			used[m] = true
+			path = append(path, edges[m].segment())
+			if edges[m].To == first {
+				path = append(path, "Z")
+				break
+			}
+		}
+	}
+	return strings.Join(path, " ")
+}
+
+// svgLinePath keeps the edges of a line in order, only moving where they are
+// not connected.
+func svgLinePath(edges []svgEdge) string {
+	var (
+		path []string
+		last svgPoint
+	)
+	for n, e := range edges {
+		if n == 0 || e.From != last {
+			path = append(path, "M"+svgNum(e.From.X)+" "+svgNum(e.From.Y))
+		}
+		path = append(path, e.segment())
+		last = e.To
+	}
+	return strings.Join(path, " ")
+}
+
+func svgColor(c RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.Red, c.Green, c.Blue)
+}
+
+func svgOpacity(c RGBA) string {
+	return strconv.FormatFloat(float64(c.Alpha)/255, 'f', -1, 64)
+}
+
+// svgPaint writes any definition needed by the fill style and returns the
+// paint and opacity to use for it. Bitmap fills are not drawn.
+func svgPaint(w io.Writer, fs *FillStyle, id string) (paint, opacity string) {
+	switch {
+	case fs.FillStyleType == FILL_SOLID:
+		return svgColor(fs.Color), svgOpacity(fs.Color)
+	case isGradientFill(fs.FillStyleType):
+		m := fs.GradientMatrix
+		transform := fmt.Sprintf("matrix(%g %g %g %g %s %s)", m.ScaleX, m.RotateSkew0, m.RotateSkew1, m.ScaleY, svgNum(m.TranslateX), svgNum(m.TranslateY))
+		spread := [...]string{"pad", "reflect", "repeat", "pad"}[fs.Gradient.SpreadMode&3]
+		if fs.FillStyleType == FILL_LINEAR_GRADIENT {
+			fmt.Fprintf(w, "<linearGradient id=%q gradientUnits=\"userSpaceOnUse\" x1=\"-819.2\" x2=\"819.2\" y1=\"0\" y2=\"0\" spreadMethod=%q gradientTransform=%q>\n", id, spread, transform)
+		} else {
+			fx := strconv.FormatFloat(float64(fs.Gradient.FocalPoint)*819.2, 'f', -1, 64)
+			fmt.Fprintf(w, "<radialGradient id=%q gradientUnits=\"userSpaceOnUse\" cx=\"0\" cy=\"0\" r=\"819.2\" fx=%q fy=\"0\" spreadMethod=%q gradientTransform=%q>\n", id, fx, spread, transform)
+		}
+		for _, g := range fs.Gradient.GradientRecords {
+			fmt.Fprintf(w, "<stop offset=\"%s\" stop-color=\"%s\" stop-opacity=\"%s\"/>\n", strconv.FormatFloat(float64(g.Ratio)/255, 'f', -1, 64), svgColor(g.Color), svgOpacity(g.Color))
+		}
+		if fs.FillStyleType == FILL_LINEAR_GRADIENT {
+			fmt.Fprintln(w, "</linearGradient>")
+		} else {
+			fmt.Fprintln(w, "</radialGradient>")
+		}
+		return "url(#" + id + ")", "1"
+	}
+	return "none", "1"
+}
+
+// WriteSVG writes the shape as an SVG image covering the given bounds.
+func (s *ShapeWithStyle) WriteSVG(w io.Writer, bounds Rect) error {
+	return writeSVG(w, []*ShapeWithStyle{s}, bounds)
+}
+
+// writeSVG writes the shapes, in order, as an SVG image covering the given
+// bounds.
+func writeSVG(w io.Writer, shapes []*ShapeWithStyle, bounds Rect) error {
+	b := bufio.NewWriter(w)
+	width, height := bounds.Xmax-bounds.Xmin, bounds.Ymax-bounds.Ymin
+	fmt.Fprintf(b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"%s %s %s %s\">\n", svgNum(width), svgNum(height), svgNum(bounds.Xmin), svgNum(bounds.Ymin), svgNum(width), svgNum(height))
+	for n, s := range shapes {
+		var prefix string
+		if n > 0 {
+			prefix = "shape" + strconv.Itoa(n+1) + "-"
+		}
+		s.writeSVGPaths(b, prefix)
+	}
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
+
+// writeSVGPaths writes the paths of the shape, prefixing the ids of any
+// gradient or pattern definitions.
+func (s *ShapeWithStyle) writeSVGPaths(b *bufio.Writer, prefix string) {
+	fills, lines := svgEdges(s)
+	for n, edges := range fills {
+		if len(edges) == 0 {
+			continue
+		}
+		paint, opacity := svgPaint(b, &s.FillStyles[n-1], prefix+"fill"+strconv.Itoa(n))
+		fmt.Fprintf(b, "<path d=%q fill=%q fill-opacity=%q fill-rule=\"evenodd\" stroke=\"none\"/>\n", svgFillPath(edges), paint, opacity)
+	}
+	for n, edges := range lines {
+		if len(edges) == 0 {
+			continue
+		}
+		ls := &s.LineStyles[n-1]
+		paint, opacity := svgColor(ls.Color), svgOpacity(ls.Color)
+		if ls.HasFill {
+			paint, opacity = svgPaint(b, &ls.FillType, prefix+"line"+strconv.Itoa(n))
+		}
+		width := "1"
+		if ls.Width > 0 {
+			width = svgNum(Twips(ls.Width))
+		}
+		lineCap := [...]string{"round", "butt", "square", "round"}[ls.StartCapStyle&3]
+		join := [...]string{"round", "bevel", "miter", "round"}[ls.JoinStyle&3]
+		fmt.Fprintf(b, "<path d=%q fill=\"none\" stroke=%q stroke-opacity=%q stroke-width=%q stroke-linecap=%q stroke-linejoin=%q", svgLinePath(edges), paint, opacity, width, lineCap, join)
+		if ls.JoinStyle == JOIN_MITER {
+			fmt.Fprintf(b, " stroke-miterlimit=\"%g\"", ls.MiterLimitFactor)
+		}
+		fmt.Fprintln(b, "/>")
+	}
+}
+
+// WriteSVG writes the morph shape, at the given PlaceObject2 ratio, as an SVG
+// image.
+func (d *DefineMorphShape) WriteSVG(w io.Writer, ratio uint16) error {
+	s, err := d.Interpolate(ratio)
+	if err != nil {
+		return err
+	}
+	return s.WriteSVG(w, d.Bounds(ratio))
+}
@@ -0,0 +1,60 @@
+package swf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestShapeSVG(t *testing.T) {
+	s := &ShapeWithStyle{
+		FillStyles: []FillStyle{
+			{FillStyleType: FILL_SOLID, Color: RGBA{RGB{255, 0, 0}, 255}},
+			{
+				FillStyleType:  FILL_RADIAL_GRADIENT,
+				GradientMatrix: Matrix{ScaleX: 0.5, ScaleY: 0.5, TranslateX: 200},
+				Gradient: Gradient{GradientRecords: []GradientRecord{
+					{RGBA{RGB{255, 255, 255}, 255}, 0},
+					{RGBA{RGB{0, 0, 0}, 0}, 255},
+				}},
+			},
+		},
+		LineStyles: []LineStyle{{Width: 40, Color: RGBA{RGB{0, 0, 255}, 255}}},
+		ShapeRecords: []ShapeRecord{
+			&StyleChangeRecord{MoveTo: true, HasFillStyle0: true, FillStyle0: 1, HasLineStyle: true, LineStyle: 1},
+			&StraightEdgeRecord{0, 200},
+			&StraightEdgeRecord{200, 0},
+			&StyleChangeRecord{HasFillStyle1: true, FillStyle1: 2},
+			&CurvedEdgeRecord{100, -100, -100, -100},
+			&StyleChangeRecord{HasFillStyle1: true, FillStyle1: 0, HasLineStyle: true, LineStyle: 0},
+			&StraightEdgeRecord{-200, 0},
+			&StyleChangeRecord{MoveTo: true, MoveDeltaX: 200, HasFillStyle0: true, FillStyle0: 0, HasFillStyle1: true, FillStyle1: 2},
+			&StraightEdgeRecord{200, 200},
+			&StraightEdgeRecord{-200, 0},
+		},
+	}
+	expected := `<svg xmlns="http://www.w3.org/2000/svg" width="20" height="10" viewBox="0 0 20 10">
+<path d="M0 10 L0 0 L10 0 Q15 5 10 10 L0 10 Z" fill="#ff0000" fill-opacity="1" fill-rule="evenodd" stroke="none"/>
+<radialGradient id="fill2" gradientUnits="userSpaceOnUse" cx="0" cy="0" r="819.2" fx="0" fy="0" spreadMethod="pad" gradientTransform="matrix(0.5 0 0 0.5 10 0)">
+<stop offset="0" stop-color="#ffffff" stop-opacity="1"/>
+<stop offset="1" stop-color="#000000" stop-opacity="0"/>
+</radialGradient>
+<path d="M10 10 Q15 5 10 0 L20 10 L10 10 Z" fill="url(#fill2)" fill-opacity="1" fill-rule="evenodd" stroke="none"/>
+<path d="M0 0 L0 10 L10 10 Q15 5 10 0" fill="none" stroke="#0000ff" stroke-opacity="1" stroke-width="2" stroke-linecap="round" stroke-linejoin="round"/>
+</svg>
+`
+	var buf bytes.Buffer
+	if err := s.WriteSVG(&buf, Rect{0, 400, 0, 200}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if buf.String() != expected {
+		t.Errorf("expecting:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestMorphShapeSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testMorphShape().WriteSVG(&buf, 65535); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if !bytes.Contains(buf.Bytes(), []byte(`<path d="M1 1 Q6 0 11 1 L11 11 L1 11 L1 1 Z" fill="#0000ff" fill-opacity="0.5019607843137255"`)) {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
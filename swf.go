@@ -64,6 +64,14 @@ func (e ErrMinVersion) Error() string {
 	return fmt.Sprintf("tag %q requires a SWF file of at least version %d.", e.Tag, e.Ver)
 }
 
+type ErrNotAllowed struct {
+	Tag string
+}
+
+func (e ErrNotAllowed) Error() string {
+	return fmt.Sprintf("tag %q is not allowed here", e.Tag)
+}
+
 type InvalidTagCode struct {
 	TagCode uint16
 }
@@ -80,6 +88,10 @@ func (p ParserError) Error() string {
 	return fmt.Sprintf("unable to parse tag %q, field %q - found %q", p.Tag, p.Field, p.Found)
 }
 
+// ErrLZMAWrite is returned when writing a SWF file with LZMA compression,
+// which is only supported for reading.
+var ErrLZMAWrite = errors.New("swf: writing LZMA compressed files is not supported")
+
 type BadHeader struct {
 	Code uint8
 	Err  error
@@ -113,6 +125,53 @@ func (c compression) String() string {
 
 const MAX_VER uint8 = 11
 
+const (
+	TAG_END                     uint16 = 0
+	TAG_SHOW_FRAME              uint16 = 1
+	TAG_PLACE_OBJECT            uint16 = 4
+	TAG_REMOVE_OBJECT           uint16 = 5
+	TAG_DEFINE_BUTTON           uint16 = 7
+	TAG_DEFINE_FONT             uint16 = 10
+	TAG_DEFINE_TEXT             uint16 = 11
+	TAG_DO_ACTION               uint16 = 12
+	TAG_DEFINE_FONT_INFO        uint16 = 13
+	TAG_DEFINE_SOUND            uint16 = 14
+	TAG_START_SOUND             uint16 = 15
+	TAG_DEFINE_BUTTON_SOUND     uint16 = 17
+	TAG_SOUND_STREAM_HEAD       uint16 = 18
+	TAG_SOUND_STREAM_BLOCK      uint16 = 19
+	TAG_DEFINE_BUTTON_CXFORM    uint16 = 23
+	TAG_PLACE_OBJECT2           uint16 = 26
+	TAG_REMOVE_OBJECT2          uint16 = 28
+	TAG_DEFINE_TEXT2            uint16 = 33
+	TAG_DEFINE_BUTTON2          uint16 = 34
+	TAG_DEFINE_EDIT_TEXT        uint16 = 37
+	TAG_DEFINE_SPRITE           uint16 = 39
+	TAG_FRAME_LABEL             uint16 = 43
+	TAG_SOUND_STREAM_HEAD2      uint16 = 45
+	TAG_DEFINE_MORPH_SHAPE      uint16 = 46
+	TAG_DEFINE_FONT2            uint16 = 48
+	TAG_EXPORT_ASSETS           uint16 = 56
+	TAG_DO_INIT_ACTION          uint16 = 59
+	TAG_DEFINE_VIDEO_STREAM     uint16 = 60
+	TAG_VIDEO_FRAME             uint16 = 61
+	TAG_DEFINE_FONT_INFO2       uint16 = 62
+	TAG_FILE_ATTRIBUTES         uint16 = 69
+	TAG_PLACE_OBJECT3           uint16 = 70
+	TAG_DO_ABC_DEFINE           uint16 = 72
+	TAG_DEFINE_FONT_ALIGN_ZONES uint16 = 73
+	TAG_CSM_TEXT_SETTINGS       uint16 = 74
+	TAG_DEFINE_FONT3            uint16 = 75
+	TAG_SYMBOL_CLASS            uint16 = 76
+	TAG_METADATA                uint16 = 77
+	TAG_DEFINE_SCALING_GRID     uint16 = 78
+	TAG_DO_ABC                  uint16 = 82
+	TAG_DEFINE_MORPH_SHAPE2     uint16 = 84
+	TAG_DEFINE_FONT_NAME        uint16 = 88
+	TAG_START_SOUND2            uint16 = 89
+	TAG_DEFINE_FONT4            uint16 = 91
+)
+
 const (
 	COMPRESS_NONE compression = iota
 	COMPRESS_ZLIB
@@ -124,7 +183,101 @@ func TagFromId(id uint16) Tag {
 }
 
 func TagFromIdVer(id uint16, ver uint8) Tag {
-	return nil
+	var tag Tag
+	switch id {
+	case TAG_SHOW_FRAME:
+		tag = new(ShowFrame)
+	case TAG_PLACE_OBJECT:
+		tag = new(PlaceObject)
+	case TAG_REMOVE_OBJECT:
+		tag = new(RemoveObject)
+	case TAG_DEFINE_BUTTON:
+		tag = new(DefineButton)
+	case TAG_DEFINE_FONT:
+		tag = new(DefineFont)
+	case TAG_DEFINE_TEXT:
+		tag = new(DefineText)
+	case TAG_DO_ACTION:
+		tag = new(DoAction)
+	case TAG_DEFINE_FONT_INFO:
+		tag = new(DefineFontInfo)
+	case TAG_DEFINE_SOUND:
+		tag = new(DefineSound)
+	case TAG_START_SOUND:
+		tag = new(StartSound)
+	case TAG_DEFINE_BUTTON_SOUND:
+		tag = new(DefineButtonSound)
+	case TAG_SOUND_STREAM_HEAD:
+		tag = new(SoundStreamHead)
+	case TAG_SOUND_STREAM_BLOCK:
+		tag = new(SoundStreamBlock)
+	case TAG_DEFINE_BUTTON_CXFORM:
+		tag = new(DefineButtonCxform)
+	case TAG_PLACE_OBJECT2:
+		tag = new(PlaceObject2)
+	case TAG_REMOVE_OBJECT2:
+		tag = new(RemoveObject2)
+	case TAG_DEFINE_TEXT2:
+		tag = new(DefineText2)
+	case TAG_DEFINE_BUTTON2:
+		tag = new(DefineButton2)
+	case TAG_DEFINE_EDIT_TEXT:
+		tag = new(DefineEditText)
+	case TAG_DEFINE_SPRITE:
+		tag = new(DefineSprite)
+	case TAG_FRAME_LABEL:
+		tag = new(FrameLabel)
+	case TAG_SOUND_STREAM_HEAD2:
+		tag = new(SoundStreamHead2)
+	case TAG_DEFINE_MORPH_SHAPE:
+		tag = new(DefineMorphShape)
+	case TAG_DEFINE_FONT2:
+		tag = new(DefineFont2)
+	case TAG_EXPORT_ASSETS:
+		tag = new(ExportAssets)
+	case TAG_DO_INIT_ACTION:
+		tag = new(DoInitAction)
+	case TAG_DEFINE_VIDEO_STREAM:
+		tag = new(DefineVideoStream)
+	case TAG_VIDEO_FRAME:
+		tag = new(VideoFrame)
+	case TAG_DEFINE_FONT_INFO2:
+		tag = new(DefineFontInfo2)
+	case TAG_FILE_ATTRIBUTES:
+		tag = new(FileAttributes)
+	case TAG_PLACE_OBJECT3:
+		tag = new(PlaceObject3)
+	case TAG_DO_ABC_DEFINE:
+		tag = new(DoABCDefine)
+	case TAG_DEFINE_FONT_ALIGN_ZONES:
+		tag = new(DefineFontAlignZones)
+	case TAG_CSM_TEXT_SETTINGS:
+		tag = new(CSMTextSettings)
+	case TAG_DEFINE_FONT3:
+		tag = new(DefineFont3)
+	case TAG_SYMBOL_CLASS:
+		tag = new(SymbolClass)
+	case TAG_METADATA:
+		tag = new(Metadata)
+	case TAG_DEFINE_SCALING_GRID:
+		tag = new(DefineScalingGrid)
+	case TAG_DO_ABC:
+		tag = new(DoABC)
+	case TAG_DEFINE_MORPH_SHAPE2:
+		tag = new(DefineMorphShape2)
+	case TAG_DEFINE_FONT_NAME:
+		tag = new(DefineFontName)
+	case TAG_START_SOUND2:
+		tag = new(StartSound2)
+	case TAG_DEFINE_FONT4:
+		tag = new(DefineFont4)
+	default:
+		return nil
+	}
+	if tag.MinVersion() > ver {
+		return nil
+	}
+	return tag
 }
 
 type SWF struct {
@@ -202,61 +355,61 @@ func (s *SWF) ReadFrom(f io.Reader) (total int64, err error) {
 		err = &BadHeader{0, err}
 		return
 	}
-	fmt.Println(s)
 	// 	s.frames = make([]frame, 0, s.frameCount)
 	// 	s.dictionary = make(dictionary)
-	var (
-		tagCode uint16
-		length  uint32
-	)
-	if s.Version >= 8 {
-		if err = binary.Read(f, binary.LittleEndian, &tagCode); err != nil {
-			err = &BadHeader{0, err}
-			return
-		}
-		length = uint32(tagCode & 63)
-		tagCode >>= 6
-		if length == 63 {
-			if err = binary.Read(f, binary.LittleEndian, &length); err != nil {
-				err = &BadHeader{0, err}
-				return
-			}
-		}
-		fmt.Printf("Tag: %d Length: %d\n", tagCode, length)
-		lr := io.LimitReader(f, int64(length))
-		if tagCode != 69 {
-			err = &InvalidTagCode{tagCode}
-			return
-		}
-		// 		if err = fileAttributes(s, lr); err != nil {
-		// 			return
-		// 		}
-		_, err = io.Copy(ioutil.Discard, lr)
+	if s.Tags, err = readTags(f, s.Version, nil); err != nil || s.Version < 8 {
+		return
+	}
+	if len(s.Tags) == 0 {
+		err = &InvalidTagCode{TAG_END}
+	} else if code := s.Tags[0].TagId(); code != TAG_FILE_ATTRIBUTES {
+		err = &InvalidTagCode{code}
+	}
+	return
+}
+
+func readTagHeader(f io.Reader) (tagCode uint16, length uint32, err error) {
+	if err = binary.Read(f, binary.LittleEndian, &tagCode); err != nil {
+		return
+	}
+	length = uint32(tagCode & 63)
+	tagCode >>= 6
+	if length == 63 {
+		err = binary.Read(f, binary.LittleEndian, &length)
 	}
+	return
+}
+
+// readTags reads tags up to and including the End tag. If allowed is not nil,
+// any tag it does not allow causes an ErrNotAllowed error.
+func readTags(f io.Reader, ver uint8, allowed func(uint16) bool) (tags []Tag, err error) {
+	tags = make([]Tag, 0)
 	for {
-		if err = binary.Read(f, binary.LittleEndian, &tagCode); err != nil {
+		var (
+			tagCode uint16
+			length  uint32
+		)
+		if tagCode, length, err = readTagHeader(f); err != nil {
 			return
 		}
-		if tagCode == 0 {
-			fmt.Println("END")
+		if tagCode == TAG_END {
 			break
 		}
-		length = uint32(tagCode & 63)
-		tagCode >>= 6
-		if length == 63 {
-			if err = binary.Read(f, binary.LittleEndian, &length); err != nil {
-				return
-			}
-		}
-		fmt.Printf("Tag: %d Length: %d\n", tagCode, length)
 		lr := io.LimitReader(f, int64(length))
-		tag := TagFromIdVer(tagCode, s.Version)
+		tag := TagFromId(tagCode)
 		if tag == nil {
 			err = &InvalidTagCode{tagCode}
 			return
+		} else if tag.MinVersion() > ver {
+			err = &ErrMinVersion{tag.Name(), tag.MinVersion()}
+			return
+		} else if allowed != nil && !allowed(tagCode) {
+			err = &ErrNotAllowed{tag.Name()}
+			return
 		}
-		s.Tags = append(s.Tags, tag)
-		if _, err = tag.ReadFrom(lr); err != nil {
+		tags = append(tags, tag)
+		if _, err = tag.ReadFrom(lr, ver, tagCode); err != nil {
+			err = wrapError(tag.Name(), &err)
 			return
 		}
 		_, err = io.Copy(ioutil.Discard, lr)
@@ -267,6 +420,43 @@ func (s *SWF) ReadFrom(f io.Reader) (total int64, err error) {
 	return
 }
 
+// writeTags writes the tags followed by an End tag.
+func writeTags(f io.Writer, tags []Tag, ver uint8, allowed func(uint16) bool) (err error) {
+	for _, tag := range tags {
+		if v := tag.MinVersion(); v > ver {
+			return &ErrMinVersion{tag.Name(), v}
+		} else if allowed != nil && !allowed(tag.TagId()) {
+			return &ErrNotAllowed{tag.Name()}
+		}
+		l := tag.Size(ver, tag.TagId())
+		if l >= 63 {
+			if err = binary.Write(f, binary.LittleEndian, tag.TagId()<<6|63); err != nil {
+				return
+			} else if err = binary.Write(f, binary.LittleEndian, l); err != nil {
+				return
+			}
+		} else if err = binary.Write(f, binary.LittleEndian, tag.TagId()<<6|uint16(l)); err != nil {
+			return
+		}
+		if _, err = tag.WriteTo(f, ver, tag.TagId()); err != nil {
+			return wrapError(tag.Name(), &err)
+		}
+	}
+	return binary.Write(f, binary.LittleEndian, TAG_END)
+}
+
+func tagsSize(tags []Tag, ver uint8) int32 {
+	length := int32(2)
+	for _, tag := range tags {
+		l := tag.Size(ver, tag.TagId())
+		length += 2 + l
+		if l >= 63 {
+			length += 4
+		}
+	}
+	return length
+}
+
 func (s *SWF) WriteTo(f io.Writer) (total int64, err error) {
 	if s.Version == 0 {
 		var v uint8
@@ -278,7 +468,7 @@ func (s *SWF) WriteTo(f io.Writer) (total int64, err error) {
 	} else {
 		var v uint8
 		for n, tag := range s.Tags {
-			if v = tag.MinVersion(); v < s.Version {
+			if v = tag.MinVersion(); v > s.Version {
 				err = &ErrMinVersion{tag.Name(), v}
 				return
 			} else if u, ok := tag.(Upgradeable); ok && u.MaxVersion() < s.Version {
@@ -286,21 +476,24 @@ func (s *SWF) WriteTo(f io.Writer) (total int64, err error) {
 			}
 		}
 	}
+	tags := s.Tags
+	if s.Version >= 8 {
+		tags = s.fileAttributes()
+	}
 	c := &rwcount.CountWriter{Writer: f}
 	defer func() { total = c.BytesWritten() }()
 	switch s.Compressed {
 	case COMPRESS_NONE:
-		if err = binary.Write(c, binary.LittleEndian, 'F'); err != nil {
+		if err = binary.Write(c, binary.LittleEndian, []byte("FWS")); err != nil {
 			return
 		}
 	case COMPRESS_ZLIB:
-		if err = binary.Write(c, binary.LittleEndian, 'C'); err != nil {
+		if err = binary.Write(c, binary.LittleEndian, []byte("CWS")); err != nil {
 			return
 		}
 	case COMPRESS_LZMA:
-		if err = binary.Write(c, binary.LittleEndian, 'Z'); err != nil {
-			return
-		}
+		err = ErrLZMAWrite
+		return
 	default:
 		err = &BadHeader{Code: 2}
 		return
@@ -308,41 +501,56 @@ func (s *SWF) WriteTo(f io.Writer) (total int64, err error) {
 	if err = binary.Write(c, binary.LittleEndian, s.Version); err != nil {
 		return
 	}
-	length := int32(3 + 1 + 4 + 16 + 2 + 2)
-	for _, tag := range s.Tags {
-		length += 2
-		l := tag.Size()
-		length += l
-		if l >= 63 {
-			length += 4
-		}
-	}
+	length := int32(3+1+4+2+2) + s.FrameSize.Size() + tagsSize(tags, s.Version)
 	if err = binary.Write(c, binary.LittleEndian, length); err != nil {
 		return
 	}
-	if _, err = s.FrameSize.WriteTo(c); err != nil {
+	var w io.Writer = c
+	if s.Compressed == COMPRESS_ZLIB {
+		z := zlib.NewWriter(c)
+		defer func() {
+			if e := z.Close(); err == nil {
+				err = e
+			}
+		}()
+		w = z
+	}
+	if _, err = s.FrameSize.WriteTo(w); err != nil {
 		return
 	}
-	if err = binary.Write(c, binary.LittleEndian, s.FrameRate); err != nil {
+	if err = binary.Write(w, binary.LittleEndian, s.FrameRate); err != nil {
 		return
 	}
-	if err = binary.Write(c, binary.LittleEndian, s.FrameCount); err != nil {
+	if err = binary.Write(w, binary.LittleEndian, s.FrameCount); err != nil {
 		return
 	}
+	err = writeTags(w, tags, s.Version, nil)
+	return
+}
+
+// fileAttributes returns the tags of the file with a FileAttributes tag first,
+// moving it to the front if it is elsewhere, or creating one if there is none.
+func (s *SWF) fileAttributes() []Tag {
+	tags := make([]Tag, 1, len(s.Tags)+1)
 	for _, tag := range s.Tags {
-		l := tag.Size()
-		if l >= 63 {
-			if err = binary.Write(c, binary.LittleEndian, tag.TagId()<<6|63); err != nil {
-				return
-			} else if err = binary.Write(c, binary.LittleEndian, l); err != nil {
-				return
-			}
-		} else if err = binary.Write(c, binary.LittleEndian, tag.TagId()<<6|uint16(l)); err != nil {
-			return
+		if a, ok := tag.(*FileAttributes); ok && tags[0] == nil {
+			tags[0] = a
+		} else {
+			tags = append(tags, tag)
 		}
-		if _, err = tag.WriteTo(c); err != nil {
-			return
+	}
+	if tags[0] != nil {
+		return tags
+	}
+	a := new(FileAttributes)
+	for _, tag := range s.Tags {
+		switch tag.TagId() {
+		case TAG_METADATA:
+			a.HasMetadata = true
+		case TAG_DO_ABC, TAG_DO_ABC_DEFINE, TAG_SYMBOL_CLASS:
+			a.ActionScript3 = true
 		}
 	}
-	return
+	tags[0] = a
+	return tags
 }
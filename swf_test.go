@@ -0,0 +1,164 @@
+package swf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testTag(t *testing.T, ver uint8, data []byte, expected Tag) {
+	tag := TagFromIdVer(expected.TagId(), ver)
+	if tag == nil {
+		t.Errorf("%s: no tag for id %d, version %d", expected.Name(), expected.TagId(), ver)
+		return
+	}
+	if br, err := tag.ReadFrom(bytes.NewBuffer(data), ver, expected.TagId()); err != nil {
+		t.Errorf("%s: %q", expected.Name(), err)
+		return
+	} else if br != int64(len(data)) {
+		t.Errorf("%s: expecting to read %d bytes, read %d bytes", expected.Name(), len(data), br)
+	}
+	if !reflect.DeepEqual(tag, expected) {
+		t.Errorf("%s: expecting %v, got %v", expected.Name(), expected, tag)
+	}
+	if s := tag.Size(ver, expected.TagId()); s != int32(len(data)) {
+		t.Errorf("%s: size mismatch, got %d, expected %d", expected.Name(), s, len(data))
+	}
+	buf := new(bytes.Buffer)
+	if bw, err := tag.WriteTo(buf, ver, expected.TagId()); err != nil {
+		t.Errorf("%s: %q", expected.Name(), err)
+	} else if bw != int64(len(data)) {
+		t.Errorf("%s: read %d bytes, but wrote %d bytes", expected.Name(), len(data), bw)
+	} else if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("%s: expecting %v, got %v", expected.Name(), data, buf.Bytes())
+	}
+}
+
+func TestSWFRoundTrip(t *testing.T) {
+	s := &SWF{
+		Version:    7,
+		FrameSize:  Rect{0, 11000, 0, 8000},
+		FrameRate:  12 << 8,
+		FrameCount: 1,
+		Tags: []Tag{
+			&DefineSound{SoundId: 1, SoundFormat: SOUND_MP3, SoundRate: SOUND_RATE_44, Sound16Bit: true, SoundSampleCount: 1152, SoundData: make([]byte, 100)},
+			&StartSound{SoundId: 1, SoundInfo: SoundInfo{HasLoops: true, LoopCount: 2}},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if _, err := s.WriteTo(buf); err != nil {
+		t.Fatalf("unexpected error writing: %q", err)
+	}
+	var r SWF
+	if _, err := r.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("unexpected error reading: %q", err)
+	}
+	if !reflect.DeepEqual(s.Tags, r.Tags) {
+		t.Errorf("expecting %v, got %v", s.Tags, r.Tags)
+	}
+}
+
+func TestSWFCompressedRoundTrip(t *testing.T) {
+	s := &SWF{
+		Version:    7,
+		Compressed: COMPRESS_ZLIB,
+		FrameSize:  Rect{0, 11000, 0, 8000},
+		FrameRate:  12 << 8,
+		FrameCount: 1,
+		Tags: []Tag{
+			&DefineSound{SoundId: 1, SoundFormat: SOUND_MP3, SoundRate: SOUND_RATE_44, Sound16Bit: true, SoundSampleCount: 1152, SoundData: make([]byte, 1000)},
+			&StartSound{SoundId: 1, SoundInfo: SoundInfo{HasLoops: true, LoopCount: 2}},
+		},
+	}
+	buf := new(bytes.Buffer)
+	n, err := s.WriteTo(buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing: %q", err)
+	} else if n != int64(buf.Len()) {
+		t.Errorf("expecting to have written %d bytes, reported %d", buf.Len(), n)
+	}
+	if sig := string(buf.Bytes()[:3]); sig != "CWS" {
+		t.Errorf("expecting signature \"CWS\", got %q", sig)
+	}
+	var r SWF
+	if _, err := r.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("unexpected error reading: %q", err)
+	}
+	if r.Compressed != COMPRESS_ZLIB {
+		t.Errorf("expecting %s, got %s", COMPRESS_ZLIB, r.Compressed)
+	}
+	if !reflect.DeepEqual(s.Tags, r.Tags) {
+		t.Errorf("expecting %v, got %v", s.Tags, r.Tags)
+	}
+	s.Compressed = COMPRESS_LZMA
+	if _, err := s.WriteTo(new(bytes.Buffer)); err != ErrLZMAWrite {
+		t.Errorf("expecting error %q, got %q", ErrLZMAWrite, err)
+	}
+}
+
+func TestFileAttributes(t *testing.T) {
+	testTag(t, 8, []byte{0x19, 0, 0, 0}, &FileAttributes{HasMetadata: true, ActionScript3: true, UseNetwork: true})
+	testTag(t, 10, []byte{0x66, 0, 0, 0}, &FileAttributes{UseDirectBlit: true, UseGPU: true, SuppressCrossDomainCaching: true, SWFRelativeURLs: true})
+}
+
+func TestSWFABCRoundTrip(t *testing.T) {
+	s := &SWF{
+		Version:    9,
+		FrameSize:  Rect{0, 11000, 0, 8000},
+		FrameRate:  24 << 8,
+		FrameCount: 1,
+		Tags: []Tag{
+			&DoABC{LazyInitialize: true, ABCName: "m", ABCData: []byte{16, 0, 46, 0}},
+			&SymbolClass{ExportAssets{[]Asset{{0, "Main"}}}},
+			&ShowFrame{},
+		},
+	}
+	buf := new(bytes.Buffer)
+	n, err := s.WriteTo(buf)
+	if err != nil {
+		t.Fatalf("unexpected error writing: %q", err)
+	} else if n != int64(buf.Len()) {
+		t.Errorf("expecting to have written %d bytes, reported %d", buf.Len(), n)
+	}
+	var r SWF
+	if _, err := r.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("unexpected error reading: %q", err)
+	}
+	expected := append([]Tag{&FileAttributes{ActionScript3: true}}, s.Tags...)
+	if !reflect.DeepEqual(r.Tags, expected) {
+		t.Errorf("expecting %v, got %v", expected, r.Tags)
+	}
+	buf.Reset()
+	if _, err := r.WriteTo(buf); err != nil {
+		t.Fatalf("unexpected error writing: %q", err)
+	}
+	var rr SWF
+	if _, err := rr.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("unexpected error reading: %q", err)
+	} else if !reflect.DeepEqual(rr.Tags, expected) {
+		t.Errorf("expecting %v, got %v", expected, rr.Tags)
+	}
+	buf.Reset()
+	a := &FileAttributes{ActionScript3: true, UseNetwork: true}
+	s.Tags = append(s.Tags, a)
+	if _, err := s.WriteTo(buf); err != nil {
+		t.Fatalf("unexpected error writing: %q", err)
+	}
+	if _, err := r.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("unexpected error reading: %q", err)
+	}
+	expected = append([]Tag{a}, s.Tags[:len(s.Tags)-1]...)
+	if !reflect.DeepEqual(r.Tags, expected) {
+		t.Errorf("expecting %v, got %v", expected, r.Tags)
+	}
+	buf.Reset()
+	s.Tags = []Tag{&ShowFrame{}}
+	s.Version = 7
+	if _, err := s.WriteTo(buf); err != nil {
+		t.Fatalf("unexpected error writing: %q", err)
+	}
+	buf.Bytes()[3] = 9
+	if _, err := r.ReadFrom(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("expecting error for missing FileAttributes tag")
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+)
+
+var (
+	ErrUnknownFont    = errors.New("defineText: unknown font")
+	ErrGlyphEntryBits = errors.New("defineText: glyph entry does not fit in GlyphBits/AdvanceBits")
+	ErrTooManyGlyphs  = errors.New("textRecord: too many glyph entries")
+)
+
+type GlyphEntry struct {
+	GlyphIndex   uint32
+	GlyphAdvance int32
+}
+
+type TextRecord struct {
+	HasFont, HasColor, HasXOffset, HasYOffset bool
+	FontId                                    uint16
+	TextColor                                 RGBA
+	XOffset, YOffset                          int16
+	TextHeight                                uint16
+	GlyphEntries                              []GlyphEntry
+}
+
+func (t *TextRecord) readFrom(f io.Reader, flags uint8, alpha bool, glyphBits, advanceBits uint8) (err error) {
+	if flags&0x80 == 0 {
+		return &ParserError{"TextRecord", "TextRecordType", fmt.Sprintf("%d", flags>>7)}
+	}
+	unpackFlags(flags, &t.HasFont, &t.HasColor, &t.HasYOffset, &t.HasXOffset)
+	if t.HasFont {
+		if err = binary.Read(f, binary.LittleEndian, &t.FontId); err != nil {
+			return
+		}
+	}
+	if t.HasColor {
+		if alpha {
+			_, err = t.TextColor.ReadFrom(f)
+		} else {
+			_, err = t.TextColor.RGB.ReadFrom(f)
+			t.TextColor.Alpha = 255
+		}
+		if err != nil {
+			return
+		}
+	}
+	if t.HasXOffset {
+		if err = binary.Read(f, binary.LittleEndian, &t.XOffset); err != nil {
+			return
+		}
+	}
+	if t.HasYOffset {
+		if err = binary.Read(f, binary.LittleEndian, &t.YOffset); err != nil {
+			return
+		}
+	}
+	if t.HasFont {
+		if err = binary.Read(f, binary.LittleEndian, &t.TextHeight); err != nil {
+			return
+		}
+	}
+	var count uint8
+	if err = binary.Read(f, binary.LittleEndian, &count); err != nil {
+		return
+	}
+	t.GlyphEntries = make([]GlyphEntry, count)
+	b := &bitReader{Reader: f}
+	for n := range t.GlyphEntries {
+		var (
+			index   BitUint
+			advance BitInt
+		)
+		if err = index.ReadBitsFrom(b, glyphBits); err != nil {
+			return
+		}
+		if err = advance.ReadBitsFrom(b, advanceBits); err != nil {
+			return
+		}
+		t.GlyphEntries[n] = GlyphEntry{uint32(index), int32(advance)}
+	}
+	return
+}
+
+func (t *TextRecord) writeTo(f io.Writer, alpha bool, glyphBits, advanceBits uint8) (err error) {
+	if len(t.GlyphEntries) > 255 {
+		return ErrTooManyGlyphs
+	}
+	if err = binary.Write(f, binary.LittleEndian, packFlags(true, false, false, false, t.HasFont, t.HasColor, t.HasYOffset, t.HasXOffset)); err != nil {
+		return
+	}
+	if t.HasFont {
+		if err = binary.Write(f, binary.LittleEndian, t.FontId); err != nil {
+			return
+		}
+	}
+	if t.HasColor {
+		if alpha {
+			_, err = t.TextColor.WriteTo(f)
+		} else {
+			_, err = t.TextColor.RGB.WriteTo(f)
+		}
+		if err != nil {
+			return
+		}
+	}
+	if t.HasXOffset {
+		if err = binary.Write(f, binary.LittleEndian, t.XOffset); err != nil {
+			return
+		}
+	}
+	if t.HasYOffset {
+		if err = binary.Write(f, binary.LittleEndian, t.YOffset); err != nil {
+			return
+		}
+	}
+	if t.HasFont {
+		if err = binary.Write(f, binary.LittleEndian, t.TextHeight); err != nil {
+			return
+		}
+	}
+	if err = binary.Write(f, binary.LittleEndian, uint8(len(t.GlyphEntries))); err != nil {
+		return
+	}
+	b := &bitWriter{Writer: f}
+	defer b.Align()
+	for _, g := range t.GlyphEntries {
+		index, advance := BitUint(g.GlyphIndex), BitInt(g.GlyphAdvance)
+		if err = index.WriteBitsTo(b, glyphBits); err != nil {
+			return
+		}
+		if err = advance.WriteBitsTo(b, advanceBits); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (t *TextRecord) size(alpha bool, glyphBits, advanceBits uint8) int32 {
+	total := int32(1 + 1)
+	if t.HasFont {
+		total += 2 + 2
+	}
+	if t.HasColor {
+		if alpha {
+			total += 4
+		} else {
+			total += 3
+		}
+	}
+	if t.HasXOffset {
+		total += 2
+	}
+	if t.HasYOffset {
+		total += 2
+	}
+	return total + (int32(len(t.GlyphEntries))*(int32(glyphBits)+int32(advanceBits))+7)/8
+}
+
+type DefineText struct {
+	CharacterId uint16
+	TextBounds  Rect
+	TextMatrix  Matrix
+	GlyphBits   uint8
+	AdvanceBits uint8
+	TextRecords []TextRecord
+}
+
+func (d *DefineText) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &d.CharacterId); err != nil {
+		return
+	}
+	if err = ReadAll(c, &d.TextBounds, &d.TextMatrix); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &d.GlyphBits); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &d.AdvanceBits); err != nil {
+		return
+	}
+	if d.GlyphBits > 32 {
+		err = &ParserError{d.Name(), "GlyphBits", fmt.Sprintf("%d", d.GlyphBits)}
+		return
+	} else if d.AdvanceBits > 32 {
+		err = &ParserError{d.Name(), "AdvanceBits", fmt.Sprintf("%d", d.AdvanceBits)}
+		return
+	}
+	d.TextRecords = nil
+	for {
+		var flags uint8
+		if err = binary.Read(c, binary.LittleEndian, &flags); err != nil {
+			return
+		}
+		if flags == 0 {
+			break
+		}
+		var t TextRecord
+		if err = t.readFrom(c, flags, id == TAG_DEFINE_TEXT2, d.GlyphBits, d.AdvanceBits); err != nil {
+			return
+		}
+		d.TextRecords = append(d.TextRecords, t)
+	}
+	return
+}
+
+func (d *DefineText) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	if !d.bitsFit() {
+		err = ErrGlyphEntryBits
+		return
+	}
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, d.CharacterId); err != nil {
+		return
+	}
+	if err = WriteAll(c, &d.TextBounds, &d.TextMatrix); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, [2]uint8{d.GlyphBits, d.AdvanceBits}); err != nil {
+		return
+	}
+	for n := range d.TextRecords {
+		if err = d.TextRecords[n].writeTo(c, id == TAG_DEFINE_TEXT2, d.GlyphBits, d.AdvanceBits); err != nil {
+			return
+		}
+	}
+	err = binary.Write(c, binary.LittleEndian, uint8(0))
+	return
+}
+
+func (d *DefineText) Size(ver uint8, id uint16) int32 {
+	total := 2 + d.TextBounds.Size() + d.TextMatrix.Size() + 2 + 1
+	for n := range d.TextRecords {
+		total += d.TextRecords[n].size(id == TAG_DEFINE_TEXT2, d.GlyphBits, d.AdvanceBits)
+	}
+	return total
+}
+
+func (d *DefineText) MinVersion() uint8 {
+	return 1
+}
+
+func (d *DefineText) TagId() uint16 {
+	return TAG_DEFINE_TEXT
+}
+
+func (d *DefineText) Name() string {
+	return "DefineText"
+}
+
+func (d *DefineText) bitsFit() bool {
+	glyphBits, advanceBits := d.minBits()
+	return glyphBits <= d.GlyphBits && advanceBits <= d.AdvanceBits
+}
+
+func (d *DefineText) minBits() (glyphBits, advanceBits uint8) {
+	for _, t := range d.TextRecords {
+		for _, g := range t.GlyphEntries {
+			if g.GlyphIndex != 0 {
+				index := BitUint(g.GlyphIndex)
+				glyphBits = uint8(max(int32(glyphBits), index.Size()))
+			}
+			if g.GlyphAdvance != 0 {
+				advance := BitInt(g.GlyphAdvance)
+				advanceBits = uint8(max(int32(advanceBits), advance.Size()))
+			}
+		}
+	}
+	return
+}
+
+// FitBits sets GlyphBits and AdvanceBits to the smallest values that can hold
+// every glyph entry.
+func (d *DefineText) FitBits() {
+	d.GlyphBits, d.AdvanceBits = d.minBits()
+}
+
+// Text returns the string drawn by the text records, using the code tables to
+// map glyph indices back to characters. Glyphs missing from a code table are
+// returned as U+FFFD and a change in YOffset starts a new line.
+func (d *DefineText) Text(codeTables CodeTables) (string, error) {
+	var (
+		runes []rune
+		codes []uint16
+		font  bool
+		y     int16
+		hasY  bool
+	)
+	for _, t := range d.TextRecords {
+		if t.HasFont {
+			if codes, font = codeTables[t.FontId]; !font {
+				return "", ErrUnknownFont
+			}
+		}
+		if t.HasYOffset {
+			if hasY && t.YOffset != y {
+				runes = append(runes, '\n')
+			}
+			y, hasY = t.YOffset, true
+		}
+		if len(t.GlyphEntries) > 0 && !font {
+			return "", ErrUnknownFont
+		}
+		for _, g := range t.GlyphEntries {
+			if g.GlyphIndex < uint32(len(codes)) {
+				runes = append(runes, rune(codes[g.GlyphIndex]))
+			} else {
+				runes = append(runes, '\uFFFD')
+			}
+		}
+	}
+	return string(runes), nil
+}
+
+type DefineText2 struct {
+	DefineText
+}
+
+func (d *DefineText2) MinVersion() uint8 {
+	return 3
+}
+
+func (d *DefineText2) TagId() uint16 {
+	return TAG_DEFINE_TEXT2
+}
+
+func (d *DefineText2) Name() string {
+	return "DefineText2"
+}
+
+// CodeTables maps font ids to the code table of the font.
+type CodeTables map[uint16][]uint16
+
+func NewCodeTables(tags []Tag) CodeTables {
+	c := make(CodeTables)
+	for _, tag := range tags {
+		switch t := tag.(type) {
+		case *DefineFontInfo:
+			c[t.FontId] = t.CodeTable
+		case *DefineFontInfo2:
+			c[t.FontId] = t.CodeTable
+		case *DefineFont2:
+			c[t.FontId] = t.CodeTable
+		case *DefineFont3:
+			c[t.FontId] = t.CodeTable
+		}
+	}
+	return c
+}
+
+func (s *SWF) CodeTables() CodeTables {
+	return NewCodeTables(s.Tags)
+}
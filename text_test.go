@@ -0,0 +1,82 @@
+package swf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testTextRecords(alpha uint8) []TextRecord {
+	return []TextRecord{
+		{
+			HasFont:    true,
+			HasColor:   true,
+			HasXOffset: true,
+			HasYOffset: true,
+			FontId:     2,
+			TextColor:  RGBA{RGB{255, 0, 0}, alpha},
+			XOffset:    20,
+			YOffset:    40,
+			TextHeight: 240,
+			GlyphEntries: []GlyphEntry{
+				{1, 10},
+				{2, -3},
+			},
+		},
+		{
+			HasYOffset:   true,
+			YOffset:      80,
+			GlyphEntries: []GlyphEntry{{0, 7}},
+		},
+	}
+}
+
+func TestDefineText(t *testing.T) {
+	testTag(t, 1, []byte{5, 0, 8, 0, 0, 3, 5, 0x8f, 2, 0, 255, 0, 0, 20, 0, 40, 0, 240, 0, 2, 0x2a, 0x5d, 0x82, 80, 0, 1, 7, 0}, &DefineText{
+		CharacterId: 5,
+		TextMatrix:  Matrix{ScaleX: 1, ScaleY: 1},
+		GlyphBits:   3,
+		AdvanceBits: 5,
+		TextRecords: testTextRecords(255),
+	})
+}
+
+func TestDefineText2(t *testing.T) {
+	testTag(t, 3, []byte{5, 0, 8, 0, 0, 3, 5, 0x8f, 2, 0, 255, 0, 0, 128, 20, 0, 40, 0, 240, 0, 2, 0x2a, 0x5d, 0x82, 80, 0, 1, 7, 0}, &DefineText2{DefineText{
+		CharacterId: 5,
+		TextMatrix:  Matrix{ScaleX: 1, ScaleY: 1},
+		GlyphBits:   3,
+		AdvanceBits: 5,
+		TextRecords: testTextRecords(128),
+	}})
+}
+
+func TestDefineTextBits(t *testing.T) {
+	d := &DefineText{TextMatrix: Matrix{ScaleX: 1, ScaleY: 1}, GlyphBits: 1, AdvanceBits: 5, TextRecords: testTextRecords(255)}
+	if _, err := d.WriteTo(new(bytes.Buffer), 1, TAG_DEFINE_TEXT); err != ErrGlyphEntryBits {
+		t.Errorf("expecting error %q, got %q", ErrGlyphEntryBits, err)
+	}
+	d.FitBits()
+	if d.GlyphBits != 2 || d.AdvanceBits != 5 {
+		t.Errorf("expecting bits 2 and 5, got %d and %d", d.GlyphBits, d.AdvanceBits)
+	}
+}
+
+func TestDefineTextText(t *testing.T) {
+	s := &SWF{Tags: []Tag{
+		&DefineFontInfo{FontId: 2, CodeTable: []uint16{'H', 'i', '!'}},
+		&DefineText{TextRecords: testTextRecords(255)},
+	}}
+	d := s.Tags[1].(*DefineText)
+	if str, err := d.Text(s.CodeTables()); err != nil {
+		t.Error(err)
+	} else if str != "i!\nH" {
+		t.Errorf("expecting %q, got %q", "i!\nH", str)
+	}
+	d.TextRecords[1].GlyphEntries[0].GlyphIndex = 3
+	if str, _ := d.Text(s.CodeTables()); str != "i!\n�" {
+		t.Errorf("expecting %q, got %q", "i!\n�", str)
+	}
+	if _, err := d.Text(CodeTables{}); err != ErrUnknownFont {
+		t.Errorf("expecting error %q, got %q", ErrUnknownFont, err)
+	}
+}
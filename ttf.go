@@ -0,0 +1,553 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"sort"
+	"unicode/utf16"
+)
+
+var ErrNoGlyphs = errors.New("ttf: font has no glyphs")
+
+type ttfPoint struct {
+	X, Y    int16
+	OnCurve bool
+}
+
+type ttfGlyph struct {
+	Contours               [][]ttfPoint
+	XMin, YMin, XMax, YMax int16
+}
+
+func ttfScale(v int32, scale int32) int16 {
+	if v < 0 {
+		return int16((v - scale/2) / scale)
+	}
+	return int16((v + scale/2) / scale)
+}
+
+// glyphContours converts the edges of a glyph to TrueType contours, flipping
+// the Y axis so that the baseline is at zero with Y increasing upwards.
+func glyphContours(s *Shape, scale int32) *ttfGlyph {
+	var (
+		g       ttfGlyph
+		x, y    int32
+		contour []ttfPoint
+	)
+	point := func(x, y int32, onCurve bool) ttfPoint {
+		return ttfPoint{ttfScale(x, scale), -ttfScale(y, scale), onCurve}
+	}
+	closeContour := func() {
+		if l := len(contour); l > 1 && contour[0] == contour[l-1] {
+			contour = contour[:l-1]
+		}
+		if len(contour) > 1 {
+			g.Contours = append(g.Contours, contour)
+		}
+		contour = nil
+	}
+	for _, r := range s.ShapeRecords {
+		switch r := r.(type) {
+		case *StyleChangeRecord:
+			if r.MoveTo {
+				closeContour()
+				x, y = int32(r.MoveDeltaX), int32(r.MoveDeltaY)
+			}
+		case *StraightEdgeRecord:
+			if contour == nil {
+				contour = append(contour, point(x, y, true))
+			}
+			x += int32(r.DeltaX)
+			y += int32(r.DeltaY)
+			contour = append(contour, point(x, y, true))
+		case *CurvedEdgeRecord:
+			if contour == nil {
+				contour = append(contour, point(x, y, true))
+			}
+			x += int32(r.ControlDeltaX)
+			y += int32(r.ControlDeltaY)
+			contour = append(contour, point(x, y, false))
+			x += int32(r.AnchorDeltaX)
+			y += int32(r.AnchorDeltaY)
+			contour = append(contour, point(x, y, true))
+		}
+	}
+	closeContour()
+	first := true
+	for _, c := range g.Contours {
+		for _, p := range c {
+			if first {
+				g.XMin, g.XMax, g.YMin, g.YMax = p.X, p.X, p.Y, p.Y
+				first = false
+			}
+			if p.X < g.XMin {
+				g.XMin = p.X
+			} else if p.X > g.XMax {
+				g.XMax = p.X
+			}
+			if p.Y < g.YMin {
+				g.YMin = p.Y
+			} else if p.Y > g.YMax {
+				g.YMax = p.Y
+			}
+		}
+	}
+	return &g
+}
+
+func (g *ttfGlyph) numPoints() (total int) {
+	for _, c := range g.Contours {
+		total += len(c)
+	}
+	return
+}
+
+func (g *ttfGlyph) bytes() []byte {
+	if len(g.Contours) == 0 {
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, [5]int16{int16(len(g.Contours)), g.XMin, g.YMin, g.XMax, g.YMax})
+	end := -1
+	for _, c := range g.Contours {
+		end += len(c)
+		binary.Write(buf, binary.BigEndian, uint16(end))
+	}
+	binary.Write(buf, binary.BigEndian, uint16(0))
+	for _, c := range g.Contours {
+		for _, p := range c {
+			if p.OnCurve {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		}
+	}
+	var last int16
+	for _, c := range g.Contours {
+		for _, p := range c {
+			binary.Write(buf, binary.BigEndian, p.X-last)
+			last = p.X
+		}
+	}
+	last = 0
+	for _, c := range g.Contours {
+		for _, p := range c {
+			binary.Write(buf, binary.BigEndian, p.Y-last)
+			last = p.Y
+		}
+	}
+	for buf.Len()%4 != 0 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+type ttfTable struct {
+	Tag  string
+	Data []byte
+}
+
+func ttfChecksum(data []byte) (sum uint32) {
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+	for i := 0; i < len(data); i += 4 {
+		sum += binary.BigEndian.Uint32(data[i:])
+	}
+	return
+}
+
+func ttfSearch(n int, size int) (searchRange, entrySelector, rangeShift uint16) {
+	for 1<<(entrySelector+1) <= n {
+		entrySelector++
+	}
+	searchRange = uint16(size << entrySelector)
+	rangeShift = uint16(n*size) - searchRange
+	return
+}
+
+// windows1252 holds the characters of bytes 0x80 to 0x9f in the Windows ANSI
+// code page; the rest of the page matches Unicode.
+var windows1252 = [32]uint16{
+	0x20ac, 0xffff, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021, 0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0xffff, 0x017d, 0xffff,
+	0xffff, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014, 0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0xffff, 0x017e, 0x0178,
+}
+
+// unicodeCodes returns the code table of the font as Unicode code points.
+// Fonts without wide codes hold single byte ANSI or Shift-JIS codes, of which
+// those with no character are returned as 0xffff.
+func (d *DefineFont2) unicodeCodes() []uint16 {
+	if d.WideCodes {
+		return d.CodeTable
+	}
+	codes := make([]uint16, len(d.CodeTable))
+	for n, c := range d.CodeTable {
+		switch {
+		case c < 0x80:
+		case d.ShiftJIS && c >= 0xa1 && c <= 0xdf:
+			// half-width katakana
+			c += 0xff61 - 0xa1
+		case d.ShiftJIS || c > 0xff:
+			c = 0xffff
+		case c < 0xa0:
+			c = windows1252[c-0x80]
+		}
+		codes[n] = c
+	}
+	return codes
+}
+
+func ttfCmap(codes []uint16) []byte {
+	type mapping struct {
+		code, glyph uint16
+	}
+	maps := make([]mapping, 0, len(codes))
+	seen := make(map[uint16]bool)
+	for n, c := range codes {
+		if c != 0xffff && !seen[c] {
+			seen[c] = true
+			maps = append(maps, mapping{c, uint16(n + 1)})
+		}
+	}
+	sort.Slice(maps, func(i, j int) bool { return maps[i].code < maps[j].code })
+	var starts, ends, deltas []uint16
+	for n, m := range maps {
+		if n > 0 && m.code == maps[n-1].code+1 && m.glyph == maps[n-1].glyph+1 {
+			ends[len(ends)-1] = m.code
+			continue
+		}
+		starts = append(starts, m.code)
+		ends = append(ends, m.code)
+		deltas = append(deltas, m.glyph-m.code)
+	}
+	starts = append(starts, 0xffff)
+	ends = append(ends, 0xffff)
+	deltas = append(deltas, 1)
+	segCount := len(starts)
+	searchRange, entrySelector, rangeShift := ttfSearch(segCount, 2)
+	sub := new(bytes.Buffer)
+	binary.Write(sub, binary.BigEndian, [7]uint16{4, uint16(16 + 8*segCount), 0, uint16(2 * segCount), searchRange, entrySelector, rangeShift})
+	binary.Write(sub, binary.BigEndian, ends)
+	binary.Write(sub, binary.BigEndian, uint16(0))
+	binary.Write(sub, binary.BigEndian, starts)
+	binary.Write(sub, binary.BigEndian, deltas)
+	binary.Write(sub, binary.BigEndian, make([]uint16, segCount))
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, [2]uint16{0, 2})
+	binary.Write(buf, binary.BigEndian, [3]uint16{0, 3, 0})
+	binary.Write(buf, binary.BigEndian, uint16(20))
+	binary.Write(buf, binary.BigEndian, [3]uint16{3, 1, 0})
+	binary.Write(buf, binary.BigEndian, uint16(20))
+	buf.Write(sub.Bytes())
+	return buf.Bytes()
+}
+
+func ttfName(family, subfamily string) []byte {
+	names := []string{1: family, 2: subfamily, 3: family + " " + subfamily, 4: family + " " + subfamily, 6: family + "-" + subfamily}
+	var (
+		records []uint16
+		strs    []byte
+	)
+	for id, name := range names {
+		if id == 0 || id == 5 {
+			continue
+		}
+		var enc []byte
+		for _, u := range utf16.Encode([]rune(name)) {
+			enc = append(enc, byte(u>>8), byte(u))
+		}
+		if id == 6 {
+			enc = enc[:0]
+			for _, r := range name {
+				if r > ' ' && r < 127 {
+					enc = append(enc, 0, byte(r))
+				}
+			}
+		}
+		records = append(records, 3, 1, 0x409, uint16(id), uint16(len(enc)), uint16(len(strs)))
+		strs = append(strs, enc...)
+	}
+	count := len(records) / 6
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, [3]uint16{0, uint16(count), uint16(6 + 12*count)})
+	binary.Write(buf, binary.BigEndian, records)
+	buf.Write(strs)
+	return buf.Bytes()
+}
+
+// writeTTF converts a glyph font into a TrueType font. The scale is the
+// number of glyph units to each unit in the 1024 unit EM square.
+func writeTTF(w io.Writer, d *DefineFont2, scale int32) (total int64, err error) {
+	if len(d.GlyphShapeTable) == 0 {
+		return 0, ErrNoGlyphs
+	}
+	c := &rwcount.CountWriter{Writer: w}
+	defer func() { total = c.BytesWritten() }()
+	numGlyphs := len(d.GlyphShapeTable) + 1
+	glyphs := make([]*ttfGlyph, numGlyphs)
+	glyphs[0] = new(ttfGlyph)
+	for n := range d.GlyphShapeTable {
+		glyphs[n+1] = glyphContours(&d.GlyphShapeTable[n], scale)
+	}
+	advances := make([]uint16, numGlyphs)
+	advances[0] = 512
+	for n := 1; n < numGlyphs; n++ {
+		if d.HasLayout && n-1 < len(d.FontAdvanceTable) {
+			advances[n] = uint16(ttfScale(int32(d.FontAdvanceTable[n-1]), scale))
+		} else if glyphs[n].XMax > 0 {
+			advances[n] = uint16(glyphs[n].XMax)
+		}
+	}
+	ascent, descent, lineGap := int16(880), int16(-144), int16(0)
+	if d.HasLayout {
+		ascent = ttfScale(int32(d.FontAscent), scale)
+		descent = -ttfScale(int32(d.FontDescent), scale)
+		lineGap = ttfScale(int32(d.FontLeading), scale)
+	}
+	var (
+		xMin, yMin, xMax, yMax    int16
+		maxPoints, maxContours    int
+		advanceMax                uint16
+		minLSB, minRSB, maxExtent int16
+		first                     = true
+	)
+	glyf := new(bytes.Buffer)
+	loca := new(bytes.Buffer)
+	hmtx := new(bytes.Buffer)
+	for n, g := range glyphs {
+		binary.Write(loca, binary.BigEndian, uint32(glyf.Len()))
+		glyf.Write(g.bytes())
+		binary.Write(hmtx, binary.BigEndian, advances[n])
+		binary.Write(hmtx, binary.BigEndian, g.XMin)
+		if advances[n] > advanceMax {
+			advanceMax = advances[n]
+		}
+		if len(g.Contours) == 0 {
+			continue
+		}
+		if p := g.numPoints(); p > maxPoints {
+			maxPoints = p
+		}
+		if len(g.Contours) > maxContours {
+			maxContours = len(g.Contours)
+		}
+		rsb := int16(advances[n]) - g.XMax
+		if first {
+			xMin, yMin, xMax, yMax = g.XMin, g.YMin, g.XMax, g.YMax
+			minLSB, minRSB, maxExtent = g.XMin, rsb, g.XMax
+			first = false
+		}
+		xMin, yMin = int16(min(int32(xMin), int32(g.XMin))), int16(min(int32(yMin), int32(g.YMin)))
+		xMax, yMax = int16(max(int32(xMax), int32(g.XMax))), int16(max(int32(yMax), int32(g.YMax)))
+		minLSB, minRSB = int16(min(int32(minLSB), int32(g.XMin))), int16(min(int32(minRSB), int32(rsb)))
+		maxExtent = int16(max(int32(maxExtent), int32(g.XMax)))
+	}
+	binary.Write(loca, binary.BigEndian, uint32(glyf.Len()))
+	var macStyle, fsSelection, weight uint16 = 0, 0, 400
+	subfamily := "Regular"
+	if d.Bold {
+		macStyle |= 1
+		fsSelection |= 32
+		weight = 700
+		subfamily = "Bold"
+	}
+	if d.Italic {
+		macStyle |= 2
+		fsSelection |= 1
+		if d.Bold {
+			subfamily = "Bold Italic"
+		} else {
+			subfamily = "Italic"
+		}
+	}
+	if fsSelection == 0 {
+		fsSelection = 64
+	}
+	head := new(bytes.Buffer)
+	binary.Write(head, binary.BigEndian, struct {
+		Version, FontRevision, ChecksumAdjustment, Magic uint32
+		Flags, UnitsPerEm                                uint16
+		Created, Modified                                int64
+		XMin, YMin, XMax, YMax                           int16
+		MacStyle, LowestRecPPEM                          uint16
+		FontDirectionHint, IndexToLocFormat, GlyphData   int16
+	}{0x00010000, 0x00010000, 0, 0x5F0F3CF5, 3, 1024, 0, 0, xMin, yMin, xMax, yMax, macStyle, 8, 2, 1, 0})
+	hhea := new(bytes.Buffer)
+	binary.Write(hhea, binary.BigEndian, struct {
+		Version                                    uint32
+		Ascender, Descender, LineGap               int16
+		AdvanceWidthMax                            uint16
+		MinLSB, MinRSB, XMaxExtent                 int16
+		CaretSlopeRise, CaretSlopeRun, CaretOffset int16
+		Reserved                                   [4]int16
+		MetricDataFormat                           int16
+		NumberOfHMetrics                           uint16
+	}{0x00010000, ascent, descent, lineGap, advanceMax, minLSB, minRSB, maxExtent, 1, 0, 0, [4]int16{}, 0, uint16(numGlyphs)})
+	maxp := new(bytes.Buffer)
+	binary.Write(maxp, binary.BigEndian, struct {
+		Version   uint32
+		NumGlyphs uint16
+		Values    [13]uint16
+	}{0x00010000, uint16(numGlyphs), [13]uint16{uint16(maxPoints), uint16(maxContours), 0, 0, 2}})
+	codes := d.unicodeCodes()
+	var firstChar, lastChar uint16 = 0xffff, 0
+	for _, code := range codes {
+		if code == 0xffff {
+			continue
+		}
+		if code < firstChar {
+			firstChar = code
+		}
+		if code > lastChar {
+			lastChar = code
+		}
+	}
+	var avgWidth int32
+	for _, a := range advances {
+		avgWidth += int32(a)
+	}
+	avgWidth /= int32(numGlyphs)
+	os2 := new(bytes.Buffer)
+	binary.Write(os2, binary.BigEndian, struct {
+		Version                                  uint16
+		XAvgCharWidth                            int16
+		WeightClass, WidthClass, FsType          uint16
+		SubscriptX, SubscriptY, SubOffX, SubOffY int16
+		SuperX, SuperY, SuperOffX, SuperOffY     int16
+		StrikeoutSize, StrikeoutPosition         int16
+		FamilyClass                              int16
+		Panose                                   [10]uint8
+		UnicodeRange                             [4]uint32
+		VendId                                   [4]byte
+		FsSelection, FirstChar, LastChar         uint16
+		TypoAscender, TypoDescender, TypoLineGap int16
+		WinAscent, WinDescent                    uint16
+		CodePageRange                            [2]uint32
+	}{
+		1, int16(avgWidth), weight, 5, 0,
+		666, 614, 0, 77, 666, 614, 0, 358, 51, 264, 0,
+		[10]uint8{}, [4]uint32{1}, [4]byte{'n', 'o', 'n', 'e'},
+		fsSelection, firstChar, lastChar,
+		ascent, descent, lineGap, uint16(yMax), uint16(-yMin),
+		[2]uint32{1},
+	})
+	post := new(bytes.Buffer)
+	binary.Write(post, binary.BigEndian, [8]uint32{0x00030000})
+	tables := []ttfTable{
+		{"OS/2", os2.Bytes()},
+		{"cmap", ttfCmap(codes)},
+		{"glyf", glyf.Bytes()},
+		{"head", head.Bytes()},
+		{"hhea", hhea.Bytes()},
+		{"hmtx", hmtx.Bytes()},
+		{"loca", loca.Bytes()},
+		{"maxp", maxp.Bytes()},
+		{"name", ttfName(d.FontName, subfamily)},
+		{"post", post.Bytes()},
+	}
+	if kern := ttfKern(d, scale); kern != nil {
+		tables = append(tables, ttfTable{"kern", kern})
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Tag < tables[j].Tag })
+	font := new(bytes.Buffer)
+	searchRange, entrySelector, rangeShift := ttfSearch(len(tables), 16)
+	binary.Write(font, binary.BigEndian, uint32(0x00010000))
+	binary.Write(font, binary.BigEndian, [4]uint16{uint16(len(tables)), searchRange, entrySelector, rangeShift})
+	offset := uint32(12 + 16*len(tables))
+	headOffset := uint32(0)
+	for _, t := range tables {
+		font.WriteString(t.Tag)
+		binary.Write(font, binary.BigEndian, [3]uint32{ttfChecksum(t.Data), offset, uint32(len(t.Data))})
+		if t.Tag == "head" {
+			headOffset = offset
+		}
+		offset += uint32(len(t.Data)+3) &^ 3
+	}
+	for _, t := range tables {
+		font.Write(t.Data)
+		for i := len(t.Data); i%4 != 0; i++ {
+			font.WriteByte(0)
+		}
+	}
+	data := font.Bytes()
+	binary.BigEndian.PutUint32(data[headOffset+8:], 0xB1B0AFBA-ttfChecksum(data))
+	_, err = c.Write(data)
+	return
+}
+
+func ttfKern(d *DefineFont2, scale int32) []byte {
+	if len(d.FontKerningTable) == 0 {
+		return nil
+	}
+	glyphs := make(map[uint16]uint16)
+	for n, code := range d.CodeTable {
+		if _, ok := glyphs[code]; !ok {
+			glyphs[code] = uint16(n + 1)
+		}
+	}
+	type pair struct {
+		left, right uint16
+		value       int16
+	}
+	var pairs []pair
+	for _, k := range d.FontKerningTable {
+		left, lok := glyphs[k.FontKerningCode1]
+		right, rok := glyphs[k.FontKerningCode2]
+		if lok && rok {
+			pairs = append(pairs, pair{left, right, ttfScale(int32(k.FontKerningAdjustment), scale)})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].left < pairs[j].left || (pairs[i].left == pairs[j].left && pairs[i].right < pairs[j].right)
+	})
+	searchRange, entrySelector, rangeShift := ttfSearch(len(pairs), 6)
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, [2]uint16{0, 1})
+	binary.Write(buf, binary.BigEndian, [7]uint16{0, uint16(14 + 6*len(pairs)), 1, uint16(len(pairs)), searchRange, entrySelector, rangeShift})
+	for _, p := range pairs {
+		binary.Write(buf, binary.BigEndian, [2]uint16{p.left, p.right})
+		binary.Write(buf, binary.BigEndian, p.value)
+	}
+	return buf.Bytes()
+}
+
+// WriteTTF converts the glyph outlines of the font into a TrueType font.
+func (d *DefineFont2) WriteTTF(w io.Writer) (int64, error) {
+	return writeTTF(w, d, 1)
+}
+
+// WriteTTF converts the glyph outlines of the font into a TrueType font.
+func (d *DefineFont3) WriteTTF(w io.Writer) (int64, error) {
+	return writeTTF(w, &d.DefineFont2, 20)
+}
@@ -0,0 +1,67 @@
+package swf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestWriteTTF(t *testing.T) {
+	f := &DefineFont2{
+		FontName:         "Test",
+		HasLayout:        true,
+		WideCodes:        true,
+		GlyphShapeTable:  []Shape{testGlyphShape(), testGlyphShape()},
+		CodeTable:        []uint16{'A', 'B'},
+		FontAscent:       900,
+		FontDescent:      200,
+		FontAdvanceTable: []int16{600, 650},
+		FontBoundsTable:  make([]Rect, 2),
+		FontKerningTable: []KerningRecord{{'A', 'B', -50}},
+	}
+	buf := new(bytes.Buffer)
+	if _, err := f.WriteTTF(buf); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+	data := buf.Bytes()
+	if sum := ttfChecksum(data); sum != 0xB1B0AFBA {
+		t.Errorf("invalid font checksum: %x", sum)
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	tables := make(map[string]uint32)
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		tables[string(record[:4])] = binary.BigEndian.Uint32(record[8:])
+	}
+	for _, tag := range []string{"OS/2", "cmap", "glyf", "head", "hhea", "hmtx", "kern", "loca", "maxp", "name", "post"} {
+		if _, ok := tables[tag]; !ok {
+			t.Errorf("missing table %q", tag)
+		}
+	}
+	if n := binary.BigEndian.Uint16(data[tables["maxp"]+4:]); n != 3 {
+		t.Errorf("expecting 3 glyphs, got %d", n)
+	}
+	if _, err := new(DefineFont2).WriteTTF(buf); err != ErrNoGlyphs {
+		t.Errorf("expecting ErrNoGlyphs, got %v", err)
+	}
+}
+
+func TestUnicodeCodes(t *testing.T) {
+	f := &DefineFont2{CodeTable: []uint16{'A', 0x80, 0x81, 0xe9}}
+	if codes := f.unicodeCodes(); !reflect.DeepEqual(codes, []uint16{'A', 0x20ac, 0xffff, 0xe9}) {
+		t.Errorf("ANSI: got %x", codes)
+	}
+	f.ShiftJIS = true
+	if codes := f.unicodeCodes(); !reflect.DeepEqual(codes, []uint16{'A', 0xffff, 0xffff, 0xffff}) {
+		t.Errorf("Shift-JIS: got %x", codes)
+	}
+	f.CodeTable = []uint16{0xa1, 0xb1, 0xdf}
+	if codes := f.unicodeCodes(); !reflect.DeepEqual(codes, []uint16{0xff61, 0xff71, 0xff9f}) {
+		t.Errorf("Shift-JIS: got %x", codes)
+	}
+	f.WideCodes = true
+	if codes := f.unicodeCodes(); !reflect.DeepEqual(codes, f.CodeTable) {
+		t.Errorf("wide codes: got %x", codes)
+	}
+}
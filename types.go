@@ -47,6 +47,7 @@ type Tag interface {
 	VSizer
 	MinVersion() uint8
 	TagId() uint16
+	Name() string
 }
 
 type Upgradeable interface {
@@ -59,7 +60,7 @@ type Sizer interface {
 }
 
 type VSizer interface {
-	Size(uint8, uint16)
+	Size(uint8, uint16) int32
 }
 
 type Int8 int8
@@ -575,6 +576,7 @@ func (e *EncodedU32) ReadFrom(f io.Reader) (total int64, err error) {
 	c := &rwcount.CountReader{Reader: f}
 	defer func() { total = c.BytesRead() }()
 	shift := EncodedU32(0)
+	*e = 0
 	b := []byte{255}
 	read := 0
 	for b[0]>>7 == 1 {
@@ -587,7 +589,7 @@ func (e *EncodedU32) ReadFrom(f io.Reader) (total int64, err error) {
 			err = errors.New("encodedU32: malformed data")
 			return
 		}
-		*e |= EncodedU32(b[0]) << shift
+		*e |= EncodedU32(b[0]&127) << shift
 		shift += 7
 	}
 	return
@@ -791,7 +793,8 @@ func (s *String) String() string {
 }
 
 const (
-	LANGUAGE_LATIN LanguageCode = iota + 1
+	LANGUAGE_NONE LanguageCode = iota
+	LANGUAGE_LATIN
 	LANGUAGE_JAPENESE
 	LANGUAGE_KOREAN
 	LANGUAGE_SIMPLIFIED_CHINESE
@@ -804,14 +807,14 @@ func (l *LanguageCode) ReadFrom(f io.Reader) (total int64, err error) {
 	c := &rwcount.CountReader{Reader: f}
 	defer func() { total = c.BytesRead() }()
 	err = binary.Read(c, binary.LittleEndian, l)
-	if *l == 0 || *l > 5 {
+	if *l > LANGUAGE_TRADITIONAL_CHINESE {
 		err = errors.New("languageCode: unknown id")
 	}
 	return
 }
 
 func (l *LanguageCode) WriteTo(f io.Writer) (total int64, err error) {
-	if *l == 0 || *l > 5 {
+	if *l > LANGUAGE_TRADITIONAL_CHINESE {
 		err = errors.New("languageCode: unknown id")
 		return
 	}
@@ -827,6 +830,8 @@ func (l *LanguageCode) Size() int32 {
 
 func (l *LanguageCode) String() string {
 	switch *l {
+	case LANGUAGE_NONE:
+		return "None"
 	case LANGUAGE_LATIN:
 		return "Latin"
 	case LANGUAGE_JAPENESE:
@@ -1048,26 +1053,21 @@ func (m *Matrix) ReadFrom(f io.Reader) (total int64, err error) {
 		}
 		m.RotateSkew1 = float32(bf)
 	} else {
-		m.RotateSkew0 = 1
-		m.RotateSkew1 = 1
+		m.RotateSkew0 = 0
+		m.RotateSkew1 = 0
 	}
-	if err = d.ReadBitsFrom(b, 1); err != nil {
+	if err = d.ReadBitsFrom(b, 5); err != nil {
 		return
 	}
-	if d == 1 {
-		if err = d.ReadBitsFrom(b, 5); err != nil {
-			return
-		}
-		var sB BitInt
-		if err = sB.ReadBitsFrom(b, uint8(d)); err != nil {
-			return
-		}
-		m.TranslateX = Twips(sB)
-		if err = sB.ReadBitsFrom(b, uint8(d)); err != nil {
-			return
-		}
-		m.TranslateY = Twips(sB)
+	var sB BitInt
+	if err = sB.ReadBitsFrom(b, uint8(d)); err != nil {
+		return
+	}
+	m.TranslateX = Twips(sB)
+	if err = sB.ReadBitsFrom(b, uint8(d)); err != nil {
+		return
 	}
+	m.TranslateY = Twips(sB)
 	return
 }
 
@@ -1098,7 +1098,7 @@ func (m *Matrix) WriteTo(f io.Writer) (total int64, err error) {
 	} else if err = zero.WriteBitsTo(b, 1); err != nil {
 		return
 	}
-	if m.RotateSkew0 != 1 || m.RotateSkew1 != 1 {
+	if m.RotateSkew0 != 0 || m.RotateSkew1 != 0 {
 		if err = one.WriteBitsTo(b, 1); err != nil {
 			return
 		}
@@ -1116,40 +1116,34 @@ func (m *Matrix) WriteTo(f io.Writer) (total int64, err error) {
 	} else if err = zero.WriteBitsTo(b, 1); err != nil {
 		return
 	}
-	if m.TranslateX != 0 || m.TranslateY != 0 {
-		if err = one.WriteBitsTo(b, 1); err != nil {
-			return
-		}
-		x, y := BitInt(m.TranslateX), BitInt(m.TranslateY)
+	x, y := BitInt(m.TranslateX), BitInt(m.TranslateY)
+	size = 0
+	if x != 0 || y != 0 {
 		size = BitUint(max(x.Size(), y.Size()))
-		if err = size.WriteBitsTo(b, 5); err != nil {
-			return
-		}
-		if err = x.WriteBitsTo(b, uint8(size)); err != nil {
-			return
-		}
-		if err = y.WriteBitsTo(b, uint8(size)); err != nil {
-			return
-		}
-	} else if err = zero.WriteBitsTo(b, 1); err != nil {
+	}
+	if err = size.WriteBitsTo(b, 5); err != nil {
 		return
 	}
+	if err = x.WriteBitsTo(b, uint8(size)); err != nil {
+		return
+	}
+	err = y.WriteBitsTo(b, uint8(size))
 	return
 }
 
 func (m *Matrix) Size() int32 {
-	total := int32(0)
+	total := int32(1 + 1 + 5)
 	if m.ScaleX != 1 || m.ScaleY != 1 {
 		scaleX, scaleY := BitFixed(m.ScaleX), BitFixed(m.ScaleY)
-		total += 1 + 5 + 2*max(scaleX.Size(), scaleY.Size())
+		total += 5 + 2*max(scaleX.Size(), scaleY.Size())
 	}
 	if m.RotateSkew0 != 0 || m.RotateSkew1 != 0 {
 		rotateSkew0, rotateSkew1 := BitFixed(m.RotateSkew0), BitFixed(m.RotateSkew1)
-		total += 1 + 5 + 2*max(rotateSkew0.Size(), rotateSkew1.Size())
+		total += 5 + 2*max(rotateSkew0.Size(), rotateSkew1.Size())
 	}
 	if m.TranslateX != 0 || m.TranslateY != 0 {
 		x, y := BitInt(m.TranslateX), BitInt(m.TranslateY)
-		total += 1 + 5 + 2*max(x.Size(), y.Size())
+		total += 2 * max(x.Size(), y.Size())
 	}
 	if total%8 == 0 {
 		return total / 8
@@ -1168,10 +1162,13 @@ type CXForm struct {
 func (c *CXForm) ReadFrom(f io.Reader) (total int64, err error) {
 	cr := &rwcount.CountReader{Reader: f}
 	defer func() { total = cr.BytesRead() }()
-	var a, m, n BitUint
+	var (
+		a, m, n BitUint
+		t       BitInt
+	)
 	b := &bitReader{Reader: cr}
-	if err = m.ReadBitsFrom(b, 1); err == nil {
-		if err = a.ReadBitsFrom(b, 1); err == nil {
+	if err = a.ReadBitsFrom(b, 1); err == nil {
+		if err = m.ReadBitsFrom(b, 1); err == nil {
 			err = n.ReadBitsFrom(b, 4)
 		}
 	}
@@ -1180,34 +1177,36 @@ func (c *CXForm) ReadFrom(f io.Reader) (total int64, err error) {
 	}
 	bits := uint8(n)
 	if m == 1 {
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.RedMultTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		c.RedMultTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.GreenMultTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil && !(a == 0 && err == io.EOF) {
+		c.GreenMultTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil && !(a == 0 && err == io.EOF) {
 			return
 		}
-		c.BlueMultTerm = int16(n)
+		c.BlueMultTerm = int16(t)
 	} else {
 		c.RedMultTerm, c.GreenMultTerm, c.BlueMultTerm = 256, 256, 256
 	}
 	if a == 1 {
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.RedAddTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		c.RedAddTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.GreenAddTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		c.GreenAddTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.BlueAddTerm = int16(n)
+		c.BlueAddTerm = int16(t)
+	} else {
+		c.RedAddTerm, c.GreenAddTerm, c.BlueAddTerm = 0, 0, 0
 	}
 	return
 }
@@ -1333,10 +1332,13 @@ type CXFormWithAlpha struct {
 func (c *CXFormWithAlpha) ReadFrom(f io.Reader) (total int64, err error) {
 	cr := &rwcount.CountReader{Reader: f}
 	defer func() { total = cr.BytesRead() }()
-	var a, m, n BitUint
+	var (
+		a, m, n BitUint
+		t       BitInt
+	)
 	b := &bitReader{Reader: cr}
-	if err = m.ReadBitsFrom(b, 1); err == nil {
-		err = a.ReadBitsFrom(b, 1)
+	if err = a.ReadBitsFrom(b, 1); err == nil {
+		err = m.ReadBitsFrom(b, 1)
 		if err == nil {
 			err = n.ReadBitsFrom(b, 4)
 		}
@@ -1346,42 +1348,44 @@ func (c *CXFormWithAlpha) ReadFrom(f io.Reader) (total int64, err error) {
 	}
 	bits := uint8(n)
 	if m == 1 {
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.RedMultTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		c.RedMultTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.GreenMultTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		c.GreenMultTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.BlueMultTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil && !(a == 0 && err == io.EOF) {
+		c.BlueMultTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil && !(a == 0 && err == io.EOF) {
 			return
 		}
-		c.AlphaMultTerm = int16(n)
+		c.AlphaMultTerm = int16(t)
 	} else {
 		c.RedMultTerm, c.GreenMultTerm, c.BlueMultTerm, c.AlphaMultTerm = 256, 256, 256, 256
 	}
 	if a == 1 {
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.RedAddTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		c.RedAddTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.GreenAddTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		c.GreenAddTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.BlueAddTerm = int16(n)
-		if err = n.ReadBitsFrom(b, bits); err != nil {
+		c.BlueAddTerm = int16(t)
+		if err = t.ReadBitsFrom(b, bits); err != nil {
 			return
 		}
-		c.AlphaAddTerm = int16(n)
+		c.AlphaAddTerm = int16(t)
+	} else {
+		c.RedAddTerm, c.GreenAddTerm, c.BlueAddTerm, c.AlphaAddTerm = 0, 0, 0, 0
 	}
 	return
 }
@@ -1414,7 +1418,7 @@ func (c *CXFormWithAlpha) WriteTo(f io.Writer) (total int64, err error) {
 	} else if err = zero.WriteBitsTo(b, 1); err != nil {
 		return
 	}
-	if rm != 256 || ga != 256 || bm != 256 || am != 256 {
+	if rm != 256 || gm != 256 || bm != 256 || am != 256 {
 		if err = one.WriteBitsTo(b, 1); err != nil {
 			return
 		}
@@ -1496,7 +1500,7 @@ func (c *CXFormWithAlpha) String() string {
 	return s
 }
 
-func ReadAll(f io.Reader, fs ...[]io.ReaderFrom) (err error) {
+func ReadAll(f io.Reader, fs ...io.ReaderFrom) (err error) {
 	for _, r := range fs {
 		if _, err = r.ReadFrom(f); err != nil {
 			return
@@ -1505,7 +1509,7 @@ func ReadAll(f io.Reader, fs ...[]io.ReaderFrom) (err error) {
 	return
 }
 
-func WriteAll(w io.Writer, fs ...[]io.WriterTo) (err error) {
+func WriteAll(w io.Writer, fs ...io.WriterTo) (err error) {
 	for _, r := range fs {
 		if _, err = r.WriteTo(w); err != nil {
 			return
@@ -1514,13 +1518,29 @@ func WriteAll(w io.Writer, fs ...[]io.WriterTo) (err error) {
 	return
 }
 
-func SizeAll(ss ...[]Sizer) (total int32) {
+func SizeAll(ss ...Sizer) (total int32) {
 	for _, s := range ss {
 		total += s.Size()
 	}
 	return
 }
 
+func packFlags(flags ...bool) (b uint8) {
+	for _, f := range flags {
+		b <<= 1
+		if f {
+			b |= 1
+		}
+	}
+	return
+}
+
+func unpackFlags(b uint8, flags ...*bool) {
+	for n, f := range flags {
+		*f = b>>uint(len(flags)-n-1)&1 == 1
+	}
+}
+
 func min(sizes ...int32) int32 {
 	if len(sizes) == 0 {
 		return 0
@@ -229,10 +229,11 @@ func TestFixed8(t *testing.T) {
 }
 
 func TestEncodedU32(t *testing.T) {
-	test(t, new(EncodedU32), []byte{0, 127, 255, 1, 255, 255, 255, 255, 15}, []equaler.Equaler{
+	test(t, new(EncodedU32), []byte{0, 127, 255, 1, 128, 128, 2, 255, 255, 255, 255, 15}, []equaler.Equaler{
 		NewEncodedU32(0),
 		NewEncodedU32(127),
 		NewEncodedU32(255),
+		NewEncodedU32(32768),
 		NewEncodedU32(4294967295),
 	})
 }
@@ -313,12 +314,13 @@ func TestString(t *testing.T) {
 }
 
 func TestLanguageCode(t *testing.T) {
-	test(t, new(LanguageCode), []byte{3, 4, 5, 1, 2}, []equaler.Equaler{
+	test(t, new(LanguageCode), []byte{3, 4, 5, 1, 2, 0}, []equaler.Equaler{
 		NewLanguageCode(uint8(LANGUAGE_KOREAN)),
 		NewLanguageCode(uint8(LANGUAGE_SIMPLIFIED_CHINESE)),
 		NewLanguageCode(uint8(LANGUAGE_TRADITIONAL_CHINESE)),
 		NewLanguageCode(uint8(LANGUAGE_LATIN)),
 		NewLanguageCode(uint8(LANGUAGE_JAPENESE)),
+		NewLanguageCode(uint8(LANGUAGE_NONE)),
 	})
 }
 
@@ -362,10 +364,11 @@ func TestRectSize(t *testing.T) {
 }
 
 func TestMatrix(t *testing.T) {
-	test(t, new(Matrix), []byte{205, 0, 0, 8, 0, 12, 194, 0, 3, 0, 0, 165, 145, 216, 217, 52, 0, 1, 32, 0, 49, 64, 0, 8, 0, 37, 103, 0, 236, 109, 128, 0, 32, 8, 0, 15, 131, 252, 128, 0, 127, 248, 128, 2, 224, 31, 47, 160}, []equaler.Equaler{
+	test(t, new(Matrix), []byte{205, 0, 0, 8, 0, 12, 194, 0, 3, 0, 0, 75, 35, 176, 217, 52, 0, 1, 32, 0, 49, 64, 0, 8, 0, 10, 206, 0, 236, 109, 128, 0, 32, 8, 0, 15, 131, 252, 128, 0, 127, 248, 128, 1, 192, 62, 95, 64, 0}, []equaler.Equaler{
 		NewMatrix(2, 0.5, 0.25, 3, 200, -40),
 		NewMatrix(19.25, 4.5, 0.5, 0.125, 12, -4),
 		NewMatrix(219, 512.5, 1020.5, 8190.125, 124, -4192),
+		NewMatrix(1, 1, 0, 0, 0, 0),
 	})
 }
 
@@ -374,13 +377,15 @@ func TestMatrixSize(t *testing.T) {
 		{NewMatrix(2, 0.5, 0.25, 3, 200, -40), 14},
 		{NewMatrix(19.25, 4.5, 0.5, 0.125, 12, -4), 14},
 		{NewMatrix(219, 512.5, 1020.5, 8190.125, 124, -4192), 20},
+		{NewMatrix(1, 1, 0, 0, 0, 0), 1},
 	})
 }
 
 func TestCXForm(t *testing.T) {
-	test(t, new(CXForm), []byte{229, 56, 247, 106, 177, 73, 230, 192, 229, 134, 173, 77, 21, 83, 238, 96}, []equaler.Equaler{
+	test(t, new(CXForm), []byte{229, 56, 247, 106, 177, 73, 230, 192, 229, 134, 173, 77, 21, 83, 238, 96, 139, 16}, []equaler.Equaler{
 		NewCXForm(156, 247, 213, 197, 79, 108),
 		NewCXForm(195, 173, 154, 85, 159, 230),
+		NewCXForm(256, 256, 256, -1, 0, 1),
 	})
 }
 
@@ -393,9 +398,10 @@ func TestCXFormSize(t *testing.T) {
 }
 
 func TestCXFormWithAlpha(t *testing.T) {
-	test(t, new(CXFormWithAlpha), []byte{229, 56, 247, 106, 160, 24, 164, 243, 98, 104, 229, 134, 173, 77, 53, 202, 169, 247, 48, 56}, []equaler.Equaler{
+	test(t, new(CXFormWithAlpha), []byte{229, 56, 247, 106, 160, 24, 164, 243, 98, 104, 229, 134, 173, 77, 53, 202, 169, 247, 48, 56, 139, 24}, []equaler.Equaler{
 		NewCXFormWithAlpha(156, 247, 213, 128, 197, 79, 108, 154),
 		NewCXFormWithAlpha(195, 173, 154, 215, 85, 159, 230, 14),
+		NewCXFormWithAlpha(256, 256, 256, 256, -1, 0, 1, -2),
 	})
 }
 
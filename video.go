@@ -0,0 +1,314 @@
+// Copyright (c) 2013 - Michael Woolnough <michael.woolnough@gmail.com>
+//
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//    list of conditions and the following disclaimer.
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//    this list of conditions and the following disclaimer in the documentation
+//    and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+// ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER OR CONTRIBUTORS BE LIABLE FOR
+// ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+// (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+// LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND
+// ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+// (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+// SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package swf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/MJKWoolnough/rwcount"
+	"io"
+	"io/ioutil"
+)
+
+type VideoCodec uint8
+
+const (
+	VIDEO_H263      VideoCodec = 2
+	VIDEO_SCREEN    VideoCodec = 3
+	VIDEO_VP6       VideoCodec = 4
+	VIDEO_VP6_ALPHA VideoCodec = 5
+	VIDEO_SCREEN_V2 VideoCodec = 6
+)
+
+func (v VideoCodec) String() string {
+	switch v {
+	case VIDEO_H263:
+		return "Sorenson H.263"
+	case VIDEO_SCREEN:
+		return "Screen video"
+	case VIDEO_VP6:
+		return "On2 VP6"
+	case VIDEO_VP6_ALPHA:
+		return "On2 VP6 with alpha channel"
+	case VIDEO_SCREEN_V2:
+		return "Screen video version 2"
+	}
+	return "Unknown video codec"
+}
+
+type DefineVideoStream struct {
+	CharacterId uint16
+	NumFrames   uint16
+	Width       uint16
+	Height      uint16
+	Deblocking  uint8
+	Smoothing   bool
+	CodecId     VideoCodec
+}
+
+func (d *DefineVideoStream) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	var data struct {
+		CharacterId, NumFrames, Width, Height uint16
+		Flags                                 uint8
+		CodecId                               VideoCodec
+	}
+	if err = binary.Read(c, binary.LittleEndian, &data); err != nil {
+		return
+	}
+	d.CharacterId = data.CharacterId
+	d.NumFrames = data.NumFrames
+	d.Width = data.Width
+	d.Height = data.Height
+	d.Deblocking = data.Flags >> 1 & 7
+	d.Smoothing = data.Flags&1 != 0
+	d.CodecId = data.CodecId
+	if d.CodecId < VIDEO_H263 || d.CodecId > VIDEO_SCREEN_V2 {
+		err = &ParserError{d.Name(), "CodecID", fmt.Sprintf("%d", d.CodecId)}
+	}
+	return
+}
+
+func (d *DefineVideoStream) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	flags := (d.Deblocking & 7) << 1
+	if d.Smoothing {
+		flags |= 1
+	}
+	err = binary.Write(c, binary.LittleEndian, struct {
+		CharacterId, NumFrames, Width, Height uint16
+		Flags                                 uint8
+		CodecId                               VideoCodec
+	}{d.CharacterId, d.NumFrames, d.Width, d.Height, flags, d.CodecId})
+	return
+}
+
+func (d *DefineVideoStream) Size(ver uint8, id uint16) int32 {
+	return 10
+}
+
+func (d *DefineVideoStream) MinVersion() uint8 {
+	return 6
+}
+
+func (d *DefineVideoStream) TagId() uint16 {
+	return TAG_DEFINE_VIDEO_STREAM
+}
+
+func (d *DefineVideoStream) Name() string {
+	return "DefineVideoStream"
+}
+
+type VideoFrame struct {
+	StreamId  uint16
+	FrameNum  uint16
+	VideoData []byte
+}
+
+func (v *VideoFrame) ReadFrom(f io.Reader, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountReader{Reader: f}
+	defer func() { total = c.BytesRead() }()
+	if err = binary.Read(c, binary.LittleEndian, &v.StreamId); err != nil {
+		return
+	}
+	if err = binary.Read(c, binary.LittleEndian, &v.FrameNum); err != nil {
+		return
+	}
+	v.VideoData, err = ioutil.ReadAll(c)
+	return
+}
+
+func (v *VideoFrame) WriteTo(f io.Writer, ver uint8, id uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: f}
+	defer func() { total = c.BytesWritten() }()
+	if err = binary.Write(c, binary.LittleEndian, v.StreamId); err != nil {
+		return
+	}
+	if err = binary.Write(c, binary.LittleEndian, v.FrameNum); err != nil {
+		return
+	}
+	_, err = c.Write(v.VideoData)
+	return
+}
+
+func (v *VideoFrame) Size(ver uint8, id uint16) int32 {
+	return 4 + int32(len(v.VideoData))
+}
+
+func (v *VideoFrame) MinVersion() uint8 {
+	return 6
+}
+
+func (v *VideoFrame) TagId() uint16 {
+	return TAG_VIDEO_FRAME
+}
+
+func (v *VideoFrame) Name() string {
+	return "VideoFrame"
+}
+
+const (
+	flvKeyFrame        uint8 = 1
+	flvInterFrame      uint8 = 2
+	flvDisposableFrame uint8 = 3
+)
+
+var ErrNoVideoStream = errors.New("video: no DefineVideoStream with that character id")
+
+// frameType determines the FLV frame type of the given frame data.
+func (d *DefineVideoStream) frameType(data []byte) uint8 {
+	switch d.CodecId {
+	case VIDEO_H263:
+		var (
+			u           BitUint
+			pictureType uint8 = flvInterFrame
+		)
+		b := &bitReader{Reader: bytes.NewReader(data)}
+		if u.ReadBitsFrom(b, 30) != nil || u.ReadBitsFrom(b, 3) != nil {
+			break
+		}
+		switch u {
+		case 0:
+			u.ReadBitsFrom(b, 16)
+		case 1:
+			u.ReadBitsFrom(b, 32)
+		}
+		if u.ReadBitsFrom(b, 2) == nil {
+			pictureType = uint8(u) + 1
+		}
+		return pictureType
+	case VIDEO_VP6, VIDEO_VP6_ALPHA:
+		if d.CodecId == VIDEO_VP6_ALPHA {
+			if len(data) < 3 {
+				break
+			}
+			data = data[3:]
+		}
+		if len(data) > 0 && data[0]&0x80 == 0 {
+			return flvKeyFrame
+		}
+	case VIDEO_SCREEN, VIDEO_SCREEN_V2:
+		if len(data) < 4 {
+			break
+		}
+		blockWidth := (uint32(data[0]>>4) + 1) * 16
+		imageWidth := uint32(binary.BigEndian.Uint16(data)) & 0xfff
+		blockHeight := (uint32(data[2]>>4) + 1) * 16
+		imageHeight := uint32(binary.BigEndian.Uint16(data[2:])) & 0xfff
+		blocks := ((imageWidth + blockWidth - 1) / blockWidth) * ((imageHeight + blockHeight - 1) / blockHeight)
+		data = data[4:]
+		if d.CodecId == VIDEO_SCREEN_V2 {
+			if len(data) < 1 {
+				break
+			}
+			data = data[1:]
+		}
+		for ; blocks > 0; blocks-- {
+			if len(data) < 2 {
+				return flvInterFrame
+			}
+			size := int(binary.BigEndian.Uint16(data))
+			if size == 0 || len(data) < size+2 {
+				return flvInterFrame
+			}
+			data = data[size+2:]
+		}
+		return flvKeyFrame
+	}
+	return flvInterFrame
+}
+
+// WriteFLV writes the given video frames into an FLV container, using the
+// frame rate (in the 8.8 fixed point format of SWF.FrameRate) for the
+// timestamps.
+func (d *DefineVideoStream) WriteFLV(w io.Writer, frames []*VideoFrame, frameRate uint16) (total int64, err error) {
+	c := &rwcount.CountWriter{Writer: w}
+	defer func() { total = c.BytesWritten() }()
+	if frameRate == 0 {
+		frameRate = 256
+	}
+	if _, err = c.Write([]byte{'F', 'L', 'V', 1, 1, 0, 0, 0, 9, 0, 0, 0, 0}); err != nil {
+		return
+	}
+	for _, frame := range frames {
+		if frame.StreamId != d.CharacterId {
+			continue
+		}
+		header := []byte{d.frameType(frame.VideoData)<<4 | uint8(d.CodecId)}
+		if d.CodecId == VIDEO_VP6 || d.CodecId == VIDEO_VP6_ALPHA {
+			header = append(header, 0)
+		}
+		dataSize := uint32(len(header) + len(frame.VideoData))
+		timestamp := uint32(uint64(frame.FrameNum) * 1000 * 256 / uint64(frameRate))
+		tag := []byte{
+			9,
+			byte(dataSize >> 16), byte(dataSize >> 8), byte(dataSize),
+			byte(timestamp >> 16), byte(timestamp >> 8), byte(timestamp), byte(timestamp >> 24),
+			0, 0, 0,
+		}
+		if _, err = c.Write(tag); err != nil {
+			return
+		}
+		if _, err = c.Write(header); err != nil {
+			return
+		}
+		if _, err = c.Write(frame.VideoData); err != nil {
+			return
+		}
+		if err = binary.Write(c, binary.BigEndian, uint32(len(tag))+dataSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// WriteFLV extracts the video stream with the given character id as an FLV
+// file.
+func (s *SWF) WriteFLV(w io.Writer, characterId uint16) (int64, error) {
+	var (
+		stream *DefineVideoStream
+		frames []*VideoFrame
+	)
+	for _, tag := range allTags(s.Tags) {
+		switch t := tag.(type) {
+		case *DefineVideoStream:
+			if t.CharacterId == characterId {
+				stream = t
+			}
+		case *VideoFrame:
+			if t.StreamId == characterId {
+				frames = append(frames, t)
+			}
+		}
+	}
+	if stream == nil {
+		return 0, ErrNoVideoStream
+	}
+	return stream.WriteFLV(w, frames, s.FrameRate)
+}
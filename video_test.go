@@ -0,0 +1,64 @@
+package swf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDefineVideoStream(t *testing.T) {
+	testTag(t, 6, []byte{5, 0, 10, 0, 64, 1, 240, 0, 5, 4}, &DefineVideoStream{
+		CharacterId: 5,
+		NumFrames:   10,
+		Width:       320,
+		Height:      240,
+		Deblocking:  2,
+		Smoothing:   true,
+		CodecId:     VIDEO_VP6,
+	})
+}
+
+func TestVideoFrame(t *testing.T) {
+	testTag(t, 6, []byte{5, 0, 2, 0, 1, 2, 3}, &VideoFrame{
+		StreamId:  5,
+		FrameNum:  2,
+		VideoData: []byte{1, 2, 3},
+	})
+}
+
+func TestWriteFLV(t *testing.T) {
+	s := &SWF{
+		FrameRate: 10 << 8,
+		Tags: []Tag{
+			&DefineVideoStream{CharacterId: 1, NumFrames: 2, Width: 16, Height: 16, CodecId: VIDEO_VP6},
+			&VideoFrame{StreamId: 1, FrameNum: 0, VideoData: []byte{0x00, 0xaa}},
+			&VideoFrame{StreamId: 2, FrameNum: 0, VideoData: []byte{0xff}},
+			&VideoFrame{StreamId: 1, FrameNum: 1, VideoData: []byte{0x80}},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if _, err := s.WriteFLV(buf, 1); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+	expected := []byte{
+		'F', 'L', 'V', 1, 1, 0, 0, 0, 9, 0, 0, 0, 0,
+		9, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0x14, 0, 0x00, 0xaa, 0, 0, 0, 15,
+		9, 0, 0, 3, 0, 0, 100, 0, 0, 0, 0, 0x24, 0, 0x80, 0, 0, 0, 14,
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("expecting %v, got %v", expected, buf.Bytes())
+	}
+	if _, err := s.WriteFLV(buf, 3); err != ErrNoVideoStream {
+		t.Errorf("expecting ErrNoVideoStream, got %v", err)
+	}
+	s.Tags = []Tag{
+		s.Tags[0],
+		&DefineSprite{SpriteId: 2, FrameCount: 2, ControlTags: []Tag{s.Tags[1], &ShowFrame{}, s.Tags[3], &ShowFrame{}}},
+	}
+	buf.Reset()
+	if _, err := s.WriteFLV(buf, 1); err != nil {
+		t.Fatalf("unexpected error: %q", err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("expecting %v, got %v", expected, buf.Bytes())
+	}
+}